@@ -0,0 +1,264 @@
+// api/handlers/attribute_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type AttributeHandler struct {
+	attributeService service.AttributeService
+	logger           logger.Logger
+}
+
+func NewAttributeHandler(attributeService service.AttributeService, logger logger.Logger) *AttributeHandler {
+	return &AttributeHandler{
+		attributeService: attributeService,
+		logger:           logger,
+	}
+}
+
+// ListAttributes godoc
+// @Summary List attribute definitions
+// @Description Every custom product attribute, ordered by name
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.AttributeDefinition
+// @Failure 500 {object} ErrorResponse
+// @Router /attributes [get]
+// @Security BearerAuth
+func (h *AttributeHandler) ListAttributes(c *gin.Context) {
+	defs, err := h.attributeService.ListAttributes(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get attribute definitions", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve attributes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, defs)
+}
+
+// GetAttribute godoc
+// @Summary Get attribute definition
+// @Description Get a custom product attribute by name
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param name path string true "Attribute name"
+// @Success 200 {object} domain.AttributeDefinition
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /attributes/{name} [get]
+// @Security BearerAuth
+func (h *AttributeHandler) GetAttribute(c *gin.Context) {
+	name := c.Param("name")
+
+	def, err := h.attributeService.GetAttribute(c.Request.Context(), name)
+	if err != nil {
+		h.logger.Error("Failed to get attribute definition", err, logger.Fields{"attribute": name})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve attribute",
+		})
+		return
+	}
+
+	if def == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Attribute not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, def)
+}
+
+// CreateAttribute godoc
+// @Summary Create attribute definition
+// @Description Define a custom product attribute (admin only). Products in the given categories,
+// @Description or every product if none are given, are validated against it.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param attribute body domain.AttributeDefinition true "Attribute definition"
+// @Success 201 {object} domain.AttributeDefinition
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /attributes [post]
+// @Security BearerAuth
+func (h *AttributeHandler) CreateAttribute(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var def domain.AttributeDefinition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := def.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.attributeService.CreateAttribute(c.Request.Context(), def)
+	if err != nil {
+		h.logger.Error("Failed to create attribute definition", err, logger.Fields{"attribute": def.Name})
+
+		if errors.Is(err, domain.ErrAttributeExists) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "An attribute with this name already exists",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to create attribute",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateAttribute godoc
+// @Summary Replace attribute definition
+// @Description Replace a custom product attribute (admin only). The type cannot change while products use the attribute.
+// @Description Existing product values are not revalidated until the product is next written.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param name path string true "Attribute name"
+// @Param attribute body domain.AttributeDefinition true "Attribute definition"
+// @Success 200 {object} domain.AttributeDefinition
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /attributes/{name} [put]
+// @Security BearerAuth
+func (h *AttributeHandler) UpdateAttribute(c *gin.Context) {
+	name := c.Param("name")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var def domain.AttributeDefinition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	// The name identifies the attribute and cannot be changed
+	def.Name = name
+	if err := def.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	updated, err := h.attributeService.UpdateAttribute(c.Request.Context(), def)
+	if err != nil {
+		h.logger.Error("Failed to update attribute definition", err, logger.Fields{"attribute": name})
+
+		if errors.Is(err, domain.ErrAttributeInUse) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "The type of an attribute in use cannot be changed",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to update attribute",
+		})
+		return
+	}
+
+	if updated == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Attribute not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteAttribute godoc
+// @Summary Delete attribute definition
+// @Description Delete a custom product attribute that no product uses, including products in the trash (admin only)
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param name path string true "Attribute name"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /attributes/{name} [delete]
+// @Security BearerAuth
+func (h *AttributeHandler) DeleteAttribute(c *gin.Context) {
+	name := c.Param("name")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	err := h.attributeService.DeleteAttribute(c.Request.Context(), name)
+	if err != nil {
+		h.logger.Error("Failed to delete attribute definition", err, logger.Fields{"attribute": name})
+
+		if errors.Is(err, domain.ErrAttributeNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Status: http.StatusNotFound,
+				Error:  "Attribute not found",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrAttributeInUse) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "Attribute is still used by products",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to delete attribute",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,392 @@
+// api/handlers/category_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+	logger          logger.Logger
+}
+
+func NewCategoryHandler(categoryService service.CategoryService, logger logger.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+		logger:          logger,
+	}
+}
+
+// ListCategories godoc
+// @Summary List categories
+// @Description The whole category tree, depth first with siblings ordered by position and name, or only the children of a category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param parent_id query string false "List only the children of this category"
+// @Success 200 {array} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories [get]
+// @Security BearerAuth
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	parentID := c.Query("parent_id")
+
+	categories, err := h.categoryService.ListCategories(c.Request.Context(), parentID)
+	if err != nil {
+		h.logger.Error("Failed to get categories", err, logger.Fields{"parentId": parentID})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid category ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve categories",
+		})
+		return
+	}
+
+	if categories == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary Get category
+// @Description Get a category by ID
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [get]
+// @Security BearerAuth
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	category, err := h.categoryService.GetCategory(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get category", err, logger.Fields{"categoryId": id})
+		h.respondError(c, err, "Failed to retrieve category")
+		return
+	}
+
+	if category == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory godoc
+// @Summary Create category
+// @Description Create a category below parent_id, or at the root (admin only). The slug is derived from the name if not given.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body domain.Category true "Category"
+// @Success 201 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories [post]
+// @Security BearerAuth
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var category domain.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := category.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.categoryService.CreateCategory(c.Request.Context(), category)
+	if err != nil {
+		h.logger.Error("Failed to create category", err, logger.Fields{"slug": category.Slug})
+		h.respondError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateCategory godoc
+// @Summary Update category
+// @Description Change the name, slug and position of a category (admin only). A new slug is applied to the paths
+// @Description of its subcategories and to the products in the category. Use move to change the parent.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body domain.Category true "Category"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [put]
+// @Security BearerAuth
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var category domain.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := category.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	updated, err := h.categoryService.UpdateCategory(c.Request.Context(), id, category)
+	if err != nil {
+		h.logger.Error("Failed to update category", err, logger.Fields{"categoryId": id})
+		h.respondError(c, err, "Failed to update category")
+		return
+	}
+
+	if updated == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// MoveCategory godoc
+// @Summary Move category
+// @Description Move a category and its subcategories below another category, or to the root if parent_id is empty (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param move body MoveCategoryRequest true "New parent and position"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id}/move [post]
+// @Security BearerAuth
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	moved, err := h.categoryService.MoveCategory(c.Request.Context(), id, req.ParentID, req.Position)
+	if err != nil {
+		h.logger.Error("Failed to move category", err, logger.Fields{"categoryId": id, "parentId": req.ParentID})
+		h.respondError(c, err, "Failed to move category")
+		return
+	}
+
+	if moved == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, moved)
+}
+
+// MergeCategory godoc
+// @Summary Merge category
+// @Description Reassign the products and subcategories of a category, including products in the trash,
+// @Description to the target category and delete it (admin only). Returns the target.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param merge body MergeCategoryRequest true "Target category"
+// @Success 200 {object} domain.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id}/merge [post]
+// @Security BearerAuth
+func (h *CategoryHandler) MergeCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	target, err := h.categoryService.MergeCategory(c.Request.Context(), id, req.TargetID)
+	if err != nil {
+		h.logger.Error("Failed to merge category", err, logger.Fields{"categoryId": id, "targetId": req.TargetID})
+		h.respondError(c, err, "Failed to merge category")
+		return
+	}
+
+	if target == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Delete a category without subcategories or products, including products in the trash (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [delete]
+// @Security BearerAuth
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete category", err, logger.Fields{"categoryId": id})
+		h.respondError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondError maps the errors of the category service to a response, or
+// answers with message and a 500.
+func (h *CategoryHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		respondValidationError(c, err)
+	case errors.Is(err, domain.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid category ID",
+		})
+	case errors.Is(err, domain.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Category not found",
+		})
+	case errors.Is(err, domain.ErrCategoryExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "A category with this slug already exists",
+		})
+	case errors.Is(err, domain.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "Category still has subcategories or products",
+		})
+	case errors.Is(err, domain.ErrCategoryCycle), errors.Is(err, domain.ErrCategoryTooDeep):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status:  http.StatusConflict,
+			Error:   "Category cannot be placed there",
+			Details: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  message,
+		})
+	}
+}
+
+// MoveCategoryRequest places a category below ParentID, or at the root if
+// it is empty, at Position among its new siblings.
+type MoveCategoryRequest struct {
+	ParentID string `json:"parent_id"`
+	Position int    `json:"position" binding:"min=0"`
+}
+
+// MergeCategoryRequest names the category to merge into.
+type MergeCategoryRequest struct {
+	TargetID string `json:"target_id" binding:"required"`
+}
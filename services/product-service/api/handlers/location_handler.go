@@ -0,0 +1,247 @@
+// api/handlers/location_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type LocationHandler struct {
+	locationService service.LocationService
+	logger          logger.Logger
+}
+
+func NewLocationHandler(locationService service.LocationService, logger logger.Logger) *LocationHandler {
+	return &LocationHandler{
+		locationService: locationService,
+		logger:          logger,
+	}
+}
+
+// ListLocations godoc
+// @Summary List stock locations
+// @Description Every warehouse and store that keeps stock, the default location first, then by priority
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Location
+// @Failure 500 {object} ErrorResponse
+// @Router /locations [get]
+// @Security BearerAuth
+func (h *LocationHandler) ListLocations(c *gin.Context) {
+	locations, err := h.locationService.ListLocations(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get locations", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve locations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetLocation godoc
+// @Summary Get stock location
+// @Description Get a stock location by ID
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param id path string true "Location ID"
+// @Success 200 {object} domain.Location
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /locations/{id} [get]
+// @Security BearerAuth
+func (h *LocationHandler) GetLocation(c *gin.Context) {
+	id := c.Param("id")
+
+	location, err := h.locationService.GetLocation(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get location", err, logger.Fields{"locationId": id})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve location",
+		})
+		return
+	}
+
+	if location == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Location not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// CreateLocation godoc
+// @Summary Create stock location
+// @Description Create a warehouse or store that keeps stock (admin only). Stock is received at it through inventory
+// @Description adjustments and moved to it through transfers; reservations take stock from it by priority or distance.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param location body domain.Location true "Location"
+// @Success 201 {object} domain.Location
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /locations [post]
+// @Security BearerAuth
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var location domain.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := location.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.locationService.CreateLocation(c.Request.Context(), location)
+	if err != nil {
+		h.logger.Error("Failed to create location", err, logger.Fields{"locationId": location.ID})
+		h.respondError(c, err, "Failed to create location")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateLocation godoc
+// @Summary Replace stock location
+// @Description Change the name, type, priority, position and disabled flag of a stock location (admin only).
+// @Description Disabled locations keep their stock but are not allocated from.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param id path string true "Location ID"
+// @Param location body domain.Location true "Location"
+// @Success 200 {object} domain.Location
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /locations/{id} [put]
+// @Security BearerAuth
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var location domain.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	// The ID identifies the location and cannot be changed
+	location.ID = id
+	if err := location.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	updated, err := h.locationService.UpdateLocation(c.Request.Context(), location)
+	if err != nil {
+		h.logger.Error("Failed to update location", err, logger.Fields{"locationId": id})
+		h.respondError(c, err, "Failed to update location")
+		return
+	}
+
+	if updated == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Location not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteLocation godoc
+// @Summary Delete stock location
+// @Description Delete a stock location that holds no stock (admin only). The default location cannot be deleted.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param id path string true "Location ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /locations/{id} [delete]
+// @Security BearerAuth
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	if err := h.locationService.DeleteLocation(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete location", err, logger.Fields{"locationId": id})
+		h.respondError(c, err, "Failed to delete location")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondError maps the errors of the location service to a response, or
+// answers with message and a 500.
+func (h *LocationHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		respondValidationError(c, err)
+	case errors.Is(err, domain.ErrLocationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Location not found",
+		})
+	case errors.Is(err, domain.ErrLocationExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "A location with this ID already exists",
+		})
+	case errors.Is(err, domain.ErrLocationInUse):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "Location is the default or holds stock",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  message,
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// api/handlers/preconditions.go
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns the strong entity tag of a product version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// representationTag returns the strong entity tag of a product as read by a
+// caller: its version and a fingerprint of what else the body depends on,
+// such as the price lists its effective prices are resolved from.
+func representationTag(version int64, fingerprint string) string {
+	return `"` + strconv.FormatInt(version, 10) + "-" + fingerprint + `"`
+}
+
+// ifMatchVersion reads the version a write is conditional on from If-Match.
+// The header may list several tags; each is compared strongly and as a
+// whole, so it must be the tag of a version or of a read of one, the version
+// before a dash and a fingerprint after it. When the tags name more than one
+// version, the one the product is at is returned, so that only a list
+// naming none of them fails. It returns 0 for "*", and for a missing header
+// unless If-Match is required. Weak or unknown tags can never match. On
+// failure the 412 or 428 response is written and ok is false.
+func (h *ProductHandler) ifMatchVersion(c *gin.Context) (version int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+
+	if header == "" {
+		if h.concurrency.RequireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, ErrorResponse{
+				Status: http.StatusPreconditionRequired,
+				Error:  "If-Match header with the product ETag is required",
+			})
+			return 0, false
+		}
+		return 0, true
+	}
+
+	var versions []int64
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return 0, true
+		}
+		if v, ok := tagVersion(candidate); ok && !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		respondPreconditionFailed(c)
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	// The write itself reports a product that is missing, or that changed
+	// since it was read here
+	product, err := h.productService.GetProductByID(c.Request.Context(), c.Param("id"), true)
+	if err != nil || product == nil {
+		return versions[0], true
+	}
+	if slices.Contains(versions, product.Version) {
+		return product.Version, true
+	}
+
+	respondPreconditionFailed(c)
+	return 0, false
+}
+
+// tagVersion returns the version of a strong tag made by etag or
+// representationTag.
+func tagVersion(tag string) (int64, bool) {
+	if len(tag) < 3 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, fingerprint, found := strings.Cut(tag[1:len(tag)-1], "-")
+	if found && (fingerprint == "" || strings.Trim(fingerprint, "0123456789abcdef") != "") {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// noneMatch reports whether an If-None-Match header lists tag, using the
+// weak comparison that RFC 9110 prescribes for it.
+func noneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func respondPreconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, ErrorResponse{
+		Status: http.StatusPreconditionFailed,
+		Error:  "Product was modified; fetch it again and retry with the new ETag",
+	})
+}
@@ -0,0 +1,80 @@
+// api/handlers/preconditions_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/config"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		require bool
+		// current is the version the product is at
+		current     int64
+		wantVersion int64
+		wantStatus  int
+	}{
+		{"", false, 4, 0, 0},
+		{"", true, 4, 0, http.StatusPreconditionRequired},
+		{"*", true, 4, 0, 0},
+		{etag(7), true, 4, 7, 0},
+		{representationTag(7, "0123abcd"), true, 4, 7, 0},
+		{` "7" `, false, 4, 7, 0},
+		{`W/"7"`, false, 4, 0, http.StatusPreconditionFailed},
+		{`"x-7"`, false, 4, 0, http.StatusPreconditionFailed},
+		{`"0"`, false, 4, 0, http.StatusPreconditionFailed},
+		{`7`, false, 4, 0, http.StatusPreconditionFailed},
+		{`"7-"`, false, 4, 0, http.StatusPreconditionFailed},
+		{`"7-xyz"`, false, 4, 0, http.StatusPreconditionFailed},
+		{`"3-abc", "4-def"`, false, 4, 4, 0},
+		{`"3-abc", "4-def"`, false, 3, 3, 0},
+		{`"3-abc", "4-def"`, false, 5, 0, http.StatusPreconditionFailed},
+		{`"4", "4-abc"`, false, 9, 4, 0},
+		{`W/"4", "3"`, false, 4, 3, 0},
+		{`"3", *`, false, 4, 0, 0},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		h := &ProductHandler{productService: &fakeProducts{version: tt.current}, concurrency: config.ConcurrencyConfig{RequireIfMatch: tt.require}}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/products/507f1f77bcf86cd799439011", nil)
+		c.Request.Header.Set("If-Match", tt.header)
+
+		version, ok := h.ifMatchVersion(c)
+		if version != tt.wantVersion || ok != (tt.wantStatus == 0) {
+			t.Errorf("ifMatchVersion(%q, required %v) = %d, %v, want %d, %v", tt.header, tt.require, version, ok, tt.wantVersion, tt.wantStatus == 0)
+		}
+		if tt.wantStatus != 0 && w.Code != tt.wantStatus {
+			t.Errorf("ifMatchVersion(%q, required %v) answered %d, want %d", tt.header, tt.require, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"2", "3"`, true},
+		{`*`, true},
+		{`"2"`, false},
+		{`"33"`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		if got := noneMatch(tt.header, etag(3)); got != tt.want {
+			t.Errorf("noneMatch(%q, %s) = %v, want %v", tt.header, etag(3), got, tt.want)
+		}
+	}
+}
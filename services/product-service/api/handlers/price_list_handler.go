@@ -0,0 +1,285 @@
+// api/handlers/price_list_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type PriceListHandler struct {
+	priceListService service.PriceListService
+	logger           logger.Logger
+}
+
+func NewPriceListHandler(priceListService service.PriceListService, logger logger.Logger) *PriceListHandler {
+	return &PriceListHandler{
+		priceListService: priceListService,
+		logger:           logger,
+	}
+}
+
+// ListPriceLists godoc
+// @Summary List price lists
+// @Description Every price list, the default one first
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.PriceList
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists [get]
+// @Security BearerAuth
+func (h *PriceListHandler) ListPriceLists(c *gin.Context) {
+	lists, err := h.priceListService.ListPriceLists(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get price lists", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve price lists",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// GetPriceList godoc
+// @Summary Get price list
+// @Description Get a price list by ID
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Success 200 {object} domain.PriceList
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists/{id} [get]
+// @Security BearerAuth
+func (h *PriceListHandler) GetPriceList(c *gin.Context) {
+	id := c.Param("id")
+
+	list, err := h.priceListService.GetPriceList(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get price list", err, logger.Fields{"priceListId": id})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve price list",
+		})
+		return
+	}
+
+	if list == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Price list not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// CreatePriceList godoc
+// @Summary Create price list
+// @Description Create a price list for a currency, optionally limited to a region or customer group (admin only).
+// @Description Products are priced in it through their prices, keyed by the list ID.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param priceList body domain.PriceList true "Price list"
+// @Success 201 {object} domain.PriceList
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists [post]
+// @Security BearerAuth
+func (h *PriceListHandler) CreatePriceList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var list domain.PriceList
+	if err := c.ShouldBindJSON(&list); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := list.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.priceListService.CreatePriceList(c.Request.Context(), list)
+	if err != nil {
+		h.logger.Error("Failed to create price list", err, logger.Fields{"priceListId": list.ID})
+		h.respondError(c, err, "Failed to create price list")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdatePriceList godoc
+// @Summary Replace price list
+// @Description Change the name, currency, region and customer group of a price list (admin only).
+// @Description The currency cannot change while products have prices in the list, nor for the default list.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Param priceList body domain.PriceList true "Price list"
+// @Success 200 {object} domain.PriceList
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists/{id} [put]
+// @Security BearerAuth
+func (h *PriceListHandler) UpdatePriceList(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var list domain.PriceList
+	if err := c.ShouldBindJSON(&list); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	// The ID identifies the list and cannot be changed
+	list.ID = id
+	if err := list.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	updated, err := h.priceListService.UpdatePriceList(c.Request.Context(), list)
+	if err != nil {
+		h.logger.Error("Failed to update price list", err, logger.Fields{"priceListId": id})
+		h.respondError(c, err, "Failed to update price list")
+		return
+	}
+
+	if updated == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Price list not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePriceList godoc
+// @Summary Delete price list
+// @Description Delete a price list no product has prices in (admin only). The default list cannot be deleted.
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /price-lists/{id} [delete]
+// @Security BearerAuth
+func (h *PriceListHandler) DeletePriceList(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	if err := h.priceListService.DeletePriceList(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete price list", err, logger.Fields{"priceListId": id})
+		h.respondError(c, err, "Failed to delete price list")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondError maps the errors of the price list service to a response, or
+// answers with message and a 500.
+func (h *PriceListHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		respondValidationError(c, err)
+	case errors.Is(err, domain.ErrPriceListNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Price list not found",
+		})
+	case errors.Is(err, domain.ErrPriceListExists):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "A price list with this ID already exists",
+		})
+	case errors.Is(err, domain.ErrPriceListInUse):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "Price list is the default or products have prices in it",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  message,
+		})
+	}
+}
+
+// priceContext reads who is asking for prices: the price_list, currency and
+// region query parameters, the first supported currency of Accept-Currency,
+// and the customer group of the token. It writes a 400 and returns false
+// for an unsupported currency.
+func priceContext(c *gin.Context) (domain.PriceContext, bool) {
+	pc := domain.PriceContext{
+		PriceList:     c.Query("price_list"),
+		Region:        strings.ToUpper(strings.TrimSpace(c.Query("region"))),
+		CustomerGroup: c.GetString("CustomerGroup"),
+		Staff:         isStaff(c),
+	}
+
+	if currency := c.Query("currency"); currency != "" {
+		code, err := domain.ParseCurrency(currency)
+		if err != nil {
+			verr := &domain.ValidationError{}
+			verr.Add("currency", "%s", err.Error())
+			respondValidationError(c, verr)
+			return pc, false
+		}
+		pc.Currency = code
+		return pc, true
+	}
+
+	// Accept-Currency: EUR, USD;q=0.5; weights are not honoured beyond order
+	for _, part := range strings.Split(c.GetHeader("Accept-Currency"), ",") {
+		code, _, _ := strings.Cut(part, ";")
+		if code, err := domain.ParseCurrency(code); err == nil {
+			pc.Currency = code
+			break
+		}
+	}
+
+	return pc, true
+}
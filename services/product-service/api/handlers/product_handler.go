@@ -2,24 +2,40 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/ntdt/product-service/api/middleware"
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/consumer"
 	"github.com/ntdt/product-service/internal/domain"
 	"github.com/ntdt/product-service/internal/service"
 	"github.com/ntdt/product-service/pkg/logger"
 )
 
 type ProductHandler struct {
-	productService service.ProductService
-	logger         logger.Logger
+	productService   service.ProductService
+	priceListService service.PriceListService
+	logger           logger.Logger
+	pagination       config.PaginationConfig
+	concurrency      config.ConcurrencyConfig
 }
 
-func NewProductHandler(productService service.ProductService, logger logger.Logger) *ProductHandler {
+func NewProductHandler(productService service.ProductService, priceListService service.PriceListService, logger logger.Logger, pagination config.PaginationConfig, concurrency config.ConcurrencyConfig) *ProductHandler {
 	return &ProductHandler{
-		productService: productService,
-		logger:         logger,
+		productService:   productService,
+		priceListService: priceListService,
+		logger:           logger,
+		pagination:       pagination,
+		concurrency:      concurrency,
 	}
 }
 
@@ -29,16 +45,32 @@ func NewProductHandler(productService service.ProductService, logger logger.Logg
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param name query string false "Product name (supports partial matching)"
-// @Param categories query []string false "Product categories"
-// @Param min_price query number false "Minimum price"
-// @Param max_price query number false "Maximum price"
-// @Param sort_by query string false "Field to sort by"
+// @Param name query string false "Product name, matched literally and case-insensitively"
+// @Param name_match query string false "How name is matched (exact, prefix or contains)" default(exact)
+// @Param categories query []string false "Category slugs or names; only the categories themselves, not their descendants"
+// @Param category_id query string false "Category ID; includes its descendants"
+// @Param min_price query number false "Minimum price in the currency of the default price list (0 is a valid bound)"
+// @Param max_price query number false "Maximum price in the currency of the default price list (0 is a valid bound)"
+// @Param filter query string false "Filter expression, e.g. price ge 10 and inventory gt 0 and categories any ('shoes','sale'); prices are in the currency of the default price list"
+// @Param sort_by query string false "Field to sort by (name, price, sku, inventory, created_at, updated_at)"
 // @Param sort_order query string false "Sort order (asc or desc)"
 // @Param limit query int false "Number of records to return" default(10)
-// @Param offset query int false "Number of records to skip" default(0)
-// @Success 200 {array} domain.Product
+// @Param offset query int false "Number of records to skip (ignored when cursor is set)" default(0)
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor"
+// @Param fields query string false "Comma separated fields or presets (summary, full) to return; id is always included. effective_price also sets the effective prices of the variants selected"
+// @Param include_deleted query bool false "Include products in the trash (admin only)"
+// @Param status query []string false "Lifecycle states to include (draft, review, published, discontinued, archived); the public only sees published"
+// @Param variant query []string false "Variant attributes as option:value, e.g. size:M; one variant must match all of them"
+// @Param attribute query []string false "Custom attribute values as name:value, or name:min..max for numbers, e.g. brand:acme or weight:1..5"
+// @Param location query string false "Stock location ID; only products with stock available there"
+// @Param price_list query string false "Price list to resolve effective prices from"
+// @Param currency query string false "Currency of effective prices (ISO 4217); overrides Accept-Currency"
+// @Param region query string false "Region of the caller, selecting regional price lists"
+// @Param Accept-Currency header string false "Preferred currencies of effective prices, the first supported one is used"
+// @Success 200 {object} domain.ProductPage
+// @Header 200 {string} Link "RFC 8288 links to the next, prev and first pages"
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products [get]
 // @Security BearerAuth
@@ -47,14 +79,46 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		h.logger.Error("Failed to bind query parameters", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Status: http.StatusBadRequest,
-			Error:  "Invalid query parameters",
+			Status:  http.StatusBadRequest,
+			Error:   "Invalid query parameters",
+			Details: err.Error(),
 		})
 		return
 	}
 
-	products, err := h.productService.GetProducts(c.Request.Context(), filter)
+	if err := filter.Validate(h.pagination.MaxLimit); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	if filter.IncludeDeleted && !requireAdmin(c) {
+		return
+	}
+
+	if !restrictToPublished(c, &filter) {
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.productService.GetProducts(c.Request.Context(), filter)
 	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			respondValidationError(c, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid or expired cursor",
+			})
+			return
+		}
+
 		h.logger.Error("Failed to get products", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Status: http.StatusInternalServerError,
@@ -63,7 +127,224 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	if link := paginationLinks(c.Request.URL, page); link != "" {
+		c.Header("Link", link)
+	}
+
+	products := make([]*domain.Product, 0, len(page.Items))
+	for i := range page.Items {
+		products = append(products, &page.Items[i])
+	}
+	if !h.applyDerived(c, pc, filter.FieldSet, products...) {
+		return
+	}
+
+	if filter.FieldSet != nil {
+		c.JSON(http.StatusOK, ProductPageResponse{
+			ProductPage: page,
+			Items:       projectProducts(page.Items, filter.FieldSet),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// paginationLinks builds an RFC 8288 Link header value pointing at the
+// next, previous and first pages of the current listing.
+func paginationLinks(u *url.URL, page *domain.ProductPage) string {
+	link := func(rel, cursor string) string {
+		query := u.Query()
+		query.Del("offset")
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, link("next", page.NextCursor))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, link("prev", page.PrevCursor))
+	}
+	links = append(links, link("first", ""))
+
+	return strings.Join(links, ", ")
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Full-text search over name, SKU, categories and description, ranked by relevance.
+// @Description Falls back to prefix and typo tolerant matching when no word matches exactly.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param q query string true "Search terms"
+// @Param name query string false "Product name, matched literally and case-insensitively"
+// @Param name_match query string false "How name is matched (exact, prefix or contains)" default(exact)
+// @Param categories query []string false "Category slugs or names; only the categories themselves, not their descendants"
+// @Param category_id query string false "Category ID; includes its descendants"
+// @Param min_price query number false "Minimum price in the currency of the default price list (0 is a valid bound)"
+// @Param max_price query number false "Maximum price in the currency of the default price list (0 is a valid bound)"
+// @Param filter query string false "Filter expression, e.g. price ge 10 and inventory gt 0; prices are in the currency of the default price list"
+// @Param sort_by query string false "Field to sort by instead of relevance (name, price, sku, inventory, created_at, updated_at)"
+// @Param sort_order query string false "Sort order (asc or desc)"
+// @Param limit query int false "Number of records to return" default(10)
+// @Param offset query int false "Number of records to skip" default(0)
+// @Param fields query string false "Comma separated fields or presets (summary, full) to return; id is always included. effective_price also sets the effective prices of the variants selected"
+// @Param include_deleted query bool false "Include products in the trash (admin only)"
+// @Param status query []string false "Lifecycle states to include (draft, review, published, discontinued, archived); the public only sees published"
+// @Param variant query []string false "Variant attributes as option:value, e.g. size:M; one variant must match all of them"
+// @Param attribute query []string false "Custom attribute values as name:value, or name:min..max for numbers, e.g. brand:acme or weight:1..5"
+// @Param location query string false "Stock location ID; only products with stock available there"
+// @Param price_list query string false "Price list to resolve effective prices from"
+// @Param currency query string false "Currency of effective prices (ISO 4217); overrides Accept-Currency"
+// @Param region query string false "Region of the caller, selecting regional price lists"
+// @Param Accept-Currency header string false "Preferred currencies of effective prices, the first supported one is used"
+// @Success 200 {object} domain.SearchPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/search [get]
+// @Security BearerAuth
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	var query domain.SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Failed to bind query parameters", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status:  http.StatusBadRequest,
+			Error:   "Invalid query parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if err := query.Validate(h.pagination.MaxLimit); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	if query.IncludeDeleted && !requireAdmin(c) {
+		return
+	}
+
+	if !restrictToPublished(c, &query.ProductFilter) {
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.productService.SearchProducts(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			respondValidationError(c, err)
+			return
+		}
+
+		h.logger.Error("Failed to search products", err, logger.Fields{"q": query.Q})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to search products",
+		})
+		return
+	}
+
+	products := make([]*domain.Product, 0, len(page.Items))
+	for i := range page.Items {
+		products = append(products, &page.Items[i].Product)
+	}
+	if !h.applyDerived(c, pc, query.FieldSet, products...) {
+		return
+	}
+
+	if query.FieldSet != nil {
+		items := make([]SearchResultResponse, 0, len(page.Items))
+		for _, item := range page.Items {
+			items = append(items, SearchResultResponse{
+				SearchResult: item,
+				Product:      query.FieldSet.Project(item.Product),
+			})
+		}
+		c.JSON(http.StatusOK, SearchPageResponse{SearchPage: page, Items: items})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetFacets godoc
+// @Summary Product facets
+// @Description Category counts, price histogram, stock counts, price range and attribute value counts of the products matching the filter
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param name query string false "Product name, matched literally and case-insensitively"
+// @Param name_match query string false "How name is matched (exact, prefix or contains)" default(exact)
+// @Param categories query []string false "Category slugs or names; only the categories themselves, not their descendants"
+// @Param category_id query string false "Category ID; includes its descendants"
+// @Param min_price query number false "Minimum price in the currency of the default price list (0 is a valid bound)"
+// @Param max_price query number false "Maximum price in the currency of the default price list (0 is a valid bound)"
+// @Param filter query string false "Filter expression, e.g. price ge 10 and inventory gt 0; prices are in the currency of the default price list"
+// @Param include_deleted query bool false "Include products in the trash (admin only)"
+// @Param status query []string false "Lifecycle states to include (draft, review, published, discontinued, archived); the public only sees published"
+// @Param variant query []string false "Variant attributes as option:value, e.g. size:M; one variant must match all of them"
+// @Param attribute query []string false "Custom attribute values as name:value, or name:min..max for numbers, e.g. brand:acme or weight:1..5"
+// @Param facet_attributes query []string false "Custom attributes to count values of"
+// @Param location query string false "Stock location ID; only products with stock available there"
+// @Success 200 {object} domain.ProductFacets
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/facets [get]
+// @Security BearerAuth
+func (h *ProductHandler) GetFacets(c *gin.Context) {
+	var filter domain.ProductFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		h.logger.Error("Failed to bind query parameters", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status:  http.StatusBadRequest,
+			Error:   "Invalid query parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if err := filter.Validate(h.pagination.MaxLimit); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	if filter.IncludeDeleted && !requireAdmin(c) {
+		return
+	}
+
+	if !restrictToPublished(c, &filter) {
+		return
+	}
+
+	facets, err := h.productService.GetFacets(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			respondValidationError(c, err)
+			return
+		}
+
+		h.logger.Error("Failed to get product facets", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve product facets",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, facets)
 }
 
 // GetProduct godoc
@@ -73,7 +354,18 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param fields query string false "Comma separated fields or presets (summary, full) to return; id is always included. effective_price also sets the effective prices of the variants selected"
+// @Param include_deleted query bool false "Also find the product if it is in the trash (admin only)"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param price_list query string false "Price list to resolve effective prices from"
+// @Param currency query string false "Currency of effective prices (ISO 4217); overrides Accept-Currency"
+// @Param region query string false "Region of the caller, selecting regional price lists"
+// @Param Accept-Currency header string false "Preferred currencies of effective prices, the first supported one is used"
 // @Success 200 {object} domain.Product
+// @Header 200 {string} ETag "Product version and a fingerprint of the prices that apply to the caller, omitted for sparse fieldsets; If-Match accepts it"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [get]
@@ -81,9 +373,43 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	product, err := h.productService.GetProductByID(c.Request.Context(), id)
+	fields, err := domain.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		verr := &domain.ValidationError{}
+		verr.Add("fields", "%s", err.Error())
+		respondValidationError(c, verr)
+		return
+	}
+
+	includeDeleted, err := strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+	if err != nil {
+		verr := &domain.ValidationError{}
+		verr.Add("include_deleted", "must be true or false")
+		respondValidationError(c, verr)
+		return
+	}
+
+	if includeDeleted && !requireAdmin(c) {
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.GetProductByID(c.Request.Context(), id, includeDeleted)
 	if err != nil {
 		h.logger.Error("Failed to get product", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Status: http.StatusInternalServerError,
 			Error:  "Failed to retrieve product",
@@ -91,7 +417,8 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	if product == nil {
+	// Unpublished products do not exist as far as the public is concerned
+	if product == nil || !visible(c, product) {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Status: http.StatusNotFound,
 			Error:  "Product not found",
@@ -99,9 +426,178 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	// The tag is settled before prices and stock are resolved, so that a
+	// cached copy costs no more reads. Stock moves bump the version, which
+	// covers the locations; the effective prices also depend on the price
+	// lists and on the caller.
+	if fields == nil {
+		lists, err := h.priceListService.PriceLists(c.Request.Context())
+		if err != nil {
+			h.logger.Error("Failed to resolve prices", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Status: http.StatusInternalServerError,
+				Error:  "Failed to resolve prices",
+			})
+			return
+		}
+
+		tag := representationTag(product.Version, lists.Fingerprint(pc))
+		c.Header("ETag", tag)
+		c.Header("Vary", priceVary)
+		if noneMatch(c.GetHeader("If-None-Match"), tag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	if !h.applyDerived(c, pc, fields, product) {
+		return
+	}
+
+	if fields != nil {
+		c.JSON(http.StatusOK, fields.Project(*product))
+		return
+	}
+
 	c.JSON(http.StatusOK, product)
 }
 
+// GetProductBySKU godoc
+// @Summary Get product by SKU
+// @Description Get a product by its SKU or the SKU of one of its variants (case-insensitive).
+// @Description A variant SKU also returns the matching variant.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param sku path string true "Product or variant SKU"
+// @Param price_list query string false "Price list to resolve effective prices from"
+// @Param currency query string false "Currency of effective prices (ISO 4217); overrides Accept-Currency"
+// @Param region query string false "Region of the caller, selecting regional price lists"
+// @Param Accept-Currency header string false "Preferred currencies of effective prices, the first supported one is used"
+// @Success 200 {object} SKUProductResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/sku/{sku} [get]
+// @Security BearerAuth
+func (h *ProductHandler) GetProductBySKU(c *gin.Context) {
+	sku := c.Param("sku")
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.GetProductBySKU(c.Request.Context(), sku)
+	if err != nil {
+		h.logger.Error("Failed to get product by SKU", err, logger.Fields{"sku": sku})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve product",
+		})
+		return
+	}
+
+	if product == nil || !visible(c, product) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	if !h.applyPrices(c, pc, product) {
+		return
+	}
+	if !h.applyLocations(c, product) {
+		return
+	}
+
+	c.JSON(http.StatusOK, SKUProductResponse{
+		Product: product,
+		Variant: product.Variant(domain.NormalizeSKU(sku)),
+	})
+}
+
+// ResolveSKUs godoc
+// @Summary Resolve SKUs
+// @Description Look up several products by SKU in one call
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body SKUResolveRequest true "SKUs to resolve"
+// @Param price_list query string false "Price list to resolve effective prices from"
+// @Param currency query string false "Currency of effective prices (ISO 4217); overrides Accept-Currency"
+// @Param region query string false "Region of the caller, selecting regional price lists"
+// @Param Accept-Currency header string false "Preferred currencies of effective prices, the first supported one is used"
+// @Success 200 {object} SKUResolveResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/sku/resolve [post]
+// @Security BearerAuth
+func (h *ProductHandler) ResolveSKUs(c *gin.Context) {
+	var req SKUResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	found, notFound, err := h.productService.ResolveSKUs(c.Request.Context(), req.SKUs)
+	if err != nil {
+		h.logger.Error("Failed to resolve SKUs", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to resolve SKUs",
+		})
+		return
+	}
+
+	if !isStaff(c) {
+		for sku, product := range found {
+			if !visible(c, &product) {
+				delete(found, sku)
+				notFound = append(notFound, sku)
+			}
+		}
+		sort.Strings(notFound)
+	}
+
+	if notFound == nil {
+		notFound = []string{}
+	}
+
+	priced := make(map[string]*domain.Product, len(found))
+	products := make([]*domain.Product, 0, len(found))
+	for sku, product := range found {
+		product := product
+		priced[sku] = &product
+		products = append(products, &product)
+	}
+	if !h.applyPrices(c, pc, products...) {
+		return
+	}
+	if !h.applyLocations(c, products...) {
+		return
+	}
+	for sku, product := range priced {
+		found[sku] = *product
+	}
+
+	c.JSON(http.StatusOK, SKUResolveResponse{
+		Products: found,
+		NotFound: notFound,
+	})
+}
+
 // CreateProduct godoc
 // @Summary Create product
 // @Description Create a new product
@@ -111,6 +607,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 // @Param product body domain.Product true "Product data"
 // @Success 201 {object} domain.Product
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products [post]
 // @Security BearerAuth
@@ -128,6 +625,22 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	createdProduct, err := h.productService.CreateProduct(c.Request.Context(), product)
 	if err != nil {
 		h.logger.Error("Failed to create product", err)
+
+		if errors.Is(err, domain.ErrValidation) {
+			respondValidationError(c, err)
+			return
+		}
+
+		var dupErr *domain.DuplicateSKUError
+		if errors.As(err, &dupErr) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status:  http.StatusConflict,
+				Error:   "A product with this SKU already exists",
+				Details: gin.H{"sku": dupErr.SKU, "product_id": dupErr.ProductID, "deleted": dupErr.Deleted},
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Status: http.StatusInternalServerError,
 			Error:  "Failed to create product",
@@ -135,26 +648,45 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", etag(createdProduct.Version))
 	c.JSON(http.StatusCreated, createdProduct)
 }
 
 // UpdateProduct godoc
-// @Summary Update product
-// @Description Update an existing product
+// @Summary Replace product
+// @Description Replace every client-managed field of a product. Omitted fields are reset;
+// @Description id, created_at and updated_at are managed by the server and ignored.
+// @Description Stock is kept as stored; it changes through inventory adjustments, except for new variants.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the update is conditional on"
+// @Param reason query string false "Reason recorded in the price history for the prices the update changes"
 // @Param product body domain.Product true "Product data"
 // @Success 200 {object} domain.Product
+// @Header 200 {string} ETag "New product version"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [put]
 // @Security BearerAuth
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	audit, ok := priceAudit(c)
+	if !ok {
+		return
+	}
+
 	var product domain.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
 		h.logger.Error("Failed to bind request body", err)
@@ -165,9 +697,38 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	updatedProduct, err := h.productService.UpdateProduct(c.Request.Context(), id, product)
+	updatedProduct, err := h.productService.UpdateProduct(c.Request.Context(), id, product, version, audit)
 	if err != nil {
 		h.logger.Error("Failed to update product", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrValidation) {
+			respondValidationError(c, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			respondPreconditionFailed(c)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		var dupErr *domain.DuplicateSKUError
+		if errors.As(err, &dupErr) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status:  http.StatusConflict,
+				Error:   "A product with this SKU already exists",
+				Details: gin.H{"sku": dupErr.SKU, "product_id": dupErr.ProductID, "deleted": dupErr.Deleted},
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Status: http.StatusInternalServerError,
 			Error:  "Failed to update product",
@@ -183,29 +744,159 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", etag(updatedProduct.Version))
 	c.JSON(http.StatusOK, updatedProduct)
 }
 
+// PatchProduct godoc
+// @Summary Patch product
+// @Description Update only the fields given in a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) document
+// @Tags products
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the patch is conditional on"
+// @Param reason query string false "Reason recorded in the price history for the prices the patch changes"
+// @Param patch body object true "Patch document"
+// @Success 200 {object} domain.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id} [patch]
+// @Security BearerAuth
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	audit, ok := priceAudit(c)
+	if !ok {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		h.logger.Error("Failed to read request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	patchedProduct, err := h.productService.PatchProduct(c.Request.Context(), id, c.ContentType(), patch, version, audit)
+	if err != nil {
+		h.logger.Error("Failed to patch product", err, logger.Fields{"productId": id})
+
+		switch {
+		case errors.Is(err, domain.ErrVersionMismatch):
+			respondPreconditionFailed(c)
+			return
+		case errors.Is(err, domain.ErrUnsupportedPatch):
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Status:  http.StatusUnsupportedMediaType,
+				Error:   "Unsupported patch format",
+				Details: []string{domain.MergePatchMediaType, domain.JSONPatchMediaType},
+			})
+			return
+		case errors.Is(err, domain.ErrInvalidPatch):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status:  http.StatusBadRequest,
+				Error:   "Invalid patch",
+				Details: err.Error(),
+			})
+			return
+		case errors.Is(err, domain.ErrValidation):
+			respondValidationError(c, err)
+			return
+		case errors.Is(err, domain.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		var dupErr *domain.DuplicateSKUError
+		if errors.As(err, &dupErr) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status:  http.StatusConflict,
+				Error:   "A product with this SKU already exists",
+				Details: gin.H{"sku": dupErr.SKU, "product_id": dupErr.ProductID, "deleted": dupErr.Deleted},
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to patch product",
+		})
+		return
+	}
+
+	if patchedProduct == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.Header("ETag", etag(patchedProduct.Version))
+	c.JSON(http.StatusOK, patchedProduct)
+}
+
 // DeleteProduct godoc
 // @Summary Delete product
-// @Description Delete a product
+// @Description Move a product to the trash. It can be restored until the retention period ends.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the deletion is conditional on"
 // @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [delete]
 // @Security BearerAuth
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.productService.DeleteProduct(c.Request.Context(), id)
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err := h.productService.DeleteProduct(c.Request.Context(), id, version, c.GetString("UserID"))
 	if err != nil {
 		h.logger.Error("Failed to delete product", err, logger.Fields{"productId": id})
 
-		if err.Error() == "mongo: no documents in result" {
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			respondPreconditionFailed(c)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, ErrorResponse{
 				Status: http.StatusNotFound,
 				Error:  "Product not found",
@@ -223,23 +914,803 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// RestoreProduct godoc
+// @Summary Restore product
+// @Description Take a deleted product out of the trash (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the restore is conditional on"
+// @Success 200 {object} domain.Product
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/restore [post]
+// @Security BearerAuth
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	restoredProduct, err := h.productService.RestoreProduct(c.Request.Context(), id, version)
+	if err != nil {
+		h.logger.Error("Failed to restore product", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			respondPreconditionFailed(c)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrProductNotDeleted) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "Product is not deleted",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to restore product",
+		})
+		return
+	}
+
+	if restoredProduct == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.Header("ETag", etag(restoredProduct.Version))
+	c.JSON(http.StatusOK, restoredProduct)
+}
+
 // HealthCheck godoc
 // @Summary Health check endpoint
-// @Description Check if service is healthy
+// @Description Check if service is healthy. The service keeps serving requests while an event consumer is unhealthy;
+// @Description the status is then "degraded" and the consumers report their lag and errors.
 // @Tags health
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
 // @Router /health [get]
-func HealthCheck() gin.HandlerFunc {
+func HealthCheck(consumers ...consumer.Reporter) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		status := "ok"
+		statuses := make([]consumer.Status, 0, len(consumers))
+		for _, r := range consumers {
+			s := r.Status()
+			if !s.Healthy {
+				status = "degraded"
+			}
+			statuses = append(statuses, s)
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
+			"status":    status,
+			"consumers": statuses,
+		})
+	}
+}
+
+// TransitionProduct godoc
+// @Summary Change product status
+// @Description Move a product through its lifecycle: draft, review, published, discontinued, archived (editor or admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the transition is conditional on"
+// @Param request body TransitionRequest true "Target status"
+// @Success 200 {object} domain.Product
+// @Header 200 {string} ETag "Product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/transitions [post]
+// @Security BearerAuth
+func (h *ProductHandler) TransitionProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if !req.Status.Valid() {
+		verr := &domain.ValidationError{}
+		verr.Add("status", "must be one of %v", domain.Statuses)
+		respondValidationError(c, verr)
+		return
+	}
+
+	updatedProduct, err := h.productService.TransitionProduct(c.Request.Context(), id, req.Status, c.GetString("UserID"), version)
+	if err != nil {
+		h.logger.Error("Failed to change product status", err, logger.Fields{"productId": id, "status": req.Status})
+
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			respondPreconditionFailed(c)
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		var transErr *domain.TransitionError
+		if errors.As(err, &transErr) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status:  http.StatusConflict,
+				Error:   transErr.Error(),
+				Details: gin.H{"status": transErr.From, "allowed": transErr.From.Transitions()},
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to change product status",
+		})
+		return
+	}
+
+	if updatedProduct == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
 		})
+		return
 	}
+
+	c.Header("ETag", etag(updatedProduct.Version))
+	c.JSON(http.StatusOK, updatedProduct)
+}
+
+// GetPriceHistory godoc
+// @Summary Price history
+// @Description Price changes of a product, including its variants and price lists, most recent first (editor or admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param from query string false "Only changes at or after this time (RFC 3339)"
+// @Param to query string false "Only changes at or before this time (RFC 3339)"
+// @Param limit query int false "Number of records to return" default(50)
+// @Param offset query int false "Number of records to skip" default(0)
+// @Success 200 {array} domain.PriceChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price-history [get]
+// @Security BearerAuth
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	var query domain.PriceHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Failed to bind query parameters", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status:  http.StatusBadRequest,
+			Error:   "Invalid query parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	changes, err := h.productService.GetPriceHistory(c.Request.Context(), id, query)
+	if err != nil {
+		h.logger.Error("Failed to get price history", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve price history",
+		})
+		return
+	}
+
+	if changes == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// AdjustInventory godoc
+// @Summary Adjust inventory
+// @Description Atomically add to or take from the stock of a product, or of one of its variants, at a location (the default
+// @Description location when left out) and record the movement in the inventory ledger (editor or admin only).
+// @Description Stock reserved for orders cannot be taken, so the available stock never drops below zero.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param adjustment body domain.InventoryAdjustment true "Inventory adjustment"
+// @Success 201 {object} domain.InventoryMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/inventory/adjustments [post]
+// @Security BearerAuth
+func (h *ProductHandler) AdjustInventory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	var adjustment domain.InventoryAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := adjustment.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	movement, err := h.productService.AdjustInventory(c.Request.Context(), id, adjustment, c.GetString("UserID"))
+	if err != nil {
+		h.logger.Error("Failed to adjust inventory", err, logger.Fields{"productId": id})
+
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			respondValidationError(c, err)
+		case errors.Is(err, domain.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+		case errors.Is(err, domain.ErrInsufficientInventory):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "Insufficient inventory",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Status: http.StatusInternalServerError,
+				Error:  "Failed to adjust inventory",
+			})
+		}
+		return
+	}
+
+	if movement == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+// ListInventoryMovements godoc
+// @Summary Inventory ledger
+// @Description Stock movements of a product, most recent first (editor or admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param sku query string false "Only movements of this SKU"
+// @Param reason query string false "Only movements for this reason" Enums(receipt, sale, return, correction, transfer)
+// @Param location query string false "Only movements at this stock location"
+// @Param reference query string false "Only movements with this reference, e.g. an order ID"
+// @Param from query string false "Only movements at or after this time (RFC 3339)"
+// @Param to query string false "Only movements at or before this time (RFC 3339)"
+// @Param limit query int false "Number of records to return" default(50)
+// @Param offset query int false "Number of records to skip" default(0)
+// @Success 200 {array} domain.InventoryMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/inventory/movements [get]
+// @Security BearerAuth
+func (h *ProductHandler) ListInventoryMovements(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	var query domain.InventoryMovementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Failed to bind query parameters", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status:  http.StatusBadRequest,
+			Error:   "Invalid query parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	movements, err := h.productService.GetInventoryMovements(c.Request.Context(), id, query)
+	if err != nil {
+		h.logger.Error("Failed to get inventory movements", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve inventory movements",
+		})
+		return
+	}
+
+	if movements == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+// TransferStock godoc
+// @Summary Transfer stock
+// @Description Move available stock of a product, or of one of its variants, from one location to another and record
+// @Description both sides in the inventory ledger (editor or admin only). The stock of the product as a whole does not change.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param transfer body domain.StockTransfer true "Stock transfer"
+// @Success 201 {array} domain.InventoryMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/inventory/transfers [post]
+// @Security BearerAuth
+func (h *ProductHandler) TransferStock(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	var transfer domain.StockTransfer
+	if err := c.ShouldBindJSON(&transfer); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := transfer.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	movements, err := h.productService.TransferStock(c.Request.Context(), id, transfer, c.GetString("UserID"))
+	if err != nil {
+		h.logger.Error("Failed to transfer stock", err, logger.Fields{"productId": id})
+
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			respondValidationError(c, err)
+		case errors.Is(err, domain.ErrInvalidID):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+		case errors.Is(err, domain.ErrInsufficientInventory):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "Insufficient inventory at the source location",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Status: http.StatusInternalServerError,
+				Error:  "Failed to transfer stock",
+			})
+		}
+		return
+	}
+
+	if movements == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, movements)
+}
+
+// GetLocationStock godoc
+// @Summary Stock by location
+// @Description Stock on hand, reserved and available of every SKU of a product at every location that keeps it (editor or admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} domain.LocationStock
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/inventory/locations [get]
+// @Security BearerAuth
+func (h *ProductHandler) GetLocationStock(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	levels, err := h.productService.GetLocationStock(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get location stock", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve location stock",
+		})
+		return
+	}
+
+	if levels == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+// ReconcileInventory godoc
+// @Summary Reconcile inventory
+// @Description Compare the stock of every SKU of a product with the sum of its ledger, by reason (editor or admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} domain.InventoryReconciliation
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/inventory/reconciliation [get]
+// @Security BearerAuth
+func (h *ProductHandler) ReconcileInventory(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	reconciliation, err := h.productService.ReconcileInventory(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to reconcile inventory", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to reconcile inventory",
+		})
+		return
+	}
+
+	if reconciliation == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reconciliation)
+}
+
+// priceAudit reads the author of a write from the token and the reason for
+// its price changes from the reason query parameter. It writes a 400 and
+// returns false if the reason is too long.
+func priceAudit(c *gin.Context) (domain.Audit, bool) {
+	audit := domain.Audit{
+		By:     c.GetString("UserID"),
+		Reason: strings.TrimSpace(c.Query("reason")),
+	}
+	if err := audit.Validate(); err != nil {
+		respondValidationError(c, err)
+		return audit, false
+	}
+	return audit, true
+}
+
+// isStaff reports whether the caller may see and manage products that are
+// not published.
+func isStaff(c *gin.Context) bool {
+	return middleware.HasRole(c, middleware.RoleAdmin) || middleware.HasRole(c, middleware.RoleEditor)
+}
+
+// visible reports whether the caller may see p. The public only sees
+// published products.
+func visible(c *gin.Context, p *domain.Product) bool {
+	return p.Status == domain.StatusPublished || isStaff(c)
+}
+
+// restrictToPublished limits a listing to published products unless the
+// caller is staff. It writes a 403 and returns false if the public asked
+// for other states.
+func restrictToPublished(c *gin.Context, filter *domain.ProductFilter) bool {
+	if isStaff(c) {
+		return true
+	}
+
+	if len(filter.Status) == 0 {
+		filter.Status = []domain.Status{domain.StatusPublished}
+		return true
+	}
+	if filter.OnlyPublished() {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Status: http.StatusForbidden,
+		Error:  "Only published products are visible",
+	})
+	return false
+}
+
+// priceVary lists the request headers effective prices depend on; the
+// customer group comes from the token.
+const priceVary = "Accept-Currency, Authorization"
+
+// applyPrices sets the effective prices of products for the caller. It
+// writes an error response and returns false if they cannot be resolved.
+func (h *ProductHandler) applyPrices(c *gin.Context, pc domain.PriceContext, products ...*domain.Product) bool {
+	c.Header("Vary", priceVary)
+
+	err := h.priceListService.ApplyPrices(c.Request.Context(), pc, products...)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, domain.ErrValidation) {
+		respondValidationError(c, err)
+		return false
+	}
+
+	h.logger.Error("Failed to resolve prices", err)
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Status: http.StatusInternalServerError,
+		Error:  "Failed to resolve prices",
+	})
+	return false
+}
+
+// applyDerived sets the fields of products that are not stored, as far as
+// fields selects them. It writes an error response and returns false if they
+// cannot be resolved.
+func (h *ProductHandler) applyDerived(c *gin.Context, pc domain.PriceContext, fields domain.FieldSet, products ...*domain.Product) bool {
+	if fields.Has("effective_price") && !h.applyPrices(c, pc, products...) {
+		return false
+	}
+	if fields.Has("locations") && !h.applyLocations(c, products...) {
+		return false
+	}
+	return true
+}
+
+// applyLocations sets the stock of products at each location. It writes a
+// 500 and returns false if it cannot be read.
+func (h *ProductHandler) applyLocations(c *gin.Context, products ...*domain.Product) bool {
+	if err := h.productService.ApplyLocations(c.Request.Context(), products...); err != nil {
+		h.logger.Error("Failed to get location stock", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve location stock",
+		})
+		return false
+	}
+	return true
+}
+
+// requireStaff writes a 403 and returns false unless the caller has the
+// editor or admin role.
+func requireStaff(c *gin.Context) bool {
+	if isStaff(c) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Status: http.StatusForbidden,
+		Error:  "Editor or administrator role required",
+	})
+	return false
+}
+
+// requireAdmin writes a 403 and returns false unless the caller has the
+// admin role. The trash is only visible to administrators.
+func requireAdmin(c *gin.Context) bool {
+	if middleware.HasRole(c, middleware.RoleAdmin) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Status: http.StatusForbidden,
+		Error:  "Administrator role required",
+	})
+	return false
+}
+
+// respondValidationError writes a 400 listing every invalid field of a
+// domain.ValidationError. Other errors are reported without details.
+func respondValidationError(c *gin.Context, err error) {
+	resp := ErrorResponse{
+		Status: http.StatusBadRequest,
+		Error:  "Invalid request parameters",
+	}
+
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		resp.Details = verr.Fields
+	}
+
+	c.JSON(http.StatusBadRequest, resp)
 }
 
 type ErrorResponse struct {
-	Status int    `json:"status"`
-	Error  string `json:"error"`
+	Status  int         `json:"status"`
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ProductPageResponse is a ProductPage whose items are trimmed to a sparse
+// fieldset. The outer Items field shadows the embedded one when encoding.
+type ProductPageResponse struct {
+	*domain.ProductPage
+	Items []map[string]json.RawMessage `json:"items"`
+}
+
+// SearchPageResponse is a SearchPage whose products are trimmed to a sparse
+// fieldset.
+type SearchPageResponse struct {
+	*domain.SearchPage
+	Items []SearchResultResponse `json:"items"`
+}
+
+type SearchResultResponse struct {
+	domain.SearchResult
+	Product map[string]json.RawMessage `json:"product"`
+}
+
+func projectProducts(products []domain.Product, fields domain.FieldSet) []map[string]json.RawMessage {
+	projected := make([]map[string]json.RawMessage, 0, len(products))
+	for _, p := range products {
+		projected = append(projected, fields.Project(p))
+	}
+	return projected
+}
+
+type SKUResolveRequest struct {
+	SKUs []string `json:"skus" binding:"required,min=1,max=100,dive,required"`
+}
+
+// TransitionRequest names the lifecycle state to move a product to.
+type TransitionRequest struct {
+	Status domain.Status `json:"status" binding:"required"`
+}
+
+// SKUProductResponse is a product looked up by SKU. Variant is set when the
+// SKU belongs to one of its variants.
+type SKUProductResponse struct {
+	*domain.Product
+	Variant *domain.Variant `json:"variant,omitempty"`
+}
+
+type SKUResolveResponse struct {
+	Products map[string]domain.Product `json:"products"`
+	NotFound []string                  `json:"not_found"`
 }
@@ -0,0 +1,134 @@
+// api/handlers/product_handler_test.go
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+const productID = "507f1f77bcf86cd799439011"
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Fields)        {}
+func (nopLogger) Info(string, ...logger.Fields)         {}
+func (nopLogger) Warn(string, error, ...logger.Fields)  {}
+func (nopLogger) Error(string, error, ...logger.Fields) {}
+func (nopLogger) Fatal(string, error, ...logger.Fields) {}
+
+// fakeProducts serves one published product and counts the location reads.
+type fakeProducts struct {
+	service.ProductService
+	version   int64
+	locations int
+}
+
+func (p *fakeProducts) GetProductByID(context.Context, string, bool) (*domain.Product, error) {
+	return &domain.Product{ID: productID, Name: "Leather jacket", SKU: "JKT-001", Status: domain.StatusPublished, Version: p.version}, nil
+}
+
+func (p *fakeProducts) ApplyLocations(context.Context, ...*domain.Product) error {
+	p.locations++
+	return nil
+}
+
+// fakePriceLists serves one price list and counts the price resolutions.
+type fakePriceLists struct {
+	service.PriceListService
+	updatedAt time.Time
+	applied   int
+}
+
+func (l *fakePriceLists) PriceLists(context.Context) (domain.PriceLists, error) {
+	return domain.PriceLists{{ID: domain.DefaultPriceListID, Currency: "USD", Default: true, UpdatedAt: l.updatedAt}}, nil
+}
+
+func (l *fakePriceLists) ApplyPrices(context.Context, domain.PriceContext, ...*domain.Product) error {
+	l.applied++
+	return nil
+}
+
+func getProduct(h *ProductHandler, target, ifNoneMatch string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/products/:id", h.GetProduct)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetProductETag(t *testing.T) {
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		change     func(*fakeProducts, *fakePriceLists)
+		target     string
+		wantStatus int
+	}{
+		{
+			name:       "unchanged",
+			change:     func(*fakeProducts, *fakePriceLists) {},
+			target:     "/products/" + productID,
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "new version",
+			change:     func(p *fakeProducts, _ *fakePriceLists) { p.version++ },
+			target:     "/products/" + productID,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "price list changed",
+			change:     func(_ *fakeProducts, l *fakePriceLists) { l.updatedAt = l.updatedAt.Add(time.Second) },
+			target:     "/products/" + productID,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "other currency",
+			change:     func(*fakeProducts, *fakePriceLists) {},
+			target:     "/products/" + productID + "?currency=EUR",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeProducts{version: 3}
+			lists := &fakePriceLists{updatedAt: updated}
+			h := NewProductHandler(products, lists, nopLogger{}, config.PaginationConfig{}, config.ConcurrencyConfig{})
+
+			first := getProduct(h, "/products/"+productID, "")
+			tag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || tag == "" {
+				t.Fatalf("first read = %d with ETag %q", first.Code, tag)
+			}
+
+			tt.change(products, lists)
+			products.locations, lists.applied = 0, 0
+
+			w := getProduct(h, tt.target, tag)
+			if w.Code != tt.wantStatus {
+				t.Errorf("conditional read = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code == http.StatusNotModified && (products.locations > 0 || lists.applied > 0) {
+				t.Error("304 resolved prices or locations")
+			}
+		})
+	}
+}
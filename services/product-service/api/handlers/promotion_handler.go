@@ -0,0 +1,350 @@
+// api/handlers/promotion_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type PromotionHandler struct {
+	promotionService service.PromotionService
+	logger           logger.Logger
+}
+
+func NewPromotionHandler(promotionService service.PromotionService, logger logger.Logger) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+		logger:           logger,
+	}
+}
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Description Every promotion, including inactive and expired ones, by priority (editor or admin)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Promotion
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions [get]
+// @Security BearerAuth
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	if !requireStaff(c) {
+		return
+	}
+
+	promotions, err := h.promotionService.ListPromotions(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get promotions", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve promotions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotions)
+}
+
+// GetPromotion godoc
+// @Summary Get promotion
+// @Description Get a promotion by ID (editor or admin)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} domain.Promotion
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [get]
+// @Security BearerAuth
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	promotion, err := h.promotionService.GetPromotion(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get promotion", err, logger.Fields{"promotionId": id})
+		h.respondError(c, err, "Failed to retrieve promotion")
+		return
+	}
+
+	if promotion == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Promotion not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// CreatePromotion godoc
+// @Summary Create promotion
+// @Description Create a percentage, fixed amount or bundle discount for products, categories (with their subcategories) and SKUs (admin only).
+// @Description A promotion without targets applies to every product. Promotions that are not stackable only apply on their own.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param promotion body domain.Promotion true "Promotion"
+// @Success 201 {object} domain.Promotion
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions [post]
+// @Security BearerAuth
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var promotion domain.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := promotion.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	created, err := h.promotionService.CreatePromotion(c.Request.Context(), promotion)
+	if err != nil {
+		h.logger.Error("Failed to create promotion", err)
+		h.respondError(c, err, "Failed to create promotion")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdatePromotion godoc
+// @Summary Replace promotion
+// @Description Replace a promotion (admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Param promotion body domain.Promotion true "Promotion"
+// @Success 200 {object} domain.Promotion
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [put]
+// @Security BearerAuth
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	var promotion domain.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := promotion.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	updated, err := h.promotionService.UpdatePromotion(c.Request.Context(), id, promotion)
+	if err != nil {
+		h.logger.Error("Failed to update promotion", err, logger.Fields{"promotionId": id})
+		h.respondError(c, err, "Failed to update promotion")
+		return
+	}
+
+	if updated == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Promotion not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePromotion godoc
+// @Summary Delete promotion
+// @Description Delete a promotion (admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/{id} [delete]
+// @Security BearerAuth
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireAdmin(c) {
+		return
+	}
+
+	if err := h.promotionService.DeletePromotion(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete promotion", err, logger.Fields{"promotionId": id})
+		h.respondError(c, err, "Failed to delete promotion")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EvaluateCart godoc
+// @Summary Evaluate cart
+// @Description Price the items of a cart, identified by product or variant SKU, after the promotions active now.
+// @Description The cart is priced in one currency: the requested one, or that of the default price list.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param cart body CartRequest true "Cart items"
+// @Param price_list query string false "Price in this price list"
+// @Param currency query string false "Price in this currency"
+// @Param region query string false "Region of the caller"
+// @Param Accept-Currency header string false "Preferred currencies, e.g. EUR, USD"
+// @Success 200 {object} domain.CartEvaluation
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /promotions/evaluate [post]
+// @Security BearerAuth
+func (h *PromotionHandler) EvaluateCart(c *gin.Context) {
+	var req CartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	cart, err := h.promotionService.EvaluateCart(c.Request.Context(), pc, req.Items)
+	if err != nil {
+		h.logger.Error("Failed to evaluate cart", err)
+		h.respondError(c, err, "Failed to evaluate cart")
+		return
+	}
+
+	c.Header("Vary", priceVary)
+	c.JSON(http.StatusOK, cart)
+}
+
+// EvaluateProduct godoc
+// @Summary Get promotional price
+// @Description Price a product, or one of its variants, after the promotions active now
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param sku query string false "Variant SKU, the product SKU by default"
+// @Param quantity query int false "Quantity" default(1)
+// @Param price_list query string false "Price in this price list"
+// @Param currency query string false "Price in this currency"
+// @Param region query string false "Region of the caller"
+// @Param Accept-Currency header string false "Preferred currencies, e.g. EUR, USD"
+// @Success 200 {object} domain.ItemEvaluation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price [get]
+// @Security BearerAuth
+func (h *PromotionHandler) EvaluateProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	quantity, err := strconv.Atoi(c.DefaultQuery("quantity", "1"))
+	if err != nil {
+		verr := &domain.ValidationError{}
+		verr.Add("quantity", "must be an integer")
+		respondValidationError(c, verr)
+		return
+	}
+
+	pc, ok := priceContext(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.promotionService.EvaluateProduct(c.Request.Context(), pc, id, c.Query("sku"), quantity)
+	if err != nil {
+		h.logger.Error("Failed to evaluate product price", err, logger.Fields{"productId": id})
+		h.respondError(c, err, "Failed to evaluate product price")
+		return
+	}
+
+	if item == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.Header("Vary", priceVary)
+	c.JSON(http.StatusOK, item)
+}
+
+// respondError maps the errors of the promotion service to a response, or
+// answers with message and a 500.
+func (h *PromotionHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		respondValidationError(c, err)
+	case errors.Is(err, domain.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid ID",
+		})
+	case errors.Is(err, domain.ErrPromotionNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Promotion not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  message,
+		})
+	}
+}
+
+// CartRequest lists the items of a cart to evaluate.
+type CartRequest struct {
+	Items []domain.CartItem `json:"items" binding:"required,min=1,max=100,dive"`
+}
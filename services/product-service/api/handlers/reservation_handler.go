@@ -0,0 +1,228 @@
+// api/handlers/reservation_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/api/middleware"
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type ReservationHandler struct {
+	reservationService service.ReservationService
+	logger             logger.Logger
+}
+
+func NewReservationHandler(reservationService service.ReservationService, logger logger.Logger) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+		logger:             logger,
+	}
+}
+
+// CreateReservation godoc
+// @Summary Reserve stock
+// @Description Hold stock for an order while it is paid (order service or admin). Each item names a product and, for products with variants, a variant SKU.
+// @Description Reserving an order again with the same items returns its reservation; with other items it fails with 409. Unless confirmed, the stock is released when the reservation expires.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param reservation body domain.ReservationRequest true "Order and items"
+// @Success 200 {object} domain.Reservation "Existing reservation"
+// @Success 201 {object} domain.Reservation
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reservations [post]
+// @Security BearerAuth
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	if !requireOrders(c) {
+		return
+	}
+
+	var req domain.ReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	reservation, created, err := h.reservationService.Reserve(c.Request.Context(), req, c.GetString("UserID"))
+	if err != nil {
+		h.logger.Error("Failed to reserve stock", err, logger.Fields{"orderId": req.OrderID})
+		h.respondError(c, err, "Failed to reserve stock")
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, reservation)
+		return
+	}
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// GetReservation godoc
+// @Summary Get reservation
+// @Description Get the stock reservation of an order (order service or admin)
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Success 200 {object} domain.Reservation
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reservations/{orderId} [get]
+// @Security BearerAuth
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	if !requireOrders(c) {
+		return
+	}
+
+	reservation, err := h.reservationService.GetReservation(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to get reservation", err, logger.Fields{"orderId": orderID})
+		h.respondError(c, err, "Failed to retrieve reservation")
+		return
+	}
+
+	if reservation == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Reservation not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ConfirmReservation godoc
+// @Summary Confirm reservation
+// @Description Sell the stock held for an order once it is paid (order service or admin). Confirming again returns the reservation; a released or expired one fails with 409.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Success 200 {object} domain.Reservation
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reservations/{orderId}/confirm [post]
+// @Security BearerAuth
+func (h *ReservationHandler) ConfirmReservation(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	if !requireOrders(c) {
+		return
+	}
+
+	reservation, err := h.reservationService.ConfirmReservation(c.Request.Context(), orderID, c.GetString("UserID"))
+	if err != nil {
+		h.logger.Error("Failed to confirm reservation", err, logger.Fields{"orderId": orderID})
+		h.respondError(c, err, "Failed to confirm reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation godoc
+// @Summary Release reservation
+// @Description Give the stock held for an order back, e.g. when it is cancelled (order service or admin). Releasing again returns the reservation; a confirmed one fails with 409.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param orderId path string true "Order ID"
+// @Success 200 {object} domain.Reservation
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reservations/{orderId}/release [post]
+// @Security BearerAuth
+func (h *ReservationHandler) ReleaseReservation(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	if !requireOrders(c) {
+		return
+	}
+
+	reservation, err := h.reservationService.ReleaseReservation(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to release reservation", err, logger.Fields{"orderId": orderID})
+		h.respondError(c, err, "Failed to release reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// respondError maps the errors of the reservation service to a response, or
+// answers with message and a 500.
+func (h *ReservationHandler) respondError(c *gin.Context, err error, message string) {
+	var stateErr *domain.ReservationStateError
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		respondValidationError(c, err)
+	case errors.Is(err, domain.ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Reservation not found",
+		})
+	case errors.Is(err, domain.ErrReservationMismatch):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status: http.StatusConflict,
+			Error:  "Order already has a reservation for other items",
+		})
+	case errors.As(err, &stateErr):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status:  http.StatusConflict,
+			Error:   "Reservation is no longer pending",
+			Details: gin.H{"state": stateErr.State},
+		})
+	case errors.Is(err, domain.ErrInsufficientInventory), errors.Is(err, domain.ErrProductNotFound):
+		// The error names the item that could not be held
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Status:  http.StatusConflict,
+			Error:   "Insufficient inventory",
+			Details: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  message,
+		})
+	}
+}
+
+// requireOrders writes a 403 and returns false unless the caller is the
+// order service or an administrator.
+func requireOrders(c *gin.Context) bool {
+	if middleware.HasRole(c, middleware.RoleOrders) || middleware.HasRole(c, middleware.RoleAdmin) {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Status: http.StatusForbidden,
+		Error:  "Order service or administrator role required",
+	})
+	return false
+}
@@ -0,0 +1,225 @@
+// api/handlers/schedule_handler.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type ScheduleHandler struct {
+	scheduleService service.ScheduleService
+	logger          logger.Logger
+}
+
+func NewScheduleHandler(scheduleService service.ScheduleService, logger logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+		logger:          logger,
+	}
+}
+
+// ListSchedules godoc
+// @Summary List scheduled changes
+// @Description Pending and past scheduled changes of a product, soonest first (editor or admin only)
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} domain.ScheduledChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/schedules [get]
+// @Security BearerAuth
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	changes, err := h.scheduleService.GetSchedules(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get scheduled changes", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to retrieve scheduled changes",
+		})
+		return
+	}
+
+	if changes == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// CreateSchedule godoc
+// @Summary Schedule a change
+// @Description Publish, unpublish or reprice a product at a future time (editor or admin only).
+// @Description Publishing and unpublishing follow the lifecycle rules in force when the change runs.
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param change body domain.ScheduledChange true "Change to schedule (action, run_at and, for price changes, price)"
+// @Success 201 {object} domain.ScheduledChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/schedules [post]
+// @Security BearerAuth
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	var change domain.ScheduledChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		h.logger.Error("Failed to bind request body", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: http.StatusBadRequest,
+			Error:  "Invalid request payload",
+		})
+		return
+	}
+
+	if err := change.Validate(time.Now()); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	// Only the change itself comes from the client
+	change = domain.ScheduledChange{
+		Action:    change.Action,
+		RunAt:     change.RunAt,
+		Price:     change.Price,
+		CreatedBy: c.GetString("UserID"),
+	}
+
+	created, err := h.scheduleService.ScheduleChange(c.Request.Context(), id, change)
+	if err != nil {
+		h.logger.Error("Failed to schedule change", err, logger.Fields{"productId": id})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrTooManySchedules) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status:  http.StatusConflict,
+				Error:   "Too many pending scheduled changes",
+				Details: gin.H{"max_pending": domain.MaxPendingSchedules},
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to schedule change",
+		})
+		return
+	}
+
+	if created == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: http.StatusNotFound,
+			Error:  "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a scheduled change
+// @Description Cancel a change that has not run yet (editor or admin only)
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param scheduleId path string true "Scheduled change ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/schedules/{scheduleId} [delete]
+// @Security BearerAuth
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	id := c.Param("id")
+	scheduleID := c.Param("scheduleId")
+
+	if !requireStaff(c) {
+		return
+	}
+
+	err := h.scheduleService.CancelSchedule(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		h.logger.Error("Failed to cancel scheduled change", err, logger.Fields{"productId": id, "scheduleId": scheduleID})
+
+		if errors.Is(err, domain.ErrInvalidID) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Status: http.StatusBadRequest,
+				Error:  "Invalid product ID",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrScheduleNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Status: http.StatusNotFound,
+				Error:  "Scheduled change not found",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrScheduleNotPending) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Status: http.StatusConflict,
+				Error:  "Scheduled change has already run or been cancelled",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: http.StatusInternalServerError,
+			Error:  "Failed to cancel scheduled change",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -95,9 +95,9 @@ func Logger(log logger.Logger) gin.HandlerFunc {
 func Cors() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match, If-None-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, ETag, Link, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Cache-Control, Content-Language, Content-Type")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -187,6 +187,51 @@ func Auth(cfg config.AuthConfig) gin.HandlerFunc {
 			c.Set("UserID", userID)
 		}
 
+		// Set roles in context; tokens carry either a "roles" list or a single "role"
+		c.Set("Roles", claimRoles(claims))
+
+		// Customer group the caller is priced as, e.g. "wholesale"
+		if group, ok := claims["customer_group"].(string); ok {
+			c.Set("CustomerGroup", group)
+		}
+
 		c.Next()
 	}
 }
+
+// Roles checked by the handlers. Admins may do everything editors may.
+const (
+	// RoleAdmin grants access to administrative views such as the trash.
+	RoleAdmin = "admin"
+	// RoleEditor grants access to products that are not published yet and
+	// to their lifecycle transitions.
+	RoleEditor = "editor"
+	// RoleOrders grants the order service access to stock reservations.
+	RoleOrders = "orders"
+)
+
+// claimRoles reads the roles granted by a token.
+func claimRoles(claims jwt.MapClaims) []string {
+	var roles []string
+	if list, ok := claims["roles"].([]interface{}); ok {
+		for _, r := range list {
+			if role, ok := r.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if role, ok := claims["role"].(string); ok {
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// HasRole reports whether the authenticated caller was granted role.
+func HasRole(c *gin.Context, role string) bool {
+	for _, r := range c.GetStringSlice("Roles") {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/ntdt/product-service/api/handlers"
 	"github.com/ntdt/product-service/api/middleware"
 	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/consumer"
 	"github.com/ntdt/product-service/internal/service"
 	"github.com/ntdt/product-service/pkg/logger"
 
@@ -14,7 +15,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(productService service.ProductService, logger logger.Logger, cfg *config.Config) *gin.Engine {
+func NewRouter(productService service.ProductService, scheduleService service.ScheduleService, attributeService service.AttributeService, categoryService service.CategoryService, priceListService service.PriceListService, promotionService service.PromotionService, reservationService service.ReservationService, locationService service.LocationService, consumers []consumer.Reporter, logger logger.Logger, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 
 	// Middleware
@@ -28,7 +29,7 @@ func NewRouter(productService service.ProductService, logger logger.Logger, cfg
 	r.Use(middleware.RateLimiter(cfg.RateLimit))
 
 	// Health check
-	r.GET("/health", handlers.HealthCheck())
+	r.GET("/health", handlers.HealthCheck(consumers...))
 
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -40,12 +41,95 @@ func NewRouter(productService service.ProductService, logger logger.Logger, cfg
 
 		products := v1.Group("/products")
 		{
-			h := handlers.NewProductHandler(productService, logger)
+			h := handlers.NewProductHandler(productService, priceListService, logger, cfg.Pagination, cfg.Concurrency)
 			products.GET("", h.ListProducts)
+			products.GET("/search", h.SearchProducts)
+			products.GET("/facets", h.GetFacets)
+			products.GET("/sku/:sku", h.GetProductBySKU)
+			products.POST("/sku/resolve", h.ResolveSKUs)
 			products.GET("/:id", h.GetProduct)
 			products.POST("", h.CreateProduct)
 			products.PUT("/:id", h.UpdateProduct)
+			products.PATCH("/:id", h.PatchProduct)
 			products.DELETE("/:id", h.DeleteProduct)
+			products.POST("/:id/restore", h.RestoreProduct)
+			products.POST("/:id/transitions", h.TransitionProduct)
+			products.GET("/:id/price-history", h.GetPriceHistory)
+			products.POST("/:id/inventory/adjustments", h.AdjustInventory)
+			products.GET("/:id/inventory/movements", h.ListInventoryMovements)
+			products.GET("/:id/inventory/reconciliation", h.ReconcileInventory)
+			products.POST("/:id/inventory/transfers", h.TransferStock)
+			products.GET("/:id/inventory/locations", h.GetLocationStock)
+
+			ph := handlers.NewPromotionHandler(promotionService, logger)
+			products.GET("/:id/price", ph.EvaluateProduct)
+
+			sh := handlers.NewScheduleHandler(scheduleService, logger)
+			products.GET("/:id/schedules", sh.ListSchedules)
+			products.POST("/:id/schedules", sh.CreateSchedule)
+			products.DELETE("/:id/schedules/:scheduleId", sh.CancelSchedule)
+		}
+
+		attributes := v1.Group("/attributes")
+		{
+			h := handlers.NewAttributeHandler(attributeService, logger)
+			attributes.GET("", h.ListAttributes)
+			attributes.GET("/:name", h.GetAttribute)
+			attributes.POST("", h.CreateAttribute)
+			attributes.PUT("/:name", h.UpdateAttribute)
+			attributes.DELETE("/:name", h.DeleteAttribute)
+		}
+
+		categories := v1.Group("/categories")
+		{
+			h := handlers.NewCategoryHandler(categoryService, logger)
+			categories.GET("", h.ListCategories)
+			categories.GET("/:id", h.GetCategory)
+			categories.POST("", h.CreateCategory)
+			categories.PUT("/:id", h.UpdateCategory)
+			categories.POST("/:id/move", h.MoveCategory)
+			categories.POST("/:id/merge", h.MergeCategory)
+			categories.DELETE("/:id", h.DeleteCategory)
+		}
+
+		priceLists := v1.Group("/price-lists")
+		{
+			h := handlers.NewPriceListHandler(priceListService, logger)
+			priceLists.GET("", h.ListPriceLists)
+			priceLists.GET("/:id", h.GetPriceList)
+			priceLists.POST("", h.CreatePriceList)
+			priceLists.PUT("/:id", h.UpdatePriceList)
+			priceLists.DELETE("/:id", h.DeletePriceList)
+		}
+
+		promotions := v1.Group("/promotions")
+		{
+			h := handlers.NewPromotionHandler(promotionService, logger)
+			promotions.GET("", h.ListPromotions)
+			promotions.POST("/evaluate", h.EvaluateCart)
+			promotions.GET("/:id", h.GetPromotion)
+			promotions.POST("", h.CreatePromotion)
+			promotions.PUT("/:id", h.UpdatePromotion)
+			promotions.DELETE("/:id", h.DeletePromotion)
+		}
+
+		reservations := v1.Group("/reservations")
+		{
+			h := handlers.NewReservationHandler(reservationService, logger)
+			reservations.POST("", h.CreateReservation)
+			reservations.GET("/:orderId", h.GetReservation)
+			reservations.POST("/:orderId/confirm", h.ConfirmReservation)
+			reservations.POST("/:orderId/release", h.ReleaseReservation)
+		}
+
+		locations := v1.Group("/locations")
+		{
+			h := handlers.NewLocationHandler(locationService, logger)
+			locations.GET("", h.ListLocations)
+			locations.GET("/:id", h.GetLocation)
+			locations.POST("", h.CreateLocation)
+			locations.PUT("/:id", h.UpdateLocation)
+			locations.DELETE("/:id", h.DeleteLocation)
 		}
 	}
 
@@ -0,0 +1,235 @@
+// cmd/server/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ntdt/product-service/api"
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/consumer"
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/migration"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/database"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+// version is set at build time via -ldflags
+var version = "dev"
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	log := logger.NewLogger(cfg.LogLevel)
+
+	// Fatal exits without running deferred calls, so it is left to main and
+	// run returns its errors once everything it opened is closed
+	if err := run(cfg, log); err != nil {
+		log.Fatal("Product service failed", err)
+	}
+}
+
+// run serves the API, or runs the migrate command, until it is interrupted.
+func run(cfg *config.Config, log logger.Logger) error {
+	ids, err := domain.NewIDGenerator(cfg.IDs.Format)
+	if err != nil {
+		return fmt.Errorf("invalid ID configuration: %w", err)
+	}
+
+	store, err := openStorage(cfg, ids)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.close()
+
+	// "product-service migrate <up|down|status>" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(store.migrator, os.Args[2:], log); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		return nil
+	}
+
+	log.Info("Starting product service", logger.Fields{"version": version, "storage": cfg.Storage.Driver})
+
+	if cfg.Migrations.RunOnStart {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		applied, err := store.migrator.Up(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		for _, m := range applied {
+			log.Info("Applied migration", logger.Fields{"version": m.Version, "description": m.Description})
+		}
+	}
+
+	redisClient, err := cache.NewRedisClient(cfg.Redis)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	defer redisClient.Close()
+
+	rabbitClient, err := messaging.NewRabbitMQClient(cfg.RabbitMQ)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer rabbitClient.Close()
+
+	attributeService := service.NewAttributeService(store.attributes, store.products, redisClient, log)
+	categoryService := service.NewCategoryService(store.categories, store.products, attributeService, redisClient, rabbitClient, log)
+	priceListService := service.NewPriceListService(store.priceLists, store.products, redisClient, log)
+	locationService := service.NewLocationService(store.locations, store.stock, log)
+	productService := service.NewProductService(store.products, store.categories, attributeService, priceListService, store.priceHistory, store.inventory, store.stock, store.locations, cfg.Inventory.LowStockThreshold, redisClient, rabbitClient, log)
+	scheduleService := service.NewScheduleService(store.schedules, productService, log)
+	promotionService := service.NewPromotionService(store.promotions, productService, priceListService, store.categories, redisClient, rabbitClient, log)
+	reservationService := service.NewReservationService(store.reservations, productService, locationService, rabbitClient, log)
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// A consumer that cannot subscribe is reported by the health check; the
+	// API keeps serving without it
+	var consumers []consumer.Reporter
+	if cfg.OrderEvents.Enabled {
+		orderConsumer := consumer.NewOrderConsumer(rabbitClient, reservationService, productService, store.events, cfg.OrderEvents, log)
+		if err := orderConsumer.Start(jobCtx); err != nil {
+			log.Error("Failed to start order events consumer", err)
+		}
+		consumers = append(consumers, orderConsumer)
+	}
+
+	router := api.NewRouter(productService, scheduleService, attributeService, categoryService, priceListService, promotionService, reservationService, locationService, consumers, log, cfg)
+
+	go runPurgeJob(jobCtx, productService, cfg.Trash, log)
+	go runReservationSweeper(jobCtx, reservationService, cfg.Inventory, log)
+
+	// Every replica runs the scheduler; the Redis lease elects the one that
+	// applies the changes
+	leaderLock := redisClient.NewLock(schedulerLockKey, uuid.NewString(), time.Duration(cfg.Scheduler.LockTTL)*time.Second)
+	go runScheduler(jobCtx, scheduleService, leaderLock, cfg.Scheduler, log)
+
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("HTTP server listening", logger.Fields{"port": cfg.Server.Port})
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shut down the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	}
+
+	log.Info("Shutting down server")
+	stopJobs()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error("Server forced to shutdown", err)
+	}
+	return nil
+}
+
+// storage bundles the repositories of the configured backend.
+type storage struct {
+	products     repository.ProductRepository
+	schedules    repository.ScheduleRepository
+	attributes   repository.AttributeRepository
+	categories   repository.CategoryRepository
+	priceLists   repository.PriceListRepository
+	priceHistory repository.PriceHistoryRepository
+	promotions   repository.PromotionRepository
+	inventory    repository.InventoryRepository
+	locations    repository.LocationRepository
+	stock        repository.LocationStockRepository
+	reservations repository.ReservationRepository
+	events       repository.ProcessedEventRepository
+	migrator     *migration.Migrator
+	close        func()
+}
+
+// openStorage connects to the configured storage backend and builds its
+// repositories and migrator.
+func openStorage(cfg *config.Config, ids domain.IDGenerator) (*storage, error) {
+	switch cfg.Storage.Driver {
+	case "postgres":
+		pool, err := database.NewPostgresPool(cfg.Postgres)
+		if err != nil {
+			return nil, err
+		}
+
+		return &storage{
+			products:     repository.NewPostgresProductRepository(pool, ids),
+			schedules:    repository.NewPostgresScheduleRepository(pool, ids),
+			attributes:   repository.NewPostgresAttributeRepository(pool),
+			categories:   repository.NewPostgresCategoryRepository(pool, ids),
+			priceLists:   repository.NewPostgresPriceListRepository(pool),
+			priceHistory: repository.NewPostgresPriceHistoryRepository(pool, ids),
+			promotions:   repository.NewPostgresPromotionRepository(pool, ids),
+			inventory:    repository.NewPostgresInventoryRepository(pool, ids),
+			locations:    repository.NewPostgresLocationRepository(pool),
+			stock:        repository.NewPostgresLocationStockRepository(pool),
+			reservations: repository.NewPostgresReservationRepository(pool),
+			events:       repository.NewPostgresProcessedEventRepository(pool),
+			migrator:     migration.NewMigrator(repository.NewPostgresMigrationStore(pool), repository.PostgresMigrations(pool)),
+			close:        pool.Close,
+		}, nil
+	default:
+		client, err := database.NewMongoClient(cfg.MongoDB)
+		if err != nil {
+			return nil, err
+		}
+
+		db := cfg.MongoDB.Database
+		return &storage{
+			products:     repository.NewProductRepository(client, db, ids),
+			schedules:    repository.NewScheduleRepository(client, db, ids),
+			attributes:   repository.NewAttributeRepository(client, db),
+			categories:   repository.NewCategoryRepository(client, db, ids),
+			priceLists:   repository.NewPriceListRepository(client, db),
+			priceHistory: repository.NewPriceHistoryRepository(client, db, ids),
+			promotions:   repository.NewPromotionRepository(client, db, ids),
+			inventory:    repository.NewInventoryRepository(client, db, ids),
+			locations:    repository.NewLocationRepository(client, db),
+			stock:        repository.NewLocationStockRepository(client, db),
+			reservations: repository.NewReservationRepository(client, db),
+			events:       repository.NewProcessedEventRepository(client, db),
+			migrator:     migration.NewMigrator(repository.NewMongoMigrationStore(client, db), repository.MongoMigrations(client, db, ids)),
+			close: func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				client.Disconnect(ctx)
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,75 @@
+// cmd/server/migrate.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ntdt/product-service/internal/migration"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+const migrateUsage = "usage: product-service migrate <up|down [steps]|status>"
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(migrator *migration.Migrator, args []string, log logger.Logger) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Info("Applied migration", logger.Fields{"version": m.Version, "description": m.Description})
+		}
+		if err == nil && len(applied) == 0 {
+			log.Info("Schema is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Info("Reverted migration", logger.Fields{"version": m.Version, "description": m.Description})
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,40 @@
+// cmd/server/purge.go
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+// runPurgeJob permanently removes products that have been in the trash
+// longer than the retention period. It runs until ctx is cancelled.
+func runPurgeJob(ctx context.Context, productService service.ProductService, cfg config.TrashConfig, log logger.Logger) {
+	if cfg.PurgeInterval <= 0 {
+		log.Info("Trash purge job disabled")
+		return
+	}
+
+	retention := time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	ticker := time.NewTicker(time.Duration(cfg.PurgeInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		purged, err := productService.PurgeDeletedProducts(ctx, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to purge deleted products", err)
+		}
+		if purged > 0 {
+			log.Info("Purged deleted products", logger.Fields{"count": purged, "retentionDays": cfg.RetentionDays})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// cmd/server/reservations.go
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+// runReservationSweeper releases the stock of reservations that expired
+// before their order was confirmed. Every replica runs it; each reservation
+// is only released once. It runs until ctx is cancelled.
+func runReservationSweeper(ctx context.Context, reservationService service.ReservationService, cfg config.InventoryConfig, log logger.Logger) {
+	if cfg.SweepInterval <= 0 {
+		log.Info("Reservation sweeper disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.SweepInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		expired, err := reservationService.ExpireReservations(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to expire reservations", err)
+		}
+		if expired > 0 {
+			log.Info("Expired reservations", logger.Fields{"count": expired})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
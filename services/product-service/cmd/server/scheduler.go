@@ -0,0 +1,66 @@
+// cmd/server/scheduler.go
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+// schedulerLockKey is the Redis key of the scheduler leader lease.
+const schedulerLockKey = "product:scheduler:leader"
+
+// runScheduler applies due scheduled changes on every tick while this
+// replica holds the leader lease. It runs until ctx is cancelled and then
+// hands the lease over.
+func runScheduler(ctx context.Context, scheduleService service.ScheduleService, lock cache.Lock, cfg config.SchedulerConfig, log logger.Logger) {
+	if cfg.Interval <= 0 {
+		log.Info("Scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
+	defer ticker.Stop()
+
+	leader := false
+	defer func() {
+		if leader {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := lock.Release(releaseCtx); err != nil {
+				log.Error("Failed to release scheduler lease", err)
+			}
+		}
+	}()
+
+	for {
+		held, err := lock.Acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to acquire scheduler lease", err)
+		}
+		if held != leader {
+			log.Info("Scheduler leadership changed", logger.Fields{"leader": held})
+			leader = held
+		}
+
+		if leader {
+			applied, err := scheduleService.ApplyDueChanges(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				log.Error("Failed to apply scheduled changes", err)
+			}
+			if applied > 0 {
+				log.Info("Applied scheduled changes", logger.Fields{"count": applied})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// internal/consumer/order_consumer.go
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+const (
+	// orderEventsName identifies the consumer in health reports and as the
+	// author of the reservations and stock movements it makes
+	orderEventsName = "order-events"
+	// orderEventsRoutingKey binds every order event; those the consumer
+	// does not act on are acknowledged and dropped
+	orderEventsRoutingKey = "order.*"
+
+	// retryDelay slows down redeliveries of events that failed for a reason
+	// that may go away, such as an unreachable database
+	retryDelay = time.Second
+	// pruneInterval is how often processed events past their retention are
+	// forgotten
+	pruneInterval = time.Hour
+)
+
+// OrderConsumer drives the stock from the events of the order service: new
+// orders reserve stock, cancelled orders release it and delivered orders
+// sell it. Events are processed once by event ID.
+type OrderConsumer struct {
+	bus          messaging.RabbitMQClient
+	reservations service.ReservationService
+	products     service.ProductService
+	events       repository.ProcessedEventRepository
+	cfg          config.OrderEventsConfig
+	logger       logger.Logger
+
+	mu              sync.Mutex
+	status          Status
+	lastProcessedAt time.Time
+	// attempts counts the failed attempts at events still being retried,
+	// by event ID
+	attempts map[string]int
+}
+
+func NewOrderConsumer(bus messaging.RabbitMQClient, reservations service.ReservationService, products service.ProductService, events repository.ProcessedEventRepository, cfg config.OrderEventsConfig, logger logger.Logger) *OrderConsumer {
+	return &OrderConsumer{
+		bus:          bus,
+		reservations: reservations,
+		products:     products,
+		events:       events,
+		cfg:          cfg,
+		logger:       logger,
+		status:       Status{Name: orderEventsName, Queue: cfg.Queue},
+		attempts:     map[string]int{},
+	}
+}
+
+// Start subscribes to the order events and prunes processed events until
+// ctx is cancelled.
+func (c *OrderConsumer) Start(ctx context.Context) error {
+	err := c.bus.Subscribe(c.cfg.Exchange, orderEventsRoutingKey, c.cfg.Queue, func(body []byte) error {
+		return c.handle(ctx, body)
+	})
+	if err != nil {
+		c.update(func(s *Status) { s.LastError = err.Error() })
+		return err
+	}
+
+	c.update(func(s *Status) { s.Subscribed = true })
+	go c.prune(ctx)
+	return nil
+}
+
+// Status reports the progress of the consumer. It is unhealthy when it is
+// not subscribed, when the last event it processed was older than the
+// maximum lag, or when events wait in the queue and none was processed
+// within the maximum lag.
+func (c *OrderConsumer) Status() Status {
+	c.mu.Lock()
+	status := c.status
+	lastProcessedAt := c.lastProcessedAt
+	c.mu.Unlock()
+
+	if !status.Subscribed {
+		return status
+	}
+
+	// The queue only exists once subscribed
+	pending, err := c.bus.QueueDepth(c.cfg.Queue)
+	if err != nil {
+		status.LastError = err.Error()
+		return status
+	}
+	status.Pending = pending
+
+	maxLag := time.Duration(c.cfg.MaxLag) * time.Second
+	stalled := pending > 0 && time.Since(lastProcessedAt) > maxLag
+	status.Healthy = !stalled && status.LagSeconds <= maxLag.Seconds()
+	return status
+}
+
+// handle processes a delivery. It fails, and so has the event redelivered,
+// when processing may succeed later. Malformed events and events that
+// failed too many times are rejected to the dead-letter queue.
+func (c *OrderConsumer) handle(ctx context.Context, body []byte) error {
+	var event domain.OrderEvent
+	err := json.Unmarshal(body, &event)
+	if err == nil {
+		err = event.Validate()
+	}
+	if err != nil {
+		c.logger.Error("Dead-lettering malformed order event", err)
+		c.update(func(s *Status) { s.Rejected++ })
+		return messaging.Reject(err)
+	}
+
+	switch event.Type {
+	case domain.OrderCreated, domain.OrderCancelled, domain.OrderDelivered:
+	default:
+		return nil
+	}
+
+	processed, err := c.events.IsProcessed(ctx, event.ID)
+	if err != nil {
+		return c.retry(ctx, event, err)
+	}
+	if processed {
+		c.update(func(s *Status) { s.Duplicates++ })
+		return nil
+	}
+
+	outcome, err := c.process(ctx, event)
+	rejected := false
+	if err != nil {
+		if !permanent(err) {
+			return c.retry(ctx, event, err)
+		}
+		c.logger.Error("Rejected order event", err, logger.Fields{"eventId": event.ID, "type": event.Type, "orderId": event.Order.ID})
+		if event.Type == domain.OrderCreated {
+			c.publishReservationFailed(event, err)
+		}
+		outcome = "rejected: " + err.Error()
+		rejected = true
+	}
+
+	now := time.Now()
+	record := domain.ProcessedEvent{ID: event.ID, Type: event.Type, OrderID: event.Order.ID, Outcome: outcome, ProcessedAt: now}
+	if err := c.events.Record(ctx, record); err != nil {
+		// Reservations are keyed by order and items sold without one are
+		// checked against the ledger, so a redelivery does no harm
+		c.logger.Error("Failed to record processed order event", err, logger.Fields{"eventId": event.ID})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.attempts, event.ID)
+	if rejected {
+		c.status.Rejected++
+	} else {
+		c.status.Processed++
+	}
+	c.lastProcessedAt = now
+	if !event.Timestamp.IsZero() {
+		c.status.LastEventAt = &event.Timestamp
+		c.status.LagSeconds = now.Sub(event.Timestamp).Seconds()
+	}
+	return nil
+}
+
+// process applies an event to the stock and describes what it did.
+func (c *OrderConsumer) process(ctx context.Context, event domain.OrderEvent) (string, error) {
+	switch event.Type {
+	case domain.OrderCreated:
+		req := domain.ReservationRequest{OrderID: event.Order.ID, Items: event.ReservationItems(), TTL: c.cfg.ReservationTTL}
+		if err := req.Validate(); err != nil {
+			return "", err
+		}
+		_, created, err := c.reservations.Reserve(ctx, req, orderEventsName)
+		if err != nil {
+			return "", err
+		}
+		if !created {
+			return "already reserved", nil
+		}
+		return "reserved", nil
+	case domain.OrderCancelled:
+		if _, err := c.reservations.ReleaseReservation(ctx, event.Order.ID); err != nil {
+			return "", err
+		}
+		return "released", nil
+	default:
+		return c.deliver(ctx, event)
+	}
+}
+
+// deliver sells the stock of a delivered order. The stock reserved for it
+// is sold if the reservation still holds; otherwise the items are sold from
+// the available stock, except those the ledger already shows sold for the
+// order by an earlier delivery of the event.
+func (c *OrderConsumer) deliver(ctx context.Context, event domain.OrderEvent) (string, error) {
+	_, err := c.reservations.ConfirmReservation(ctx, event.Order.ID, orderEventsName)
+	if err == nil {
+		return "sold", nil
+	}
+	var stateErr *domain.ReservationStateError
+	expired := errors.As(err, &stateErr) && stateErr.State == domain.ReservationExpired
+	if !expired && !errors.Is(err, domain.ErrReservationNotFound) {
+		return "", err
+	}
+
+	// Items are not retried: some may already be sold. Failures are logged
+	// and the stock is fixed by hand from the logged item.
+	failed := 0
+	for i, item := range event.Order.Items {
+		sold, err := c.soldForOrder(ctx, event.Order.ID, item)
+		if err != nil {
+			return "", err
+		}
+		if sold {
+			continue
+		}
+
+		adjustment := domain.InventoryAdjustment{SKU: item.SKU, Delta: -item.Quantity, Reason: domain.InventorySale, Reference: event.Order.ID}
+		err = adjustment.Validate()
+		if err == nil {
+			var movement *domain.InventoryMovement
+			movement, err = c.products.AdjustInventory(ctx, item.ProductID.String(), adjustment, orderEventsName)
+			if err == nil && movement == nil {
+				err = domain.ErrProductNotFound
+			}
+		}
+		if err != nil {
+			failed++
+			c.logger.Error("Failed to sell delivered order item", err, logger.Fields{"orderId": event.Order.ID, "item": i, "productId": item.ProductID, "sku": item.SKU})
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Sprintf("sold without reservation, %d of %d items failed", failed, len(event.Order.Items)), nil
+	}
+	return "sold without reservation", nil
+}
+
+// soldForOrder reports whether the ledger shows an item of an order sold.
+// Movements are recorded after the stock changes and a failure to record
+// one is only logged, so a sale may in rare cases be missing from the
+// ledger; reconciliation reports those.
+func (c *OrderConsumer) soldForOrder(ctx context.Context, orderID string, item domain.OrderEventItem) (bool, error) {
+	query := domain.InventoryMovementQuery{SKU: item.SKU, Reason: domain.InventorySale, Reference: orderID, Limit: 1}
+	if err := query.Validate(); err != nil {
+		return false, err
+	}
+
+	movements, err := c.products.GetInventoryMovements(ctx, item.ProductID.String(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidID) {
+			// Reported when the item is sold
+			return false, nil
+		}
+		return false, err
+	}
+
+	return len(movements) > 0, nil
+}
+
+// retry records a failure that may go away and has the event redelivered
+// after a delay, or dead-lettered once it failed MaxAttempts times.
+func (c *OrderConsumer) retry(ctx context.Context, event domain.OrderEvent, err error) error {
+	c.mu.Lock()
+	c.attempts[event.ID]++
+	attempts := c.attempts[event.ID]
+	exhausted := c.cfg.MaxAttempts > 0 && attempts >= c.cfg.MaxAttempts
+	c.status.Failed++
+	c.status.LastError = err.Error()
+	if exhausted {
+		delete(c.attempts, event.ID)
+		c.status.Rejected++
+	}
+	c.mu.Unlock()
+
+	fields := logger.Fields{"eventId": event.ID, "type": event.Type, "attempts": attempts}
+	if exhausted {
+		c.logger.Error("Dead-lettering order event that keeps failing", err, fields)
+		return messaging.Reject(err)
+	}
+	c.logger.Error("Failed to process order event, retrying", err, fields)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryDelay):
+	}
+	return err
+}
+
+// prune forgets processed events past their retention until ctx is
+// cancelled.
+func (c *OrderConsumer) prune(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	retention := time.Duration(c.cfg.RetentionDays) * 24 * time.Hour
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pruned, err := c.events.Prune(ctx, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			c.logger.Error("Failed to prune processed order events", err)
+		}
+		if pruned > 0 {
+			c.logger.Info("Pruned processed order events", logger.Fields{"count": pruned})
+		}
+
+		// Events retried here may have succeeded on another instance; their
+		// counts would otherwise be kept forever
+		c.mu.Lock()
+		c.attempts = map[string]int{}
+		c.mu.Unlock()
+	}
+}
+
+// publishReservationFailed tells the order service that the stock of a new
+// order could not be reserved. Failures are logged.
+func (c *OrderConsumer) publishReservationFailed(event domain.OrderEvent, reason error) {
+	failure := map[string]interface{}{
+		"order_id":  event.Order.ID,
+		"event_id":  event.ID,
+		"reason":    reason.Error(),
+		"timestamp": time.Now(),
+	}
+
+	failureJSON, err := json.Marshal(failure)
+	if err == nil {
+		err = c.bus.Publish("product_exchange", "stock.reservation_failed", failureJSON)
+	}
+	if err != nil {
+		c.logger.Error("Failed to publish reservation failed event", err, logger.Fields{"orderId": event.Order.ID})
+	}
+}
+
+func (c *OrderConsumer) update(change func(s *Status)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change(&c.status)
+}
+
+// permanent reports whether processing an event failed for a reason that
+// redelivering it would not change.
+func permanent(err error) bool {
+	for _, target := range []error{
+		domain.ErrValidation,
+		domain.ErrInvalidID,
+		domain.ErrProductNotFound,
+		domain.ErrInsufficientInventory,
+		domain.ErrReservationNotFound,
+		domain.ErrReservationMismatch,
+		domain.ErrReservationNotPending,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
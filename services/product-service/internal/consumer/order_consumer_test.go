@@ -0,0 +1,208 @@
+// internal/consumer/order_consumer_test.go
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ntdt/product-service/config"
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/internal/service"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+const (
+	productA = domain.ID("507f1f77bcf86cd799439011")
+	productB = domain.ID("507f1f77bcf86cd799439012")
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Fields)        {}
+func (nopLogger) Info(string, ...logger.Fields)         {}
+func (nopLogger) Warn(string, error, ...logger.Fields)  {}
+func (nopLogger) Error(string, error, ...logger.Fields) {}
+func (nopLogger) Fatal(string, error, ...logger.Fields) {}
+
+type fakeBus struct {
+	messaging.RabbitMQClient
+}
+
+func (fakeBus) Publish(string, string, []byte) error { return nil }
+
+// fakeReservations has no reservations, so delivered orders are sold
+// without one.
+type fakeReservations struct {
+	service.ReservationService
+}
+
+func (fakeReservations) ConfirmReservation(context.Context, string, string) (*domain.Reservation, error) {
+	return nil, domain.ErrReservationNotFound
+}
+
+// fakeProducts keeps the ledger of the sales made through it.
+type fakeProducts struct {
+	service.ProductService
+	ledger []domain.InventoryMovement
+}
+
+func (p *fakeProducts) AdjustInventory(_ context.Context, id string, adjustment domain.InventoryAdjustment, adjustedBy string) (*domain.InventoryMovement, error) {
+	movement := domain.InventoryMovement{
+		ProductID: domain.ID(id),
+		SKU:       adjustment.SKU,
+		Delta:     adjustment.Delta,
+		Reason:    adjustment.Reason,
+		Reference: adjustment.Reference,
+		CreatedBy: adjustedBy,
+	}
+	p.ledger = append(p.ledger, movement)
+	return &movement, nil
+}
+
+func (p *fakeProducts) GetInventoryMovements(_ context.Context, id string, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error) {
+	movements := []domain.InventoryMovement{}
+	for _, m := range p.ledger {
+		if m.ProductID.String() == id && (query.SKU == "" || m.SKU == query.SKU) &&
+			(query.Reason == "" || m.Reason == query.Reason) && (query.Reference == "" || m.Reference == query.Reference) {
+			movements = append(movements, m)
+		}
+	}
+	return movements, nil
+}
+
+// fakeEvents never remembers an event, as if recording it always failed.
+type fakeEvents struct {
+	repository.ProcessedEventRepository
+}
+
+func (fakeEvents) IsProcessed(context.Context, string) (bool, error) { return false, nil }
+
+func (fakeEvents) Record(context.Context, domain.ProcessedEvent) error {
+	return errors.New("connection reset")
+}
+
+func deliveredEvent(t *testing.T) []byte {
+	t.Helper()
+
+	event := domain.OrderEvent{ID: "evt-1", Type: domain.OrderDelivered, Timestamp: time.Now()}
+	event.Order.ID = "order-1"
+	event.Order.Items = []domain.OrderEventItem{
+		{ProductID: productA, SKU: "A-1", Quantity: 2},
+		{ProductID: productB, SKU: "B-1", Quantity: 1},
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestDeliverWithoutReservation(t *testing.T) {
+	tests := []struct {
+		name       string
+		ledger     []domain.InventoryMovement
+		deliveries int
+		wantSales  map[domain.ID]int
+	}{
+		{
+			name:       "sells every item",
+			deliveries: 1,
+			wantSales:  map[domain.ID]int{productA: 1, productB: 1},
+		},
+		{
+			name:       "redelivery sells nothing again",
+			deliveries: 3,
+			wantSales:  map[domain.ID]int{productA: 1, productB: 1},
+		},
+		{
+			name: "sells the items an interrupted delivery left",
+			ledger: []domain.InventoryMovement{
+				{ProductID: productA, SKU: "A-1", Delta: -2, Reason: domain.InventorySale, Reference: "order-1"},
+			},
+			deliveries: 1,
+			wantSales:  map[domain.ID]int{productA: 1, productB: 1},
+		},
+		{
+			name: "sales of other orders do not count",
+			ledger: []domain.InventoryMovement{
+				{ProductID: productA, SKU: "A-1", Delta: -2, Reason: domain.InventorySale, Reference: "order-2"},
+			},
+			deliveries: 1,
+			wantSales:  map[domain.ID]int{productA: 2, productB: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeProducts{ledger: tt.ledger}
+			c := NewOrderConsumer(fakeBus{}, fakeReservations{}, products, fakeEvents{}, config.OrderEventsConfig{}, nopLogger{})
+
+			body := deliveredEvent(t)
+			for i := 0; i < tt.deliveries; i++ {
+				if err := c.handle(context.Background(), body); err != nil {
+					t.Fatalf("delivery %d: %v", i+1, err)
+				}
+			}
+
+			sales := map[domain.ID]int{}
+			for _, m := range products.ledger {
+				if m.Reason == domain.InventorySale {
+					sales[m.ProductID]++
+				}
+			}
+			for id, want := range tt.wantSales {
+				if sales[id] != want {
+					t.Errorf("product %s sold %d times, want %d", id, sales[id], want)
+				}
+			}
+		})
+	}
+}
+
+// unreachableEvents fails every lookup, as if the database were down.
+type unreachableEvents struct {
+	repository.ProcessedEventRepository
+}
+
+func (unreachableEvents) IsProcessed(context.Context, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestHandleDeadLetters(t *testing.T) {
+	cfg := config.OrderEventsConfig{MaxAttempts: 3}
+	c := NewOrderConsumer(fakeBus{}, fakeReservations{}, &fakeProducts{}, unreachableEvents{}, cfg, nopLogger{})
+
+	// The retry delay ends with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.handle(ctx, []byte(`{"id": "evt-1"`)); !messaging.IsRejection(err) {
+		t.Errorf("handle(malformed) error = %v, want a rejection", err)
+	}
+
+	body := deliveredEvent(t)
+	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+		err := c.handle(ctx, body)
+		if err == nil {
+			t.Fatalf("attempt %d succeeded", attempt)
+		}
+		if rejected := messaging.IsRejection(err); rejected != (attempt == cfg.MaxAttempts) {
+			t.Errorf("attempt %d rejected = %v, want a rejection on the last attempt only", attempt, rejected)
+		}
+	}
+
+	if status := c.Status(); status.Rejected != 2 || status.Failed != int64(cfg.MaxAttempts) {
+		t.Errorf("status rejected %d, failed %d, want 2, %d", status.Rejected, status.Failed, cfg.MaxAttempts)
+	}
+
+	// The count starts over for the next delivery
+	if err := c.handle(ctx, body); err == nil || messaging.IsRejection(err) {
+		t.Errorf("handle() after dead-lettering error = %v, want a retry", err)
+	}
+}
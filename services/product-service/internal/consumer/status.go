@@ -0,0 +1,32 @@
+// internal/consumer/status.go
+package consumer
+
+import "time"
+
+// Status reports the health and progress of an event consumer.
+type Status struct {
+	Name       string `json:"name"`
+	Queue      string `json:"queue"`
+	Healthy    bool   `json:"healthy"`
+	Subscribed bool   `json:"subscribed"`
+	// Pending is the number of events waiting in the queue
+	Pending int `json:"pending"`
+	// LagSeconds is how old the last event processed was when it was
+	// processed
+	LagSeconds  float64    `json:"lag_seconds"`
+	LastEventAt *time.Time `json:"last_event_at,omitempty"`
+	Processed   int64      `json:"processed"`
+	Duplicates  int64      `json:"duplicates"`
+	// Rejected counts the events that could not be applied: malformed
+	// events and those that failed too often are dead-lettered, the others
+	// are recorded as rejected
+	Rejected int64 `json:"rejected"`
+	// Failed counts the attempts that failed and were retried
+	Failed    int64  `json:"failed"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Reporter is implemented by the consumers that report their health.
+type Reporter interface {
+	Status() Status
+}
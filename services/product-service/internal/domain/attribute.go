@@ -0,0 +1,348 @@
+// internal/domain/attribute.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AttributeType is the kind of value an attribute holds.
+type AttributeType string
+
+const (
+	AttributeString  AttributeType = "string"
+	AttributeNumber  AttributeType = "number"
+	AttributeEnum    AttributeType = "enum"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// AttributeTypes lists every attribute type.
+var AttributeTypes = []AttributeType{AttributeString, AttributeNumber, AttributeEnum, AttributeBoolean}
+
+// Valid reports whether t is a known attribute type.
+func (t AttributeType) Valid() bool {
+	for _, known := range AttributeTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds enforced on attribute definitions and product attributes.
+const (
+	MaxAttributeLabelLength  = 100
+	MaxAttributeUnitLength   = 16
+	MaxAttributeEnumValues   = 100
+	MaxAttributeStringLength = 200
+	MaxProductAttributes     = 50
+	MaxAttributeFilters      = 10
+	MaxAttributeFacets       = 10
+	// FacetAttributeValueLimit caps how many values are counted per
+	// attribute, most common first.
+	FacetAttributeValueLimit = 50
+)
+
+// ErrAttributeNotFound is returned when an attribute definition does not exist.
+var ErrAttributeNotFound = errors.New("attribute not found")
+
+// ErrAttributeExists is returned when creating an attribute definition whose
+// name is taken.
+var ErrAttributeExists = errors.New("attribute already exists")
+
+// ErrAttributeInUse is returned when deleting an attribute definition, or
+// changing its type, while products still have a value for it.
+var ErrAttributeInUse = errors.New("attribute is in use")
+
+// AttributeDefinition describes a custom product attribute such as brand,
+// material or weight.
+type AttributeDefinition struct {
+	Name  string        `json:"name" bson:"_id"`
+	Label string        `json:"label" bson:"label" binding:"required"`
+	Type  AttributeType `json:"type" bson:"type" binding:"required"`
+	// Unit is the unit number values are given in, e.g. kg or cm
+	Unit string `json:"unit,omitempty" bson:"unit,omitempty"`
+	// Values lists the allowed values of an enum attribute
+	Values   []string `json:"values,omitempty" bson:"values,omitempty"`
+	Required bool     `json:"required" bson:"required"`
+	// Categories assigns the attribute to the schemas of these categories.
+	// An attribute without categories applies to every product.
+	Categories []string  `json:"categories" bson:"categories"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks a definition submitted by a client.
+func (d *AttributeDefinition) Validate() error {
+	verr := &ValidationError{}
+
+	if !identifierPattern.MatchString(d.Name) {
+		verr.Add("name", "must be a lowercase identifier of at most 32 characters")
+	}
+	if d.Label == "" || utf8.RuneCountInString(d.Label) > MaxAttributeLabelLength {
+		verr.Add("label", "must be 1 to %d characters", MaxAttributeLabelLength)
+	}
+	if !d.Type.Valid() {
+		verr.Add("type", "must be one of %v", AttributeTypes)
+	}
+
+	if d.Unit != "" && d.Type != AttributeNumber {
+		verr.Add("unit", "is only allowed for %s attributes", AttributeNumber)
+	} else if utf8.RuneCountInString(d.Unit) > MaxAttributeUnitLength {
+		verr.Add("unit", "must be at most %d characters", MaxAttributeUnitLength)
+	}
+
+	if d.Type == AttributeEnum {
+		if len(d.Values) == 0 || len(d.Values) > MaxAttributeEnumValues {
+			verr.Add("values", "must contain 1 to %d entries", MaxAttributeEnumValues)
+		}
+		seen := make(map[string]bool, len(d.Values))
+		for _, v := range d.Values {
+			if v == "" || seen[v] {
+				verr.Add("values", "must be unique and non-empty")
+				break
+			}
+			seen[v] = true
+		}
+	} else if len(d.Values) > 0 {
+		verr.Add("values", "are only allowed for %s attributes", AttributeEnum)
+	}
+
+	if len(d.Categories) > MaxCategoriesFilter {
+		verr.Add("categories", "must contain at most %d entries", MaxCategoriesFilter)
+	}
+	for _, c := range d.Categories {
+		if c == "" || utf8.RuneCountInString(c) > MaxCategoryFilterLength {
+			verr.Add("categories", "entries must be 1 to %d characters", MaxCategoryFilterLength)
+			break
+		}
+	}
+
+	return verr.Err()
+}
+
+// appliesTo reports whether the definition belongs to the schema of a
+// product in categories.
+func (d *AttributeDefinition) appliesTo(categories []string) bool {
+	if len(d.Categories) == 0 {
+		return true
+	}
+	for _, c := range d.Categories {
+		for _, pc := range categories {
+			if c == pc {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseValue converts a filter value from a query string to the type of
+// the attribute.
+func (d *AttributeDefinition) parseValue(s string) (interface{}, error) {
+	switch d.Type {
+	case AttributeNumber:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", d.Name)
+		}
+		return f, nil
+	case AttributeBoolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", d.Name)
+		}
+		return b, nil
+	default:
+		return s, nil
+	}
+}
+
+// AttributeSchema is a set of attribute definitions keyed by name.
+type AttributeSchema map[string]AttributeDefinition
+
+// NewAttributeSchema indexes defs by name.
+func NewAttributeSchema(defs []AttributeDefinition) AttributeSchema {
+	schema := make(AttributeSchema, len(defs))
+	for _, d := range defs {
+		schema[d.Name] = d
+	}
+	return schema
+}
+
+// ForCategories returns the schema of a product in categories: the
+// definitions assigned to any of them, plus those assigned to none.
+func (s AttributeSchema) ForCategories(categories []string) AttributeSchema {
+	schema := make(AttributeSchema, len(s))
+	for name, d := range s {
+		if d.appliesTo(categories) {
+			schema[name] = d
+		}
+	}
+	return schema
+}
+
+// Check validates the attributes of a product against the schema. Number
+// values are converted to float64 in place.
+func (s AttributeSchema) Check(attrs map[string]interface{}) error {
+	verr := &ValidationError{}
+
+	if len(attrs) > MaxProductAttributes {
+		verr.Add("attributes", "must contain at most %d entries", MaxProductAttributes)
+	}
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := "attributes." + name
+		d, ok := s[name]
+		if !ok {
+			verr.Add(field, "is not defined for the categories of the product")
+			continue
+		}
+
+		switch v := attrs[name].(type) {
+		case string:
+			switch {
+			case d.Type == AttributeEnum:
+				if !contains(d.Values, v) {
+					verr.Add(field, "must be one of %v", d.Values)
+				}
+			case d.Type != AttributeString:
+				verr.Add(field, "must be a %s", d.Type)
+			case v == "" || utf8.RuneCountInString(v) > MaxAttributeStringLength:
+				verr.Add(field, "must be 1 to %d characters", MaxAttributeStringLength)
+			}
+		case bool:
+			if d.Type != AttributeBoolean {
+				verr.Add(field, "must be a %s", d.Type)
+			}
+		default:
+			f, ok := toFloat(v)
+			if !ok || d.Type != AttributeNumber {
+				verr.Add(field, "must be a %s", d.Type)
+				continue
+			}
+			attrs[name] = f
+		}
+	}
+
+	required := make([]string, 0, len(s))
+	for name, d := range s {
+		if _, ok := attrs[name]; d.Required && !ok {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	for _, name := range required {
+		verr.Add("attributes."+name, "is required")
+	}
+
+	return verr.Err()
+}
+
+// AttributeFilter restricts a listing to products whose attribute Name
+// equals Value or, for a range, lies between Min and Max inclusive.
+type AttributeFilter struct {
+	Name  string
+	Value interface{}
+	Min   *float64
+	Max   *float64
+}
+
+// ParseFilters parses attribute filters of the form "name:value" or, for
+// number attributes, "name:min..max" with either bound optional.
+func (s AttributeSchema) ParseFilters(filters []string) ([]AttributeFilter, error) {
+	parsed := make([]AttributeFilter, 0, len(filters))
+	seen := make(map[string]bool, len(filters))
+	for _, f := range filters {
+		name, value, ok := strings.Cut(f, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%q must have the form name:value", f)
+		}
+		d, ok := s[name]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a defined attribute", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("attribute %q is given twice", name)
+		}
+		seen[name] = true
+
+		filter := AttributeFilter{Name: name}
+		if lo, hi, isRange := strings.Cut(value, ".."); isRange && d.Type == AttributeNumber {
+			for _, bound := range []struct {
+				text string
+				dst  **float64
+			}{{lo, &filter.Min}, {hi, &filter.Max}} {
+				if bound.text == "" {
+					continue
+				}
+				v, err := d.parseValue(bound.text)
+				if err != nil {
+					return nil, err
+				}
+				n := v.(float64)
+				*bound.dst = &n
+			}
+			if filter.Min == nil && filter.Max == nil {
+				return nil, fmt.Errorf("%q needs at least one bound", f)
+			}
+		} else {
+			v, err := d.parseValue(value)
+			if err != nil {
+				return nil, err
+			}
+			filter.Value = v
+		}
+		parsed = append(parsed, filter)
+	}
+	return parsed, nil
+}
+
+// AttributeFacet counts the matching products per value of an attribute.
+type AttributeFacet struct {
+	Name   string                `json:"name"`
+	Values []AttributeValueCount `json:"values"`
+}
+
+// AttributeValueCount is the number of matching products with an attribute
+// value.
+type AttributeValueCount struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
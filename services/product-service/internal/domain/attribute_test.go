@@ -0,0 +1,151 @@
+// internal/domain/attribute_test.go
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testSchema() AttributeSchema {
+	return NewAttributeSchema([]AttributeDefinition{
+		{Name: "brand", Type: AttributeString, Required: true},
+		{Name: "material", Type: AttributeEnum, Values: []string{"cotton", "wool"}, Categories: []string{"shirts"}},
+		{Name: "weight", Type: AttributeNumber, Unit: "kg"},
+		{Name: "organic", Type: AttributeBoolean, Categories: []string{"shirts", "food"}},
+		{Name: "wattage", Type: AttributeNumber, Categories: []string{"lamps"}},
+	})
+}
+
+func TestAttributeDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		d         AttributeDefinition
+		wantField string
+	}{
+		{"string", AttributeDefinition{Name: "brand", Label: "Brand", Type: AttributeString}, ""},
+		{"number with unit", AttributeDefinition{Name: "weight", Label: "Weight", Type: AttributeNumber, Unit: "kg"}, ""},
+		{"enum", AttributeDefinition{Name: "material", Label: "Material", Type: AttributeEnum, Values: []string{"cotton", "wool"}, Categories: []string{"shirts"}}, ""},
+		{"name", AttributeDefinition{Name: "Brand", Label: "Brand", Type: AttributeString}, "name"},
+		{"label", AttributeDefinition{Name: "brand", Type: AttributeString}, "label"},
+		{"type", AttributeDefinition{Name: "brand", Label: "Brand", Type: "date"}, "type"},
+		{"unit on string", AttributeDefinition{Name: "brand", Label: "Brand", Type: AttributeString, Unit: "kg"}, "unit"},
+		{"long unit", AttributeDefinition{Name: "weight", Label: "Weight", Type: AttributeNumber, Unit: "kilograms per metre"}, "unit"},
+		{"enum without values", AttributeDefinition{Name: "material", Label: "Material", Type: AttributeEnum}, "values"},
+		{"repeated enum value", AttributeDefinition{Name: "material", Label: "Material", Type: AttributeEnum, Values: []string{"wool", "wool"}}, "values"},
+		{"values on number", AttributeDefinition{Name: "weight", Label: "Weight", Type: AttributeNumber, Values: []string{"1"}}, "values"},
+		{"empty category", AttributeDefinition{Name: "brand", Label: "Brand", Type: AttributeString, Categories: []string{""}}, "categories"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.d.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) || !strings.HasPrefix(err.Error(), "validation failed: "+tt.wantField+": ") {
+				t.Errorf("Validate() error = %v, want one on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestAttributeSchemaForCategories(t *testing.T) {
+	tests := []struct {
+		categories []string
+		want       []string
+	}{
+		{nil, []string{"brand", "weight"}},
+		{[]string{"shirts"}, []string{"brand", "material", "organic", "weight"}},
+		{[]string{"food", "lamps"}, []string{"brand", "organic", "wattage", "weight"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for name := range testSchema().ForCategories(tt.categories) {
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ForCategories(%v) = %v, want %v", tt.categories, got, tt.want)
+		}
+	}
+}
+
+func TestAttributeSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]interface{}
+		want  []string
+	}{
+		{"valid", map[string]interface{}{"brand": "Acme", "material": "wool", "weight": 1.5, "organic": true}, nil},
+		{"integer number", map[string]interface{}{"brand": "Acme", "weight": 2}, nil},
+		{"undefined", map[string]interface{}{"brand": "Acme", "colour": "red"}, []string{"attributes.colour"}},
+		{"missing required", map[string]interface{}{"weight": 1.5}, []string{"attributes.brand"}},
+		{"empty string", map[string]interface{}{"brand": ""}, []string{"attributes.brand"}},
+		{"enum value", map[string]interface{}{"brand": "Acme", "material": "silk"}, []string{"attributes.material"}},
+		{"wrong types", map[string]interface{}{"brand": true, "organic": "yes", "weight": "heavy"}, []string{"attributes.brand", "attributes.organic", "attributes.weight"}},
+		{"number for string", map[string]interface{}{"brand": 7}, []string{"attributes.brand"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			var verr *ValidationError
+			if err := testSchema().Check(tt.attrs); errors.As(err, &verr) {
+				for _, f := range verr.Fields {
+					got = append(got, f.Field)
+				}
+				sort.Strings(got)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	attrs := map[string]interface{}{"brand": "Acme", "weight": int64(3)}
+	if err := testSchema().Check(attrs); err != nil || attrs["weight"] != 3.0 {
+		t.Errorf("Check() = %v with weight %#v, want float64 3", err, attrs["weight"])
+	}
+}
+
+func TestAttributeSchemaParseFilters(t *testing.T) {
+	num := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		in      []string
+		want    []AttributeFilter
+		wantErr bool
+	}{
+		{in: nil, want: []AttributeFilter{}},
+		{in: []string{"brand:Acme", "organic:true"}, want: []AttributeFilter{{Name: "brand", Value: "Acme"}, {Name: "organic", Value: true}}},
+		{in: []string{"weight:1.5"}, want: []AttributeFilter{{Name: "weight", Value: 1.5}}},
+		{in: []string{"weight:1..2.5"}, want: []AttributeFilter{{Name: "weight", Min: num(1), Max: num(2.5)}}},
+		{in: []string{"weight:..2"}, want: []AttributeFilter{{Name: "weight", Max: num(2)}}},
+		{in: []string{"brand:a..b"}, want: []AttributeFilter{{Name: "brand", Value: "a..b"}}},
+		{in: []string{"weight:.."}, wantErr: true},
+		{in: []string{"weight:1..x"}, wantErr: true},
+		{in: []string{"weight:heavy"}, wantErr: true},
+		{in: []string{"organic:maybe"}, wantErr: true},
+		{in: []string{"colour:red"}, wantErr: true},
+		{in: []string{"brand"}, wantErr: true},
+		{in: []string{"brand:Acme", "brand:Other"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := testSchema().ParseFilters(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFilters(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFilters(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// internal/domain/category.go
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds enforced on categories.
+const (
+	MaxCategoryNameLength = 100
+	MaxCategoryDepth      = 6
+	// MaxProductCategories bounds the categories a product is assigned to
+	MaxProductCategories = MaxCategoriesFilter
+)
+
+// ErrCategoryNotFound is returned when a category does not exist.
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrCategoryExists is returned when a slug is already used by another
+// category.
+var ErrCategoryExists = errors.New("category slug already exists")
+
+// ErrCategoryInUse is returned when deleting a category that still has
+// subcategories or products.
+var ErrCategoryInUse = errors.New("category is in use")
+
+// ErrCategoryCycle is returned when a move or merge would place a category
+// below itself.
+var ErrCategoryCycle = errors.New("category cannot be placed below itself")
+
+// ErrCategoryTooDeep is returned when a category would end up more than
+// MaxCategoryDepth levels deep.
+var ErrCategoryTooDeep = errors.New("category tree too deep")
+
+// slugPattern restricts slugs to lowercase words joined by single dashes.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Category is a node of the product taxonomy. Path and Ancestors are
+// materialized from the parents, so a subtree can be read with one query.
+type Category struct {
+	ID   ID     `json:"id" bson:"_id,omitempty"`
+	Name string `json:"name" bson:"name" binding:"required"`
+	// Slug identifies the category in URLs and in the categories of a
+	// product. It is unique across the taxonomy.
+	Slug     string `json:"slug" bson:"slug"`
+	ParentID ID     `json:"parent_id,omitempty" bson:"parent_id"`
+	// Path is the slugs from the root down to the category, e.g.
+	// clothing/shoes/sneakers
+	Path string `json:"path" bson:"path"`
+	// Ancestors lists the IDs from the root down to the parent
+	Ancestors []ID `json:"ancestors" bson:"ancestors"`
+	// Position orders the category among its siblings
+	Position  int       `json:"position" bson:"position"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks a category submitted by a client. An empty slug is
+// derived from the name.
+func (c *Category) Validate() error {
+	verr := &ValidationError{}
+
+	if c.Name == "" || utf8.RuneCountInString(c.Name) > MaxCategoryNameLength {
+		verr.Add("name", "must be 1 to %d characters", MaxCategoryNameLength)
+	}
+
+	if c.Slug == "" {
+		c.Slug = Slugify(c.Name)
+	}
+	if !slugPattern.MatchString(c.Slug) || len(c.Slug) > MaxCategoryFilterLength {
+		verr.Add("slug", "must be lowercase letters and digits separated by dashes, at most %d characters", MaxCategoryFilterLength)
+	}
+
+	if c.Position < 0 {
+		verr.Add("position", "must not be negative")
+	}
+
+	return verr.Err()
+}
+
+// Place positions the category below parent, or at the root when parent is
+// nil, materializing its path and ancestors.
+func (c *Category) Place(parent *Category) {
+	if parent == nil {
+		c.ParentID = ""
+		c.Path = c.Slug
+		c.Ancestors = []ID{}
+		return
+	}
+
+	c.ParentID = parent.ID
+	c.Path = parent.Path + "/" + c.Slug
+	c.Ancestors = append(append([]ID{}, parent.Ancestors...), parent.ID)
+}
+
+// Depth is the number of levels above the category; roots have depth 0.
+func (c *Category) Depth() int {
+	return len(c.Ancestors)
+}
+
+// HasAncestor reports whether id is the parent of the category or one of
+// its ancestors.
+func (c *Category) HasAncestor(id ID) bool {
+	for _, a := range c.Ancestors {
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Slugify derives a slug from a category name: lowercase ASCII letters and
+// digits, with every other run of characters replaced by a dash.
+func Slugify(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return sb.String()
+}
+
+// SortCategoryTree orders categories depth first, siblings by position and
+// then name, as a navigation tree lists them.
+func SortCategoryTree(categories []Category) []Category {
+	children := make(map[ID][]Category)
+	ids := make(map[ID]bool, len(categories))
+	for _, c := range categories {
+		ids[c.ID] = true
+	}
+	for _, c := range categories {
+		parent := c.ParentID
+		if !ids[parent] {
+			// Subtrees are listed from their top category
+			parent = ""
+		}
+		children[parent] = append(children[parent], c)
+	}
+
+	sorted := make([]Category, 0, len(categories))
+	var walk func(parent ID)
+	walk = func(parent ID) {
+		siblings := children[parent]
+		sort.SliceStable(siblings, func(i, j int) bool {
+			if siblings[i].Position != siblings[j].Position {
+				return siblings[i].Position < siblings[j].Position
+			}
+			return siblings[i].Name < siblings[j].Name
+		})
+		for _, c := range siblings {
+			sorted = append(sorted, c)
+			walk(c.ID)
+		}
+	}
+	walk("")
+
+	return sorted
+}
@@ -0,0 +1,119 @@
+// internal/domain/category_test.go
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Shoes", "shoes"},
+		{"Men's Shoes", "men-s-shoes"},
+		{"  T-Shirts & Tops!  ", "t-shirts-tops"},
+		{"Size 42", "size-42"},
+		{"Café Crème", "caf-cr-me"},
+		{"---", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.in); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         Category
+		wantField string
+		wantSlug  string
+	}{
+		{"derived slug", Category{Name: "Running Shoes"}, "", "running-shoes"},
+		{"given slug", Category{Name: "Running Shoes", Slug: "runners"}, "", "runners"},
+		{"name", Category{Slug: "shoes"}, "name", "shoes"},
+		{"no slug from name", Category{Name: "!!!"}, "slug", ""},
+		{"slug", Category{Name: "Shoes", Slug: "Shoes--2"}, "slug", "Shoes--2"},
+		{"long slug", Category{Name: "Shoes", Slug: strings.Repeat("a", MaxCategoryFilterLength+1)}, "slug", strings.Repeat("a", MaxCategoryFilterLength+1)},
+		{"position", Category{Name: "Shoes", Position: -1}, "position", "shoes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.c.Validate()
+			var verr *ValidationError
+			switch {
+			case tt.wantField == "" && err != nil:
+				t.Errorf("Validate() error = %v", err)
+			case tt.wantField != "" && (!errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != tt.wantField):
+				t.Errorf("Validate() error = %v, want only one on %s", err, tt.wantField)
+			}
+			if tt.c.Slug != tt.wantSlug {
+				t.Errorf("Validate() left slug %q, want %q", tt.c.Slug, tt.wantSlug)
+			}
+		})
+	}
+}
+
+func TestCategoryPlace(t *testing.T) {
+	clothing := Category{ID: "c1", Slug: "clothing"}
+	clothing.Place(nil)
+	shoes := Category{ID: "c2", Slug: "shoes"}
+	shoes.Place(&clothing)
+	sneakers := Category{ID: "c3", Slug: "sneakers"}
+	sneakers.Place(&shoes)
+
+	if sneakers.Path != "clothing/shoes/sneakers" || sneakers.ParentID != "c2" || !reflect.DeepEqual(sneakers.Ancestors, []ID{"c1", "c2"}) {
+		t.Errorf("Place() = path %q, parent %q, ancestors %v", sneakers.Path, sneakers.ParentID, sneakers.Ancestors)
+	}
+	if sneakers.Depth() != 2 || !sneakers.HasAncestor("c1") || sneakers.HasAncestor("c3") {
+		t.Errorf("Depth() = %d, HasAncestor(c1) = %v, HasAncestor(c3) = %v", sneakers.Depth(), sneakers.HasAncestor("c1"), sneakers.HasAncestor("c3"))
+	}
+
+	// Moving shoes to the root must not share the ancestors of sneakers
+	shoes.Place(nil)
+	if shoes.Path != "shoes" || shoes.ParentID != "" || len(shoes.Ancestors) != 0 {
+		t.Errorf("Place(nil) = path %q, parent %q, ancestors %v", shoes.Path, shoes.ParentID, shoes.Ancestors)
+	}
+	if !reflect.DeepEqual(sneakers.Ancestors, []ID{"c1", "c2"}) {
+		t.Errorf("moving the parent changed the ancestors of its child to %v", sneakers.Ancestors)
+	}
+}
+
+func TestSortCategoryTree(t *testing.T) {
+	categories := []Category{
+		{ID: "sneakers", Name: "Sneakers", ParentID: "shoes", Position: 1},
+		{ID: "boots", Name: "Boots", ParentID: "shoes", Position: 1},
+		{ID: "home", Name: "Home", Position: 2},
+		{ID: "shoes", Name: "Shoes", ParentID: "clothing"},
+		{ID: "clothing", Name: "Clothing", Position: 1},
+		{ID: "hats", Name: "Hats", ParentID: "clothing", Position: 3},
+	}
+
+	tests := []struct {
+		name       string
+		categories []Category
+		want       []ID
+	}{
+		{"whole tree", categories, []ID{"clothing", "shoes", "boots", "sneakers", "hats", "home"}},
+		{"subtree", categories[:4], []ID{"shoes", "boots", "sneakers", "home"}},
+		{"empty", nil, []ID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []ID{}
+			for _, c := range SortCategoryTree(tt.categories) {
+				got = append(got, c.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortCategoryTree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
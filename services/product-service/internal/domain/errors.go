@@ -0,0 +1,83 @@
+// internal/domain/errors.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrProductNotFound is returned by repositories when a write targets a
+// product that does not exist.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was
+// issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrVersionMismatch is returned when a conditional write names a version
+// other than the current one.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrProductNotDeleted is returned when restoring a product that is not in
+// the trash.
+var ErrProductNotDeleted = errors.New("product is not deleted")
+
+// ErrValidation matches any ValidationError via errors.Is.
+var ErrValidation = errors.New("validation failed")
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field of a request.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Add records an invalid field.
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns e if any field was recorded and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ErrDuplicateSKU matches any DuplicateSKUError via errors.Is.
+var ErrDuplicateSKU = errors.New("duplicate sku")
+
+// DuplicateSKUError reports that a SKU is already used by another product.
+type DuplicateSKUError struct {
+	SKU       string
+	ProductID ID
+	// Deleted is set when the owner is in the trash; it has to be restored
+	// or purged before the SKU can be reused.
+	Deleted bool
+}
+
+func (e *DuplicateSKUError) Error() string {
+	return fmt.Sprintf("sku %q is already used by product %s", e.SKU, e.ProductID)
+}
+
+func (e *DuplicateSKUError) Is(target error) bool {
+	return target == ErrDuplicateSKU
+}
@@ -0,0 +1,41 @@
+// internal/domain/facets.go
+package domain
+
+// Facet sizes.
+const (
+	// FacetCategoryLimit caps how many categories are counted, most common first.
+	FacetCategoryLimit = 100
+	// FacetPriceBuckets is the number of price histogram buckets. Buckets hold
+	// roughly the same number of products each.
+	FacetPriceBuckets = 5
+)
+
+// ProductFacets summarizes the products matching a filter for building
+// storefront navigation. InStock counts the products with stock available to
+// sell, not merely on hand. MinPrice and MaxPrice are nil when nothing
+// matches.
+type ProductFacets struct {
+	Total        int64           `json:"total"`
+	Categories   []CategoryCount `json:"categories"`
+	PriceBuckets []PriceBucket   `json:"price_buckets"`
+	InStock      int64           `json:"in_stock"`
+	OutOfStock   int64           `json:"out_of_stock"`
+	MinPrice     *Money          `json:"min_price"`
+	MaxPrice     *Money          `json:"max_price"`
+	// Attributes holds the value counts of the requested attributes
+	Attributes []AttributeFacet `json:"attributes,omitempty"`
+}
+
+// CategoryCount is the number of matching products in a category.
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+// PriceBucket counts the matching products priced between Min and Max,
+// inclusive. Min and Max are the lowest and highest price in the bucket.
+type PriceBucket struct {
+	Min   Money `json:"min"`
+	Max   Money `json:"max"`
+	Count int64 `json:"count"`
+}
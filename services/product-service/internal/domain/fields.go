@@ -0,0 +1,122 @@
+// internal/domain/fields.go
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SelectableFields lists the product fields accepted by fields=, by JSON name.
+var SelectableFields = []string{"id", "name", "description", "price", "sku", "inventory", "reserved", "available", "categories", "category_ids", "options", "variants", "attributes", "prices", "created_at", "updated_at", "version", "deleted_at", "deleted_by", "status", "status_changed_at", "status_changed_by", "effective_price", "locations"}
+
+// derivedFields are the selectable fields that are not stored but set after
+// a read, with the stored fields they are derived from.
+var derivedFields = map[string][]string{
+	"effective_price": {"price", "prices"},
+	"locations":       {"sku", "variants"},
+}
+
+// FieldPresets are named selections that can be used in place of, or
+// alongside, field names.
+var FieldPresets = map[string][]string{
+	"summary": {"id", "name", "price", "sku"},
+	"full":    SelectableFields,
+}
+
+// FieldSet is a sparse fieldset. A nil FieldSet selects every field.
+type FieldSet []string
+
+// ParseFieldSet parses a comma separated list of field names and presets.
+// The ID is always included so that clients can refer back to the product.
+func ParseFieldSet(s string) (FieldSet, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	set := FieldSet{"id"}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+
+		fields, ok := FieldPresets[name]
+		if !ok {
+			if !isSelectable(name) {
+				return nil, fmt.Errorf("unknown field or preset %q", name)
+			}
+			fields = []string{name}
+		}
+
+		for _, f := range fields {
+			if !set.Has(f) {
+				set = append(set, f)
+			}
+		}
+	}
+
+	if len(set) == len(SelectableFields) {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// Has reports whether field is selected.
+func (fs FieldSet) Has(field string) bool {
+	if fs == nil {
+		return true
+	}
+	for _, f := range fs {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Stored returns the fields to read from the store to serve fs: its stored
+// fields and those its derived fields are set from.
+func (fs FieldSet) Stored() FieldSet {
+	if fs == nil {
+		return nil
+	}
+
+	stored := make(FieldSet, 0, len(fs))
+	for _, f := range fs {
+		fields, derived := derivedFields[f]
+		if !derived {
+			fields = []string{f}
+		}
+		for _, field := range fields {
+			if !stored.Has(field) {
+				stored = append(stored, field)
+			}
+		}
+	}
+	return stored
+}
+
+// Project returns the JSON object of p restricted to the selected fields.
+func (fs FieldSet) Project(p Product) map[string]json.RawMessage {
+	data, _ := json.Marshal(p)
+
+	var all map[string]json.RawMessage
+	json.Unmarshal(data, &all)
+
+	if fs == nil {
+		return all
+	}
+
+	projected := make(map[string]json.RawMessage, len(fs))
+	for _, f := range fs {
+		projected[f] = all[f]
+	}
+	return projected
+}
+
+func isSelectable(field string) bool {
+	for _, f := range SelectableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// internal/domain/fields_test.go
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldSet(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FieldSet
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "name", want: FieldSet{"id", "name"}},
+		{in: " name , sku,name", want: FieldSet{"id", "name", "sku"}},
+		{in: "summary", want: FieldSet{"id", "name", "price", "sku"}},
+		{in: "summary,effective_price", want: FieldSet{"id", "name", "price", "sku", "effective_price"}},
+		{in: "locations", want: FieldSet{"id", "locations"}},
+		{in: "full", want: nil},
+		{in: "password", wantErr: true},
+		{in: "name,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFieldSet(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFieldSet(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFieldSet(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFieldSetStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields FieldSet
+		want   FieldSet
+	}{
+		{"every field", nil, nil},
+		{"stored only", FieldSet{"id", "name"}, FieldSet{"id", "name"}},
+		{"effective price", FieldSet{"id", "effective_price"}, FieldSet{"id", "price", "prices"}},
+		{"locations", FieldSet{"id", "locations"}, FieldSet{"id", "sku", "variants"}},
+		{"overlapping", FieldSet{"id", "price", "effective_price", "variants", "locations"}, FieldSet{"id", "price", "prices", "variants", "sku"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fields.Stored(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stored() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldSetProject(t *testing.T) {
+	p := Product{
+		ID:             "507f1f77bcf86cd799439011",
+		Name:           "Leather jacket",
+		EffectivePrice: &EffectivePrice{},
+	}
+
+	got := FieldSet{"id", "name", "effective_price"}.Project(p)
+	if len(got) != 3 {
+		t.Fatalf("Project() = %v, want id, name and effective_price", got)
+	}
+	if string(got["name"]) != `"Leather jacket"` {
+		t.Errorf("Project()[name] = %s", got["name"])
+	}
+	if got["effective_price"] == nil {
+		t.Error("Project() left out the effective price")
+	}
+}
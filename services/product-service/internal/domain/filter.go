@@ -0,0 +1,184 @@
+// internal/domain/filter.go
+package domain
+
+import (
+	"unicode/utf8"
+
+	"github.com/ntdt/product-service/internal/query"
+)
+
+// Name match modes. Names are always matched literally and case-insensitively.
+const (
+	NameMatchExact    = "exact"
+	NameMatchPrefix   = "prefix"
+	NameMatchContains = "contains"
+)
+
+// SortableFields lists the fields accepted by sort_by. Each one is backed by
+// an index in every storage backend.
+var SortableFields = []string{"name", "price", "sku", "inventory", "created_at", "updated_at"}
+
+// Bounds enforced on ProductFilter.
+const (
+	MaxNameFilterLength     = 100
+	MaxCategoryFilterLength = 64
+	MaxCategoriesFilter     = 20
+	MaxOffset               = 10000
+	MaxCursorLength         = 1024
+)
+
+// OnlyPublished reports whether the filter is restricted to published
+// products, as public listings must be.
+func (f *ProductFilter) OnlyPublished() bool {
+	for _, s := range f.Status {
+		if s != StatusPublished {
+			return false
+		}
+	}
+	return len(f.Status) > 0
+}
+
+// Validate checks the filter against the whitelists and bounds above, filling
+// in defaults. maxLimit is the configured upper bound for Limit.
+func (f *ProductFilter) Validate(maxLimit int) error {
+	verr := &ValidationError{}
+
+	if utf8.RuneCountInString(f.Name) > MaxNameFilterLength {
+		verr.Add("name", "must be at most %d characters", MaxNameFilterLength)
+	}
+
+	switch f.NameMatch {
+	case "":
+		f.NameMatch = NameMatchExact
+	case NameMatchExact, NameMatchPrefix, NameMatchContains:
+	default:
+		verr.Add("name_match", "must be one of %s, %s, %s", NameMatchExact, NameMatchPrefix, NameMatchContains)
+	}
+
+	if len(f.Categories) > MaxCategoriesFilter {
+		verr.Add("categories", "must contain at most %d entries", MaxCategoriesFilter)
+	}
+	for _, c := range f.Categories {
+		if c == "" || utf8.RuneCountInString(c) > MaxCategoryFilterLength {
+			verr.Add("categories", "entries must be 1 to %d characters", MaxCategoryFilterLength)
+			break
+		}
+	}
+
+	if f.CategoryID != "" {
+		id, err := ParseID(f.CategoryID)
+		if err != nil {
+			verr.Add("category_id", "must be a valid ID")
+		}
+		f.CategoryID = id.String()
+	}
+
+	if f.MinPrice != nil && *f.MinPrice < 0 {
+		verr.Add("min_price", "must not be negative")
+	}
+	if f.MaxPrice != nil && *f.MaxPrice < 0 {
+		verr.Add("max_price", "must not be negative")
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		verr.Add("max_price", "must be greater than or equal to min_price")
+	}
+
+	if len(f.Status) > len(Statuses) {
+		verr.Add("status", "must contain at most %d entries", len(Statuses))
+	}
+	for _, s := range f.Status {
+		if !s.Valid() {
+			verr.Add("status", "must be one of %v", Statuses)
+			break
+		}
+	}
+
+	// Attribute names and values are checked against the attribute
+	// definitions by the service
+	if len(f.Attribute) > MaxAttributeFilters {
+		verr.Add("attribute", "must contain at most %d entries", MaxAttributeFilters)
+	}
+	if len(f.FacetAttributes) > MaxAttributeFacets {
+		verr.Add("facet_attributes", "must contain at most %d entries", MaxAttributeFacets)
+	}
+
+	if f.Location != "" && !identifierPattern.MatchString(f.Location) {
+		verr.Add("location", "must be a location ID")
+	}
+
+	attributes, err := ParseVariantFilter(f.Variant)
+	if err != nil {
+		verr.Add("variant", "%s", err.Error())
+	}
+	f.VariantAttributes = attributes
+
+	f.Expr = nil
+	if f.Filter != "" {
+		expr, err := query.Parse(f.Filter, query.ProductSchema)
+		if err != nil {
+			verr.Add("filter", "%s", err.Error())
+		} else {
+			f.Expr = expr
+		}
+	}
+
+	fields, err := ParseFieldSet(f.Fields)
+	if err != nil {
+		verr.Add("fields", "%s", err.Error())
+	}
+	f.FieldSet = fields
+
+	if f.SortBy != "" && !isSortable(f.SortBy) {
+		verr.Add("sort_by", "must be one of %v", SortableFields)
+	}
+	switch f.SortOrder {
+	case "", "asc", "desc":
+	default:
+		verr.Add("sort_order", "must be asc or desc")
+	}
+
+	if f.Limit < 1 || f.Limit > maxLimit {
+		verr.Add("limit", "must be between 1 and %d", maxLimit)
+	}
+	if f.Offset < 0 || f.Offset > MaxOffset {
+		verr.Add("offset", "must be between 0 and %d; use cursor for deeper pages", MaxOffset)
+	}
+	if len(f.Cursor) > MaxCursorLength {
+		verr.Add("cursor", "is too long")
+	}
+
+	return verr.Err()
+}
+
+func isSortable(field string) bool {
+	for _, f := range SortableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCurrency converts the price bounds and the price comparisons of the
+// filter expression, given in the major unit of currency, to minor units.
+func (f *ProductFilter) SetCurrency(currency string) {
+	f.Currency = currency
+	f.MinAmount, f.MaxAmount = nil, nil
+	if f.MinPrice != nil {
+		amount := MoneyFromFloat(*f.MinPrice, currency).Amount
+		f.MinAmount = &amount
+	}
+	if f.MaxPrice != nil {
+		amount := MoneyFromFloat(*f.MaxPrice, currency).Amount
+		f.MaxAmount = &amount
+	}
+
+	if f.Expr != nil {
+		f.Expr = query.MapValues(f.Expr, "price", func(v interface{}) interface{} {
+			if n, ok := v.(float64); ok {
+				return MoneyFromFloat(n, currency).Amount
+			}
+			return v
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// internal/domain/filter_test.go
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ntdt/product-service/internal/query"
+)
+
+func TestProductFilterValidate(t *testing.T) {
+	price := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name      string
+		filter    ProductFilter
+		wantField string
+	}{
+		{"defaults", ProductFilter{Limit: 10}, ""},
+		{"every bound", ProductFilter{
+			Name:       strings.Repeat("a", MaxNameFilterLength),
+			NameMatch:  NameMatchContains,
+			Categories: []string{"shoes", "sale"},
+			Status:     []Status{StatusDraft, StatusPublished},
+			Variant:    []string{"size:M", "color:red"},
+			Filter:     "inventory gt 0",
+			Location:   "warehouse_1",
+			SortBy:     "created_at",
+			SortOrder:  "desc",
+			Limit:      100,
+			Offset:     MaxOffset,
+		}, ""},
+		{"equal prices", ProductFilter{MinPrice: price(10), MaxPrice: price(10), Limit: 10}, ""},
+		{"free", ProductFilter{MinPrice: price(0), MaxPrice: price(0), Limit: 10}, ""},
+		{"long name", ProductFilter{Name: strings.Repeat("é", MaxNameFilterLength+1), Limit: 10}, "name"},
+		{"regex name match", ProductFilter{NameMatch: "regex", Limit: 10}, "name_match"},
+		{"empty category", ProductFilter{Categories: []string{"shoes", ""}, Limit: 10}, "categories"},
+		{"too many categories", ProductFilter{Categories: make([]string, MaxCategoriesFilter+1), Limit: 10}, "categories"},
+		{"unknown status", ProductFilter{Status: []Status{StatusPublished, "live"}, Limit: 10}, "status"},
+		{"variant without a value", ProductFilter{Variant: []string{"size"}, Limit: 10}, "variant"},
+		{"too many attribute filters", ProductFilter{Attribute: make([]string, MaxAttributeFilters+1), Limit: 10}, "attribute"},
+		{"too many attribute facets", ProductFilter{FacetAttributes: make([]string, MaxAttributeFacets+1), Limit: 10}, "facet_attributes"},
+		{"category ID", ProductFilter{CategoryID: "shoes", Limit: 10}, "category_id"},
+		{"location", ProductFilter{Location: "Main Warehouse", Limit: 10}, "location"},
+		{"negative price", ProductFilter{MinPrice: price(-1), Limit: 10}, "min_price"},
+		{"inverted prices", ProductFilter{MinPrice: price(10), MaxPrice: price(5), Limit: 10}, "max_price"},
+		{"incomplete filter", ProductFilter{Filter: "price gt", Limit: 10}, "filter"},
+		{"unindexed sort", ProductFilter{SortBy: "description", Limit: 10}, "sort_by"},
+		{"sort order", ProductFilter{SortOrder: "up", Limit: 10}, "sort_order"},
+		{"no limit", ProductFilter{}, "limit"},
+		{"limit over the maximum", ProductFilter{Limit: 101}, "limit"},
+		{"deep offset", ProductFilter{Offset: MaxOffset + 1, Limit: 10}, "offset"},
+		{"long cursor", ProductFilter{Cursor: strings.Repeat("a", MaxCursorLength+1), Limit: 10}, "cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate(100)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Fields[0].Field != tt.wantField {
+				t.Errorf("Validate() error = %v, want one on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestProductFilterValidateDefaults(t *testing.T) {
+	f := ProductFilter{Filter: "inventory gt 0", Limit: 10}
+	if err := f.Validate(100); err != nil {
+		t.Fatal(err)
+	}
+	if f.NameMatch != NameMatchExact {
+		t.Errorf("NameMatch = %q, want %q", f.NameMatch, NameMatchExact)
+	}
+	if f.Expr == nil {
+		t.Error("Validate() left the filter expression unparsed")
+	}
+}
+
+func TestProductFilterOnlyPublished(t *testing.T) {
+	tests := []struct {
+		status []Status
+		want   bool
+	}{
+		{nil, false},
+		{[]Status{StatusPublished}, true},
+		{[]Status{StatusPublished, StatusPublished}, true},
+		{[]Status{StatusPublished, StatusDraft}, false},
+	}
+
+	for _, tt := range tests {
+		f := ProductFilter{Status: tt.status}
+		if got := f.OnlyPublished(); got != tt.want {
+			t.Errorf("OnlyPublished() with status %v = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestProductFilterSetCurrency(t *testing.T) {
+	min, max := 19.99, 250.0
+	f := ProductFilter{MinPrice: &min, MaxPrice: &max, Filter: "price lt 9.5", Limit: 10}
+	if err := f.Validate(100); err != nil {
+		t.Fatal(err)
+	}
+
+	f.SetCurrency("USD")
+	if f.MinAmount == nil || *f.MinAmount != 1999 || f.MaxAmount == nil || *f.MaxAmount != 25000 {
+		t.Errorf("SetCurrency(USD) bounds = %v..%v, want 1999..25000", f.MinAmount, f.MaxAmount)
+	}
+	if c, ok := f.Expr.(query.Comparison); !ok || c.Value != int64(950) {
+		t.Errorf("SetCurrency(USD) expression = %#v, want the price in cents", f.Expr)
+	}
+
+	// Currencies without a minor unit round to whole amounts
+	yen := ProductFilter{MinPrice: &min, MaxPrice: &max}
+	yen.SetCurrency("JPY")
+	if yen.MinAmount == nil || *yen.MinAmount != 20 || yen.MaxAmount == nil || *yen.MaxAmount != 250 {
+		t.Errorf("SetCurrency(JPY) bounds = %v..%v, want 20..250", yen.MinAmount, yen.MaxAmount)
+	}
+}
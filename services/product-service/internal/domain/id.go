@@ -0,0 +1,152 @@
+// internal/domain/id.go
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Supported ID formats
+const (
+	IDFormatObjectID = "objectid"
+	IDFormatUUIDv7   = "uuidv7"
+	IDFormatULID     = "ulid"
+)
+
+// ErrInvalidID is returned when a string is not a well-formed ID in any of
+// the supported formats.
+var ErrInvalidID = errors.New("invalid id")
+
+// ID identifies an entity independently of the storage backend. It is always
+// exchanged as a string; ObjectID-formatted IDs are stored natively in
+// MongoDB so existing documents keep working.
+type ID string
+
+// ParseID validates s against the supported formats and returns it in
+// canonical form (lowercase hex, lowercase UUID or uppercase ULID).
+func ParseID(s string) (ID, error) {
+	if primitive.IsValidObjectID(s) {
+		return ID(strings.ToLower(s)), nil
+	}
+	if u, err := uuid.Parse(s); err == nil && len(s) == 36 {
+		return ID(u.String()), nil
+	}
+	if u, err := ulid.ParseStrict(s); err == nil {
+		return ID(u.String()), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidID, s)
+}
+
+func (id ID) String() string {
+	return string(id)
+}
+
+// IsZero reports whether the ID is unset. It lets BSON omitempty skip it.
+func (id ID) IsZero() bool {
+	return id == ""
+}
+
+func (id ID) isObjectID() bool {
+	return len(id) == 24 && primitive.IsValidObjectID(string(id))
+}
+
+// MarshalBSONValue stores ObjectID-formatted IDs as BSON ObjectIDs and every
+// other format as a string.
+func (id ID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if id.isObjectID() {
+		oid, err := primitive.ObjectIDFromHex(string(id))
+		if err != nil {
+			return 0, nil, err
+		}
+		return bson.TypeObjectID, bsoncore.AppendObjectID(nil, oid), nil
+	}
+	return bson.TypeString, bsoncore.AppendString(nil, string(id)), nil
+}
+
+// UnmarshalBSONValue accepts both ObjectID and string representations.
+func (id *ID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bson.TypeObjectID:
+		oid, _, ok := bsoncore.ReadObjectID(data)
+		if !ok {
+			return fmt.Errorf("%w: malformed ObjectID", ErrInvalidID)
+		}
+		*id = ID(oid.Hex())
+	case bson.TypeString:
+		s, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return fmt.Errorf("%w: malformed string", ErrInvalidID)
+		}
+		*id = ID(s)
+	case bson.TypeNull, bson.TypeUndefined:
+		*id = ""
+	default:
+		return fmt.Errorf("%w: cannot decode BSON %s into ID", ErrInvalidID, t)
+	}
+	return nil
+}
+
+// UnmarshalJSON rejects IDs that are not in a supported format. An empty
+// string is accepted and leaves the ID unset.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: must be a string", ErrInvalidID)
+	}
+	if s == "" {
+		*id = ""
+		return nil
+	}
+	parsed, err := ParseID(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// IDGenerator creates new IDs for stored entities.
+type IDGenerator interface {
+	NewID() ID
+}
+
+// NewIDGenerator returns the generator for the given format.
+func NewIDGenerator(format string) (IDGenerator, error) {
+	switch format {
+	case "", IDFormatObjectID:
+		return objectIDGenerator{}, nil
+	case IDFormatUUIDv7:
+		return uuidV7Generator{}, nil
+	case IDFormatULID:
+		return ulidGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported id format %q", format)
+	}
+}
+
+type objectIDGenerator struct{}
+
+func (objectIDGenerator) NewID() ID {
+	return ID(primitive.NewObjectID().Hex())
+}
+
+type uuidV7Generator struct{}
+
+func (uuidV7Generator) NewID() ID {
+	return ID(uuid.Must(uuid.NewV7()).String())
+}
+
+type ulidGenerator struct{}
+
+func (ulidGenerator) NewID() ID {
+	return ID(ulid.Make().String())
+}
@@ -0,0 +1,129 @@
+// internal/domain/id_test.go
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ID
+		wantErr bool
+	}{
+		{in: "507f1f77bcf86cd799439011", want: "507f1f77bcf86cd799439011"},
+		{in: "507F1F77BCF86CD799439011", want: "507f1f77bcf86cd799439011"},
+		{in: "0190B5E8-7A3C-7C4D-9E2F-5B8A1C3D4E6F", want: "0190b5e8-7a3c-7c4d-9e2f-5b8a1c3d4e6f"},
+		{in: "01j2xk7q8r9s0t1v2w3x4y5z6a", want: "01J2XK7Q8R9S0T1V2W3X4Y5Z6A"},
+		{in: "", wantErr: true},
+		{in: "507f1f77bcf86cd79943901", wantErr: true},
+		{in: "urn:uuid:0190b5e8-7a3c-7c4d-9e2f-5b8a1c3d4e6f", wantErr: true},
+		{in: "0190b5e87a3c7c4d9e2f5b8a1c3d4e6f", wantErr: true},
+		{in: "shoes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseID(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidID) {
+				t.Errorf("ParseID(%q) error = %v, want ErrInvalidID", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseID(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestIDGenerators(t *testing.T) {
+	for _, format := range []string{"", IDFormatObjectID, IDFormatUUIDv7, IDFormatULID} {
+		gen, err := NewIDGenerator(format)
+		if err != nil {
+			t.Fatalf("NewIDGenerator(%q) error = %v", format, err)
+		}
+		a, b := gen.NewID(), gen.NewID()
+		if a == b {
+			t.Errorf("%q generator repeated %s", format, a)
+		}
+		if parsed, err := ParseID(a.String()); err != nil || parsed != a {
+			t.Errorf("%q generator made %s, which parses as %q, %v", format, a, parsed, err)
+		}
+	}
+
+	if _, err := NewIDGenerator("snowflake"); err == nil {
+		t.Error(`NewIDGenerator("snowflake") succeeded`)
+	}
+}
+
+func TestIDBSON(t *testing.T) {
+	type doc struct {
+		ID ID `bson:"_id,omitempty"`
+	}
+
+	tests := []struct {
+		name     string
+		id       ID
+		wantType bsontype.Type
+	}{
+		{"object ID", "507f1f77bcf86cd799439011", bson.TypeObjectID},
+		{"UUID", "0190b5e8-7a3c-7c4d-9e2f-5b8a1c3d4e6f", bson.TypeString},
+		{"ULID", "01J2XK7Q8R9S0T1V2W3X4Y5Z6A", bson.TypeString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(doc{ID: tt.id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bson.Raw(data).Lookup("_id").Type; got != tt.wantType {
+				t.Errorf("stored as %s, want %s", got, tt.wantType)
+			}
+
+			var decoded doc
+			if err := bson.Unmarshal(data, &decoded); err != nil || decoded.ID != tt.id {
+				t.Errorf("decoded %q, %v, want %q", decoded.ID, err, tt.id)
+			}
+		})
+	}
+
+	data, _ := bson.Marshal(doc{})
+	if _, err := bson.Raw(data).LookupErr("_id"); err == nil {
+		t.Error("empty ID was stored")
+	}
+
+	oid := primitive.NewObjectID()
+	data, _ = bson.Marshal(bson.M{"_id": oid})
+	var decoded doc
+	if err := bson.Unmarshal(data, &decoded); err != nil || decoded.ID.String() != oid.Hex() {
+		t.Errorf("native ObjectID decoded as %q, %v", decoded.ID, err)
+	}
+}
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ID
+		wantErr bool
+	}{
+		{in: `"507F1F77BCF86CD799439011"`, want: "507f1f77bcf86cd799439011"},
+		{in: `""`, want: ""},
+		{in: `"shoes"`, wantErr: true},
+		{in: `42`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var id ID
+		err := json.Unmarshal([]byte(tt.in), &id)
+		if tt.wantErr != errors.Is(err, ErrInvalidID) || id != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, %v, want %q, error %v", tt.in, id, err, tt.want, tt.wantErr)
+		}
+	}
+}
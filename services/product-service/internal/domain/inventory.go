@@ -0,0 +1,405 @@
+// internal/domain/inventory.go
+package domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// InventoryReason is why the stock of a SKU moved.
+type InventoryReason string
+
+const (
+	// InventoryReceipt adds stock delivered by a supplier
+	InventoryReceipt InventoryReason = "receipt"
+	// InventorySale removes stock sold to a customer
+	InventorySale InventoryReason = "sale"
+	// InventoryReturn adds stock returned by a customer
+	InventoryReturn InventoryReason = "return"
+	// InventoryCorrection fixes the stock after a count, in either direction
+	InventoryCorrection InventoryReason = "correction"
+	// InventoryTransfer moves stock between locations; a transfer is
+	// recorded as a movement out of one location and one into the other
+	InventoryTransfer InventoryReason = "transfer"
+)
+
+// InventoryReasons lists every inventory reason.
+var InventoryReasons = []InventoryReason{InventoryReceipt, InventorySale, InventoryReturn, InventoryCorrection, InventoryTransfer}
+
+// Valid reports whether r is a known inventory reason.
+func (r InventoryReason) Valid() bool {
+	for _, known := range InventoryReasons {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds enforced on inventory adjustments and movement listings.
+const (
+	MaxInventoryDelta             = 1000000
+	MaxInventoryReferenceLength   = 100
+	MaxInventoryNoteLength        = 500
+	DefaultInventoryMovementLimit = 50
+	MaxInventoryMovementLimit     = 500
+)
+
+// openingStockNote marks the movements that record the stock a SKU started
+// with, such as that of a new product.
+const openingStockNote = "opening stock"
+
+// ErrInsufficientInventory is returned when an adjustment would take the
+// stock of a SKU below zero.
+var ErrInsufficientInventory = errors.New("insufficient inventory")
+
+// StockChange is an atomic change to the stock of a SKU: OnHand is added to
+// the stock on hand and Reserved to the stock held for orders. Reserving
+// stock moves it from available to reserved; selling reserved stock takes
+// it off both reserved and on hand.
+type StockChange struct {
+	OnHand   int
+	Reserved int
+}
+
+// Available returns the change to the available stock.
+func (c StockChange) Available() int {
+	return c.OnHand - c.Reserved
+}
+
+// StockLevel tells how much of a SKU is left to sell.
+type StockLevel string
+
+const (
+	StockInStock    StockLevel = "in_stock"
+	StockLow        StockLevel = "low_stock"
+	StockOutOfStock StockLevel = "out_of_stock"
+)
+
+// StockLevelOf classifies the available stock of a SKU. Stock at or below
+// lowThreshold is low; a threshold of 0 only reports running out.
+func StockLevelOf(available, lowThreshold int) StockLevel {
+	switch {
+	case available <= 0:
+		return StockOutOfStock
+	case available <= lowThreshold:
+		return StockLow
+	default:
+		return StockInStock
+	}
+}
+
+// InventoryAdjustment is a change to the stock of a SKU submitted by a
+// client.
+type InventoryAdjustment struct {
+	// SKU is the variant whose stock changes. Products without variants
+	// keep their stock under the product SKU, which may be left out.
+	SKU    string          `json:"sku,omitempty"`
+	Delta  int             `json:"delta" binding:"required"`
+	Reason InventoryReason `json:"reason" binding:"required"`
+	// Reference ties the movement to an order, delivery or count
+	Reference string `json:"reference,omitempty"`
+	Note      string `json:"note,omitempty"`
+	// Location is where the stock changes, the default location if left
+	// out
+	Location string `json:"location,omitempty"`
+}
+
+// Validate checks an adjustment. Receipts and returns add stock, sales take
+// it away and corrections go either way. Transfers are not adjustments.
+func (a *InventoryAdjustment) Validate() error {
+	verr := &ValidationError{}
+
+	a.SKU = NormalizeSKU(a.SKU)
+	if a.Location == "" {
+		a.Location = DefaultLocationID
+	}
+	if !identifierPattern.MatchString(a.Location) {
+		verr.Add("location", "must be a location ID")
+	}
+
+	if a.Delta == 0 || a.Delta > MaxInventoryDelta || a.Delta < -MaxInventoryDelta {
+		verr.Add("delta", "must be non-zero and at most %d either way", MaxInventoryDelta)
+	}
+	switch a.Reason {
+	case InventoryReceipt, InventoryReturn:
+		if a.Delta < 0 {
+			verr.Add("delta", "must be positive for a %s", a.Reason)
+		}
+	case InventorySale:
+		if a.Delta > 0 {
+			verr.Add("delta", "must be negative for a %s", a.Reason)
+		}
+	case InventoryCorrection:
+	default:
+		verr.Add("reason", "must be one of %s, %s, %s, %s", InventoryReceipt, InventorySale, InventoryReturn, InventoryCorrection)
+	}
+
+	if utf8.RuneCountInString(a.Reference) > MaxInventoryReferenceLength {
+		verr.Add("reference", "must be at most %d characters", MaxInventoryReferenceLength)
+	}
+	if utf8.RuneCountInString(a.Note) > MaxInventoryNoteLength {
+		verr.Add("note", "must be at most %d characters", MaxInventoryNoteLength)
+	}
+
+	return verr.Err()
+}
+
+// InventoryMovement is an entry of the inventory ledger. Entries are only
+// ever appended.
+type InventoryMovement struct {
+	ID        ID              `json:"id" bson:"_id,omitempty"`
+	ProductID ID              `json:"product_id" bson:"product_id"`
+	SKU       string          `json:"sku" bson:"sku"`
+	Delta     int             `json:"delta" bson:"delta"`
+	Reason    InventoryReason `json:"reason" bson:"reason"`
+	Reference string          `json:"reference,omitempty" bson:"reference,omitempty"`
+	Note      string          `json:"note,omitempty" bson:"note,omitempty"`
+	// Balance is the stock of the SKU over every location right after the
+	// movement
+	Balance   int       `json:"balance" bson:"balance"`
+	CreatedBy string    `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	// LocationID is where the stock moved; movements recorded before
+	// locations existed have none
+	LocationID string `json:"location_id,omitempty" bson:"location_id,omitempty"`
+}
+
+// InventoryMovementQuery filters and pages the ledger of a product, most
+// recent movements first.
+type InventoryMovementQuery struct {
+	SKU    string          `form:"sku"`
+	Reason InventoryReason `form:"reason"`
+	From   *time.Time      `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time      `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int             `form:"limit"`
+	Offset int             `form:"offset"`
+	// Location restricts the ledger to the movements at a location
+	Location string `form:"location"`
+	// Reference restricts the ledger to the movements of an order or
+	// delivery
+	Reference string `form:"reference"`
+}
+
+// Validate checks the query and applies the default limit.
+func (q *InventoryMovementQuery) Validate() error {
+	verr := &ValidationError{}
+
+	q.SKU = NormalizeSKU(q.SKU)
+	if q.Location != "" && !identifierPattern.MatchString(q.Location) {
+		verr.Add("location", "must be a location ID")
+	}
+	if q.Reason != "" && !q.Reason.Valid() {
+		verr.Add("reason", "must be one of %s, %s, %s, %s, %s", InventoryReceipt, InventorySale, InventoryReturn, InventoryCorrection, InventoryTransfer)
+	}
+	if utf8.RuneCountInString(q.Reference) > MaxInventoryReferenceLength {
+		verr.Add("reference", "must be at most %d characters", MaxInventoryReferenceLength)
+	}
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		verr.Add("to", "must not be before from")
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultInventoryMovementLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxInventoryMovementLimit {
+		verr.Add("limit", "must be between 1 and %d", MaxInventoryMovementLimit)
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "must not be negative")
+	}
+
+	return verr.Err()
+}
+
+// InventoryTotal is the sum of the movements of a SKU for one reason.
+type InventoryTotal struct {
+	SKU      string          `json:"sku" bson:"sku"`
+	Reason   InventoryReason `json:"reason" bson:"reason"`
+	Quantity int             `json:"quantity" bson:"quantity"`
+	Count    int             `json:"count" bson:"count"`
+}
+
+// SKUReconciliation compares the stock of a SKU with its ledger.
+type SKUReconciliation struct {
+	SKU         string `json:"sku"`
+	OnHand      int    `json:"on_hand"`
+	Ledger      int    `json:"ledger"`
+	Receipts    int    `json:"receipts"`
+	Sales       int    `json:"sales"`
+	Returns     int    `json:"returns"`
+	Corrections int    `json:"corrections"`
+	// Transfers nets to zero unless half of a transfer was not recorded
+	Transfers int `json:"transfers"`
+	// Difference is the stock the ledger does not account for
+	Difference int `json:"difference"`
+}
+
+// InventoryReconciliation compares the stock of every SKU of a product
+// with its ledger.
+type InventoryReconciliation struct {
+	ProductID ID                  `json:"product_id"`
+	Items     []SKUReconciliation `json:"items"`
+	Balanced  bool                `json:"balanced"`
+}
+
+// StockSKUs returns the SKUs stock is kept under: those of the variants,
+// or the product SKU for a product without variants.
+func (p *Product) StockSKUs() []string {
+	if len(p.Variants) == 0 {
+		return []string{p.SKU}
+	}
+	skus := make([]string, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		skus = append(skus, v.SKU)
+	}
+	return skus
+}
+
+// Stock returns the stock kept under sku.
+func (p *Product) Stock(sku string) int {
+	if v := p.Variant(sku); v != nil {
+		return v.Inventory
+	}
+	if len(p.Variants) == 0 && sku == p.SKU {
+		return p.Inventory
+	}
+	return 0
+}
+
+// AvailableStock returns the stock kept under sku that is not reserved.
+func (p *Product) AvailableStock(sku string) int {
+	if v := p.Variant(sku); v != nil {
+		return v.Available
+	}
+	if len(p.Variants) == 0 && sku == p.SKU {
+		return p.Available
+	}
+	return 0
+}
+
+// InitStock makes all the stock given in p available, ignoring any
+// reserved stock or locations a client sent.
+func (p *Product) InitStock() {
+	p.Reserved, p.Available, p.Locations = 0, p.Inventory, nil
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.Reserved = 0
+		v.Available = v.Inventory
+		v.Locations = nil
+	}
+	p.sumStock()
+}
+
+// CarryInventory keeps the stored stock of before in p, which replaces it.
+// Stock only changes through adjustments and reservations; only SKUs that
+// start keeping stock, such as new variants, take the stock given in p,
+// all of it available.
+func (p *Product) CarryInventory(before *Product) {
+	p.Locations = nil
+	for i := range p.Variants {
+		p.Variants[i].Locations = nil
+	}
+
+	if len(p.Variants) == 0 {
+		if len(before.Variants) == 0 {
+			p.Inventory, p.Reserved, p.Available = before.Inventory, before.Reserved, before.Available
+		} else {
+			p.Reserved, p.Available = 0, p.Inventory
+		}
+		return
+	}
+
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if old := before.Variant(v.SKU); old != nil {
+			v.Inventory, v.Reserved, v.Available = old.Inventory, old.Reserved, old.Available
+		} else {
+			v.Reserved, v.Available = 0, v.Inventory
+		}
+	}
+	p.sumStock()
+}
+
+// sumStock totals the stock of the variants of p, if it has any.
+func (p *Product) sumStock() {
+	if len(p.Variants) == 0 {
+		return
+	}
+	p.Inventory, p.Reserved, p.Available = 0, 0, 0
+	for _, v := range p.Variants {
+		p.Inventory += v.Inventory
+		p.Reserved += v.Reserved
+		p.Available += v.Available
+	}
+}
+
+// OpeningMovements returns the receipts that record the stock of the SKUs
+// after keeps stock under and before, which may be nil, did not.
+func OpeningMovements(before, after *Product) []InventoryMovement {
+	kept := map[string]bool{}
+	if before != nil {
+		for _, sku := range before.StockSKUs() {
+			kept[sku] = true
+		}
+	}
+
+	var movements []InventoryMovement
+	for _, sku := range after.StockSKUs() {
+		stock := after.Stock(sku)
+		if kept[sku] || stock == 0 {
+			continue
+		}
+		movements = append(movements, InventoryMovement{
+			ProductID: after.ID,
+			SKU:       sku,
+			Delta:     stock,
+			Reason:    InventoryReceipt,
+			Note:      openingStockNote,
+			Balance:   stock,
+		})
+	}
+	return movements
+}
+
+// Reconcile compares the stock of p with the totals of its ledger.
+func Reconcile(p *Product, totals []InventoryTotal) InventoryReconciliation {
+	bySKU := make(map[string]*SKUReconciliation, len(p.Variants)+1)
+	rec := InventoryReconciliation{ProductID: p.ID, Items: []SKUReconciliation{}, Balanced: true}
+	for _, sku := range p.StockSKUs() {
+		rec.Items = append(rec.Items, SKUReconciliation{SKU: sku, OnHand: p.Stock(sku)})
+	}
+	for i := range rec.Items {
+		bySKU[rec.Items[i].SKU] = &rec.Items[i]
+	}
+
+	// Totals of SKUs the product no longer keeps stock under are left out
+	for _, t := range totals {
+		item, ok := bySKU[t.SKU]
+		if !ok {
+			continue
+		}
+		item.Ledger += t.Quantity
+		switch t.Reason {
+		case InventoryReceipt:
+			item.Receipts += t.Quantity
+		case InventorySale:
+			item.Sales += t.Quantity
+		case InventoryReturn:
+			item.Returns += t.Quantity
+		case InventoryCorrection:
+			item.Corrections += t.Quantity
+		case InventoryTransfer:
+			item.Transfers += t.Quantity
+		}
+	}
+
+	for i := range rec.Items {
+		item := &rec.Items[i]
+		item.Difference = item.OnHand - item.Ledger
+		if item.Difference != 0 {
+			rec.Balanced = false
+		}
+	}
+	return rec
+}
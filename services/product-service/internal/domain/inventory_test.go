@@ -0,0 +1,159 @@
+// internal/domain/inventory_test.go
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInventoryAdjustmentValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		a    InventoryAdjustment
+		// wantErr is a part of the error message, empty for none
+		wantErr string
+	}{
+		{"receipt", InventoryAdjustment{Delta: 10, Reason: InventoryReceipt}, ""},
+		{"sale", InventoryAdjustment{Delta: -1, Reason: InventorySale}, ""},
+		{"return", InventoryAdjustment{Delta: 1, Reason: InventoryReturn}, ""},
+		{"correction down", InventoryAdjustment{Delta: -3, Reason: InventoryCorrection}, ""},
+		{"largest delta", InventoryAdjustment{Delta: -MaxInventoryDelta, Reason: InventoryCorrection}, ""},
+		{"zero delta", InventoryAdjustment{Reason: InventoryCorrection}, "delta: must be non-zero"},
+		{"delta too large", InventoryAdjustment{Delta: MaxInventoryDelta + 1, Reason: InventoryReceipt}, "delta: must be non-zero"},
+		{"negative receipt", InventoryAdjustment{Delta: -1, Reason: InventoryReceipt}, "delta: must be positive for a receipt"},
+		{"positive sale", InventoryAdjustment{Delta: 1, Reason: InventorySale}, "delta: must be negative for a sale"},
+		{"unknown reason", InventoryAdjustment{Delta: 1, Reason: "theft"}, "reason: must be one of"},
+		{"reference", InventoryAdjustment{Delta: 1, Reason: InventoryReceipt, Reference: strings.Repeat("r", MaxInventoryReferenceLength+1)}, "reference: must be at most"},
+		{"note", InventoryAdjustment{Delta: 1, Reason: InventoryReceipt, Note: strings.Repeat("n", MaxInventoryNoteLength+1)}, "note: must be at most"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.a.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	a := InventoryAdjustment{SKU: " tee-m ", Delta: 1, Reason: InventoryReceipt}
+	if err := a.Validate(); err != nil || a.SKU != "TEE-M" {
+		t.Errorf("Validate() = %v with SKU %q, want it normalized", err, a.SKU)
+	}
+}
+
+func TestOpeningMovements(t *testing.T) {
+	single := &Product{ID: "p1", SKU: "TEE", Inventory: 5}
+	withVariants := &Product{ID: "p1", SKU: "TEE", Variants: []Variant{
+		{SKU: "TEE-S", Inventory: 2},
+		{SKU: "TEE-M", Inventory: 0},
+		{SKU: "TEE-L", Inventory: 4},
+	}}
+
+	tests := []struct {
+		name   string
+		before *Product
+		after  *Product
+		want   map[string]int
+	}{
+		{"new product", nil, single, map[string]int{"TEE": 5}},
+		{"new product without stock", nil, &Product{SKU: "TEE"}, map[string]int{}},
+		{"new variants", nil, withVariants, map[string]int{"TEE-S": 2, "TEE-L": 4}},
+		{"unchanged", single, single, map[string]int{}},
+		{"variants added", single, withVariants, map[string]int{"TEE-S": 2, "TEE-L": 4}},
+		{"variant added", &Product{SKU: "TEE", Variants: withVariants.Variants[:1]}, withVariants, map[string]int{"TEE-L": 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]int{}
+			for _, m := range OpeningMovements(tt.before, tt.after) {
+				if m.Reason != InventoryReceipt || m.Note != openingStockNote || m.Balance != m.Delta || m.ProductID != tt.after.ID {
+					t.Errorf("opening movement %+v", m)
+				}
+				got[m.SKU] = m.Delta
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OpeningMovements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	p := &Product{ID: "p1", SKU: "TEE", Variants: []Variant{
+		{SKU: "TEE-S", Inventory: 7},
+		{SKU: "TEE-M", Inventory: 3},
+	}}
+	totals := []InventoryTotal{
+		{SKU: "TEE-S", Reason: InventoryReceipt, Quantity: 10},
+		{SKU: "TEE-S", Reason: InventorySale, Quantity: -4},
+		{SKU: "TEE-S", Reason: InventoryReturn, Quantity: 1},
+		{SKU: "TEE-M", Reason: InventoryReceipt, Quantity: 5},
+		{SKU: "TEE-M", Reason: InventoryCorrection, Quantity: -1},
+		{SKU: "TEE-XL", Reason: InventoryReceipt, Quantity: 9},
+	}
+
+	rec := Reconcile(p, totals)
+	want := []SKUReconciliation{
+		{SKU: "TEE-S", OnHand: 7, Ledger: 7, Receipts: 10, Sales: -4, Returns: 1},
+		{SKU: "TEE-M", OnHand: 3, Ledger: 4, Receipts: 5, Corrections: -1, Difference: -1},
+	}
+	if !reflect.DeepEqual(rec.Items, want) {
+		t.Errorf("Reconcile() items = %+v, want %+v", rec.Items, want)
+	}
+	if rec.Balanced {
+		t.Error("Reconcile() balanced with a difference on TEE-M")
+	}
+
+	if rec := Reconcile(&Product{SKU: "TEE"}, nil); !rec.Balanced || len(rec.Items) != 1 {
+		t.Errorf("Reconcile() of a product without stock = %+v", rec)
+	}
+}
+
+func TestCarryInventory(t *testing.T) {
+	tests := []struct {
+		name   string
+		before Product
+		p      Product
+		want   Product
+	}{
+		{
+			name:   "keeps stored stock",
+			before: Product{SKU: "TEE", Inventory: 5, Reserved: 2, Available: 3},
+			p:      Product{SKU: "TEE", Inventory: 100, Reserved: 50},
+			want:   Product{SKU: "TEE", Inventory: 5, Reserved: 2, Available: 3},
+		},
+		{
+			name:   "variants removed",
+			before: Product{SKU: "TEE", Inventory: 4, Reserved: 1, Available: 3, Variants: []Variant{{SKU: "TEE-S", Inventory: 4, Reserved: 1, Available: 3}}},
+			p:      Product{SKU: "TEE", Inventory: 6},
+			want:   Product{SKU: "TEE", Inventory: 6, Available: 6},
+		},
+		{
+			name:   "variant added",
+			before: Product{SKU: "TEE", Inventory: 4, Reserved: 1, Available: 3, Variants: []Variant{{SKU: "TEE-S", Inventory: 4, Reserved: 1, Available: 3}}},
+			p:      Product{SKU: "TEE", Variants: []Variant{{SKU: "TEE-S", Inventory: 9}, {SKU: "TEE-M", Inventory: 2, Reserved: 2}}},
+			want: Product{SKU: "TEE", Inventory: 6, Reserved: 1, Available: 5, Variants: []Variant{
+				{SKU: "TEE-S", Inventory: 4, Reserved: 1, Available: 3},
+				{SKU: "TEE-M", Inventory: 2, Available: 2},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.p.CarryInventory(&tt.before)
+			if !reflect.DeepEqual(tt.p, tt.want) {
+				t.Errorf("CarryInventory() = %+v, want %+v", tt.p, tt.want)
+			}
+		})
+	}
+}
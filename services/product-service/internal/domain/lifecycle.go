@@ -0,0 +1,72 @@
+// internal/domain/lifecycle.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is the lifecycle state of a product.
+type Status string
+
+// Lifecycle states. New products start as drafts and only published products
+// are visible to the public.
+const (
+	StatusDraft        Status = "draft"
+	StatusReview       Status = "review"
+	StatusPublished    Status = "published"
+	StatusDiscontinued Status = "discontinued"
+	StatusArchived     Status = "archived"
+)
+
+// Statuses lists every lifecycle state in order.
+var Statuses = []Status{StatusDraft, StatusReview, StatusPublished, StatusDiscontinued, StatusArchived}
+
+// transitions is the lifecycle state machine: the states each state may move
+// to. A product under review can be sent back to draft, and a discontinued
+// one can be published again; archived is final.
+var transitions = map[Status][]Status{
+	StatusDraft:        {StatusReview, StatusArchived},
+	StatusReview:       {StatusDraft, StatusPublished},
+	StatusPublished:    {StatusDiscontinued},
+	StatusDiscontinued: {StatusPublished, StatusArchived},
+	StatusArchived:     {},
+}
+
+// Valid reports whether s is a known lifecycle state.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransition reports whether a product may move from s to to.
+func (s Status) CanTransition(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions returns the states s may move to.
+func (s Status) Transitions() []Status {
+	return transitions[s]
+}
+
+// ErrInvalidTransition matches any TransitionError via errors.Is.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError reports a transition the state machine does not allow.
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move product from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
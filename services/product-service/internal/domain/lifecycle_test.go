@@ -0,0 +1,55 @@
+// internal/domain/lifecycle_test.go
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStatusCanTransition(t *testing.T) {
+	allowed := map[[2]Status]bool{
+		{StatusDraft, StatusReview}:           true,
+		{StatusDraft, StatusArchived}:         true,
+		{StatusReview, StatusDraft}:           true,
+		{StatusReview, StatusPublished}:       true,
+		{StatusPublished, StatusDiscontinued}: true,
+		{StatusDiscontinued, StatusPublished}: true,
+		{StatusDiscontinued, StatusArchived}:  true,
+	}
+
+	for _, from := range Statuses {
+		for _, to := range Statuses {
+			if got := from.CanTransition(to); got != allowed[[2]Status{from, to}] {
+				t.Errorf("%s.CanTransition(%s) = %v", from, to, got)
+			}
+		}
+	}
+	if Status("deleted").CanTransition(StatusDraft) || StatusDraft.CanTransition("deleted") {
+		t.Error("transition to or from an unknown state allowed")
+	}
+}
+
+func TestStatusValid(t *testing.T) {
+	tests := []struct {
+		s    Status
+		want bool
+	}{
+		{StatusDraft, true},
+		{StatusArchived, true},
+		{"", false},
+		{"Published", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.s.Valid(); got != tt.want {
+			t.Errorf("Status(%q).Valid() = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionErrorIs(t *testing.T) {
+	var err error = &TransitionError{From: StatusArchived, To: StatusPublished}
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("errors.Is(%v, ErrInvalidTransition) = false", err)
+	}
+}
@@ -0,0 +1,286 @@
+// internal/domain/location.go
+package domain
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds enforced on stock locations.
+const (
+	MaxLocationNameLength = 100
+	MaxLocationPriority   = 1000
+)
+
+// DefaultLocationID is the ID of the stock location seeded by the
+// migrations. It holds the stock kept before locations existed and the
+// stock of adjustments that name no location.
+const DefaultLocationID = "default"
+
+// ErrLocationNotFound is returned when a stock location does not exist.
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrLocationExists is returned when creating a stock location whose ID is
+// taken.
+var ErrLocationExists = errors.New("location already exists")
+
+// ErrLocationInUse is returned when deleting a stock location that still
+// holds stock, or the default location.
+var ErrLocationInUse = errors.New("location is in use")
+
+// LocationType tells what kind of place a stock location is.
+type LocationType string
+
+const (
+	LocationWarehouse LocationType = "warehouse"
+	LocationStore     LocationType = "store"
+)
+
+// GeoPoint is a position on Earth in decimal degrees.
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude" bson:"latitude"`
+	Longitude float64 `json:"longitude" bson:"longitude"`
+}
+
+func (p GeoPoint) validate(verr *ValidationError, field string) {
+	if p.Latitude < -90 || p.Latitude > 90 {
+		verr.Add(field+".latitude", "must be between -90 and 90")
+	}
+	if p.Longitude < -180 || p.Longitude > 180 {
+		verr.Add(field+".longitude", "must be between -180 and 180")
+	}
+}
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between p and q.
+func (p GeoPoint) DistanceKm(q GeoPoint) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(q.Latitude - p.Latitude)
+	dLon := rad(q.Longitude - p.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(p.Latitude))*math.Cos(rad(q.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Location is a warehouse or store that keeps stock. Stock is allocated
+// to orders from the locations that are not disabled.
+type Location struct {
+	ID   string       `json:"id" bson:"_id"`
+	Name string       `json:"name" bson:"name" binding:"required"`
+	Type LocationType `json:"type" bson:"type"`
+	// Priority orders the locations stock is allocated from, lowest first
+	Priority int `json:"priority" bson:"priority"`
+	// Position places the location for nearest allocation
+	Position *GeoPoint `json:"position,omitempty" bson:"position,omitempty"`
+	// Disabled locations keep their stock but are not allocated from
+	Disabled  bool      `json:"disabled" bson:"disabled"`
+	Default   bool      `json:"default" bson:"default"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks a location submitted by a client. The type defaults to
+// warehouse.
+func (l *Location) Validate() error {
+	verr := &ValidationError{}
+
+	if l.Type == "" {
+		l.Type = LocationWarehouse
+	}
+
+	if !identifierPattern.MatchString(l.ID) {
+		verr.Add("id", "must be a lowercase identifier of at most 32 characters")
+	}
+	if l.Name == "" || utf8.RuneCountInString(l.Name) > MaxLocationNameLength {
+		verr.Add("name", "must be 1 to %d characters", MaxLocationNameLength)
+	}
+	switch l.Type {
+	case LocationWarehouse, LocationStore:
+	default:
+		verr.Add("type", "must be one of %s, %s", LocationWarehouse, LocationStore)
+	}
+	if l.Priority < 0 || l.Priority > MaxLocationPriority {
+		verr.Add("priority", "must be between 0 and %d", MaxLocationPriority)
+	}
+	if l.Position != nil {
+		l.Position.validate(verr, "position")
+	}
+
+	return verr.Err()
+}
+
+// AllocationStrategy orders the locations stock is reserved from.
+type AllocationStrategy string
+
+const (
+	// AllocatePriority takes stock from the locations by priority
+	AllocatePriority AllocationStrategy = "priority"
+	// AllocateNearest takes stock from the locations closest to the
+	// destination first; those without a position come last
+	AllocateNearest AllocationStrategy = "nearest"
+)
+
+// RankLocations returns the locations stock may be allocated from, in the
+// order strategy takes stock from them. Ties go by priority, then by ID.
+func RankLocations(locations []Location, strategy AllocationStrategy, destination *GeoPoint) []Location {
+	ranked := make([]Location, 0, len(locations))
+	for _, l := range locations {
+		if !l.Disabled {
+			ranked = append(ranked, l)
+		}
+	}
+
+	distance := func(l Location) float64 {
+		if strategy != AllocateNearest || destination == nil || l.Position == nil {
+			return math.Inf(1)
+		}
+		return destination.DistanceKm(*l.Position)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if di, dj := distance(ranked[i]), distance(ranked[j]); di != dj {
+			return di < dj
+		}
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority < ranked[j].Priority
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked
+}
+
+// LocationStock is the stock of a SKU at a location. The stock of the SKU
+// on the product is its total over every location.
+type LocationStock struct {
+	ProductID  ID        `json:"product_id" bson:"product_id"`
+	SKU        string    `json:"sku" bson:"sku"`
+	LocationID string    `json:"location_id" bson:"location_id"`
+	OnHand     int       `json:"on_hand" bson:"on_hand"`
+	Reserved   int       `json:"reserved" bson:"reserved"`
+	Available  int       `json:"available" bson:"available"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// LocationAvailability is the stock of a product or variant at a location,
+// set on reads.
+type LocationAvailability struct {
+	LocationID string `json:"location_id"`
+	OnHand     int    `json:"on_hand"`
+	Reserved   int    `json:"reserved"`
+	Available  int    `json:"available"`
+}
+
+// SetLocations sets the stock of p and its variants at each location from
+// the stock levels of p. Levels of SKUs p no longer keeps stock under are
+// left out.
+func (p *Product) SetLocations(levels []LocationStock) {
+	bySKU := map[string][]LocationAvailability{}
+	for _, l := range levels {
+		bySKU[l.SKU] = append(bySKU[l.SKU], LocationAvailability{
+			LocationID: l.LocationID,
+			OnHand:     l.OnHand,
+			Reserved:   l.Reserved,
+			Available:  l.Available,
+		})
+	}
+
+	index := map[string]int{}
+	p.Locations = []LocationAvailability{}
+	for _, sku := range p.StockSKUs() {
+		for _, a := range bySKU[sku] {
+			i, ok := index[a.LocationID]
+			if !ok {
+				i = len(p.Locations)
+				index[a.LocationID] = i
+				p.Locations = append(p.Locations, LocationAvailability{LocationID: a.LocationID})
+			}
+			total := &p.Locations[i]
+			total.OnHand += a.OnHand
+			total.Reserved += a.Reserved
+			total.Available += a.Available
+		}
+	}
+	sort.Slice(p.Locations, func(i, j int) bool { return p.Locations[i].LocationID < p.Locations[j].LocationID })
+
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.Locations = append([]LocationAvailability{}, bySKU[v.SKU]...)
+		sort.Slice(v.Locations, func(i, j int) bool { return v.Locations[i].LocationID < v.Locations[j].LocationID })
+	}
+}
+
+// StockAllocation is the part of a reservation item held at a location.
+type StockAllocation struct {
+	LocationID string `json:"location_id" bson:"location_id"`
+	Quantity   int    `json:"quantity" bson:"quantity"`
+}
+
+// AllocateStock splits quantity over the ranked locations, taking as much
+// as each has available before moving on to the next. It fails with
+// ErrInsufficientInventory if they do not have enough between them.
+func AllocateStock(ranked []Location, available map[string]int, quantity int) ([]StockAllocation, error) {
+	var allocations []StockAllocation
+	remaining := quantity
+	for _, l := range ranked {
+		if remaining == 0 {
+			break
+		}
+		take := available[l.ID]
+		if take <= 0 {
+			continue
+		}
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, StockAllocation{LocationID: l.ID, Quantity: take})
+		remaining -= take
+	}
+	if remaining > 0 {
+		return nil, ErrInsufficientInventory
+	}
+	return allocations, nil
+}
+
+// StockTransfer moves stock of a SKU from one location to another.
+type StockTransfer struct {
+	// SKU is the variant whose stock moves. Products without variants
+	// keep their stock under the product SKU, which may be left out.
+	SKU      string `json:"sku,omitempty"`
+	From     string `json:"from" binding:"required"`
+	To       string `json:"to" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+	// Reference ties the transfer to a shipment between the locations
+	Reference string `json:"reference,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// Validate checks a transfer. The locations are checked by the service.
+func (t *StockTransfer) Validate() error {
+	verr := &ValidationError{}
+
+	t.SKU = NormalizeSKU(t.SKU)
+
+	if !identifierPattern.MatchString(t.From) {
+		verr.Add("from", "must be a location ID")
+	}
+	if !identifierPattern.MatchString(t.To) {
+		verr.Add("to", "must be a location ID")
+	} else if t.To == t.From {
+		verr.Add("to", "must differ from from")
+	}
+	if t.Quantity < 1 || t.Quantity > MaxInventoryDelta {
+		verr.Add("quantity", "must be between 1 and %d", MaxInventoryDelta)
+	}
+	if utf8.RuneCountInString(t.Reference) > MaxInventoryReferenceLength {
+		verr.Add("reference", "must be at most %d characters", MaxInventoryReferenceLength)
+	}
+	if utf8.RuneCountInString(t.Note) > MaxInventoryNoteLength {
+		verr.Add("note", "must be at most %d characters", MaxInventoryNoteLength)
+	}
+
+	return verr.Err()
+}
@@ -0,0 +1,219 @@
+// internal/domain/location_test.go
+package domain
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGeoPointDistanceKm(t *testing.T) {
+	paris := GeoPoint{Latitude: 48.8566, Longitude: 2.3522}
+	london := GeoPoint{Latitude: 51.5074, Longitude: -0.1278}
+
+	tests := []struct {
+		name string
+		p, q GeoPoint
+		want float64
+	}{
+		{"same point", paris, paris, 0},
+		{"Paris to London", paris, london, 344},
+		{"London to Paris", london, paris, 344},
+		{"antipodes", GeoPoint{}, GeoPoint{Longitude: 180}, math.Pi * earthRadiusKm},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.DistanceKm(tt.q); math.Abs(got-tt.want) > 1 {
+			t.Errorf("%s: DistanceKm() = %.1f, want %.1f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLocationValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		l    Location
+		// wantErr lists the field errors, empty for none
+		wantErr string
+	}{
+		{"valid", Location{ID: "paris_1", Name: "Paris", Priority: MaxLocationPriority, Position: &GeoPoint{Latitude: 90, Longitude: -180}}, ""},
+		{"id", Location{ID: "Paris", Name: "Paris"}, "id: must be a lowercase identifier of at most 32 characters"},
+		{"name", Location{ID: "paris"}, "name: must be 1 to 100 characters"},
+		{"type", Location{ID: "paris", Name: "Paris", Type: "kiosk"}, "type: must be one of warehouse, store"},
+		{"priority", Location{ID: "paris", Name: "Paris", Priority: -1}, "priority: must be between 0 and 1000"},
+		{"position", Location{ID: "paris", Name: "Paris", Position: &GeoPoint{Latitude: 91, Longitude: 181}}, "position.latitude: must be between -90 and 90; position.longitude: must be between -180 and 180"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := tt.l.Validate(); err != nil {
+				got = strings.TrimPrefix(err.Error(), "validation failed: ")
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+
+	l := Location{ID: "paris", Name: "Paris"}
+	if err := l.Validate(); err != nil || l.Type != LocationWarehouse {
+		t.Errorf("Validate() = %v with type %q, want a warehouse", err, l.Type)
+	}
+}
+
+func TestRankLocations(t *testing.T) {
+	locations := []Location{
+		{ID: "lyon", Priority: 2, Position: &GeoPoint{Latitude: 45.764, Longitude: 4.8357}},
+		{ID: "lille", Priority: 1, Position: &GeoPoint{Latitude: 50.6292, Longitude: 3.0573}},
+		{ID: "online", Priority: 1},
+		{ID: "closed", Priority: 0, Disabled: true},
+		{ID: "default", Priority: 3},
+	}
+	paris := &GeoPoint{Latitude: 48.8566, Longitude: 2.3522}
+
+	tests := []struct {
+		name        string
+		strategy    AllocationStrategy
+		destination *GeoPoint
+		want        []string
+	}{
+		{"priority", AllocatePriority, paris, []string{"lille", "online", "lyon", "default"}},
+		{"nearest", AllocateNearest, paris, []string{"lille", "lyon", "online", "default"}},
+		{"nearest without destination", AllocateNearest, nil, []string{"lille", "online", "lyon", "default"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, l := range RankLocations(locations, tt.strategy, tt.destination) {
+				got = append(got, l.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RankLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateStock(t *testing.T) {
+	ranked := []Location{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	available := map[string]int{"a": 2, "b": 0, "c": 5}
+
+	tests := []struct {
+		name     string
+		quantity int
+		want     []StockAllocation
+		wantErr  error
+	}{
+		{"first location", 1, []StockAllocation{{LocationID: "a", Quantity: 1}}, nil},
+		{"spills over", 4, []StockAllocation{{LocationID: "a", Quantity: 2}, {LocationID: "c", Quantity: 2}}, nil},
+		{"everything", 7, []StockAllocation{{LocationID: "a", Quantity: 2}, {LocationID: "c", Quantity: 5}}, nil},
+		{"too much", 8, nil, ErrInsufficientInventory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AllocateStock(ranked, available, tt.quantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AllocateStock() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllocateStock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLocations(t *testing.T) {
+	p := &Product{SKU: "TEE", Variants: []Variant{{SKU: "TEE-S"}, {SKU: "TEE-M"}}}
+	p.SetLocations([]LocationStock{
+		{SKU: "TEE-S", LocationID: "store", OnHand: 2, Reserved: 1, Available: 1},
+		{SKU: "TEE-S", LocationID: "default", OnHand: 5, Available: 5},
+		{SKU: "TEE-M", LocationID: "store", OnHand: 3, Reserved: 3},
+		{SKU: "TEE", LocationID: "default", OnHand: 9, Available: 9},
+	})
+
+	wantProduct := []LocationAvailability{
+		{LocationID: "default", OnHand: 5, Available: 5},
+		{LocationID: "store", OnHand: 5, Reserved: 4, Available: 1},
+	}
+	if !reflect.DeepEqual(p.Locations, wantProduct) {
+		t.Errorf("product locations = %+v, want %+v", p.Locations, wantProduct)
+	}
+
+	wantSmall := []LocationAvailability{
+		{LocationID: "default", OnHand: 5, Available: 5},
+		{LocationID: "store", OnHand: 2, Reserved: 1, Available: 1},
+	}
+	if !reflect.DeepEqual(p.Variants[0].Locations, wantSmall) {
+		t.Errorf("TEE-S locations = %+v, want %+v", p.Variants[0].Locations, wantSmall)
+	}
+
+	single := &Product{SKU: "MUG"}
+	single.SetLocations(nil)
+	if single.Locations == nil || len(single.Locations) != 0 {
+		t.Errorf("locations without stock levels = %#v, want empty", single.Locations)
+	}
+}
+
+func TestStockTransferValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tr      StockTransfer
+		wantErr string
+	}{
+		{"valid", StockTransfer{From: "default", To: "store", Quantity: 3}, ""},
+		{"same location", StockTransfer{From: "store", To: "store", Quantity: 3}, "to: must differ from from"},
+		{"locations", StockTransfer{From: "", To: "Store", Quantity: 3}, "from: must be a location ID; to: must be a location ID"},
+		{"quantity", StockTransfer{From: "default", To: "store", Quantity: 0}, "quantity: must be between 1 and 1000000"},
+		{"quantity too large", StockTransfer{From: "default", To: "store", Quantity: MaxInventoryDelta + 1}, "quantity: must be between 1 and 1000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := tt.tr.Validate(); err != nil {
+				got = strings.TrimPrefix(err.Error(), "validation failed: ")
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReservationRequestStrategy(t *testing.T) {
+	items := []ReservationItem{{ProductID: "p1", Quantity: 1}}
+
+	tests := []struct {
+		name    string
+		r       ReservationRequest
+		wantErr string
+	}{
+		{"nearest", ReservationRequest{OrderID: "o", Items: items, Strategy: AllocateNearest, Destination: &GeoPoint{Latitude: 48.8, Longitude: 2.3}}, ""},
+		{"nearest without destination", ReservationRequest{OrderID: "o", Items: items, Strategy: AllocateNearest}, "destination: is required for nearest allocation"},
+		{"unknown strategy", ReservationRequest{OrderID: "o", Items: items, Strategy: "cheapest"}, "strategy: must be one of priority, nearest"},
+		{"destination", ReservationRequest{OrderID: "o", Items: items, Destination: &GeoPoint{Latitude: -91}}, "destination.latitude: must be between -90 and 90"},
+		{"location", ReservationRequest{OrderID: "o", Items: items, Location: "Store 1"}, "location: must be a location ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := tt.r.Validate(); err != nil {
+				got = strings.TrimPrefix(err.Error(), "validation failed: ")
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+
+	r := ReservationRequest{OrderID: "o", Items: items}
+	if err := r.Validate(); err != nil || r.Strategy != AllocatePriority {
+		t.Errorf("Validate() = %v with strategy %q, want priority", err, r.Strategy)
+	}
+}
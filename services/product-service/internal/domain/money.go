@@ -0,0 +1,92 @@
+// internal/domain/money.go
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyDigits maps the ISO 4217 codes accepted for prices to the number
+// of digits of their minor unit.
+var currencyDigits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2,
+	"CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EGP": 2, "EUR": 2,
+	"GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MAD": 2, "MXN": 2, "MYR": 2,
+	"NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "QAR": 2, "RON": 2,
+	"SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"UAH": 2, "USD": 2, "VND": 0, "XOF": 0, "ZAR": 2,
+}
+
+// LegacyCurrency is the currency of the prices stored before prices had
+// one. The migrations convert them, and seed the default price list, in it.
+const LegacyCurrency = "USD"
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code.
+func ValidCurrency(code string) bool {
+	_, ok := currencyDigits[code]
+	return ok
+}
+
+// CurrencyDigits returns the number of digits of the minor unit of a
+// currency, such as 2 for cents.
+func CurrencyDigits(code string) int {
+	return currencyDigits[code]
+}
+
+// Money is an amount in the minor unit of its currency, such as cents, so
+// sums and comparisons are exact.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// MoneyFromFloat converts a decimal amount in the major unit, such as a
+// price filter in dollars, rounding half away from zero.
+func MoneyFromFloat(f float64, currency string) Money {
+	return Money{Amount: int64(math.Round(f * math.Pow10(CurrencyDigits(currency)))), Currency: currency}
+}
+
+// Decimal formats the amount in the major unit, e.g. 1999 USD as "19.99".
+func (m Money) Decimal() string {
+	digits := CurrencyDigits(m.Currency)
+	if digits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := fmt.Sprintf("%0*d", digits+1, amount)
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// validate adds an error on field unless m is a positive amount in a
+// supported currency, and currency if it is given.
+func (m Money) validate(verr *ValidationError, field, currency string) {
+	switch {
+	case !ValidCurrency(m.Currency):
+		verr.Add(field+".currency", "must be a supported ISO 4217 code")
+	case currency != "" && m.Currency != currency:
+		verr.Add(field+".currency", "must be %s", currency)
+	}
+	if m.Amount <= 0 {
+		verr.Add(field+".amount", "must be greater than 0")
+	}
+}
+
+// ParseCurrency normalizes a currency code from a query parameter or header.
+func ParseCurrency(s string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+	if !ValidCurrency(code) {
+		return "", fmt.Errorf("%q is not a supported ISO 4217 currency code", s)
+	}
+	return code, nil
+}
@@ -0,0 +1,102 @@
+// internal/domain/money_test.go
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoneyFromFloat(t *testing.T) {
+	tests := []struct {
+		f        float64
+		currency string
+		want     int64
+	}{
+		{19.99, "USD", 1999},
+		{0.1 + 0.2, "USD", 30},
+		{2.675, "EUR", 268},
+		{-2.675, "EUR", -268},
+		{1500, "JPY", 1500},
+		{1499.5, "JPY", 1500},
+		{1.2345, "KWD", 1235},
+	}
+
+	for _, tt := range tests {
+		if got := MoneyFromFloat(tt.f, tt.currency); got != (Money{Amount: tt.want, Currency: tt.currency}) {
+			t.Errorf("MoneyFromFloat(%v, %s) = %v, want %d", tt.f, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{Money{Amount: 1999, Currency: "USD"}, "19.99"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: 0, Currency: "USD"}, "0.00"},
+		{Money{Amount: -1999, Currency: "USD"}, "-19.99"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 1500, Currency: "JPY"}, "1500"},
+		{Money{Amount: 1234, Currency: "BHD"}, "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Decimal(); got != tt.want {
+			t.Errorf("%d %s Decimal() = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "USD", want: "USD"},
+		{in: " eur ", want: "EUR"},
+		{in: "", wantErr: true},
+		{in: "XYZ", wantErr: true},
+		{in: "US", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCurrency(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCurrency(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMoneyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		currency string
+		want     []string
+	}{
+		{"valid", Money{Amount: 1999, Currency: "USD"}, "", nil},
+		{"expected currency", Money{Amount: 1999, Currency: "USD"}, "USD", nil},
+		{"other currency", Money{Amount: 1999, Currency: "EUR"}, "USD", []string{"price.currency"}},
+		{"unsupported currency", Money{Amount: 1999, Currency: "usd"}, "", []string{"price.currency"}},
+		{"zero", Money{Amount: 0, Currency: "USD"}, "", []string{"price.amount"}},
+		{"empty", Money{}, "USD", []string{"price.currency", "price.amount"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := &ValidationError{}
+			tt.m.validate(verr, "price", tt.currency)
+
+			var got []string
+			for _, f := range verr.Fields {
+				got = append(got, f.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
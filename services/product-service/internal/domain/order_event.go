@@ -0,0 +1,72 @@
+// internal/domain/order_event.go
+package domain
+
+import "time"
+
+// Order events the product service acts on, by routing key.
+const (
+	// OrderCreated reserves the stock of the order items
+	OrderCreated = "order.created"
+	// OrderCancelled releases the stock reserved for the order
+	OrderCancelled = "order.cancelled"
+	// OrderDelivered sells the stock reserved for the order
+	OrderDelivered = "order.delivered"
+)
+
+// OrderEvent is an event published by the order service. ID identifies the
+// event, so that redeliveries are only processed once.
+type OrderEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Order     struct {
+		ID    string           `json:"id"`
+		Items []OrderEventItem `json:"items"`
+	} `json:"order"`
+}
+
+// OrderEventItem is a line of an order. SKU names the variant ordered, if
+// the product has variants.
+type OrderEventItem struct {
+	ProductID ID     `json:"productId"`
+	SKU       string `json:"sku,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Validate checks the fields every order event needs. The items are checked
+// when they are reserved.
+func (e *OrderEvent) Validate() error {
+	verr := &ValidationError{}
+
+	if e.ID == "" {
+		verr.Add("id", "is required")
+	}
+	if e.Type == "" {
+		verr.Add("type", "is required")
+	}
+	if !orderIDPattern.MatchString(e.Order.ID) {
+		verr.Add("order.id", "must be 1 to 64 letters, digits, dashes or underscores")
+	}
+
+	return verr.Err()
+}
+
+// ReservationItems returns the items of the order as reservation items.
+func (e *OrderEvent) ReservationItems() []ReservationItem {
+	items := make([]ReservationItem, 0, len(e.Order.Items))
+	for _, item := range e.Order.Items {
+		items = append(items, ReservationItem{ProductID: item.ProductID, SKU: item.SKU, Quantity: item.Quantity})
+	}
+	return items
+}
+
+// ProcessedEvent records an event that was processed, so that it is not
+// processed again when it is redelivered.
+type ProcessedEvent struct {
+	ID      string `json:"id" bson:"_id"`
+	Type    string `json:"type" bson:"type"`
+	OrderID string `json:"order_id" bson:"order_id"`
+	// Outcome tells what processing the event did, e.g. "reserved"
+	Outcome     string    `json:"outcome" bson:"outcome"`
+	ProcessedAt time.Time `json:"processed_at" bson:"processed_at"`
+}
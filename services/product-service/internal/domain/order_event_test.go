@@ -0,0 +1,57 @@
+// internal/domain/order_event_test.go
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOrderEventValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"valid", `{"id": "evt-1", "type": "order.created", "order": {"id": "ord_1"}}`, ""},
+		{"missing id and type", `{"order": {"id": "ord_1"}}`, "validation failed: id: is required; type: is required"},
+		{"missing order", `{"id": "evt-1", "type": "order.created"}`, "validation failed: order.id: must be 1 to 64 letters, digits, dashes or underscores"},
+		{"order ID", `{"id": "evt-1", "type": "order.created", "order": {"id": "ord 1"}}`, "validation failed: order.id: must be 1 to 64 letters, digits, dashes or underscores"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e OrderEvent
+			if err := json.Unmarshal([]byte(tt.body), &e); err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if err := e.Validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrderEventReservationItems(t *testing.T) {
+	var e OrderEvent
+	body := `{"id": "evt-1", "type": "order.created", "order": {"id": "ord_1", "items": [
+		{"productId": "507f1f77bcf86cd799439011", "sku": "TEE-M", "quantity": 2},
+		{"productId": "507f191e810c19729de860ea", "quantity": 1}
+	]}}`
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ReservationItem{
+		{ProductID: "507f1f77bcf86cd799439011", SKU: "TEE-M", Quantity: 2},
+		{ProductID: "507f191e810c19729de860ea", Quantity: 1},
+	}
+	if got := e.ReservationItems(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReservationItems() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+// internal/domain/patch.go
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types accepted by PATCH.
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	JSONPatchMediaType  = "application/json-patch+json"
+)
+
+// ErrUnsupportedPatch is returned for a patch in any other media type.
+var ErrUnsupportedPatch = errors.New("unsupported patch media type")
+
+// ErrInvalidPatch matches errors from malformed patches or patches that
+// cannot be applied, such as a failed JSON Patch test operation.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ApplyPatch applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// document to the JSON form of p. Server-managed fields are read-only, and
+// the result is validated like a full update.
+func ApplyPatch(p Product, mediaType string, patch []byte) (Product, error) {
+	doc, err := json.Marshal(p)
+	if err != nil {
+		return Product{}, err
+	}
+
+	switch mediaType {
+	case MergePatchMediaType:
+		doc, err = jsonpatch.MergePatch(doc, patch)
+	case JSONPatchMediaType:
+		var ops jsonpatch.Patch
+		if ops, err = jsonpatch.DecodePatch(patch); err == nil {
+			doc, err = ops.Apply(doc)
+		}
+	default:
+		return Product{}, ErrUnsupportedPatch
+	}
+	if err != nil {
+		return Product{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var patched Product
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return Product{}, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	verr := &ValidationError{}
+	if patched.ID != p.ID {
+		verr.Add("id", "is read-only")
+	}
+	if !patched.CreatedAt.Equal(p.CreatedAt) {
+		verr.Add("created_at", "is read-only")
+	}
+	if !patched.UpdatedAt.Equal(p.UpdatedAt) {
+		verr.Add("updated_at", "is read-only")
+	}
+	if !timePtrEqual(patched.DeletedAt, p.DeletedAt) || patched.DeletedBy != p.DeletedBy {
+		verr.Add("deleted_at", "is read-only; use DELETE and restore")
+	}
+	if patched.Status != p.Status || !timePtrEqual(patched.StatusChangedAt, p.StatusChangedAt) || patched.StatusChangedBy != p.StatusChangedBy {
+		verr.Add("status", "is read-only; use a status transition")
+	}
+	if patched.Version != p.Version {
+		verr.Add("version", "is read-only; use If-Match for conditional updates")
+	}
+	if err := verr.Err(); err != nil {
+		return Product{}, err
+	}
+
+	if err := patched.Validate(); err != nil {
+		return Product{}, err
+	}
+
+	return patched, nil
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,138 @@
+// internal/domain/patch_test.go
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func patchedProduct() Product {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return Product{
+		ID:          "507f1f77bcf86cd799439011",
+		Name:        "Leather jacket",
+		Description: "Brown, lined",
+		Price:       Money{Amount: 19999, Currency: "USD"},
+		SKU:         "JKT-001",
+		Inventory:   5,
+		Categories:  []string{"jackets"},
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		Version:     3,
+		Status:      StatusPublished,
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		patch     string
+		check     func(Product) bool
+		wantErr   error
+		wantField string
+	}{
+		{
+			name:      "merge patch",
+			mediaType: MergePatchMediaType,
+			patch:     `{"name": "Suede jacket", "description": null}`,
+			check:     func(p Product) bool { return p.Name == "Suede jacket" && p.Description == "" && p.SKU == "JKT-001" },
+		},
+		{
+			name:      "json patch",
+			mediaType: JSONPatchMediaType,
+			patch:     `[{"op": "test", "path": "/sku", "value": "JKT-001"}, {"op": "replace", "path": "/price/amount", "value": 14999}]`,
+			check:     func(p Product) bool { return p.Price.Amount == 14999 && p.Name == "Leather jacket" },
+		},
+		{
+			name:      "failed test operation",
+			mediaType: JSONPatchMediaType,
+			patch:     `[{"op": "test", "path": "/sku", "value": "JKT-002"}, {"op": "replace", "path": "/name", "value": "Coat"}]`,
+			wantErr:   ErrInvalidPatch,
+		},
+		{
+			name:      "malformed json patch",
+			mediaType: JSONPatchMediaType,
+			patch:     `{"op": "replace"}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		{
+			name:      "unknown field",
+			mediaType: MergePatchMediaType,
+			patch:     `{"colour": "brown"}`,
+			wantErr:   ErrInvalidPatch,
+		},
+		{
+			name:      "unsupported media type",
+			mediaType: "application/json",
+			patch:     `{"name": "Coat"}`,
+			wantErr:   ErrUnsupportedPatch,
+		},
+		{
+			name:      "read-only id",
+			mediaType: MergePatchMediaType,
+			patch:     `{"id": "507f191e810c19729de860ea"}`,
+			wantErr:   ErrValidation,
+			wantField: "id",
+		},
+		{
+			name:      "read-only creation time",
+			mediaType: JSONPatchMediaType,
+			patch:     `[{"op": "replace", "path": "/created_at", "value": "2025-01-01T00:00:00Z"}]`,
+			wantErr:   ErrValidation,
+			wantField: "created_at",
+		},
+		{
+			name:      "read-only version",
+			mediaType: JSONPatchMediaType,
+			patch:     `[{"op": "replace", "path": "/version", "value": 4}]`,
+			wantErr:   ErrValidation,
+			wantField: "version",
+		},
+		{
+			name:      "read-only status",
+			mediaType: MergePatchMediaType,
+			patch:     `{"status": "archived"}`,
+			wantErr:   ErrValidation,
+			wantField: "status",
+		},
+		{
+			name:      "read-only deletion",
+			mediaType: MergePatchMediaType,
+			patch:     `{"deleted_at": "2026-02-01T00:00:00Z"}`,
+			wantErr:   ErrValidation,
+			wantField: "deleted_at",
+		},
+		{
+			name:      "invalid result",
+			mediaType: MergePatchMediaType,
+			patch:     `{"name": "", "price": {"amount": 0}}`,
+			wantErr:   ErrValidation,
+			wantField: "name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch(patchedProduct(), tt.mediaType, []byte(tt.patch))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ApplyPatch() error = %v", err)
+				}
+				if !tt.check(got) {
+					t.Errorf("ApplyPatch() = %+v", got)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyPatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantField != "" && !strings.Contains(err.Error(), tt.wantField+": ") {
+				t.Errorf("ApplyPatch() error = %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// internal/domain/price_history.go
+package domain
+
+import (
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds enforced on price history reads and on change reasons.
+const (
+	DefaultPriceHistoryLimit = 50
+	MaxPriceHistoryLimit     = 500
+	MaxChangeReasonLength    = 500
+)
+
+// Audit identifies who made a change to a product and why. It is recorded
+// with the price changes the write makes.
+type Audit struct {
+	By     string
+	Reason string
+}
+
+// Validate checks an audit built from a client request.
+func (a Audit) Validate() error {
+	verr := &ValidationError{}
+	if utf8.RuneCountInString(a.Reason) > MaxChangeReasonLength {
+		verr.Add("reason", "must be at most %d characters", MaxChangeReasonLength)
+	}
+	return verr.Err()
+}
+
+// PriceChange records one price of a product changing. Variant prices are
+// the overrides the variants set: a nil OldPrice or NewPrice means the
+// price was not set, so the variant fell back to the product price.
+type PriceChange struct {
+	ID         ID     `json:"id" bson:"_id,omitempty"`
+	ProductID  ID     `json:"product_id" bson:"product_id"`
+	VariantSKU string `json:"variant_sku,omitempty" bson:"variant_sku,omitempty"`
+	// PriceList is DefaultPriceListID for the product price
+	PriceList string    `json:"price_list" bson:"price_list"`
+	OldPrice  *Money    `json:"old_price" bson:"old_price"`
+	NewPrice  *Money    `json:"new_price" bson:"new_price"`
+	ChangedBy string    `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+// PriceHistoryQuery selects the price changes of a product made between
+// From and To, both inclusive and optional.
+type PriceHistoryQuery struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int        `form:"limit"`
+	Offset int        `form:"offset"`
+}
+
+// Validate checks a query built from a client request and applies the
+// default limit.
+func (q *PriceHistoryQuery) Validate() error {
+	verr := &ValidationError{}
+
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		verr.Add("to", "must not be before from")
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultPriceHistoryLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxPriceHistoryLimit {
+		verr.Add("limit", "must be between 1 and %d", MaxPriceHistoryLimit)
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "must not be negative")
+	}
+
+	return verr.Err()
+}
+
+// DiffPrices returns the prices that differ between two versions of a
+// product: its price, its prices in other lists, and the prices of the
+// variants found in both by SKU. Added and removed variants are not price
+// changes. The changes carry no ID, author or time yet.
+func DiffPrices(before, after *Product) []PriceChange {
+	var changes []PriceChange
+	add := func(sku, list string, from, to *Money) {
+		if (from == nil && to == nil) || (from != nil && to != nil && *from == *to) {
+			return
+		}
+		changes = append(changes, PriceChange{
+			ProductID:  after.ID,
+			VariantSKU: sku,
+			PriceList:  list,
+			OldPrice:   from,
+			NewPrice:   to,
+		})
+	}
+	addPrices := func(sku string, from, to map[string]Money) {
+		for _, id := range priceListIDs(from, to) {
+			add(sku, id, lookupPrice(from, id), lookupPrice(to, id))
+		}
+	}
+
+	oldPrice, newPrice := before.Price, after.Price
+	add("", DefaultPriceListID, &oldPrice, &newPrice)
+	addPrices("", before.Prices, after.Prices)
+
+	for _, v := range after.Variants {
+		prev := before.Variant(v.SKU)
+		if prev == nil {
+			continue
+		}
+		add(v.SKU, DefaultPriceListID, prev.Price, v.Price)
+		addPrices(v.SKU, prev.Prices, v.Prices)
+	}
+
+	return changes
+}
+
+// priceListIDs returns the price lists priced in either map, sorted.
+func priceListIDs(a, b map[string]Money) []string {
+	ids := make([]string, 0, len(a)+len(b))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func lookupPrice(prices map[string]Money, id string) *Money {
+	if m, ok := prices[id]; ok {
+		return &m
+	}
+	return nil
+}
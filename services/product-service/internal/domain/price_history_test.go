@@ -0,0 +1,115 @@
+// internal/domain/price_history_test.go
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffPrices(t *testing.T) {
+	usd := func(amount int64) Money { return Money{Amount: amount, Currency: "USD"} }
+	ptr := func(m Money) *Money { return &m }
+	base := Product{
+		ID:     "p1",
+		Price:  usd(1500),
+		Prices: map[string]Money{"eu": {Amount: 1400, Currency: "EUR"}},
+		Variants: []Variant{
+			{SKU: "TEE-S"},
+			{SKU: "TEE-M", Price: ptr(usd(1700))},
+		},
+	}
+
+	type change struct {
+		sku, list string
+		from, to  *Money
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Product)
+		want   []change
+	}{
+		{"unchanged", func(*Product) {}, nil},
+		{"product price", func(p *Product) { p.Price = usd(1200) }, []change{{"", DefaultPriceListID, ptr(usd(1500)), ptr(usd(1200))}}},
+		{"list price added and removed", func(p *Product) { p.Prices = map[string]Money{"uk": {Amount: 1300, Currency: "GBP"}} }, []change{
+			{"", "eu", &Money{Amount: 1400, Currency: "EUR"}, nil},
+			{"", "uk", nil, &Money{Amount: 1300, Currency: "GBP"}},
+		}},
+		{"variant override set and cleared", func(p *Product) {
+			p.Variants = []Variant{{SKU: "TEE-S", Price: ptr(usd(1600))}, {SKU: "TEE-M"}}
+		}, []change{
+			{"TEE-S", DefaultPriceListID, nil, ptr(usd(1600))},
+			{"TEE-M", DefaultPriceListID, ptr(usd(1700)), nil},
+		}},
+		{"variants added and removed", func(p *Product) {
+			p.Variants = []Variant{{SKU: "TEE-S"}, {SKU: "TEE-L", Price: ptr(usd(1900))}}
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			after.Prices = map[string]Money{"eu": base.Prices["eu"]}
+			after.Variants = append([]Variant(nil), base.Variants...)
+			tt.change(&after)
+
+			var got []change
+			for _, c := range DiffPrices(&base, &after) {
+				if c.ProductID != "p1" {
+					t.Errorf("change of product %q", c.ProductID)
+				}
+				got = append(got, change{c.VariantSKU, c.PriceList, c.OldPrice, c.NewPrice})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffPrices() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceHistoryQueryValidate(t *testing.T) {
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := day.Add(-time.Second)
+
+	tests := []struct {
+		name    string
+		q       PriceHistoryQuery
+		wantErr string
+	}{
+		{"defaults", PriceHistoryQuery{}, ""},
+		{"same instant", PriceHistoryQuery{From: &day, To: &day, Limit: MaxPriceHistoryLimit}, ""},
+		{"inverted range", PriceHistoryQuery{From: &day, To: &before}, "validation failed: to: must not be before from"},
+		{"limit", PriceHistoryQuery{Limit: MaxPriceHistoryLimit + 1}, "validation failed: limit: must be between 1 and 500"},
+		{"negative limit", PriceHistoryQuery{Limit: -1}, "validation failed: limit: must be between 1 and 500"},
+		{"offset", PriceHistoryQuery{Offset: -1}, "validation failed: offset: must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := tt.q.Validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+
+	q := PriceHistoryQuery{}
+	if err := q.Validate(); err != nil || q.Limit != DefaultPriceHistoryLimit {
+		t.Errorf("Validate() = %v with limit %d, want the default", err, q.Limit)
+	}
+}
+
+func TestAuditValidate(t *testing.T) {
+	if err := (Audit{By: "admin", Reason: strings.Repeat("é", MaxChangeReasonLength)}).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want the longest reason accepted", err)
+	}
+	if err := (Audit{Reason: strings.Repeat("a", MaxChangeReasonLength+1)}).Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("Validate() = %v, want a validation error for a long reason", err)
+	}
+}
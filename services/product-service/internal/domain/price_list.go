@@ -0,0 +1,222 @@
+// internal/domain/price_list.go
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds enforced on price lists.
+const (
+	MaxPriceListNameLength = 100
+	MaxRegionLength        = 32
+	// MaxProductPrices bounds the price lists a product is priced in
+	MaxProductPrices = 50
+)
+
+// DefaultPriceListID is the ID of the price list seeded by the migrations.
+// Its currency is the currency of every product price.
+const DefaultPriceListID = "default"
+
+// ErrPriceListNotFound is returned when a price list does not exist.
+var ErrPriceListNotFound = errors.New("price list not found")
+
+// ErrPriceListExists is returned when creating a price list whose ID is
+// taken.
+var ErrPriceListExists = errors.New("price list already exists")
+
+// ErrPriceListInUse is returned when deleting a price list that products
+// still have prices in, or the default price list.
+var ErrPriceListInUse = errors.New("price list is in use")
+
+// PriceList is a named set of prices in one currency, optionally limited to
+// a region or a customer group. Prices in the default list are the product
+// prices; those in other lists are kept in the prices of the products.
+type PriceList struct {
+	ID       string `json:"id" bson:"_id"`
+	Name     string `json:"name" bson:"name" binding:"required"`
+	Currency string `json:"currency" bson:"currency" binding:"required"`
+	// Region and CustomerGroup limit the list to callers in that region or
+	// group. Empty means any.
+	Region        string    `json:"region,omitempty" bson:"region"`
+	CustomerGroup string    `json:"customer_group,omitempty" bson:"customer_group"`
+	Default       bool      `json:"default" bson:"default"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks a price list submitted by a client. Currencies and regions
+// are normalized to upper case.
+func (l *PriceList) Validate() error {
+	verr := &ValidationError{}
+
+	l.Currency = strings.ToUpper(l.Currency)
+	l.Region = strings.ToUpper(l.Region)
+
+	if !identifierPattern.MatchString(l.ID) {
+		verr.Add("id", "must be a lowercase identifier of at most 32 characters")
+	}
+	if l.Name == "" || utf8.RuneCountInString(l.Name) > MaxPriceListNameLength {
+		verr.Add("name", "must be 1 to %d characters", MaxPriceListNameLength)
+	}
+	if !ValidCurrency(l.Currency) {
+		verr.Add("currency", "must be a supported ISO 4217 code")
+	}
+
+	if len(l.Region) > MaxRegionLength {
+		verr.Add("region", "must be at most %d characters", MaxRegionLength)
+	}
+	if l.CustomerGroup != "" && !identifierPattern.MatchString(l.CustomerGroup) {
+		verr.Add("customer_group", "must be a lowercase identifier of at most 32 characters")
+	}
+
+	return verr.Err()
+}
+
+// validatePrices checks the price list prices of a product or variant. The
+// currencies of the lists are checked by the service.
+func validatePrices(verr *ValidationError, field string, prices map[string]Money) {
+	if len(prices) > MaxProductPrices {
+		verr.Add(field, "must contain at most %d entries", MaxProductPrices)
+	}
+
+	ids := make([]string, 0, len(prices))
+	for id := range prices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if !identifierPattern.MatchString(id) || id == DefaultPriceListID {
+			verr.Add(field, "%q is not a price list other than the default", id)
+			continue
+		}
+		prices[id].validate(verr, field+"."+id, "")
+	}
+}
+
+// PriceContext describes who is asking for prices. An empty field matches
+// any price list.
+type PriceContext struct {
+	// PriceList asks for the prices of one list
+	PriceList     string
+	Currency      string
+	Region        string
+	CustomerGroup string
+	// Staff may ask for lists limited to any customer group
+	Staff bool
+}
+
+// EffectivePrice is the price that applies to a caller and the list it
+// comes from.
+type EffectivePrice struct {
+	PriceList string `json:"price_list"`
+	Price     Money  `json:"price"`
+}
+
+// PriceLists is the set of price lists prices are resolved from.
+type PriceLists []PriceList
+
+// Default returns the default price list, or nil before it is seeded.
+func (ls PriceLists) Default() *PriceList {
+	for i := range ls {
+		if ls[i].Default {
+			return &ls[i]
+		}
+	}
+	return nil
+}
+
+// Currency returns the currency of the default price list, which every
+// product price is in.
+func (ls PriceLists) Currency() string {
+	if def := ls.Default(); def != nil {
+		return def.Currency
+	}
+	return LegacyCurrency
+}
+
+// Find returns the price list with the given ID, or nil.
+func (ls PriceLists) Find(id string) *PriceList {
+	for i := range ls {
+		if ls[i].ID == id {
+			return &ls[i]
+		}
+	}
+	return nil
+}
+
+// Resolve returns the price a caller pays, given the default price and the
+// prices in other lists, or nil if no list open to the caller has a price.
+// The most specific list wins: a customer group list over a regional one,
+// and either over a list open to everyone. Ties go to the default list and
+// then to the lowest ID.
+func (ls PriceLists) Resolve(pc PriceContext, base Money, prices map[string]Money) *EffectivePrice {
+	var best *EffectivePrice
+	bestScore, bestDefault := -1, false
+	for _, l := range ls {
+		if pc.PriceList != "" && l.ID != pc.PriceList {
+			continue
+		}
+		if pc.Currency != "" && l.Currency != pc.Currency {
+			continue
+		}
+		if l.Region != "" && l.Region != pc.Region {
+			continue
+		}
+		if l.CustomerGroup != "" && l.CustomerGroup != pc.CustomerGroup && !(pc.Staff && pc.PriceList == l.ID) {
+			continue
+		}
+
+		price, ok := base, l.Default
+		if !l.Default {
+			price, ok = prices[l.ID]
+		}
+		if !ok {
+			continue
+		}
+
+		score := 0
+		if l.CustomerGroup != "" {
+			score += 2
+		}
+		if l.Region != "" {
+			score++
+		}
+		better := score > bestScore
+		if score == bestScore {
+			better = l.Default || (!bestDefault && l.ID < best.PriceList)
+		}
+		if better {
+			best, bestScore, bestDefault = &EffectivePrice{PriceList: l.ID, Price: price}, score, l.Default
+		}
+	}
+	return best
+}
+
+// Fingerprint identifies the effective prices ls resolves for pc from the
+// prices of a product: it changes with the price context and whenever a
+// list is created, changed or deleted.
+func (ls PriceLists) Fingerprint(pc PriceContext) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %q %q %t", pc.PriceList, pc.Currency, pc.Region, pc.CustomerGroup, pc.Staff)
+	for _, l := range ls {
+		fmt.Fprintf(h, "\n%q %q %q %q %t %d", l.ID, l.Currency, l.Region, l.CustomerGroup, l.Default, l.UpdatedAt.UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ApplyPrices sets the effective price of p and of its variants.
+func (ls PriceLists) ApplyPrices(pc PriceContext, p *Product) {
+	p.EffectivePrice = ls.Resolve(pc, p.Price, p.Prices)
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.EffectivePrice = ls.Resolve(pc, p.VariantPrice(*v), p.VariantPrices(*v))
+	}
+}
@@ -0,0 +1,134 @@
+// internal/domain/price_list_test.go
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPriceListValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    PriceList
+		wantErr bool
+	}{
+		{"valid", PriceList{ID: "eu", Name: "Europe", Currency: "eur"}, false},
+		{"limited", PriceList{ID: "us_west", Name: "US West", Currency: "USD", Region: "us-ca", CustomerGroup: "wholesale"}, false},
+		{"id", PriceList{ID: "EU", Name: "Europe", Currency: "EUR"}, true},
+		{"name", PriceList{ID: "eu", Currency: "EUR"}, true},
+		{"currency", PriceList{ID: "eu", Name: "Europe", Currency: "EURO"}, true},
+		{"customer group", PriceList{ID: "eu", Name: "Europe", Currency: "EUR", CustomerGroup: "Trade Partners"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.list.Validate()
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrValidation)) {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	l := PriceList{ID: "us_west", Name: "US West", Currency: "usd", Region: "us-ca"}
+	if err := l.Validate(); err != nil || l.Currency != "USD" || l.Region != "US-CA" {
+		t.Errorf("Validate() = %v with currency %q and region %q, want them upper case", err, l.Currency, l.Region)
+	}
+}
+
+func TestPriceListsResolve(t *testing.T) {
+	lists := PriceLists{
+		{ID: DefaultPriceListID, Currency: "USD", Default: true},
+		{ID: "eu", Currency: "EUR"},
+		{ID: "us_west", Currency: "USD", Region: "US-CA"},
+		{ID: "wholesale", Currency: "USD", CustomerGroup: "wholesale"},
+		{ID: "outlet", Currency: "USD"},
+	}
+	base := Money{Amount: 1000, Currency: "USD"}
+	prices := map[string]Money{
+		"eu":        {Amount: 950, Currency: "EUR"},
+		"us_west":   {Amount: 1100, Currency: "USD"},
+		"wholesale": {Amount: 700, Currency: "USD"},
+		"outlet":    {Amount: 800, Currency: "USD"},
+	}
+
+	tests := []struct {
+		name     string
+		pc       PriceContext
+		prices   map[string]Money
+		wantList string
+		wantAmt  int64
+	}{
+		{"anyone", PriceContext{}, prices, DefaultPriceListID, 1000},
+		{"currency", PriceContext{Currency: "EUR"}, prices, "eu", 950},
+		{"region", PriceContext{Region: "US-CA"}, prices, "us_west", 1100},
+		{"customer group over region", PriceContext{Region: "US-CA", CustomerGroup: "wholesale"}, prices, "wholesale", 700},
+		{"price list", PriceContext{PriceList: "outlet"}, prices, "outlet", 800},
+		{"group list for staff", PriceContext{PriceList: "wholesale", Staff: true}, prices, "wholesale", 700},
+		{"group list for others", PriceContext{PriceList: "wholesale"}, prices, "", 0},
+		{"no price in list", PriceContext{Currency: "EUR"}, nil, "", 0},
+		{"no list in currency", PriceContext{Currency: "GBP"}, prices, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lists.Resolve(tt.pc, base, tt.prices)
+			if tt.wantList == "" {
+				if got != nil {
+					t.Errorf("Resolve() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.PriceList != tt.wantList || got.Price.Amount != tt.wantAmt {
+				t.Errorf("Resolve() = %+v, want %d from %s", got, tt.wantAmt, tt.wantList)
+			}
+		})
+	}
+}
+
+func TestPriceListsResolveTies(t *testing.T) {
+	prices := map[string]Money{
+		"b_list": {Amount: 900, Currency: "EUR"},
+		"a_list": {Amount: 950, Currency: "EUR"},
+	}
+	lists := PriceLists{
+		{ID: DefaultPriceListID, Currency: "USD", Default: true},
+		{ID: "b_list", Currency: "EUR"},
+		{ID: "a_list", Currency: "EUR"},
+	}
+
+	if got := lists.Resolve(PriceContext{}, Money{Amount: 1000, Currency: "USD"}, prices); got == nil || got.PriceList != DefaultPriceListID {
+		t.Errorf("Resolve() = %+v, want the default list", got)
+	}
+	if got := lists.Resolve(PriceContext{Currency: "EUR"}, Money{Amount: 1000, Currency: "USD"}, prices); got == nil || got.PriceList != "a_list" {
+		t.Errorf("Resolve() = %+v, want the lowest ID", got)
+	}
+}
+
+func TestPriceListsFingerprint(t *testing.T) {
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lists := PriceLists{{ID: DefaultPriceListID, Currency: "USD", Default: true, UpdatedAt: updated}}
+	fp := lists.Fingerprint(PriceContext{})
+
+	changed := PriceLists{{ID: DefaultPriceListID, Currency: "USD", Default: true, UpdatedAt: updated.Add(time.Second)}}
+	added := append(PriceLists{{ID: "eu", Currency: "EUR", UpdatedAt: updated}}, lists...)
+
+	tests := []struct {
+		name  string
+		lists PriceLists
+		pc    PriceContext
+		same  bool
+	}{
+		{"same lists and context", lists, PriceContext{}, true},
+		{"other currency", lists, PriceContext{Currency: "EUR"}, false},
+		{"other customer group", lists, PriceContext{CustomerGroup: "wholesale"}, false},
+		{"list changed", changed, PriceContext{}, false},
+		{"list added", added, PriceContext{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.lists.Fingerprint(tt.pc); (got == fp) != tt.same {
+			t.Errorf("%s: Fingerprint() = %s, first %s, want same %v", tt.name, got, fp, tt.same)
+		}
+	}
+}
@@ -4,28 +4,134 @@ package domain
 import (
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/ntdt/product-service/internal/query"
 )
 
 type Product struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name        string             `json:"name" bson:"name" binding:"required"`
-	Description string             `json:"description" bson:"description"`
-	Price       float64            `json:"price" bson:"price" binding:"required,gt=0"`
-	SKU         string             `json:"sku" bson:"sku" binding:"required"`
-	Inventory   int                `json:"inventory" bson:"inventory" binding:"min=0"`
-	Categories  []string           `json:"categories" bson:"categories"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID          ID        `json:"id" bson:"_id,omitempty"`
+	Name        string    `json:"name" bson:"name" binding:"required"`
+	Description string    `json:"description" bson:"description"`
+	Price       Money     `json:"price" bson:"price"`
+	SKU         string    `json:"sku" bson:"sku" binding:"required"`
+	Inventory   int       `json:"inventory" bson:"inventory" binding:"min=0"`
+	Categories  []string  `json:"categories" bson:"categories"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+	// Version starts at 1 and is incremented by every write. It is exposed
+	// as the ETag for optimistic concurrency control.
+	Version int64 `json:"version" bson:"version"`
+	// DeletedAt is set while the product is in the trash. Deleted products
+	// are hidden from reads unless explicitly included.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	// Status is the lifecycle state. It only changes through transitions;
+	// writes to the product keep it as is.
+	Status          Status     `json:"status" bson:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
+	StatusChangedBy string     `json:"status_changed_by,omitempty" bson:"status_changed_by,omitempty"`
+	// Options and Variants describe the versions of a product that differ
+	// in size, color and so on. With variants, Inventory is their total.
+	Options  []ProductOption `json:"options,omitempty" bson:"options,omitempty"`
+	Variants []Variant       `json:"variants,omitempty" bson:"variants,omitempty"`
+	// Attributes holds the custom attributes defined for the categories of
+	// the product, such as brand or weight, keyed by attribute name
+	Attributes map[string]interface{} `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	// CategoryIDs assigns the product to categories of the taxonomy.
+	// Categories holds their slugs and is kept in step by the service.
+	CategoryIDs []ID `json:"category_ids" bson:"category_ids"`
+	// Prices holds the prices of the product in price lists other than the
+	// default one, keyed by price list ID. Price is its default price.
+	Prices map[string]Money `json:"prices,omitempty" bson:"prices,omitempty"`
+	// EffectivePrice is the price resolved for the caller, set on reads
+	EffectivePrice *EffectivePrice `json:"effective_price,omitempty" bson:"-"`
+	// Reserved is the stock held for orders awaiting payment and Available
+	// the rest of Inventory. Like Inventory, they only change with the stock.
+	Reserved  int `json:"reserved" bson:"reserved"`
+	Available int `json:"available" bson:"available"`
+	// Locations is the stock at each location, set on reads
+	Locations []LocationAvailability `json:"locations,omitempty" bson:"-"`
+}
+
+// Validate checks the rules of the binding tags above for products that are
+// not bound from a request body, such as patched ones, along with the
+// options and variants, which binding tags cannot express.
+func (p *Product) Validate() error {
+	verr := &ValidationError{}
+
+	if p.Name == "" {
+		verr.Add("name", "is required")
+	}
+	p.Price.validate(verr, "price", "")
+	validatePrices(verr, "prices", p.Prices)
+	if p.SKU == "" {
+		verr.Add("sku", "is required")
+	}
+	if p.Inventory < 0 {
+		verr.Add("inventory", "must not be negative")
+	}
+	p.validateVariants(verr)
+
+	return verr.Err()
 }
 
 type ProductFilter struct {
 	Name       string   `form:"name"`
+	NameMatch  string   `form:"name_match"`
 	Categories []string `form:"categories"`
-	MinPrice   float64  `form:"min_price"`
-	MaxPrice   float64  `form:"max_price"`
+	// CategoryID restricts the listing to a category and its descendants
+	CategoryID string   `form:"category_id"`
+	MinPrice   *float64 `form:"min_price"`
+	MaxPrice   *float64 `form:"max_price"`
+	Filter     string   `form:"filter"`
 	SortBy     string   `form:"sort_by"`
 	SortOrder  string   `form:"sort_order"`
 	Limit      int      `form:"limit,default=10"`
 	Offset     int      `form:"offset,default=0"`
+	Cursor     string   `form:"cursor"`
+	Fields     string   `form:"fields"`
+	// IncludeDeleted adds products in the trash; admins only
+	IncludeDeleted bool `form:"include_deleted"`
+	// Status restricts the listing to products in any of these states
+	Status []Status `form:"status"`
+	// Variant restricts the listing to products with a variant that has
+	// all of these option:value attributes, e.g. size:M and color:red
+	Variant []string `form:"variant"`
+	// Attribute restricts the listing to products with these attribute
+	// values, given as name:value or, for numbers, name:min..max
+	Attribute []string `form:"attribute"`
+	// FacetAttributes names the attributes whose values facets count
+	FacetAttributes []string `form:"facet_attributes"`
+	// Location restricts the listing to products with stock available at
+	// a location
+	Location string `form:"location"`
+
+	// Expr is the parsed form of Filter, set by Validate
+	Expr query.Expr `form:"-"`
+	// FieldSet is the parsed form of Fields, set by Validate
+	FieldSet FieldSet `form:"-"`
+	// VariantAttributes is the parsed form of Variant, set by Validate
+	VariantAttributes map[string]string `form:"-"`
+	// AttributeFilters is the typed form of Attribute, set by the service
+	// from the attribute definitions
+	AttributeFilters []AttributeFilter `form:"-"`
+	// CategoryIDs is CategoryID and the IDs of its descendants, set by the
+	// service from the taxonomy
+	CategoryIDs []ID `form:"-"`
+	// StockedIDs holds the products with stock available at Location, set
+	// by the service from the stock levels
+	StockedIDs []ID `form:"-"`
+	// Currency is the currency of product prices, set by SetCurrency along
+	// with MinAmount and MaxAmount, the price bounds in its minor unit
+	Currency  string `form:"-"`
+	MinAmount *int64 `form:"-"`
+	MaxAmount *int64 `form:"-"`
+}
+
+// ProductPage is one page of a product listing. Cursors are opaque tokens
+// for keyset pagination; an empty cursor means there is no such page.
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int64     `json:"total"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
 }
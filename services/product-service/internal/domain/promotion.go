@@ -0,0 +1,352 @@
+// internal/domain/promotion.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// PromotionType is the kind of discount a promotion gives.
+type PromotionType string
+
+const (
+	// PromotionPercentage takes a percentage off the price
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixed takes an amount off the price of every unit
+	PromotionFixed PromotionType = "fixed"
+	// PromotionBundle gives units away for units bought, e.g. buy 2 get 1
+	PromotionBundle PromotionType = "bundle"
+)
+
+// PromotionTypes lists every promotion type.
+var PromotionTypes = []PromotionType{PromotionPercentage, PromotionFixed, PromotionBundle}
+
+// Valid reports whether t is a known promotion type.
+func (t PromotionType) Valid() bool {
+	for _, known := range PromotionTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds enforced on promotions and on the carts they are evaluated for.
+const (
+	MaxPromotionNameLength        = 100
+	MaxPromotionDescriptionLength = 1000
+	// MaxPromotionTargets bounds each of the product, category and SKU
+	// targets of a promotion
+	MaxPromotionTargets = 500
+	MaxBundleQuantity   = 100
+	MaxCartItems        = 100
+	MaxCartItemQuantity = 10000
+)
+
+// ErrPromotionNotFound is returned when a promotion does not exist.
+var ErrPromotionNotFound = errors.New("promotion not found")
+
+// Promotion is a discount rule such as 20% off the shoes category this
+// weekend. It applies to the products, categories and SKUs it targets; a
+// promotion without targets applies to every product.
+type Promotion struct {
+	ID          ID            `json:"id" bson:"_id,omitempty"`
+	Name        string        `json:"name" bson:"name" binding:"required"`
+	Description string        `json:"description,omitempty" bson:"description"`
+	Type        PromotionType `json:"type" bson:"type" binding:"required"`
+	// Percent is the discount of a percentage promotion, e.g. 20 for 20% off
+	Percent float64 `json:"percent,omitempty" bson:"percent,omitempty"`
+	// AmountOff is taken off every unit by a fixed promotion. It only
+	// applies to prices in its currency.
+	AmountOff *Money `json:"amount_off,omitempty" bson:"amount_off,omitempty"`
+	// BuyQuantity and FreeQuantity describe a bundle: out of every
+	// BuyQuantity+FreeQuantity units of an item, FreeQuantity are free
+	BuyQuantity  int  `json:"buy_quantity,omitempty" bson:"buy_quantity,omitempty"`
+	FreeQuantity int  `json:"free_quantity,omitempty" bson:"free_quantity,omitempty"`
+	ProductIDs   []ID `json:"product_ids" bson:"product_ids"`
+	// CategoryIDs also targets the subcategories of each category
+	CategoryIDs []ID     `json:"category_ids" bson:"category_ids"`
+	SKUs        []string `json:"skus" bson:"skus"`
+	// StartsAt and EndsAt bound the validity window. EndsAt is exclusive
+	// and either may be left open.
+	StartsAt *time.Time `json:"starts_at,omitempty" bson:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty" bson:"ends_at,omitempty"`
+	// Priority orders the promotions applied to an item, highest first
+	Priority int `json:"priority" bson:"priority"`
+	// Stackable promotions combine with each other. A promotion that is not
+	// stackable only applies on its own.
+	Stackable bool      `json:"stackable" bson:"stackable"`
+	Active    bool      `json:"active" bson:"active"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks a promotion submitted by a client. SKUs are normalized.
+func (p *Promotion) Validate() error {
+	verr := &ValidationError{}
+
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > MaxPromotionNameLength {
+		verr.Add("name", "must be 1 to %d characters", MaxPromotionNameLength)
+	}
+	if utf8.RuneCountInString(p.Description) > MaxPromotionDescriptionLength {
+		verr.Add("description", "must be at most %d characters", MaxPromotionDescriptionLength)
+	}
+
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Percent <= 0 || p.Percent > 100 {
+			verr.Add("percent", "must be greater than 0 and at most 100")
+		}
+	case PromotionFixed:
+		if p.AmountOff == nil {
+			verr.Add("amount_off", "is required for %s promotions", PromotionFixed)
+		} else {
+			p.AmountOff.validate(verr, "amount_off", "")
+		}
+	case PromotionBundle:
+		if p.BuyQuantity < 1 || p.BuyQuantity > MaxBundleQuantity {
+			verr.Add("buy_quantity", "must be between 1 and %d", MaxBundleQuantity)
+		}
+		if p.FreeQuantity < 1 || p.FreeQuantity > MaxBundleQuantity {
+			verr.Add("free_quantity", "must be between 1 and %d", MaxBundleQuantity)
+		}
+	default:
+		verr.Add("type", "must be one of %s, %s, %s", PromotionPercentage, PromotionFixed, PromotionBundle)
+	}
+	if p.Percent != 0 && p.Type != PromotionPercentage {
+		verr.Add("percent", "is only allowed for %s promotions", PromotionPercentage)
+	}
+	if p.AmountOff != nil && p.Type != PromotionFixed {
+		verr.Add("amount_off", "is only allowed for %s promotions", PromotionFixed)
+	}
+	if (p.BuyQuantity != 0 || p.FreeQuantity != 0) && p.Type != PromotionBundle {
+		verr.Add("buy_quantity", "is only allowed for %s promotions", PromotionBundle)
+	}
+
+	if len(p.ProductIDs) > MaxPromotionTargets {
+		verr.Add("product_ids", "must contain at most %d entries", MaxPromotionTargets)
+	}
+	if len(p.CategoryIDs) > MaxPromotionTargets {
+		verr.Add("category_ids", "must contain at most %d entries", MaxPromotionTargets)
+	}
+	if len(p.SKUs) > MaxPromotionTargets {
+		verr.Add("skus", "must contain at most %d entries", MaxPromotionTargets)
+	}
+	for i, sku := range p.SKUs {
+		p.SKUs[i] = NormalizeSKU(sku)
+		if p.SKUs[i] == "" {
+			verr.Add(fmt.Sprintf("skus[%d]", i), "must not be empty")
+		}
+	}
+	if p.ProductIDs == nil {
+		p.ProductIDs = []ID{}
+	}
+	if p.CategoryIDs == nil {
+		p.CategoryIDs = []ID{}
+	}
+	if p.SKUs == nil {
+		p.SKUs = []string{}
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		verr.Add("ends_at", "must be after starts_at")
+	}
+
+	return verr.Err()
+}
+
+// ActiveAt reports whether the promotion is enabled and within its
+// validity window at t.
+func (p *Promotion) ActiveAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || t.Before(*p.EndsAt)
+}
+
+// Targets reports whether the promotion applies to item.
+func (p *Promotion) Targets(item PricedItem) bool {
+	if len(p.ProductIDs) == 0 && len(p.CategoryIDs) == 0 && len(p.SKUs) == 0 {
+		return true
+	}
+
+	for _, id := range p.ProductIDs {
+		if id == item.Product.ID {
+			return true
+		}
+	}
+	for _, id := range p.CategoryIDs {
+		if item.Categories[id] {
+			return true
+		}
+	}
+	for _, sku := range p.SKUs {
+		if sku == item.SKU || sku == item.Product.SKU {
+			return true
+		}
+	}
+	return false
+}
+
+// discount returns what the promotion takes off total, the price of
+// quantity units after the promotions applied before it. It never exceeds
+// total.
+func (p *Promotion) discount(total Money, quantity int) int64 {
+	var amount int64
+	switch p.Type {
+	case PromotionPercentage:
+		amount = int64(math.Round(float64(total.Amount) * p.Percent / 100))
+	case PromotionFixed:
+		if p.AmountOff != nil && p.AmountOff.Currency == total.Currency {
+			amount = p.AmountOff.Amount * int64(quantity)
+		}
+	case PromotionBundle:
+		group := p.BuyQuantity + p.FreeQuantity
+		if group > 0 {
+			free := int64(quantity / group * p.FreeQuantity)
+			// The free units' share of the total, rounded half up
+			amount = (total.Amount*free*2 + int64(quantity)) / (int64(quantity) * 2)
+		}
+	}
+
+	if amount > total.Amount {
+		return total.Amount
+	}
+	return amount
+}
+
+// CartItem is a line of a cart to evaluate, identified by the SKU of a
+// product or variant.
+type CartItem struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+// PricedItem is a cart item resolved to its product and the price the
+// caller pays for one unit.
+type PricedItem struct {
+	Product  *Product
+	SKU      string
+	Quantity int
+	Price    EffectivePrice
+	// Categories holds the categories of the product and their ancestors
+	Categories map[ID]bool
+}
+
+// AppliedPromotion is a promotion that discounted an item.
+type AppliedPromotion struct {
+	ID       ID     `json:"id"`
+	Name     string `json:"name"`
+	Discount Money  `json:"discount"`
+}
+
+// ItemEvaluation is the price of a cart item after promotions.
+type ItemEvaluation struct {
+	ProductID  ID                 `json:"product_id"`
+	SKU        string             `json:"sku"`
+	Quantity   int                `json:"quantity"`
+	PriceList  string             `json:"price_list"`
+	UnitPrice  Money              `json:"unit_price"`
+	Subtotal   Money              `json:"subtotal"`
+	Discount   Money              `json:"discount"`
+	Total      Money              `json:"total"`
+	Promotions []AppliedPromotion `json:"promotions"`
+}
+
+// CartEvaluation is the price of a cart after promotions.
+type CartEvaluation struct {
+	Items    []ItemEvaluation `json:"items"`
+	Subtotal Money            `json:"subtotal"`
+	Discount Money            `json:"discount"`
+	Total    Money            `json:"total"`
+}
+
+// Promotions is the set of promotions items are evaluated against.
+type Promotions []Promotion
+
+// Sort orders promotions by priority, highest first, then by ID.
+func (ps Promotions) Sort() {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].Priority != ps[j].Priority {
+			return ps[i].Priority > ps[j].Priority
+		}
+		return ps[i].ID < ps[j].ID
+	})
+}
+
+// Evaluate applies the promotions active at now to item. They are taken in
+// priority order, each discounting what is left of the price. A promotion
+// that is not stackable only applies if it comes first, and then alone.
+// Promotions that would take nothing off are passed over.
+func (ps Promotions) Evaluate(now time.Time, item PricedItem) ItemEvaluation {
+	sorted := append(Promotions(nil), ps...)
+	sorted.Sort()
+
+	unit := item.Price.Price
+	subtotal := Money{Amount: unit.Amount * int64(item.Quantity), Currency: unit.Currency}
+	total := subtotal
+
+	applied := []AppliedPromotion{}
+	for i := range sorted {
+		p := &sorted[i]
+		if !p.ActiveAt(now) || !p.Targets(item) {
+			continue
+		}
+		if !p.Stackable && len(applied) > 0 {
+			continue
+		}
+
+		amount := p.discount(total, item.Quantity)
+		if amount == 0 {
+			continue
+		}
+		total.Amount -= amount
+		applied = append(applied, AppliedPromotion{
+			ID:       p.ID,
+			Name:     p.Name,
+			Discount: Money{Amount: amount, Currency: unit.Currency},
+		})
+
+		if !p.Stackable {
+			break
+		}
+	}
+
+	return ItemEvaluation{
+		ProductID:  item.Product.ID,
+		SKU:        item.SKU,
+		Quantity:   item.Quantity,
+		PriceList:  item.Price.PriceList,
+		UnitPrice:  unit,
+		Subtotal:   subtotal,
+		Discount:   Money{Amount: subtotal.Amount - total.Amount, Currency: unit.Currency},
+		Total:      total,
+		Promotions: applied,
+	}
+}
+
+// EvaluateCart evaluates every item of a cart, whose prices are all in
+// currency.
+func (ps Promotions) EvaluateCart(now time.Time, currency string, items []PricedItem) CartEvaluation {
+	cart := CartEvaluation{
+		Items:    make([]ItemEvaluation, 0, len(items)),
+		Subtotal: Money{Currency: currency},
+		Discount: Money{Currency: currency},
+		Total:    Money{Currency: currency},
+	}
+	for _, item := range items {
+		e := ps.Evaluate(now, item)
+		cart.Items = append(cart.Items, e)
+		cart.Subtotal.Amount += e.Subtotal.Amount
+		cart.Discount.Amount += e.Discount.Amount
+		cart.Total.Amount += e.Total.Amount
+	}
+	return cart
+}
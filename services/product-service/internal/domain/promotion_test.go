@@ -0,0 +1,187 @@
+// internal/domain/promotion_test.go
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromotionValidate(t *testing.T) {
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	next := day.Add(24 * time.Hour)
+
+	tests := []struct {
+		name string
+		p    Promotion
+		// want lists the fields with errors, comma separated
+		want string
+	}{
+		{"percentage", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 100}, ""},
+		{"fixed", Promotion{Name: "Sale", Type: PromotionFixed, AmountOff: &Money{Amount: 500, Currency: "USD"}}, ""},
+		{"bundle", Promotion{Name: "3 for 2", Type: PromotionBundle, BuyQuantity: 2, FreeQuantity: 1}, ""},
+		{"window", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 10, StartsAt: &day, EndsAt: &next}, ""},
+		{"name", Promotion{Type: PromotionPercentage, Percent: 10}, "name"},
+		{"type", Promotion{Name: "Sale", Type: "coupon"}, "type"},
+		{"percent", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 101}, "percent"},
+		{"zero percent", Promotion{Name: "Sale", Type: PromotionPercentage}, "percent"},
+		{"missing amount", Promotion{Name: "Sale", Type: PromotionFixed}, "amount_off"},
+		{"invalid amount", Promotion{Name: "Sale", Type: PromotionFixed, AmountOff: &Money{Amount: 0, Currency: "USD"}}, "amount_off.amount"},
+		{"bundle quantities", Promotion{Name: "Free", Type: PromotionBundle, BuyQuantity: 0, FreeQuantity: MaxBundleQuantity + 1}, "buy_quantity, free_quantity"},
+		{"fields of another type", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 10, AmountOff: &Money{Amount: 1, Currency: "USD"}, BuyQuantity: 1}, "amount_off, buy_quantity"},
+		{"empty SKU", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 10, SKUs: []string{"TEE", " "}}, "skus[1]"},
+		{"empty window", Promotion{Name: "Sale", Type: PromotionPercentage, Percent: 10, StartsAt: &day, EndsAt: &day}, "ends_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			var verr *ValidationError
+			if err := tt.p.Validate(); errors.As(err, &verr) {
+				for _, f := range verr.Fields {
+					fields = append(fields, f.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate() error = %v, want a ValidationError", err)
+			}
+			if got := strings.Join(fields, ", "); got != tt.want {
+				t.Errorf("Validate() errors on %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromotionActiveAt(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+	p := Promotion{Active: true, StartsAt: &start, EndsAt: &end}
+
+	tests := []struct {
+		name string
+		p    Promotion
+		at   time.Time
+		want bool
+	}{
+		{"before the window", p, start.Add(-time.Second), false},
+		{"at the start", p, start, true},
+		{"within", p, start.Add(time.Hour), true},
+		{"at the end", p, end, false},
+		{"disabled", Promotion{StartsAt: &start}, start.Add(time.Hour), false},
+		{"open window", Promotion{Active: true}, start, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.ActiveAt(tt.at); got != tt.want {
+			t.Errorf("%s: ActiveAt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionTargets(t *testing.T) {
+	item := PricedItem{
+		Product:    &Product{ID: "p1", SKU: "TEE"},
+		SKU:        "TEE-M",
+		Categories: map[ID]bool{"shirts": true, "clothing": true},
+	}
+
+	tests := []struct {
+		name string
+		p    Promotion
+		want bool
+	}{
+		{"every product", Promotion{}, true},
+		{"product", Promotion{ProductIDs: []ID{"p2", "p1"}}, true},
+		{"ancestor category", Promotion{CategoryIDs: []ID{"clothing"}}, true},
+		{"variant SKU", Promotion{SKUs: []string{"TEE-M"}}, true},
+		{"product SKU", Promotion{SKUs: []string{"TEE"}}, true},
+		{"other product", Promotion{ProductIDs: []ID{"p2"}}, false},
+		{"other variant", Promotion{SKUs: []string{"TEE-L"}}, false},
+		{"other category", Promotion{CategoryIDs: []ID{"shoes"}, SKUs: []string{"MUG"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.Targets(item); got != tt.want {
+			t.Errorf("%s: Targets() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionsEvaluate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	usd := func(amount int64) *Money { return &Money{Amount: amount, Currency: "USD"} }
+	percent := func(id ID, pct float64, priority int, stackable bool) Promotion {
+		return Promotion{ID: id, Name: string(id), Type: PromotionPercentage, Percent: pct, Priority: priority, Stackable: stackable, Active: true}
+	}
+
+	tests := []struct {
+		name         string
+		promotions   Promotions
+		quantity     int
+		wantDiscount int64
+		wantApplied  []ID
+	}{
+		{"none", nil, 2, 0, []ID{}},
+		{"percentage", Promotions{percent("p20", 20, 0, false)}, 2, 400, []ID{"p20"}},
+		{"fixed per unit", Promotions{{ID: "f", Type: PromotionFixed, AmountOff: usd(150), Active: true}}, 2, 300, []ID{"f"}},
+		{"fixed in another currency", Promotions{{ID: "f", Type: PromotionFixed, AmountOff: &Money{Amount: 150, Currency: "EUR"}, Active: true}}, 2, 0, []ID{}},
+		{"fixed capped at the price", Promotions{{ID: "f", Type: PromotionFixed, AmountOff: usd(5000), Active: true}}, 2, 2000, []ID{"f"}},
+		{"bundle", Promotions{{ID: "b", Type: PromotionBundle, BuyQuantity: 2, FreeQuantity: 1, Active: true}}, 7, 2000, []ID{"b"}},
+		{"bundle not reached", Promotions{{ID: "b", Type: PromotionBundle, BuyQuantity: 2, FreeQuantity: 1, Active: true}}, 2, 0, []ID{}},
+		{"stacked in priority order", Promotions{percent("p10", 10, 1, true), percent("p20", 20, 2, true)}, 2, 560, []ID{"p20", "p10"}},
+		{"not stackable first", Promotions{percent("p10", 10, 1, true), percent("p50", 50, 2, false)}, 2, 1000, []ID{"p50"}},
+		{"not stackable after another", Promotions{percent("p10", 10, 2, true), percent("p50", 50, 1, false)}, 2, 200, []ID{"p10"}},
+		{"not yet started", Promotions{{ID: "p", Type: PromotionPercentage, Percent: 10, Active: true, StartsAt: &later}}, 2, 0, []ID{}},
+		{"not targeted", Promotions{{ID: "p", Type: PromotionPercentage, Percent: 10, Active: true, ProductIDs: []ID{"p2"}}}, 2, 0, []ID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := PricedItem{
+				Product:  &Product{ID: "p1", SKU: "TEE"},
+				SKU:      "TEE",
+				Quantity: tt.quantity,
+				Price:    EffectivePrice{PriceList: DefaultPriceListID, Price: Money{Amount: 1000, Currency: "USD"}},
+			}
+			e := tt.promotions.Evaluate(now, item)
+
+			subtotal := 1000 * int64(tt.quantity)
+			if e.Subtotal.Amount != subtotal || e.Discount.Amount != tt.wantDiscount || e.Total.Amount != subtotal-tt.wantDiscount {
+				t.Errorf("Evaluate() = subtotal %d, discount %d, total %d, want discount %d of %d", e.Subtotal.Amount, e.Discount.Amount, e.Total.Amount, tt.wantDiscount, subtotal)
+			}
+
+			applied := []ID{}
+			for _, a := range e.Promotions {
+				applied = append(applied, a.ID)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("Evaluate() applied %v, want %v", applied, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestPromotionsEvaluateCart(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	ps := Promotions{{ID: "tees", Type: PromotionPercentage, Percent: 50, SKUs: []string{"TEE"}, Active: true}}
+	items := []PricedItem{
+		{Product: &Product{ID: "p1", SKU: "TEE"}, SKU: "TEE", Quantity: 2, Price: EffectivePrice{Price: Money{Amount: 1000, Currency: "USD"}}},
+		{Product: &Product{ID: "p2", SKU: "MUG"}, SKU: "MUG", Quantity: 1, Price: EffectivePrice{Price: Money{Amount: 799, Currency: "USD"}}},
+	}
+
+	cart := ps.EvaluateCart(now, "USD", items)
+	if len(cart.Items) != 2 {
+		t.Fatalf("EvaluateCart() returned %d items, want 2", len(cart.Items))
+	}
+	want := CartEvaluation{
+		Items:    cart.Items,
+		Subtotal: Money{Amount: 2799, Currency: "USD"},
+		Discount: Money{Amount: 1000, Currency: "USD"},
+		Total:    Money{Amount: 1799, Currency: "USD"},
+	}
+	if !reflect.DeepEqual(cart, want) {
+		t.Errorf("EvaluateCart() = %+v, want %+v", cart, want)
+	}
+}
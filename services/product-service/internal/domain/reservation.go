@@ -0,0 +1,200 @@
+// internal/domain/reservation.go
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// ReservationState is the lifecycle state of a stock reservation.
+type ReservationState string
+
+const (
+	// ReservationPending holds stock while the order is being paid
+	ReservationPending ReservationState = "pending"
+	// ReservationConfirmed sold the held stock
+	ReservationConfirmed ReservationState = "confirmed"
+	// ReservationReleased gave the held stock back, e.g. for a cancelled order
+	ReservationReleased ReservationState = "released"
+	// ReservationExpired gave the held stock back when the order was not
+	// confirmed in time
+	ReservationExpired ReservationState = "expired"
+)
+
+// Bounds enforced on reservations.
+const (
+	MaxReservationItems    = 100
+	MaxReservationQuantity = 10000
+	DefaultReservationTTL  = 15 * time.Minute
+	MinReservationTTL      = time.Minute
+	MaxReservationTTL      = 24 * time.Hour
+)
+
+// orderIDPattern restricts order IDs, which key reservations, to the IDs the
+// order service generates and similar opaque identifiers.
+var orderIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var (
+	// ErrReservationNotFound is returned when an order has no reservation.
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrReservationMismatch is returned when an order is reserved again
+	// with items other than those of its reservation.
+	ErrReservationMismatch = errors.New("order already has a reservation for other items")
+	// ErrReservationNotPending matches any ReservationStateError via
+	// errors.Is.
+	ErrReservationNotPending = errors.New("reservation is not pending")
+)
+
+// ReservationStateError reports a reservation that can no longer be
+// confirmed or released because it left the pending state.
+type ReservationStateError struct {
+	State ReservationState
+}
+
+func (e *ReservationStateError) Error() string {
+	return fmt.Sprintf("reservation is %s", e.State)
+}
+
+func (e *ReservationStateError) Is(target error) bool {
+	return target == ErrReservationNotPending
+}
+
+// ReservationItem is stock held for an order.
+type ReservationItem struct {
+	ProductID ID `json:"product_id" bson:"product_id" binding:"required"`
+	// SKU is the variant to reserve; it may be left out for products without
+	// variants, whose stock is kept under the product SKU
+	SKU      string `json:"sku,omitempty" bson:"sku"`
+	Quantity int    `json:"quantity" bson:"quantity" binding:"required,min=1"`
+	// Available is the stock of the SKU left to sell, set on responses
+	Available *int `json:"available,omitempty" bson:"-"`
+	// Allocations tells where the stock is held. Items reserved before
+	// locations existed have none; their stock is held at the default
+	// location.
+	Allocations []StockAllocation `json:"allocations,omitempty" bson:"allocations,omitempty"`
+}
+
+// HeldAt returns where the stock of the item is held.
+func (i ReservationItem) HeldAt() []StockAllocation {
+	if len(i.Allocations) == 0 {
+		return []StockAllocation{{LocationID: DefaultLocationID, Quantity: i.Quantity}}
+	}
+	return i.Allocations
+}
+
+// Reservation holds stock for an order until it is confirmed, released or
+// expires. The order ID keys it, so reserving an order again is idempotent.
+type Reservation struct {
+	OrderID     string            `json:"order_id" bson:"_id"`
+	Items       []ReservationItem `json:"items" bson:"items"`
+	State       ReservationState  `json:"state" bson:"state"`
+	ExpiresAt   time.Time         `json:"expires_at" bson:"expires_at"`
+	CreatedBy   string            `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+	ConfirmedAt *time.Time        `json:"confirmed_at,omitempty" bson:"confirmed_at,omitempty"`
+	ReleasedAt  *time.Time        `json:"released_at,omitempty" bson:"released_at,omitempty"`
+}
+
+// SameItems reports whether items, once resolved to SKUs, hold the same
+// stock as the reservation, whatever their order.
+func (r *Reservation) SameItems(items []ReservationItem) bool {
+	if len(items) != len(r.Items) {
+		return false
+	}
+
+	key := func(items []ReservationItem) []string {
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			keys = append(keys, fmt.Sprintf("%s/%s/%d", item.ProductID, item.SKU, item.Quantity))
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	a, b := key(r.Items), key(items)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ReservationRequest asks to hold stock for an order.
+type ReservationRequest struct {
+	OrderID string            `json:"order_id" binding:"required"`
+	Items   []ReservationItem `json:"items" binding:"required,min=1,dive"`
+	// TTL is how long, in seconds, the stock is held unless the reservation
+	// is confirmed; the service default when left out
+	TTL int `json:"ttl,omitempty"`
+	// Strategy orders the locations the stock is taken from, priority when
+	// left out. Nearest needs the destination of the order.
+	Strategy    AllocationStrategy `json:"strategy,omitempty"`
+	Destination *GeoPoint          `json:"destination,omitempty"`
+	// Location holds all the stock at one location, e.g. for a pickup in
+	// store
+	Location string `json:"location,omitempty"`
+}
+
+// Validate checks a request. Items are keyed by product and SKU, which
+// may only appear once.
+func (r *ReservationRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if !orderIDPattern.MatchString(r.OrderID) {
+		verr.Add("order_id", "must be 1 to 64 letters, digits, dashes or underscores")
+	}
+	if len(r.Items) == 0 || len(r.Items) > MaxReservationItems {
+		verr.Add("items", "must contain 1 to %d entries", MaxReservationItems)
+	}
+
+	seen := make(map[string]bool, len(r.Items))
+	for i := range r.Items {
+		item := &r.Items[i]
+		field := fmt.Sprintf("items[%d]", i)
+
+		item.SKU = NormalizeSKU(item.SKU)
+		item.Allocations = nil
+		if item.ProductID == "" {
+			verr.Add(field+".product_id", "is required")
+		}
+		if item.Quantity < 1 || item.Quantity > MaxReservationQuantity {
+			verr.Add(field+".quantity", "must be between 1 and %d", MaxReservationQuantity)
+		}
+
+		key := fmt.Sprintf("%s/%s", item.ProductID, item.SKU)
+		if seen[key] {
+			verr.Add(field, "repeats the product and SKU of another item")
+		}
+		seen[key] = true
+	}
+
+	ttl := time.Duration(r.TTL) * time.Second
+	if r.TTL != 0 && (ttl < MinReservationTTL || ttl > MaxReservationTTL) {
+		verr.Add("ttl", "must be between %d and %d seconds", int(MinReservationTTL.Seconds()), int(MaxReservationTTL.Seconds()))
+	}
+
+	switch r.Strategy {
+	case "":
+		r.Strategy = AllocatePriority
+	case AllocatePriority:
+	case AllocateNearest:
+		if r.Destination == nil {
+			verr.Add("destination", "is required for nearest allocation")
+		}
+	default:
+		verr.Add("strategy", "must be one of %s, %s", AllocatePriority, AllocateNearest)
+	}
+	if r.Destination != nil {
+		r.Destination.validate(verr, "destination")
+	}
+	if r.Location != "" && !identifierPattern.MatchString(r.Location) {
+		verr.Add("location", "must be a location ID")
+	}
+
+	return verr.Err()
+}
@@ -0,0 +1,140 @@
+// internal/domain/reservation_test.go
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReservationRequestValidate(t *testing.T) {
+	item := func(productID ID, sku string, quantity int) ReservationItem {
+		return ReservationItem{ProductID: productID, SKU: sku, Quantity: quantity}
+	}
+
+	tests := []struct {
+		name string
+		r    ReservationRequest
+		want []string
+	}{
+		{"valid", ReservationRequest{OrderID: "ord_123", Items: []ReservationItem{item("p1", "TEE-S", 2), item("p1", "TEE-M", 1)}}, nil},
+		{"shortest TTL", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("p1", "", 1)}, TTL: 60}, nil},
+		{"order ID", ReservationRequest{OrderID: "order 1", Items: []ReservationItem{item("p1", "", 1)}}, []string{"order_id"}},
+		{"long order ID", ReservationRequest{OrderID: strings.Repeat("o", 65), Items: []ReservationItem{item("p1", "", 1)}}, []string{"order_id"}},
+		{"no items", ReservationRequest{OrderID: "o"}, []string{"items"}},
+		{"product", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("", "TEE", 1)}}, []string{"items[0].product_id"}},
+		{"quantity", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("p1", "", 0), item("p2", "", MaxReservationQuantity+1)}}, []string{"items[0].quantity", "items[1].quantity"}},
+		{"repeated SKU", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("p1", "TEE-S", 1), item("p1", " tee-s", 1)}}, []string{"items[1]"}},
+		{"TTL too short", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("p1", "", 1)}, TTL: 59}, []string{"ttl"}},
+		{"TTL too long", ReservationRequest{OrderID: "o", Items: []ReservationItem{item("p1", "", 1)}, TTL: 86401}, []string{"ttl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Fields) != len(tt.want) {
+				t.Fatalf("Validate() = %v, want errors on %v", err, tt.want)
+			}
+			for i, f := range verr.Fields {
+				if f.Field != tt.want[i] {
+					t.Errorf("Validate() error %d on %q, want %q", i, f.Field, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReservationRequestNormalizes(t *testing.T) {
+	r := ReservationRequest{OrderID: "o", Items: []ReservationItem{{
+		ProductID:   "p1",
+		SKU:         " tee-s ",
+		Quantity:    1,
+		Allocations: []StockAllocation{{LocationID: "store", Quantity: 1}},
+	}}}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if item := r.Items[0]; item.SKU != "TEE-S" || item.Allocations != nil {
+		t.Errorf("Validate() left SKU %q and allocations %v", item.SKU, item.Allocations)
+	}
+}
+
+func TestReservationSameItems(t *testing.T) {
+	r := &Reservation{Items: []ReservationItem{
+		{ProductID: "p1", SKU: "TEE-S", Quantity: 2},
+		{ProductID: "p2", SKU: "MUG", Quantity: 1},
+	}}
+
+	tests := []struct {
+		name  string
+		items []ReservationItem
+		want  bool
+	}{
+		{"same order", r.Items, true},
+		{"other order", []ReservationItem{r.Items[1], r.Items[0]}, true},
+		{"other quantity", []ReservationItem{r.Items[0], {ProductID: "p2", SKU: "MUG", Quantity: 2}}, false},
+		{"fewer items", r.Items[:1], false},
+		{"other SKU", []ReservationItem{{ProductID: "p1", SKU: "TEE-M", Quantity: 2}, r.Items[1]}, false},
+	}
+
+	for _, tt := range tests {
+		if got := r.SameItems(tt.items); got != tt.want {
+			t.Errorf("%s: SameItems() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStockLevelOf(t *testing.T) {
+	tests := []struct {
+		available, threshold int
+		want                 StockLevel
+	}{
+		{10, 5, StockInStock},
+		{5, 5, StockLow},
+		{1, 5, StockLow},
+		{0, 5, StockOutOfStock},
+		{-1, 5, StockOutOfStock},
+		{1, 0, StockInStock},
+		{0, 0, StockOutOfStock},
+	}
+
+	for _, tt := range tests {
+		if got := StockLevelOf(tt.available, tt.threshold); got != tt.want {
+			t.Errorf("StockLevelOf(%d, %d) = %s, want %s", tt.available, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestStockChangeAvailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		change StockChange
+		want   int
+	}{
+		{"receipt", StockChange{OnHand: 5}, 5},
+		{"reserve", StockChange{Reserved: 2}, -2},
+		{"release", StockChange{Reserved: -2}, 2},
+		{"sell reserved", StockChange{OnHand: -2, Reserved: -2}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.Available(); got != tt.want {
+			t.Errorf("%s: Available() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReservationStateErrorIs(t *testing.T) {
+	var err error = &ReservationStateError{State: ReservationExpired}
+	if !errors.Is(err, ErrReservationNotPending) {
+		t.Errorf("errors.Is(%v, ErrReservationNotPending) = false", err)
+	}
+}
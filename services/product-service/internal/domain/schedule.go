@@ -0,0 +1,87 @@
+// internal/domain/schedule.go
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ScheduleAction is the change a ScheduledChange applies to its product.
+type ScheduleAction string
+
+// Schedulable changes. Publishing and unpublishing go through the lifecycle
+// state machine, so a scheduled publish only succeeds for products that are
+// in review or discontinued at that time.
+const (
+	SchedulePublish   ScheduleAction = "publish"
+	ScheduleUnpublish ScheduleAction = "unpublish"
+	SchedulePrice     ScheduleAction = "price"
+)
+
+// ScheduleState tracks a ScheduledChange from creation to execution.
+type ScheduleState string
+
+const (
+	SchedulePending   ScheduleState = "pending"
+	ScheduleApplied   ScheduleState = "applied"
+	ScheduleFailed    ScheduleState = "failed"
+	ScheduleCancelled ScheduleState = "cancelled"
+)
+
+// MaxPendingSchedules bounds the changes waiting on a single product.
+const MaxPendingSchedules = 50
+
+// ErrScheduleNotFound is returned when a scheduled change does not exist or
+// belongs to another product.
+var ErrScheduleNotFound = errors.New("scheduled change not found")
+
+// ErrScheduleNotPending is returned when cancelling a change that has
+// already run or been cancelled.
+var ErrScheduleNotPending = errors.New("scheduled change is not pending")
+
+// ErrTooManySchedules is returned when a product already has
+// MaxPendingSchedules pending changes.
+var ErrTooManySchedules = errors.New("too many pending scheduled changes")
+
+// ScheduledChange is a change to a product that the scheduler applies at
+// RunAt, such as a sale price starting at midnight.
+type ScheduledChange struct {
+	ID        ID             `json:"id" bson:"_id,omitempty"`
+	ProductID ID             `json:"product_id" bson:"product_id"`
+	Action    ScheduleAction `json:"action" bson:"action" binding:"required"`
+	RunAt     time.Time      `json:"run_at" bson:"run_at" binding:"required"`
+	// Price is the new price of a price change
+	Price     *Money        `json:"price,omitempty" bson:"price,omitempty"`
+	State     ScheduleState `json:"state" bson:"state"`
+	Error     string        `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedBy string        `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	AppliedAt *time.Time    `json:"applied_at,omitempty" bson:"applied_at,omitempty"`
+}
+
+// Validate checks a change submitted by a client. Changes must be scheduled
+// in the future and a price change needs a positive price.
+func (sc *ScheduledChange) Validate(now time.Time) error {
+	verr := &ValidationError{}
+
+	switch sc.Action {
+	case SchedulePublish, ScheduleUnpublish:
+		if sc.Price != nil {
+			verr.Add("price", "is only allowed for %s changes", SchedulePrice)
+		}
+	case SchedulePrice:
+		if sc.Price == nil {
+			verr.Add("price", "is required for %s changes", SchedulePrice)
+		} else {
+			sc.Price.validate(verr, "price", "")
+		}
+	default:
+		verr.Add("action", "must be one of %s, %s, %s", SchedulePublish, ScheduleUnpublish, SchedulePrice)
+	}
+
+	if !sc.RunAt.After(now) {
+		verr.Add("run_at", "must be in the future")
+	}
+
+	return verr.Err()
+}
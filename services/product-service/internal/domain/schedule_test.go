@@ -0,0 +1,50 @@
+// internal/domain/schedule_test.go
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledChangeValidate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	price := &Money{Amount: 1299, Currency: "USD"}
+
+	tests := []struct {
+		name    string
+		sc      ScheduledChange
+		wantErr string
+	}{
+		{"publish", ScheduledChange{Action: SchedulePublish, RunAt: later}, ""},
+		{"unpublish", ScheduledChange{Action: ScheduleUnpublish, RunAt: later}, ""},
+		{"price", ScheduledChange{Action: SchedulePrice, RunAt: later, Price: price}, ""},
+		{"action", ScheduledChange{Action: "delete", RunAt: later},
+			"validation failed: action: must be one of publish, unpublish, price"},
+		{"price on publish", ScheduledChange{Action: SchedulePublish, RunAt: later, Price: price},
+			"validation failed: price: is only allowed for price changes"},
+		{"missing price", ScheduledChange{Action: SchedulePrice, RunAt: later},
+			"validation failed: price: is required for price changes"},
+		{"free", ScheduledChange{Action: SchedulePrice, RunAt: later, Price: &Money{Amount: 0, Currency: "USD"}},
+			"validation failed: price.amount: must be greater than 0"},
+		{"currency", ScheduledChange{Action: SchedulePrice, RunAt: later, Price: &Money{Amount: 1299, Currency: "usd"}},
+			"validation failed: price.currency: must be a supported ISO 4217 code"},
+		{"now", ScheduledChange{Action: SchedulePublish, RunAt: now},
+			"validation failed: run_at: must be in the future"},
+		{"past price change", ScheduledChange{Action: SchedulePrice, RunAt: now.Add(-time.Second)},
+			"validation failed: price: is required for price changes; run_at: must be in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sc.Validate(now)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("Validate() error = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
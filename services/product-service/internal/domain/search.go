@@ -0,0 +1,89 @@
+// internal/domain/search.go
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bounds enforced on SearchQuery.
+const (
+	MaxSearchQueryLength = 200
+	MaxSearchTerms       = 10
+)
+
+// SearchQuery is a full-text query combined with the regular listing
+// filters. Search results are ranked, so they are paged by offset only.
+type SearchQuery struct {
+	Q string `form:"q"`
+	ProductFilter
+
+	// Terms is the lower-cased, de-duplicated words of Q, set by Validate.
+	Terms []string `form:"-"`
+}
+
+// SearchResult is a product matched by a search, with its relevance score
+// and HTML snippets where the terms are wrapped in <em> tags.
+type SearchResult struct {
+	Product    Product           `json:"product"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchPage is one page of search results. Fuzzy is set when nothing
+// matched the terms exactly and the results come from prefix and typo
+// tolerant matching instead.
+type SearchPage struct {
+	Items []SearchResult `json:"items"`
+	Total int64          `json:"total"`
+	Fuzzy bool           `json:"fuzzy"`
+}
+
+// Validate checks the query and the embedded filter, filling in Terms.
+func (q *SearchQuery) Validate(maxLimit int) error {
+	verr := &ValidationError{}
+
+	if err := q.ProductFilter.Validate(maxLimit); err != nil {
+		var ferr *ValidationError
+		if !errors.As(err, &ferr) {
+			return err
+		}
+		verr.Fields = append(verr.Fields, ferr.Fields...)
+	}
+
+	q.Terms = SearchTerms(q.Q)
+	switch {
+	case utf8.RuneCountInString(q.Q) > MaxSearchQueryLength:
+		verr.Add("q", "must be at most %d characters", MaxSearchQueryLength)
+	case len(q.Terms) == 0:
+		verr.Add("q", "must contain at least one letter or digit")
+	case len(q.Terms) > MaxSearchTerms:
+		verr.Add("q", "must contain at most %d words", MaxSearchTerms)
+	}
+
+	if q.Cursor != "" {
+		verr.Add("cursor", "is not supported by search; use offset")
+	}
+
+	return verr.Err()
+}
+
+// SearchTerms splits s into lower-cased words of letters and digits,
+// dropping duplicates.
+func SearchTerms(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
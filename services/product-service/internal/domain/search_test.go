@@ -0,0 +1,71 @@
+// internal/domain/search_test.go
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{}},
+		{"Leather jacket", []string{"leather", "jacket"}},
+		{"  jacket,JACKET; Jacket!", []string{"jacket"}},
+		{"t-shirt (XL)", []string{"t", "shirt", "xl"}},
+		{"Größe 42", []string{"größe", "42"}},
+		{"*** ---", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := SearchTerms(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchTerms(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQueryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		wantErr string
+	}{
+		{"valid", SearchQuery{Q: "leather jacket"}, ""},
+		{"most words", SearchQuery{Q: "a b c d e f g h i j"}, ""},
+		{"repeated words count once", SearchQuery{Q: strings.Repeat("jacket ", MaxSearchTerms+1)}, ""},
+		{"empty", SearchQuery{}, "q: must contain at least one letter or digit"},
+		{"punctuation only", SearchQuery{Q: "?!"}, "q: must contain at least one letter or digit"},
+		{"too long", SearchQuery{Q: strings.Repeat("a", MaxSearchQueryLength+1)}, "q: must be at most"},
+		{"too many words", SearchQuery{Q: "a b c d e f g h i j k"}, "q: must contain at most"},
+		{"cursor", SearchQuery{Q: "jacket", ProductFilter: ProductFilter{Cursor: "abc"}}, "cursor: is not supported"},
+		{"filter errors kept", SearchQuery{Q: "jacket", ProductFilter: ProductFilter{SortOrder: "up"}}, "sort_order:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			if q.Limit == 0 {
+				q.Limit = 10
+			}
+
+			err := q.Validate(100)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	q := SearchQuery{Q: "Leather, leather JACKET", ProductFilter: ProductFilter{Limit: 10}}
+	if err := q.Validate(100); err != nil || !reflect.DeepEqual(q.Terms, []string{"leather", "jacket"}) {
+		t.Errorf("Validate() = %v with terms %q, want leather and jacket", err, q.Terms)
+	}
+}
@@ -0,0 +1,10 @@
+// internal/domain/sku.go
+package domain
+
+import "strings"
+
+// NormalizeSKU returns the canonical form of a SKU. SKUs are compared
+// case-insensitively, so they are trimmed and stored in upper case.
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
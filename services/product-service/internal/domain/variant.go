@@ -0,0 +1,251 @@
+// internal/domain/variant.go
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Bounds enforced on product options and variants.
+const (
+	MaxOptions       = 3
+	MaxOptionValues  = 50
+	MaxVariants      = 100
+	MaxVariantFilter = MaxOptions
+)
+
+// identifierPattern restricts option and attribute names to identifiers,
+// which keeps them safe to use as document keys and in filters.
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// ProductOption is a dimension the variants of a product differ in, such as
+// size or color, with the values it can take.
+type ProductOption struct {
+	Name   string   `json:"name" bson:"name"`
+	Values []string `json:"values" bson:"values"`
+}
+
+// Variant is a purchasable version of a product, such as the medium red
+// T-shirt. Attributes holds one value for every option of the product.
+type Variant struct {
+	SKU string `json:"sku" bson:"sku"`
+	// Price overrides the product price when set
+	Price      *Money            `json:"price,omitempty" bson:"price,omitempty"`
+	Inventory  int               `json:"inventory" bson:"inventory"`
+	Attributes map[string]string `json:"attributes" bson:"attributes"`
+	// Prices overrides the prices of the product in other price lists
+	Prices map[string]Money `json:"prices,omitempty" bson:"prices,omitempty"`
+	// EffectivePrice is the price resolved for the caller, set on reads
+	EffectivePrice *EffectivePrice `json:"effective_price,omitempty" bson:"-"`
+	// Reserved is the stock held for orders and Available the rest
+	Reserved  int `json:"reserved" bson:"reserved"`
+	Available int `json:"available" bson:"available"`
+	// Locations is the stock at each location, set on reads
+	Locations []LocationAvailability `json:"locations,omitempty" bson:"-"`
+}
+
+// SKUs returns the product SKU followed by the SKUs of its variants.
+func (p *Product) SKUs() []string {
+	skus := make([]string, 0, len(p.Variants)+1)
+	skus = append(skus, p.SKU)
+	for _, v := range p.Variants {
+		skus = append(skus, v.SKU)
+	}
+	return skus
+}
+
+// HasSKU reports whether sku is the product SKU or the SKU of a variant.
+func (p *Product) HasSKU(sku string) bool {
+	return p.SKU == sku || p.Variant(sku) != nil
+}
+
+// Variant returns the variant with the given SKU, or nil.
+func (p *Product) Variant(sku string) *Variant {
+	for i := range p.Variants {
+		if p.Variants[i].SKU == sku {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// VariantPrice returns the price of v, falling back to the product price.
+func (p *Product) VariantPrice(v Variant) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
+}
+
+// VariantPrices returns the price list prices of v, falling back to those
+// of the product.
+func (p *Product) VariantPrices(v Variant) map[string]Money {
+	if len(v.Prices) == 0 {
+		return p.Prices
+	}
+	prices := make(map[string]Money, len(p.Prices)+len(v.Prices))
+	for id, m := range p.Prices {
+		prices[id] = m
+	}
+	for id, m := range v.Prices {
+		prices[id] = m
+	}
+	return prices
+}
+
+// NormalizeVariants normalizes the variant SKUs. A product with variants is
+// stocked through them, so its inventory becomes the sum of theirs.
+func (p *Product) NormalizeVariants() {
+	if len(p.Variants) == 0 {
+		return
+	}
+
+	p.Inventory = 0
+	for i := range p.Variants {
+		p.Variants[i].SKU = NormalizeSKU(p.Variants[i].SKU)
+		p.Inventory += p.Variants[i].Inventory
+	}
+}
+
+// validateVariants checks the options and variants of p, recording problems
+// in verr.
+func (p *Product) validateVariants(verr *ValidationError) {
+	if len(p.Options) > MaxOptions {
+		verr.Add("options", "must contain at most %d entries", MaxOptions)
+	}
+
+	values := make(map[string]map[string]bool, len(p.Options))
+	for i, o := range p.Options {
+		field := fmt.Sprintf("options[%d]", i)
+		if !identifierPattern.MatchString(o.Name) {
+			verr.Add(field+".name", "must be a lowercase identifier of at most 32 characters")
+			continue
+		}
+		if values[o.Name] != nil {
+			verr.Add(field+".name", "%q is defined twice", o.Name)
+			continue
+		}
+		if len(o.Values) == 0 || len(o.Values) > MaxOptionValues {
+			verr.Add(field+".values", "must contain 1 to %d entries", MaxOptionValues)
+		}
+
+		set := make(map[string]bool, len(o.Values))
+		for _, v := range o.Values {
+			if v == "" || set[v] {
+				verr.Add(field+".values", "must be unique and non-empty")
+				break
+			}
+			set[v] = true
+		}
+		values[o.Name] = set
+	}
+
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		verr.Add("variants", "require options to be defined")
+		return
+	}
+	if len(p.Variants) > MaxVariants {
+		verr.Add("variants", "must contain at most %d entries", MaxVariants)
+	}
+
+	skus := map[string]bool{NormalizeSKU(p.SKU): true}
+	combinations := make(map[string]bool, len(p.Variants))
+	for i, v := range p.Variants {
+		field := fmt.Sprintf("variants[%d]", i)
+
+		sku := NormalizeSKU(v.SKU)
+		if sku == "" {
+			verr.Add(field+".sku", "is required")
+		} else if skus[sku] {
+			verr.Add(field+".sku", "must be unique and differ from the product SKU")
+		}
+		skus[sku] = true
+
+		if v.Price != nil {
+			v.Price.validate(verr, field+".price", p.Price.Currency)
+		}
+		validatePrices(verr, field+".prices", v.Prices)
+		if v.Inventory < 0 {
+			verr.Add(field+".inventory", "must not be negative")
+		}
+
+		if len(v.Attributes) != len(p.Options) {
+			verr.Add(field+".attributes", "must set exactly one value for each option")
+			continue
+		}
+
+		key := make([]string, 0, len(p.Options))
+		valid := true
+		for _, o := range p.Options {
+			value, ok := v.Attributes[o.Name]
+			if !ok || !values[o.Name][value] {
+				verr.Add(field+".attributes", "%s must be one of the values of option %s", o.Name, o.Name)
+				valid = false
+				break
+			}
+			key = append(key, value)
+		}
+		if !valid {
+			continue
+		}
+
+		combination := strings.Join(key, "\x00")
+		if combinations[combination] {
+			verr.Add(field+".attributes", "are the same as those of another variant")
+		}
+		combinations[combination] = true
+	}
+}
+
+// ParseVariantFilter parses variant filters of the form "name:value" into
+// the attributes a single variant must have.
+func ParseVariantFilter(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	if len(filters) > MaxVariantFilter {
+		return nil, fmt.Errorf("must contain at most %d entries", MaxVariantFilter)
+	}
+
+	attributes := make(map[string]string, len(filters))
+	for _, f := range filters {
+		name, value, ok := strings.Cut(f, ":")
+		if !ok || !identifierPattern.MatchString(name) || value == "" {
+			return nil, fmt.Errorf("%q must have the form option:value", f)
+		}
+		if _, dup := attributes[name]; dup {
+			return nil, fmt.Errorf("option %q is given twice", name)
+		}
+		attributes[name] = value
+	}
+	return attributes, nil
+}
+
+// DiffVariants compares two versions of a product's variants by SKU.
+func DiffVariants(before, after []Variant) (added, updated, removed []Variant) {
+	old := make(map[string]Variant, len(before))
+	for _, v := range before {
+		old[v.SKU] = v
+	}
+
+	for _, v := range after {
+		prev, ok := old[v.SKU]
+		switch {
+		case !ok:
+			added = append(added, v)
+		case !reflect.DeepEqual(prev, v):
+			updated = append(updated, v)
+		}
+		delete(old, v.SKU)
+	}
+
+	for _, v := range before {
+		if _, ok := old[v.SKU]; ok {
+			removed = append(removed, v)
+		}
+	}
+
+	return added, updated, removed
+}
@@ -0,0 +1,156 @@
+// internal/domain/variant_test.go
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func variantProduct(variants ...Variant) Product {
+	return Product{
+		Name:  "T-shirt",
+		SKU:   "TEE",
+		Price: Money{Amount: 1500, Currency: "USD"},
+		Options: []ProductOption{
+			{Name: "size", Values: []string{"S", "M"}},
+			{Name: "color", Values: []string{"red", "blue"}},
+		},
+		Variants: variants,
+	}
+}
+
+func TestProductValidateVariants(t *testing.T) {
+	attrs := func(size, color string) map[string]string { return map[string]string{"size": size, "color": color} }
+	mug := func(options ...ProductOption) Product {
+		return Product{Name: "Mug", SKU: "MUG", Price: Money{Amount: 800, Currency: "USD"}, Options: options}
+	}
+
+	tests := []struct {
+		name      string
+		p         Product
+		wantField string
+	}{
+		{"valid", variantProduct(
+			Variant{SKU: "TEE-S-RED", Attributes: attrs("S", "red"), Inventory: 2},
+			Variant{SKU: "TEE-M-RED", Attributes: attrs("M", "red"), Price: &Money{Amount: 1700, Currency: "USD"}},
+		), ""},
+		{"variants without options", Product{Name: "Mug", SKU: "MUG", Price: Money{Amount: 800, Currency: "USD"}, Variants: []Variant{{SKU: "MUG-1"}}}, "variants"},
+		{"option name", mug(ProductOption{Name: "Size", Values: []string{"S"}}), "options[0].name"},
+		{"repeated option", mug(ProductOption{Name: "size", Values: []string{"S"}}, ProductOption{Name: "size", Values: []string{"M"}}), "options[1].name"},
+		{"no option values", mug(ProductOption{Name: "size"}), "options[0].values"},
+		{"repeated option value", mug(ProductOption{Name: "color", Values: []string{"red", "red"}}), "options[0].values"},
+		{"missing SKU", variantProduct(Variant{Attributes: attrs("S", "red")}), "variants[0].sku"},
+		{"product SKU", variantProduct(Variant{SKU: " tee ", Attributes: attrs("S", "red")}), "variants[0].sku"},
+		{"repeated SKU", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red")}, Variant{SKU: "tee-1", Attributes: attrs("M", "red")}), "variants[1].sku"},
+		{"free", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red"), Price: &Money{Amount: 0, Currency: "USD"}}), "variants[0].price.amount"},
+		{"price currency", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red"), Price: &Money{Amount: 1700, Currency: "EUR"}}), "variants[0].price.currency"},
+		{"price list price", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red"), Prices: map[string]Money{"default": {Amount: 1700, Currency: "USD"}}}), "variants[0].prices"},
+		{"negative inventory", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red"), Inventory: -1}), "variants[0].inventory"},
+		{"missing attribute", variantProduct(Variant{SKU: "TEE-1", Attributes: map[string]string{"size": "S"}}), "variants[0].attributes"},
+		{"unknown value", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("XL", "red")}), "variants[0].attributes"},
+		{"repeated combination", variantProduct(Variant{SKU: "TEE-1", Attributes: attrs("S", "red")}, Variant{SKU: "TEE-2", Attributes: attrs("S", "red")}), "variants[1].attributes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want a ValidationError", err)
+			}
+			for _, f := range verr.Fields {
+				if f.Field == tt.wantField {
+					return
+				}
+			}
+			t.Errorf("Validate() error = %v, want one on %s", err, tt.wantField)
+		})
+	}
+}
+
+func TestNormalizeVariants(t *testing.T) {
+	p := Product{SKU: "TEE", Inventory: 99, Variants: []Variant{{SKU: " tee-s ", Inventory: 2}, {SKU: "tee-m", Inventory: 3}}}
+	p.NormalizeVariants()
+	if p.Inventory != 5 || p.Variants[0].SKU != "TEE-S" || p.Variants[1].SKU != "TEE-M" {
+		t.Errorf("NormalizeVariants() = inventory %d, SKUs %q and %q", p.Inventory, p.Variants[0].SKU, p.Variants[1].SKU)
+	}
+
+	single := Product{SKU: "MUG", Inventory: 4}
+	single.NormalizeVariants()
+	if single.Inventory != 4 {
+		t.Errorf("NormalizeVariants() without variants changed inventory to %d", single.Inventory)
+	}
+}
+
+func TestParseVariantFilter(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: nil, want: nil},
+		{in: []string{"size:M", "color:light blue"}, want: map[string]string{"size": "M", "color": "light blue"}},
+		{in: []string{"size:M:L"}, want: map[string]string{"size": "M:L"}},
+		{in: []string{"size"}, wantErr: true},
+		{in: []string{"size:"}, wantErr: true},
+		{in: []string{"Size:M"}, wantErr: true},
+		{in: []string{"size:M", "size:L"}, wantErr: true},
+		{in: []string{"a:1", "b:1", "c:1", "d:1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVariantFilter(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseVariantFilter(%q) = %v, %v, want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDiffVariants(t *testing.T) {
+	s := Variant{SKU: "TEE-S", Inventory: 1}
+	m := Variant{SKU: "TEE-M", Inventory: 1}
+	l := Variant{SKU: "TEE-L", Inventory: 1}
+	m2 := Variant{SKU: "TEE-M", Inventory: 2}
+
+	added, updated, removed := DiffVariants([]Variant{s, m, l}, []Variant{m2, s, {SKU: "TEE-XL"}})
+	if !reflect.DeepEqual(added, []Variant{{SKU: "TEE-XL"}}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(updated, []Variant{m2}) {
+		t.Errorf("updated = %v", updated)
+	}
+	if !reflect.DeepEqual(removed, []Variant{l}) {
+		t.Errorf("removed = %v", removed)
+	}
+}
+
+func TestVariantPrices(t *testing.T) {
+	p := &Product{
+		Price:  Money{Amount: 1500, Currency: "USD"},
+		Prices: map[string]Money{"eu": {Amount: 1400, Currency: "EUR"}, "uk": {Amount: 1200, Currency: "GBP"}},
+	}
+	v := Variant{Price: &Money{Amount: 1700, Currency: "USD"}, Prices: map[string]Money{"eu": {Amount: 1600, Currency: "EUR"}}}
+
+	if got := p.VariantPrice(v); got.Amount != 1700 {
+		t.Errorf("VariantPrice() = %v, want the variant price", got)
+	}
+	if got := p.VariantPrice(Variant{}); got.Amount != 1500 {
+		t.Errorf("VariantPrice() = %v, want the product price", got)
+	}
+
+	want := map[string]Money{"eu": {Amount: 1600, Currency: "EUR"}, "uk": {Amount: 1200, Currency: "GBP"}}
+	if got := p.VariantPrices(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("VariantPrices() = %v, want %v", got, want)
+	}
+	if p.Prices["eu"].Amount != 1400 {
+		t.Error("VariantPrices() changed the product prices")
+	}
+}
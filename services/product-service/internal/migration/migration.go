@@ -0,0 +1,173 @@
+// internal/migration/migration.go
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration is a single, versioned schema change. Versions must be unique and
+// increase monotonically; released migrations must never be edited.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+	Down        func(ctx context.Context) error
+	// Recorded migrations insert and remove their own record in the same
+	// transaction as their change, so a failure leaves neither behind. The
+	// migrator leaves the store alone for them.
+	Recorded bool
+}
+
+// Record describes a migration that has been applied to the database.
+type Record struct {
+	Version   int
+	AppliedAt time.Time
+}
+
+// Store persists which migrations have been applied.
+type Store interface {
+	// Lock blocks until the caller holds the migration lock of the
+	// database, so that replicas starting together migrate one at a time.
+	// The returned func releases it.
+	Lock(ctx context.Context) (unlock func(), err error)
+	Applied(ctx context.Context) ([]Record, error)
+	Insert(ctx context.Context, m Migration) error
+	Remove(ctx context.Context, version int) error
+}
+
+// Status reports whether a known migration has been applied.
+type Status struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+// Migrator applies and reverts migrations against a Store.
+type Migrator struct {
+	store      Store
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the given migrations, sorted by version.
+func NewMigrator(store Store, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Migrator{
+		store:      store,
+		migrations: sorted,
+	}
+}
+
+// Up applies every pending migration in version order and returns the ones it applied.
+// It holds the migration lock throughout; migrations another replica
+// applied while it waited for the lock are not applied again.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	unlock, err := m.store.Lock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+
+		if err := mig.Up(ctx); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", mig.Version, mig.Description, err)
+		}
+		if !mig.Recorded {
+			if err := m.store.Insert(ctx, mig); err != nil {
+				return done, fmt.Errorf("record migration %d: %w", mig.Version, err)
+			}
+		}
+		done = append(done, mig)
+	}
+
+	return done, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, holding
+// the migration lock throughout.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	unlock, err := m.store.Lock(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+
+		if mig.Down == nil {
+			return done, fmt.Errorf("migration %d (%s) is irreversible", mig.Version, mig.Description)
+		}
+		if err := mig.Down(ctx); err != nil {
+			return done, fmt.Errorf("revert migration %d (%s): %w", mig.Version, mig.Description, err)
+		}
+		if !mig.Recorded {
+			if err := m.store.Remove(ctx, mig.Version); err != nil {
+				return done, fmt.Errorf("unrecord migration %d: %w", mig.Version, err)
+			}
+		}
+		done = append(done, mig)
+	}
+
+	return done, nil
+}
+
+// Status lists every known migration with its applied state.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		rec, ok := applied[mig.Version]
+		statuses = append(statuses, Status{
+			Version:     mig.Version,
+			Description: mig.Description,
+			Applied:     ok,
+			AppliedAt:   rec.AppliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]Record, error) {
+	records, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,193 @@
+// internal/migration/migration_test.go
+package migration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore keeps its records in memory behind a lock shared by every
+// migrator using it, like the lock of a database shared by replicas.
+type fakeStore struct {
+	lock    sync.Mutex
+	mu      sync.Mutex
+	held    bool
+	records map[int]Record
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: map[int]Record{}}
+}
+
+func (s *fakeStore) Lock(context.Context) (func(), error) {
+	s.lock.Lock()
+	s.setHeld(true)
+	return func() {
+		s.setHeld(false)
+		s.lock.Unlock()
+	}, nil
+}
+
+func (s *fakeStore) setHeld(held bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held = held
+}
+
+func (s *fakeStore) isHeld() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.held
+}
+
+func (s *fakeStore) Applied(context.Context) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (s *fakeStore) Insert(_ context.Context, m Migration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[m.Version] = Record{Version: m.Version, AppliedAt: time.Now()}
+	return nil
+}
+
+func (s *fakeStore) Remove(_ context.Context, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, version)
+	return nil
+}
+
+// counting returns a migration that counts how often it is applied and
+// fails when the store's lock is not held.
+func counting(t *testing.T, store *fakeStore, version int, runs *int, mu *sync.Mutex) Migration {
+	return Migration{
+		Version: version,
+		Up: func(context.Context) error {
+			if !store.isHeld() {
+				t.Errorf("migration %d applied without the lock", version)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			*runs++
+			return nil
+		},
+		Down: func(context.Context) error {
+			if !store.isHeld() {
+				t.Errorf("migration %d reverted without the lock", version)
+			}
+			return nil
+		},
+	}
+}
+
+func TestUpConcurrentReplicas(t *testing.T) {
+	store := newFakeStore()
+	var mu sync.Mutex
+	runs := map[int]*int{1: new(int), 2: new(int), 3: new(int)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			migrator := NewMigrator(store, []Migration{
+				counting(t, store, 1, runs[1], &mu),
+				counting(t, store, 2, runs[2], &mu),
+				counting(t, store, 3, runs[3], &mu),
+			})
+			if _, err := migrator.Up(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for version, n := range runs {
+		if *n != 1 {
+			t.Errorf("migration %d applied %d times, want 1", version, *n)
+		}
+	}
+}
+
+func TestUpRecords(t *testing.T) {
+	failed := errors.New("syntax error")
+
+	tests := []struct {
+		name        string
+		migration   Migration
+		wantErr     bool
+		wantRecord  bool
+		wantApplied int
+	}{
+		{
+			name:        "recorded by the migrator",
+			migration:   Migration{Version: 1, Up: func(context.Context) error { return nil }},
+			wantRecord:  true,
+			wantApplied: 1,
+		},
+		{
+			name:        "recorded by the migration itself",
+			migration:   Migration{Version: 1, Up: func(context.Context) error { return nil }, Recorded: true},
+			wantApplied: 1,
+		},
+		{
+			name:      "failed migration not recorded",
+			migration: Migration{Version: 1, Up: func(context.Context) error { return failed }},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			done, err := NewMigrator(store, []Migration{tt.migration}).Up(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Up() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(done) != tt.wantApplied {
+				t.Errorf("Up() applied %d migrations, want %d", len(done), tt.wantApplied)
+			}
+			if _, ok := store.records[1]; ok != tt.wantRecord {
+				t.Errorf("record of migration 1 stored %v, want %v", ok, tt.wantRecord)
+			}
+			if store.isHeld() {
+				t.Error("lock still held after Up")
+			}
+		})
+	}
+}
+
+func TestDownHoldsLock(t *testing.T) {
+	store := newFakeStore()
+	var mu sync.Mutex
+	var runs int
+	migrator := NewMigrator(store, []Migration{
+		counting(t, store, 1, &runs, &mu),
+		counting(t, store, 2, &runs, &mu),
+	})
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	done, err := migrator.Down(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != 2 {
+		t.Errorf("Down(1) reverted %v, want migration 2", done)
+	}
+	if _, ok := store.records[2]; ok {
+		t.Error("migration 2 still recorded after Down")
+	}
+}
@@ -0,0 +1,126 @@
+// internal/query/ast.go
+package query
+
+import "time"
+
+// Expr is a node of a parsed filter expression.
+type Expr interface {
+	expr()
+}
+
+// And matches when every operand matches.
+type And struct {
+	Exprs []Expr
+}
+
+// Or matches when at least one operand matches.
+type Or struct {
+	Exprs []Expr
+}
+
+// Not negates its operand.
+type Not struct {
+	Expr Expr
+}
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq       Op = "eq"
+	OpNe       Op = "ne"
+	OpLt       Op = "lt"
+	OpLe       Op = "le"
+	OpGt       Op = "gt"
+	OpGe       Op = "ge"
+	OpIn       Op = "in"
+	OpContains Op = "contains"
+	OpExists   Op = "exists"
+)
+
+// Comparison applies Op to a field. Value holds a string, float64, int64 or
+// time.Time matching the field type; for OpIn it holds a slice of those, and
+// for OpExists it is nil.
+type Comparison struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+func (Comparison) expr() {}
+
+// MapValues returns expr with every value compared to field replaced by
+// fn(value), including the values of an OpIn list.
+func MapValues(expr Expr, field string, fn func(interface{}) interface{}) Expr {
+	mapAll := func(exprs []Expr) []Expr {
+		mapped := make([]Expr, 0, len(exprs))
+		for _, e := range exprs {
+			mapped = append(mapped, MapValues(e, field, fn))
+		}
+		return mapped
+	}
+
+	switch e := expr.(type) {
+	case And:
+		return And{Exprs: mapAll(e.Exprs)}
+	case Or:
+		return Or{Exprs: mapAll(e.Exprs)}
+	case Not:
+		return Not{Expr: MapValues(e.Expr, field, fn)}
+	case Comparison:
+		if e.Field != field || e.Op == OpExists {
+			return e
+		}
+		if values, ok := e.Value.([]interface{}); ok {
+			mapped := make([]interface{}, 0, len(values))
+			for _, v := range values {
+				mapped = append(mapped, fn(v))
+			}
+			e.Value = mapped
+		} else {
+			e.Value = fn(e.Value)
+		}
+		return e
+	}
+	return expr
+}
+
+// FieldType is the type of a filterable field.
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeNumber
+	TypeInteger
+	TypeTime
+	TypeStringArray
+)
+
+// Field describes a field that may appear in a filter expression.
+type Field struct {
+	Name string
+	Type FieldType
+}
+
+// Schema is the whitelist of filterable fields, keyed by name.
+type Schema map[string]Field
+
+// ProductSchema lists the product fields that can be filtered on.
+var ProductSchema = Schema{
+	"name":        {Name: "name", Type: TypeString},
+	"description": {Name: "description", Type: TypeString},
+	"sku":         {Name: "sku", Type: TypeString},
+	"price":       {Name: "price", Type: TypeNumber},
+	"inventory":   {Name: "inventory", Type: TypeInteger},
+	"categories":  {Name: "categories", Type: TypeStringArray},
+	"created_at":  {Name: "created_at", Type: TypeTime},
+	"updated_at":  {Name: "updated_at", Type: TypeTime},
+	"deleted_at":  {Name: "deleted_at", Type: TypeTime},
+	"status":      {Name: "status", Type: TypeString},
+}
+
+// dateLayouts are the accepted formats for time literals.
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
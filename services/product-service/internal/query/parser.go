@@ -0,0 +1,368 @@
+// internal/query/parser.go
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits protecting the parser and the database from oversized expressions.
+const (
+	MaxLength      = 2000
+	MaxComparisons = 50
+	MaxDepth       = 10
+	MaxInValues    = 100
+	// MaxNumber bounds number literals so that a price stays within int64
+	// once converted to the minor unit of any currency, which has at most
+	// three decimal digits.
+	MaxNumber = math.MaxInt64 / 1000
+)
+
+// SyntaxError reports where and why an expression could not be parsed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokComma
+	tokString
+	tokWord
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '\'':
+			// Single-quoted string; a doubled quote is an escaped quote
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(input) {
+					return nil, &SyntaxError{Pos: start, Msg: "unterminated string"}
+				}
+				if input[i] == '\'' {
+					if i+1 < len(input) && input[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(input[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		default:
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\n\r(),'", rune(input[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, input[start:i], start})
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(input)}), nil
+}
+
+type parser struct {
+	schema      Schema
+	tokens      []token
+	pos         int
+	depth       int
+	comparisons int
+}
+
+// Parse parses a filter expression such as
+//
+//	price ge 10 and inventory gt 0 and categories any ('shoes','sale')
+//
+// and type-checks it against schema. Keywords are case-insensitive.
+func Parse(input string, schema Schema) (Expr, error) {
+	if len(input) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("expression longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{schema: schema, tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokWord && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := []Expr{left}
+	for p.isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return Or{Exprs: exprs}, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := []Expr{left}
+	for p.isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, right)
+	}
+
+	if len(exprs) == 1 {
+		return left, nil
+	}
+	return And{Exprs: exprs}, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.isKeyword(p.peek(), "not") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	if tok.kind == tokLParen {
+		p.next()
+		p.depth++
+		if p.depth > MaxDepth {
+			return nil, p.errorf(tok, "parentheses nested deeper than %d", MaxDepth)
+		}
+
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ')'")
+		}
+		p.depth--
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != tokWord {
+		return nil, p.errorf(fieldTok, "expected field name")
+	}
+
+	field, ok := p.schema[strings.ToLower(fieldTok.text)]
+	if !ok {
+		return nil, p.errorf(fieldTok, "unknown field %q", fieldTok.text)
+	}
+
+	p.comparisons++
+	if p.comparisons > MaxComparisons {
+		return nil, p.errorf(fieldTok, "more than %d comparisons", MaxComparisons)
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokWord {
+		return nil, p.errorf(opTok, "expected operator after %q", field.Name)
+	}
+
+	op := Op(strings.ToLower(opTok.text))
+	switch op {
+	case OpEq, OpNe:
+		value, err := p.parseLiteral(field)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Field: field.Name, Op: op, Value: value}, nil
+
+	case OpLt, OpLe, OpGt, OpGe:
+		if field.Type == TypeStringArray {
+			return nil, p.errorf(opTok, "operator %s is not supported on %s", op, field.Name)
+		}
+		value, err := p.parseLiteral(field)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Field: field.Name, Op: op, Value: value}, nil
+
+	case OpIn, "any":
+		values, err := p.parseList(field)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Field: field.Name, Op: OpIn, Value: values}, nil
+
+	case OpContains:
+		if field.Type != TypeString && field.Type != TypeStringArray {
+			return nil, p.errorf(opTok, "operator contains is only supported on text fields")
+		}
+		value, err := p.parseLiteral(field)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Field: field.Name, Op: OpContains, Value: value}, nil
+
+	case OpExists:
+		return Comparison{Field: field.Name, Op: OpExists}, nil
+
+	default:
+		return nil, p.errorf(opTok, "unknown operator %q", opTok.text)
+	}
+}
+
+func (p *parser) parseList(field Field) ([]interface{}, error) {
+	if tok := p.next(); tok.kind != tokLParen {
+		return nil, p.errorf(tok, "expected '(' to start a value list")
+	}
+
+	var values []interface{}
+	for {
+		value, err := p.parseLiteral(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if len(values) > MaxInValues {
+			return nil, p.errorf(p.peek(), "more than %d values in list", MaxInValues)
+		}
+
+		tok := p.next()
+		if tok.kind == tokRParen {
+			return values, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorf(tok, "expected ',' or ')'")
+		}
+	}
+}
+
+// parseLiteral reads one value and converts it to the field's type.
+func (p *parser) parseLiteral(field Field) (interface{}, error) {
+	tok := p.next()
+	if tok.kind != tokString && tok.kind != tokWord {
+		return nil, p.errorf(tok, "expected a value for %s", field.Name)
+	}
+
+	switch field.Type {
+	case TypeString, TypeStringArray:
+		if tok.kind != tokString {
+			return nil, p.errorf(tok, "%s expects a quoted string", field.Name)
+		}
+		return tok.text, nil
+
+	case TypeNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if tok.kind != tokWord || math.IsNaN(f) || (err != nil && !math.IsInf(f, 0)) {
+			return nil, p.errorf(tok, "%s expects a number", field.Name)
+		}
+		if math.IsInf(f, 0) || math.Abs(f) > MaxNumber {
+			return nil, p.errorf(tok, "%s is out of range: at most %d either way", field.Name, int64(MaxNumber))
+		}
+		return f, nil
+
+	case TypeInteger:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if tok.kind != tokWord || err != nil {
+			return nil, p.errorf(tok, "%s expects an integer", field.Name)
+		}
+		return n, nil
+
+	case TypeTime:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, tok.text); err == nil {
+				return t, nil
+			}
+		}
+		return nil, p.errorf(tok, "%s expects a date (YYYY-MM-DD) or RFC 3339 timestamp", field.Name)
+	}
+
+	return nil, p.errorf(tok, "unsupported field type")
+}
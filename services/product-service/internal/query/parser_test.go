@@ -0,0 +1,137 @@
+// internal/query/parser_test.go
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Expr
+	}{
+		{
+			in:   "price ge 10",
+			want: Comparison{Field: "price", Op: OpGe, Value: 10.0},
+		},
+		{
+			in:   "inventory gt 0 and name contains 'shoe'",
+			want: And{Exprs: []Expr{Comparison{Field: "inventory", Op: OpGt, Value: int64(0)}, Comparison{Field: "name", Op: OpContains, Value: "shoe"}}},
+		},
+		{
+			in: "sku eq 'A' or sku eq 'B' and price lt 5",
+			want: Or{Exprs: []Expr{
+				Comparison{Field: "sku", Op: OpEq, Value: "A"},
+				And{Exprs: []Expr{Comparison{Field: "sku", Op: OpEq, Value: "B"}, Comparison{Field: "price", Op: OpLt, Value: 5.0}}},
+			}},
+		},
+		{
+			in: "(sku eq 'A' or sku eq 'B') and price lt 5",
+			want: And{Exprs: []Expr{
+				Or{Exprs: []Expr{Comparison{Field: "sku", Op: OpEq, Value: "A"}, Comparison{Field: "sku", Op: OpEq, Value: "B"}}},
+				Comparison{Field: "price", Op: OpLt, Value: 5.0},
+			}},
+		},
+		{
+			in:   "NOT Description EXISTS",
+			want: Not{Expr: Comparison{Field: "description", Op: OpExists}},
+		},
+		{
+			in:   "categories any ('shoes', 'sale')",
+			want: Comparison{Field: "categories", Op: OpIn, Value: []interface{}{"shoes", "sale"}},
+		},
+		{
+			in:   "name eq 'Kid''s shoe'",
+			want: Comparison{Field: "name", Op: OpEq, Value: "Kid's shoe"},
+		},
+		{
+			in:   "created_at ge 2026-01-02",
+			want: Comparison{Field: "created_at", Op: OpGe, Value: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in, ProductSchema)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	nested := strings.Repeat("(", MaxDepth+1) + "price gt 1" + strings.Repeat(")", MaxDepth+1)
+	many := strings.TrimSuffix(strings.Repeat("price gt 1 and ", MaxComparisons+1), " and ")
+	values := "sku in ('A'" + strings.Repeat(",'A'", MaxInValues) + ")"
+
+	tests := []struct {
+		name    string
+		in      string
+		wantPos int
+		wantMsg string
+	}{
+		{"empty", "", 0, "expected field name"},
+		{"unknown field", "colour eq 'red'", 0, "unknown field"},
+		{"unknown operator", "price like 1", 6, "unknown operator"},
+		{"missing operator", "price", 5, "expected operator"},
+		{"unquoted string", "name eq shoe", 8, "quoted string"},
+		{"quoted number", "price eq '10'", 9, "expects a number"},
+		{"not a number", "price lt NaN", 9, "expects a number"},
+		{"infinity", "price lt +Inf", 9, "out of range"},
+		{"overflowing number", "price gt 1e400", 9, "out of range"},
+		{"number beyond the minor units", "price gt -9300000000000000", 9, "out of range"},
+		{"fractional integer", "inventory eq 1.5", 13, "expects an integer"},
+		{"bad date", "created_at gt yesterday", 14, "expects a date"},
+		{"ordering on array", "categories gt 'a'", 11, "not supported"},
+		{"contains on number", "price contains 1", 6, "only supported on text"},
+		{"unterminated string", "name eq 'shoe", 8, "unterminated string"},
+		{"unclosed parenthesis", "(price gt 1", 11, "expected ')'"},
+		{"trailing input", "price gt 1 price", 11, "unexpected"},
+		{"unclosed list", "sku in ('A' 'B')", 12, "expected ',' or ')'"},
+		{"too deep", nested, MaxDepth, "nested deeper"},
+		{"too many comparisons", many, 750, "more than"},
+		{"too many values", values, len(values) - 1, "more than"},
+		{"too long", strings.Repeat(" ", MaxLength+1), MaxLength, "longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.in, ProductSchema)
+			var serr *SyntaxError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Parse() error = %v, want a SyntaxError", err)
+			}
+			if serr.Pos != tt.wantPos || !strings.Contains(serr.Msg, tt.wantMsg) {
+				t.Errorf("Parse() error = %v, want %q at position %d", err, tt.wantMsg, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestMapValues(t *testing.T) {
+	expr, err := Parse("price lt 10 or not (price in (1, 2.5) and inventory gt 3) or price exists", ProductSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cents := func(v interface{}) interface{} { return int64(v.(float64) * 100) }
+	want := Or{Exprs: []Expr{
+		Comparison{Field: "price", Op: OpLt, Value: int64(1000)},
+		Not{Expr: And{Exprs: []Expr{
+			Comparison{Field: "price", Op: OpIn, Value: []interface{}{int64(100), int64(250)}},
+			Comparison{Field: "inventory", Op: OpGt, Value: int64(3)},
+		}}},
+		Comparison{Field: "price", Op: OpExists},
+	}}
+
+	if got := MapValues(expr, "price", cents); !reflect.DeepEqual(got, want) {
+		t.Errorf("MapValues() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+// internal/repository/attribute_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type AttributeRepository interface {
+	// FindAll returns every attribute definition ordered by name
+	FindAll(ctx context.Context) ([]domain.AttributeDefinition, error)
+	FindByName(ctx context.Context, name string) (*domain.AttributeDefinition, error)
+	// Create fails with domain.ErrAttributeExists if the name is taken
+	Create(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error)
+	// Update replaces a definition. It returns nil if it does not exist.
+	Update(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error)
+	// Delete fails with domain.ErrAttributeNotFound if it does not exist
+	Delete(ctx context.Context, name string) error
+}
+
+type mongoAttributeRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewAttributeRepository(client *mongo.Client, database string) AttributeRepository {
+	return &mongoAttributeRepository{
+		client:     client,
+		database:   database,
+		collection: "attribute_definitions",
+	}
+}
+
+func (r *mongoAttributeRepository) FindAll(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	cursor, err := coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	defs := []domain.AttributeDefinition{}
+	if err = cursor.All(ctx, &defs); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+func (r *mongoAttributeRepository) FindByName(ctx context.Context, name string) (*domain.AttributeDefinition, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	var def domain.AttributeDefinition
+	err := coll.FindOne(ctx, bson.M{"_id": name}).Decode(&def)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+func (r *mongoAttributeRepository) Create(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	def.CreatedAt = time.Now()
+	def.UpdatedAt = def.CreatedAt
+	if def.Categories == nil {
+		def.Categories = []string{}
+	}
+
+	if _, err := coll.InsertOne(ctx, def); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrAttributeExists
+		}
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+func (r *mongoAttributeRepository) Update(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	if def.Categories == nil {
+		def.Categories = []string{}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"label":      def.Label,
+			"type":       def.Type,
+			"unit":       def.Unit,
+			"values":     def.Values,
+			"required":   def.Required,
+			"categories": def.Categories,
+			"updated_at": time.Now(),
+		},
+	}
+
+	var updated domain.AttributeDefinition
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": def.Name}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *mongoAttributeRepository) Delete(ctx context.Context, name string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrAttributeNotFound
+	}
+
+	return nil
+}
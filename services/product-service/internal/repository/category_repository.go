@@ -0,0 +1,178 @@
+// internal/repository/category_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type CategoryRepository interface {
+	// FindAll returns every category ordered by path
+	FindAll(ctx context.Context) ([]domain.Category, error)
+	FindByID(ctx context.Context, id string) (*domain.Category, error)
+	// FindByIDs and FindBySlugs return the categories found, in no
+	// particular order
+	FindByIDs(ctx context.Context, ids []domain.ID) ([]domain.Category, error)
+	FindBySlugs(ctx context.Context, slugs []string) ([]domain.Category, error)
+	// FindChildren returns the children of parentID, or the roots if it is
+	// empty, ordered by position and name
+	FindChildren(ctx context.Context, parentID domain.ID) ([]domain.Category, error)
+	// FindDescendants returns every category below id
+	FindDescendants(ctx context.Context, id domain.ID) ([]domain.Category, error)
+	// Create fails with domain.ErrCategoryExists if the slug is taken
+	Create(ctx context.Context, category domain.Category) (*domain.Category, error)
+	// Update writes the name, slug, position and placement of categories,
+	// such as a renamed category and its subtree, in order. It fails with
+	// domain.ErrCategoryExists if a slug is taken.
+	Update(ctx context.Context, categories []domain.Category) error
+	// Delete fails with domain.ErrCategoryNotFound if it does not exist
+	Delete(ctx context.Context, id domain.ID) error
+}
+
+type mongoCategoryRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	ids        domain.IDGenerator
+}
+
+func NewCategoryRepository(client *mongo.Client, database string, ids domain.IDGenerator) CategoryRepository {
+	return &mongoCategoryRepository{
+		client:     client,
+		database:   database,
+		collection: "categories",
+		ids:        ids,
+	}
+}
+
+func (r *mongoCategoryRepository) FindAll(ctx context.Context) ([]domain.Category, error) {
+	return r.find(ctx, bson.M{}, bson.D{{Key: "path", Value: 1}})
+}
+
+func (r *mongoCategoryRepository) FindByID(ctx context.Context, id string) (*domain.Category, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	categoryID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var category domain.Category
+	err = coll.FindOne(ctx, bson.M{"_id": categoryID}).Decode(&category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *mongoCategoryRepository) FindByIDs(ctx context.Context, ids []domain.ID) ([]domain.Category, error) {
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}}, nil)
+}
+
+func (r *mongoCategoryRepository) FindBySlugs(ctx context.Context, slugs []string) ([]domain.Category, error) {
+	return r.find(ctx, bson.M{"slug": bson.M{"$in": slugs}}, nil)
+}
+
+func (r *mongoCategoryRepository) FindChildren(ctx context.Context, parentID domain.ID) ([]domain.Category, error) {
+	return r.find(ctx, bson.M{"parent_id": parentID}, bson.D{{Key: "position", Value: 1}, {Key: "name", Value: 1}})
+}
+
+func (r *mongoCategoryRepository) FindDescendants(ctx context.Context, id domain.ID) ([]domain.Category, error) {
+	return r.find(ctx, bson.M{"ancestors": id}, bson.D{{Key: "path", Value: 1}})
+}
+
+func (r *mongoCategoryRepository) find(ctx context.Context, filter bson.M, sort bson.D) ([]domain.Category, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	opts := options.Find()
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []domain.Category{}
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *mongoCategoryRepository) Create(ctx context.Context, category domain.Category) (*domain.Category, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	category.ID = r.ids.NewID()
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = category.CreatedAt
+
+	if _, err := coll.InsertOne(ctx, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrCategoryExists
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *mongoCategoryRepository) Update(ctx context.Context, categories []domain.Category) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	if len(categories) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(categories))
+	for _, c := range categories {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": c.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"name":       c.Name,
+				"slug":       c.Slug,
+				"parent_id":  c.ParentID,
+				"path":       c.Path,
+				"ancestors":  c.Ancestors,
+				"position":   c.Position,
+				"updated_at": now,
+			}}))
+	}
+
+	// Ordered, so nothing after a rejected slug is written
+	_, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrCategoryExists
+	}
+	return err
+}
+
+func (r *mongoCategoryRepository) Delete(ctx context.Context, id domain.ID) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrCategoryNotFound
+	}
+
+	return nil
+}
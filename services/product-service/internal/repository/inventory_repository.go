@@ -0,0 +1,137 @@
+// internal/repository/inventory_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// InventoryRepository is the append-only ledger of stock movements. The
+// stock itself is kept on the products.
+type InventoryRepository interface {
+	// Record appends movements to the ledger, assigning their IDs
+	Record(ctx context.Context, movements []domain.InventoryMovement) error
+	// FindByProduct returns the movements of a product matching query, most
+	// recent first
+	FindByProduct(ctx context.Context, productID domain.ID, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error)
+	// Totals sums the movements of a product by SKU and reason
+	Totals(ctx context.Context, productID domain.ID) ([]domain.InventoryTotal, error)
+}
+
+type mongoInventoryRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	ids        domain.IDGenerator
+}
+
+func NewInventoryRepository(client *mongo.Client, database string, ids domain.IDGenerator) InventoryRepository {
+	return &mongoInventoryRepository{
+		client:     client,
+		database:   database,
+		collection: "inventory_movements",
+		ids:        ids,
+	}
+}
+
+func (r *mongoInventoryRepository) Record(ctx context.Context, movements []domain.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	docs := make([]interface{}, 0, len(movements))
+	for _, movement := range movements {
+		movement.ID = r.ids.NewID()
+		docs = append(docs, movement)
+	}
+
+	_, err := coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoInventoryRepository) FindByProduct(ctx context.Context, productID domain.ID, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"product_id": productID}
+	if query.SKU != "" {
+		filter["sku"] = query.SKU
+	}
+	if query.Reason != "" {
+		filter["reason"] = query.Reason
+	}
+	if query.Location != "" {
+		filter["location_id"] = query.Location
+	}
+	if query.Reference != "" {
+		filter["reference"] = query.Reference
+	}
+	if query.From != nil || query.To != nil {
+		createdAt := bson.M{}
+		if query.From != nil {
+			createdAt["$gte"] = *query.From
+		}
+		if query.To != nil {
+			createdAt["$lte"] = *query.To
+		}
+		filter["created_at"] = createdAt
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	movements := []domain.InventoryMovement{}
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+func (r *mongoInventoryRepository) Totals(ctx context.Context, productID domain.ID) ([]domain.InventoryTotal, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"sku": "$sku", "reason": "$reason"},
+			"quantity": bson.M{"$sum": "$delta"},
+			"count":    bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"sku":      "$_id.sku",
+			"reason":   "$_id.reason",
+			"quantity": 1,
+			"count":    1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "sku", Value: 1}, {Key: "reason", Value: 1}}}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	totals := []domain.InventoryTotal{}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
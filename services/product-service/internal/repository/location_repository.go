@@ -0,0 +1,133 @@
+// internal/repository/location_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type LocationRepository interface {
+	// FindAll returns every stock location, the default first and the
+	// others by priority, then ID
+	FindAll(ctx context.Context) ([]domain.Location, error)
+	FindByID(ctx context.Context, id string) (*domain.Location, error)
+	// Create fails with domain.ErrLocationExists if the ID is taken
+	Create(ctx context.Context, location domain.Location) (*domain.Location, error)
+	// Update replaces the name, type, priority, position and disabled flag
+	// of a location. It returns nil if it does not exist.
+	Update(ctx context.Context, location domain.Location) (*domain.Location, error)
+	// Delete fails with domain.ErrLocationNotFound if it does not exist
+	Delete(ctx context.Context, id string) error
+}
+
+type mongoLocationRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewLocationRepository(client *mongo.Client, database string) LocationRepository {
+	return &mongoLocationRepository{
+		client:     client,
+		database:   database,
+		collection: "stock_locations",
+	}
+}
+
+func (r *mongoLocationRepository) FindAll(ctx context.Context) ([]domain.Location, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "default", Value: -1}, {Key: "priority", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	locations := []domain.Location{}
+	if err = cursor.All(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+func (r *mongoLocationRepository) FindByID(ctx context.Context, id string) (*domain.Location, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	var location domain.Location
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&location)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func (r *mongoLocationRepository) Create(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	location.CreatedAt = time.Now()
+	location.UpdatedAt = location.CreatedAt
+
+	if _, err := coll.InsertOne(ctx, location); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrLocationExists
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func (r *mongoLocationRepository) Update(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":       location.Name,
+			"type":       location.Type,
+			"priority":   location.Priority,
+			"position":   location.Position,
+			"disabled":   location.Disabled,
+			"updated_at": time.Now(),
+		},
+	}
+
+	var updated domain.Location
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": location.ID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *mongoLocationRepository) Delete(ctx context.Context, id string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrLocationNotFound
+	}
+
+	return nil
+}
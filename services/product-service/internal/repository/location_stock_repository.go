@@ -0,0 +1,168 @@
+// internal/repository/location_stock_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// LocationStockRepository keeps the stock of each SKU at each location.
+// The totals over every location are kept on the products.
+type LocationStockRepository interface {
+	// Adjust applies change to the stock of a SKU at a location, which
+	// starts with none. It fails with domain.ErrInsufficientInventory if
+	// the stock available or reserved there would drop below zero.
+	Adjust(ctx context.Context, productID domain.ID, sku, locationID string, change domain.StockChange) (*domain.LocationStock, error)
+	// FindByProducts returns the stock of products at every location they
+	// keep stock at, by product, SKU and location
+	FindByProducts(ctx context.Context, productIDs []domain.ID) ([]domain.LocationStock, error)
+	// ProductsWithStock returns the products with stock available at a
+	// location
+	ProductsWithStock(ctx context.Context, locationID string) ([]domain.ID, error)
+	// HasStock reports whether any stock is on hand or reserved at a
+	// location
+	HasStock(ctx context.Context, locationID string) (bool, error)
+	// Remove forgets the stock of SKUs a product no longer keeps stock
+	// under
+	Remove(ctx context.Context, productID domain.ID, skus []string) error
+	// RemoveProducts forgets the stock of purged products
+	RemoveProducts(ctx context.Context, productIDs []domain.ID) error
+}
+
+type mongoLocationStockRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewLocationStockRepository(client *mongo.Client, database string) LocationStockRepository {
+	return &mongoLocationStockRepository{
+		client:     client,
+		database:   database,
+		collection: "location_stock",
+	}
+}
+
+func (r *mongoLocationStockRepository) Adjust(ctx context.Context, productID domain.ID, sku, locationID string, change domain.StockChange) (*domain.LocationStock, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	// As for products, the guards are part of the filter. Only stock that
+	// grows may start a level, so a level never starts out negative.
+	filter := bson.M{"product_id": productID, "sku": sku, "location_id": locationID}
+	guarded := false
+	if available := change.Available(); available < 0 {
+		filter["available"] = bson.M{"$gte": -available}
+		guarded = true
+	}
+	if change.Reserved < 0 {
+		filter["reserved"] = bson.M{"$gte": -change.Reserved}
+		guarded = true
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"on_hand": change.OnHand, "reserved": change.Reserved, "available": change.Available()},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(!guarded)
+
+	var level domain.LocationStock
+	if err := coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&level); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrInsufficientInventory
+		}
+		return nil, err
+	}
+
+	return &level, nil
+}
+
+func (r *mongoLocationStockRepository) FindByProducts(ctx context.Context, productIDs []domain.ID) ([]domain.LocationStock, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "product_id", Value: 1}, {Key: "sku", Value: 1}, {Key: "location_id", Value: 1}})
+	cursor, err := coll.Find(ctx, bson.M{"product_id": bson.M{"$in": productIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	levels := []domain.LocationStock{}
+	if err = cursor.All(ctx, &levels); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+func (r *mongoLocationStockRepository) ProductsWithStock(ctx context.Context, locationID string) ([]domain.ID, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	cursor, err := coll.Find(ctx, bson.M{"location_id": locationID, "available": bson.M{"$gt": 0}},
+		options.Find().SetProjection(bson.M{"_id": 0, "product_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Products with variants have a level per variant
+	ids := []domain.ID{}
+	seen := map[domain.ID]bool{}
+	for cursor.Next(ctx) {
+		var level struct {
+			ProductID domain.ID `bson:"product_id"`
+		}
+		if err := cursor.Decode(&level); err != nil {
+			return nil, err
+		}
+		if !seen[level.ProductID] {
+			seen[level.ProductID] = true
+			ids = append(ids, level.ProductID)
+		}
+	}
+
+	return ids, cursor.Err()
+}
+
+func (r *mongoLocationStockRepository) HasStock(ctx context.Context, locationID string) (bool, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"location_id": locationID, "$or": bson.A{
+		bson.M{"on_hand": bson.M{"$ne": 0}},
+		bson.M{"reserved": bson.M{"$ne": 0}},
+	}}
+	n, err := coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *mongoLocationStockRepository) Remove(ctx context.Context, productID domain.ID, skus []string) error {
+	if len(skus) == 0 {
+		return nil
+	}
+
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	_, err := coll.DeleteMany(ctx, bson.M{"product_id": productID, "sku": bson.M{"$in": skus}})
+	return err
+}
+
+func (r *mongoLocationStockRepository) RemoveProducts(ctx context.Context, productIDs []domain.ID) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	_, err := coll.DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+	return err
+}
@@ -0,0 +1,1069 @@
+// internal/repository/mongo_migrations.go
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/migration"
+)
+
+const (
+	migrationsCollection    = "schema_migrations"
+	migrationLockCollection = "schema_migrations_lock"
+
+	// migrationLockTTL is how long the lock outlives a replica that died
+	// holding it; the holder renews it well before then.
+	migrationLockTTL = time.Minute
+	// migrationLockPoll is how often a waiting replica retries the lock.
+	migrationLockPoll = time.Second
+)
+
+type mongoMigrationStore struct {
+	client   *mongo.Client
+	database string
+}
+
+// NewMongoMigrationStore records applied migrations in the schema_migrations collection.
+func NewMongoMigrationStore(client *mongo.Client, database string) migration.Store {
+	return &mongoMigrationStore{
+		client:   client,
+		database: database,
+	}
+}
+
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Lock leases a lock document, retrying while another replica holds an
+// unexpired lease, and renews the lease until it is released.
+func (s *mongoMigrationStore) Lock(ctx context.Context) (func(), error) {
+	coll := s.client.Database(s.database).Collection(migrationLockCollection)
+	owner := primitive.NewObjectID().Hex()
+
+	for {
+		now := time.Now()
+		// The upsert of a held lock fails on the _id of the existing document
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": "migrations", "expires_at": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+
+	renewCtx, stop := context.WithCancel(context.Background())
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(migrationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				// A failed renewal is retried on the next tick, well before
+				// the lease runs out
+				_, _ = coll.UpdateOne(renewCtx,
+					bson.M{"_id": "migrations", "owner": owner},
+					bson.M{"$set": bson.M{"expires_at": time.Now().Add(migrationLockTTL)}},
+				)
+			}
+		}
+	}()
+
+	return func() {
+		stop()
+		<-renewed
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// Left behind, the lease expires on its own
+		_, _ = coll.DeleteOne(ctx, bson.M{"_id": "migrations", "owner": owner})
+	}, nil
+}
+
+func (s *mongoMigrationStore) Applied(ctx context.Context) ([]migration.Record, error) {
+	coll := s.client.Database(s.database).Collection(migrationsCollection)
+
+	cursor, err := coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []migrationRecord
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	records := make([]migration.Record, 0, len(docs))
+	for _, doc := range docs {
+		records = append(records, migration.Record{Version: doc.Version, AppliedAt: doc.AppliedAt})
+	}
+
+	return records, nil
+}
+
+func (s *mongoMigrationStore) Insert(ctx context.Context, m migration.Migration) error {
+	coll := s.client.Database(s.database).Collection(migrationsCollection)
+
+	_, err := coll.InsertOne(ctx, migrationRecord{
+		Version:     m.Version,
+		Description: m.Description,
+		AppliedAt:   time.Now(),
+	})
+	return err
+}
+
+func (s *mongoMigrationStore) Remove(ctx context.Context, version int) error {
+	coll := s.client.Database(s.database).Collection(migrationsCollection)
+
+	_, err := coll.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+// MongoMigrations returns the schema history of the products collection.
+// ids generates the IDs of records created by data migrations.
+func MongoMigrations(client *mongo.Client, database string, ids domain.IDGenerator) []migration.Migration {
+	db := client.Database(database)
+	products := db.Collection("products")
+	schedules := db.Collection("product_schedules")
+	categories := db.Collection("categories")
+	priceLists := db.Collection("price_lists")
+	priceHistory := db.Collection("price_history")
+	promotions := db.Collection("promotions")
+	inventoryMovements := db.Collection("inventory_movements")
+	reservations := db.Collection("reservations")
+	processedEvents := db.Collection("processed_events")
+	locations := db.Collection("stock_locations")
+	locationStock := db.Collection("location_stock")
+
+	return []migration.Migration{
+		{
+			Version:     1,
+			Description: "products $jsonSchema validator",
+			Up: func(ctx context.Context) error {
+				return setValidator(ctx, db, "products", productSchemaV1)
+			},
+			Down: func(ctx context.Context) error {
+				return setValidator(ctx, db, "products", bson.M{})
+			},
+		},
+		{
+			Version:     2,
+			Description: "unique SKU index",
+			Up: func(ctx context.Context) error {
+				if err := checkDuplicateSKUs(ctx, products); err != nil {
+					return err
+				}
+				_, err := products.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "sku", Value: 1}},
+					Options: options.Index().SetName("sku_unique").SetUnique(true),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				_, err := products.Indexes().DropOne(ctx, "sku_unique")
+				return err
+			},
+		},
+		{
+			Version:     3,
+			Description: "query indexes for categories+price, sortable fields and text search",
+			Up: func(ctx context.Context) error {
+				_, err := products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "categories", Value: 1}, {Key: "price", Value: 1}},
+						Options: options.Index().SetName("categories_price"),
+					},
+					{
+						Keys:    bson.D{{Key: "created_at", Value: -1}},
+						Options: options.Index().SetName("created_at"),
+					},
+					{
+						Keys:    bson.D{{Key: "price", Value: 1}},
+						Options: options.Index().SetName("price"),
+					},
+					{
+						Keys:    bson.D{{Key: "name", Value: 1}},
+						Options: options.Index().SetName("name"),
+					},
+					{
+						Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+						Options: options.Index().SetName("text_search").SetWeights(bson.M{"name": 10, "description": 1}),
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, products, "categories_price", "created_at", "price", "name", "text_search")
+			},
+		},
+		{
+			Version:     4,
+			Description: "normalize SKUs to trimmed upper case",
+			Up: func(ctx context.Context) error {
+				// The unique index of migration 2 would reject the first of
+				// them with a duplicate key and leave the rest half updated
+				if err := checkSKUCollisions(ctx, products); err != nil {
+					return err
+				}
+				_, err := products.UpdateMany(ctx, bson.M{}, mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{"sku": bson.M{"$toUpper": bson.M{"$trim": bson.M{"input": "$sku"}}}}}},
+				})
+				return err
+			},
+			// The original spelling is not kept, so there is nothing to restore
+			Down: func(ctx context.Context) error { return nil },
+		},
+		{
+			Version:     5,
+			Description: "indexes for the remaining sortable fields",
+			Up: func(ctx context.Context) error {
+				_, err := products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "inventory", Value: 1}},
+						Options: options.Index().SetName("inventory"),
+					},
+					{
+						Keys:    bson.D{{Key: "updated_at", Value: -1}},
+						Options: options.Index().SetName("updated_at"),
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, products, "inventory", "updated_at")
+			},
+		},
+		{
+			Version:     6,
+			Description: "text index over name, SKU, categories and description",
+			Up: func(ctx context.Context) error {
+				return replaceIndex(ctx, products, "text_search", mongo.IndexModel{
+					Keys: bson.D{
+						{Key: "name", Value: "text"},
+						{Key: "sku", Value: "text"},
+						{Key: "categories", Value: "text"},
+						{Key: "description", Value: "text"},
+					},
+					Options: options.Index().SetName("text_search").
+						SetWeights(bson.M{"name": 10, "sku": 8, "categories": 5, "description": 1}),
+				})
+			},
+			Down: func(ctx context.Context) error {
+				return replaceIndex(ctx, products, "text_search", mongo.IndexModel{
+					Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+					Options: options.Index().SetName("text_search").SetWeights(bson.M{"name": 10, "description": 1}),
+				})
+			},
+		},
+		{
+			Version:     7,
+			Description: "version field for optimistic concurrency control",
+			Up: func(ctx context.Context) error {
+				_, err := products.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": int64(1)}})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				_, err := products.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"version": ""}})
+				return err
+			},
+		},
+		{
+			Version:     8,
+			Description: "sparse deleted_at index for purging the trash",
+			Up: func(ctx context.Context) error {
+				_, err := products.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+					Options: options.Index().SetName("deleted_at").SetSparse(true),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, products, "deleted_at")
+			},
+		},
+		{
+			// Products created before the lifecycle existed were already live
+			Version:     9,
+			Description: "lifecycle status, published for existing products",
+			Up: func(ctx context.Context) error {
+				_, err := products.UpdateMany(ctx, bson.M{"status": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"status": "published"}})
+				if err != nil {
+					return err
+				}
+				_, err = products.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "status", Value: 1}},
+					Options: options.Index().SetName("status"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				if err := dropIndexes(ctx, products, "status"); err != nil {
+					return err
+				}
+				_, err := products.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"status": "", "status_changed_at": "", "status_changed_by": ""}})
+				return err
+			},
+		},
+		{
+			Version:     10,
+			Description: "product_schedules indexes for due changes and per-product listings",
+			Up: func(ctx context.Context) error {
+				_, err := schedules.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "state", Value: 1}, {Key: "run_at", Value: 1}},
+						Options: options.Index().SetName("state_run_at"),
+					},
+					{
+						Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "run_at", Value: 1}},
+						Options: options.Index().SetName("product_id_run_at"),
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return schedules.Drop(ctx)
+			},
+		},
+		{
+			Version:     11,
+			Description: "unique variant SKUs and an index for variant attribute filters",
+			Up: func(ctx context.Context) error {
+				_, err := products.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						// Uniqueness holds across products; the service also
+						// keeps variant SKUs apart from product SKUs
+						Keys: bson.D{{Key: "variants.sku", Value: 1}},
+						Options: options.Index().SetName("variants_sku_unique").SetUnique(true).
+							SetPartialFilterExpression(bson.M{"variants.sku": bson.M{"$exists": true}}),
+					},
+					{
+						Keys:    bson.D{{Key: "variants.attributes.$**", Value: 1}},
+						Options: options.Index().SetName("variants_attributes"),
+					},
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, products, "variants_sku_unique", "variants_attributes")
+			},
+		},
+		{
+			Version:     12,
+			Description: "index for custom attribute filters and facets",
+			Up: func(ctx context.Context) error {
+				_, err := products.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "attributes.$**", Value: 1}},
+					Options: options.Index().SetName("attributes"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndexes(ctx, products, "attributes")
+			},
+		},
+		{
+			Version:     13,
+			Description: "category taxonomy, seeded with the categories already on products",
+			Up: func(ctx context.Context) error {
+				_, err := categories.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "slug", Value: 1}},
+						Options: options.Index().SetName("slug_unique").SetUnique(true),
+					},
+					{
+						Keys:    bson.D{{Key: "parent_id", Value: 1}, {Key: "position", Value: 1}, {Key: "name", Value: 1}},
+						Options: options.Index().SetName("parent_id_position"),
+					},
+					{
+						Keys:    bson.D{{Key: "ancestors", Value: 1}},
+						Options: options.Index().SetName("ancestors"),
+					},
+				})
+				if err != nil {
+					return err
+				}
+				_, err = products.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "category_ids", Value: 1}},
+					Options: options.Index().SetName("category_ids"),
+				})
+				if err != nil {
+					return err
+				}
+				return seedCategories(ctx, products, categories, ids)
+			},
+			Down: func(ctx context.Context) error {
+				if err := dropIndexes(ctx, products, "category_ids"); err != nil {
+					return err
+				}
+				if _, err := products.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"category_ids": ""}}); err != nil {
+					return err
+				}
+				return categories.Drop(ctx)
+			},
+		},
+		{
+			// Stored prices had no currency; they were all in LegacyCurrency
+			Version:     14,
+			Description: "prices in minor units with a currency, and the default price list",
+			Up: func(ctx context.Context) error {
+				// The new validator first, or it would reject the new prices
+				if err := setValidator(ctx, db, "products", productSchemaV2); err != nil {
+					return err
+				}
+				if err := convertPrices(ctx, products, schedules, moneyFromLegacy); err != nil {
+					return err
+				}
+				if err := replaceIndex(ctx, products, "price", mongo.IndexModel{
+					Keys:    bson.D{{Key: "price.amount", Value: 1}},
+					Options: options.Index().SetName("price"),
+				}); err != nil {
+					return err
+				}
+				if err := replaceIndex(ctx, products, "categories_price", mongo.IndexModel{
+					Keys:    bson.D{{Key: "categories", Value: 1}, {Key: "price.amount", Value: 1}},
+					Options: options.Index().SetName("categories_price"),
+				}); err != nil {
+					return err
+				}
+
+				_, err := priceLists.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "default", Value: 1}},
+					Options: options.Index().SetName("default_unique").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"default": true}),
+				})
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				return seedOnce(ctx, priceLists, domain.DefaultPriceListID, domain.PriceList{
+					ID:        domain.DefaultPriceListID,
+					Name:      "Default",
+					Currency:  domain.LegacyCurrency,
+					Default:   true,
+					CreatedAt: now,
+					UpdatedAt: now,
+				})
+			},
+			Down: func(ctx context.Context) error {
+				// The old validator first, or it would reject the old prices
+				if err := setValidator(ctx, db, "products", productSchemaV1); err != nil {
+					return err
+				}
+				if err := priceLists.Drop(ctx); err != nil {
+					return err
+				}
+				if _, err := products.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"prices": "", "variants.$[].prices": ""}}); err != nil {
+					return err
+				}
+				if err := convertPrices(ctx, products, schedules, legacyFromMoney); err != nil {
+					return err
+				}
+				if err := replaceIndex(ctx, products, "price", mongo.IndexModel{
+					Keys:    bson.D{{Key: "price", Value: 1}},
+					Options: options.Index().SetName("price"),
+				}); err != nil {
+					return err
+				}
+				return replaceIndex(ctx, products, "categories_price", mongo.IndexModel{
+					Keys:    bson.D{{Key: "categories", Value: 1}, {Key: "price", Value: 1}},
+					Options: options.Index().SetName("categories_price"),
+				})
+			},
+		},
+		{
+			Version:     15,
+			Description: "price_history index for per-product listings by date",
+			Up: func(ctx context.Context) error {
+				_, err := priceHistory.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "changed_at", Value: -1}},
+					Options: options.Index().SetName("product_id_changed_at"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return priceHistory.Drop(ctx)
+			},
+		},
+		{
+			Version:     16,
+			Description: "promotions index for evaluation in priority order",
+			Up: func(ctx context.Context) error {
+				_, err := promotions.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "priority", Value: -1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("priority"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return promotions.Drop(ctx)
+			},
+		},
+		{
+			// Existing stock is recorded as opening receipts so that the
+			// ledger reconciles from the start
+			Version:     17,
+			Description: "inventory_movements ledger with opening stock",
+			Up: func(ctx context.Context) error {
+				_, err := inventoryMovements.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("product_id_created_at"),
+				})
+				if err != nil {
+					return err
+				}
+				return seedOpeningStock(ctx, products, inventoryMovements, ids)
+			},
+			Down: func(ctx context.Context) error {
+				return inventoryMovements.Drop(ctx)
+			},
+		},
+		{
+			// Nothing is reserved yet, so all existing stock is available
+			Version:     18,
+			Description: "reserved and available stock, and reservations index for expiry",
+			Up: func(ctx context.Context) error {
+				variants := bson.M{"$map": bson.M{
+					"input": "$variants",
+					"as":    "v",
+					"in":    bson.M{"$mergeObjects": bson.A{"$$v", bson.M{"reserved": 0, "available": "$$v.inventory"}}},
+				}}
+				_, err := products.UpdateMany(ctx, bson.M{}, mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{"reserved": 0, "available": "$inventory"}}},
+				})
+				if err != nil {
+					return err
+				}
+				_, err = products.UpdateMany(ctx, bson.M{"variants.0": bson.M{"$exists": true}}, mongo.Pipeline{
+					{{Key: "$set", Value: bson.M{"variants": variants}}},
+				})
+				if err != nil {
+					return err
+				}
+
+				_, err = reservations.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "state", Value: 1}, {Key: "expires_at", Value: 1}},
+					Options: options.Index().SetName("state_expires_at"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				if err := reservations.Drop(ctx); err != nil {
+					return err
+				}
+				_, err := products.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{
+					"reserved": "", "available": "", "variants.$[].reserved": "", "variants.$[].available": "",
+				}})
+				return err
+			},
+		},
+		{
+			Version:     19,
+			Description: "processed_events index for pruning",
+			Up: func(ctx context.Context) error {
+				_, err := processedEvents.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "processed_at", Value: 1}},
+					Options: options.Index().SetName("processed_at"),
+				})
+				return err
+			},
+			Down: func(ctx context.Context) error {
+				return processedEvents.Drop(ctx)
+			},
+		},
+		{
+			// The stock kept so far is all at the default location
+			Version:     20,
+			Description: "stock locations, the default location and its stock",
+			Up: func(ctx context.Context) error {
+				_, err := locations.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "default", Value: 1}},
+					Options: options.Index().SetName("default_unique").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"default": true}),
+				})
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				err = seedOnce(ctx, locations, domain.DefaultLocationID, domain.Location{
+					ID:        domain.DefaultLocationID,
+					Name:      "Default",
+					Type:      domain.LocationWarehouse,
+					Default:   true,
+					CreatedAt: now,
+					UpdatedAt: now,
+				})
+				if err != nil {
+					return err
+				}
+
+				_, err = locationStock.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "sku", Value: 1}, {Key: "location_id", Value: 1}},
+						Options: options.Index().SetName("product_sku_location_unique").SetUnique(true),
+					},
+					{
+						Keys:    bson.D{{Key: "location_id", Value: 1}, {Key: "available", Value: 1}},
+						Options: options.Index().SetName("location_available"),
+					},
+				})
+				if err != nil {
+					return err
+				}
+				return seedLocationStock(ctx, products, locationStock)
+			},
+			Down: func(ctx context.Context) error {
+				if err := locationStock.Drop(ctx); err != nil {
+					return err
+				}
+				if err := locations.Drop(ctx); err != nil {
+					return err
+				}
+				_, err := inventoryMovements.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"location_id": ""}})
+				return err
+			},
+		},
+	}
+}
+
+// maxReportedCollisions caps the SKU collisions listed in an error.
+const maxReportedCollisions = 20
+
+// checkDuplicateSKUs fails, listing them, if SKUs are used by more than one
+// product, rather than leaving the unique index to report the first one.
+func checkDuplicateSKUs(ctx context.Context, products *mongo.Collection) error {
+	collisions, err := skuCollisions(ctx, products, "$sku")
+	if err != nil || len(collisions) == 0 {
+		return err
+	}
+
+	listed := make([]string, 0, len(collisions))
+	for _, c := range collisions {
+		listed = append(listed, fmt.Sprintf("%q (%d products)", c.SKUs[0], len(c.SKUs)))
+	}
+	return fmt.Errorf("%d SKUs are used by more than one product, rename them before adding the unique index: %s",
+		len(collisions), reportCollisions(listed))
+}
+
+// checkSKUCollisions fails, listing them, if SKUs differ only in case or
+// surrounding spaces and so would collide once normalized.
+func checkSKUCollisions(ctx context.Context, products *mongo.Collection) error {
+	collisions, err := skuCollisions(ctx, products, bson.M{"$toUpper": bson.M{"$trim": bson.M{"input": "$sku"}}})
+	if err != nil || len(collisions) == 0 {
+		return err
+	}
+
+	listed := make([]string, 0, len(collisions))
+	for _, c := range collisions {
+		listed = append(listed, fmt.Sprintf("%q", c.SKUs))
+	}
+	return fmt.Errorf("%d groups of SKUs differ only in case or surrounding spaces, rename them before normalizing: %s",
+		len(collisions), reportCollisions(listed))
+}
+
+// skuCollision is a group of SKUs with the same key.
+type skuCollision struct {
+	SKUs []string `bson:"skus"`
+}
+
+// skuCollisions groups the SKUs of all products by key, an aggregation
+// expression, and returns the groups of more than one SKU.
+func skuCollisions(ctx context.Context, products *mongo.Collection, key interface{}) ([]skuCollision, error) {
+	cursor, err := products.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   key,
+			"skus":  bson.M{"$push": "$sku"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collisions []skuCollision
+	if err := cursor.All(ctx, &collisions); err != nil {
+		return nil, err
+	}
+	return collisions, nil
+}
+
+// reportCollisions joins the first maxReportedCollisions collisions and
+// counts the rest.
+func reportCollisions(listed []string) string {
+	if more := len(listed) - maxReportedCollisions; more > 0 {
+		listed = append(listed[:maxReportedCollisions:maxReportedCollisions], fmt.Sprintf("and %d more", more))
+	}
+	return strings.Join(listed, ", ")
+}
+
+// seedOnce inserts doc under id unless a document with that id exists, so
+// that a migration retried after a failure does not fail on its own seed.
+func seedOnce(ctx context.Context, coll *mongo.Collection, id string, doc interface{}) error {
+	_, err := coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$setOnInsert": doc}, options.Update().SetUpsert(true))
+	return err
+}
+
+// seedLocationStock keeps the stock of every product, including those in
+// the trash, at the default location. It upserts, so that a run retried
+// after a failure does not seed a product twice.
+func seedLocationStock(ctx context.Context, products, locationStock *mongo.Collection) error {
+	cursor, err := products.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"sku": 1, "inventory": 1, "reserved": 1, "available": 1,
+		"variants.sku": 1, "variants.inventory": 1, "variants.reserved": 1, "variants.available": 1,
+	}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var p domain.Product
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		for _, sku := range p.StockSKUs() {
+			models = append(models, insertOnce(
+				bson.M{"product_id": p.ID, "sku": sku, "location_id": domain.DefaultLocationID},
+				domain.LocationStock{
+					ProductID:  p.ID,
+					SKU:        sku,
+					LocationID: domain.DefaultLocationID,
+					OnHand:     p.Stock(sku),
+					Reserved:   p.Stock(sku) - p.AvailableStock(sku),
+					Available:  p.AvailableStock(sku),
+					UpdatedAt:  now,
+				},
+			))
+		}
+
+		if len(models) >= 1000 {
+			if _, err := locationStock.BulkWrite(ctx, models); err != nil {
+				return err
+			}
+			models = models[:0]
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(models) > 0 {
+		_, err = locationStock.BulkWrite(ctx, models)
+	}
+	return err
+}
+
+// seedOpeningStock records the stock of every product, including those in
+// the trash, as opening receipts in the inventory ledger. It upserts, so
+// that a run retried after a failure records each receipt once.
+func seedOpeningStock(ctx context.Context, products, movements *mongo.Collection, ids domain.IDGenerator) error {
+	cursor, err := products.Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"sku": 1, "inventory": 1, "variants.sku": 1, "variants.inventory": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	var models []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var p domain.Product
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		for _, m := range domain.OpeningMovements(nil, &p) {
+			m.ID = ids.NewID()
+			m.CreatedAt = now
+			models = append(models, insertOnce(
+				bson.M{"product_id": m.ProductID, "sku": m.SKU, "reason": m.Reason, "note": m.Note},
+				m,
+			))
+		}
+
+		if len(models) >= 1000 {
+			if _, err := movements.BulkWrite(ctx, models); err != nil {
+				return err
+			}
+			models = models[:0]
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(models) > 0 {
+		_, err = movements.BulkWrite(ctx, models)
+	}
+	return err
+}
+
+// insertOnce inserts doc unless a document matching filter exists.
+func insertOnce(filter bson.M, doc interface{}) mongo.WriteModel {
+	return mongo.NewUpdateOneModel().
+		SetFilter(filter).
+		SetUpdate(bson.M{"$setOnInsert": doc}).
+		SetUpsert(true)
+}
+
+// priceConversion rewrites a stored price, given as an aggregation field
+// path, and matches the documents whose price still needs it.
+type priceConversion struct {
+	convert func(price string) bson.M
+	match   func(field string) bson.M
+}
+
+// moneyFromLegacy turns a bare decimal price into minor units of
+// LegacyCurrency.
+var moneyFromLegacy = priceConversion{
+	convert: func(price string) bson.M {
+		scale := math.Pow10(domain.CurrencyDigits(domain.LegacyCurrency))
+		return bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{price, scale}}, 0}}},
+			"currency": domain.LegacyCurrency,
+		}
+	},
+	match: func(field string) bson.M {
+		return bson.M{field: bson.M{"$type": "number"}}
+	},
+}
+
+// legacyFromMoney reverses moneyFromLegacy. Amounts are assumed to be in
+// LegacyCurrency, the only currency product prices could have before.
+var legacyFromMoney = priceConversion{
+	convert: func(price string) bson.M {
+		scale := math.Pow10(domain.CurrencyDigits(domain.LegacyCurrency))
+		return bson.M{"$divide": bson.A{price + ".amount", scale}}
+	},
+	match: func(field string) bson.M {
+		return bson.M{field + ".amount": bson.M{"$exists": true}}
+	},
+}
+
+// convertPrices applies c to the prices of products, their variants and
+// scheduled price changes.
+func convertPrices(ctx context.Context, products, schedules *mongo.Collection, c priceConversion) error {
+	_, err := products.UpdateMany(ctx, c.match("price"), mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"price": c.convert("$price")}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Variants without a price of their own are left as they are
+	variants := bson.M{"$map": bson.M{
+		"input": "$variants",
+		"as":    "v",
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$$v.price", nil}},
+			bson.M{"$mergeObjects": bson.A{"$$v", bson.M{"price": c.convert("$$v.price")}}},
+			"$$v",
+		}},
+	}}
+	_, err = products.UpdateMany(ctx, c.match("variants.price"), mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"variants": variants}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = schedules.UpdateMany(ctx, c.match("price"), mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"price": c.convert("$price")}}},
+	})
+	return err
+}
+
+// seedCategories turns the free-form categories of existing products into
+// root categories and assigns the products to them by ID. Categories that
+// only differ in case or punctuation share a slug and are merged.
+func seedCategories(ctx context.Context, products, categories *mongo.Collection, ids domain.IDGenerator) error {
+	names, err := products.Distinct(ctx, "categories", bson.M{})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	bySlug := make(map[string]domain.Category, len(names))
+	docs := make([]interface{}, 0, len(names))
+	for _, n := range names {
+		name, _ := n.(string)
+		slug := domain.Slugify(name)
+		if _, seen := bySlug[slug]; slug == "" || seen {
+			continue
+		}
+		c := domain.Category{
+			ID:        ids.NewID(),
+			Name:      name,
+			Slug:      slug,
+			Path:      slug,
+			Ancestors: []domain.ID{},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		bySlug[slug] = c
+		docs = append(docs, c)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	if _, err := categories.InsertMany(ctx, docs); err != nil {
+		return err
+	}
+
+	cursor, err := products.Find(ctx, bson.M{"categories.0": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"categories": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var models []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var p struct {
+			ID         domain.ID `bson:"_id"`
+			Categories []string  `bson:"categories"`
+		}
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+
+		categoryIDs, slugs := []domain.ID{}, []string{}
+		assigned := make(map[domain.ID]bool, len(p.Categories))
+		for _, name := range p.Categories {
+			c, ok := bySlug[domain.Slugify(name)]
+			if ok && !assigned[c.ID] {
+				assigned[c.ID] = true
+				categoryIDs = append(categoryIDs, c.ID)
+				slugs = append(slugs, c.Slug)
+			}
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": p.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"category_ids": categoryIDs, "categories": slugs}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err = products.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// productSchemaV1 mirrors domain.Product as stored before schema versioning.
+var productSchemaV1 = bson.M{
+	"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"name", "price", "sku", "created_at", "updated_at"},
+		"properties": bson.M{
+			"name":        bson.M{"bsonType": "string", "minLength": 1},
+			"description": bson.M{"bsonType": "string"},
+			"price":       bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}, "minimum": 0},
+			"sku":         bson.M{"bsonType": "string", "minLength": 1},
+			"inventory":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"categories":  bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"created_at":  bson.M{"bsonType": "date"},
+			"updated_at":  bson.M{"bsonType": "date"},
+		},
+	},
+}
+
+// productSchemaV2 stores prices in minor units with a currency.
+var productSchemaV2 = bson.M{
+	"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"name", "price", "sku", "created_at", "updated_at"},
+		"properties": bson.M{
+			"name":        bson.M{"bsonType": "string", "minLength": 1},
+			"description": bson.M{"bsonType": "string"},
+			"price": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"amount", "currency"},
+				"properties": bson.M{
+					"amount":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+					"currency": bson.M{"bsonType": "string", "minLength": 3, "maxLength": 3},
+				},
+			},
+			"prices":     bson.M{"bsonType": bson.A{"object", "null"}},
+			"sku":        bson.M{"bsonType": "string", "minLength": 1},
+			"inventory":  bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"categories": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+			"created_at": bson.M{"bsonType": "date"},
+			"updated_at": bson.M{"bsonType": "date"},
+		},
+	},
+}
+
+// setValidator installs validator on the named collection, creating the
+// collection first if it does not exist yet. Existing documents that violate
+// the schema are left alone (validationLevel moderate).
+func setValidator(ctx context.Context, db *mongo.Database, collection string, validator bson.M) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": collection})
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return db.CreateCollection(ctx, collection, options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("moderate"))
+	}
+
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+}
+
+func dropIndexes(ctx context.Context, coll *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceIndex drops the named index and creates model in its place. A
+// collection only allows one text index, so it cannot be built alongside.
+func replaceIndex(ctx context.Context, coll *mongo.Collection, name string, model mongo.IndexModel) error {
+	if err := dropIndexes(ctx, coll, name); err != nil {
+		return err
+	}
+	_, err := coll.Indexes().CreateOne(ctx, model)
+	return err
+}
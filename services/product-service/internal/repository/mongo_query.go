@@ -0,0 +1,71 @@
+// internal/repository/mongo_query.go
+package repository
+
+import (
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/ntdt/product-service/internal/query"
+)
+
+var mongoComparisonOps = map[query.Op]string{
+	query.OpNe: "$ne",
+	query.OpLt: "$lt",
+	query.OpLe: "$lte",
+	query.OpGt: "$gt",
+	query.OpGe: "$gte",
+	query.OpIn: "$in",
+}
+
+// mongoFilterFields maps the filter fields that are not stored under their
+// own name to document fields.
+var mongoFilterFields = map[string]string{
+	"price": "price.amount",
+}
+
+// mongoFilter translates a parsed filter expression into a MongoDB query.
+// Field names were whitelisted by the parser and match the document fields,
+// unless mapped by mongoFilterFields.
+func mongoFilter(expr query.Expr) bson.M {
+	switch e := expr.(type) {
+	case query.And:
+		return bson.M{"$and": mongoFilters(e.Exprs)}
+	case query.Or:
+		return bson.M{"$or": mongoFilters(e.Exprs)}
+	case query.Not:
+		// $not only applies to operator expressions, $nor negates any filter
+		return bson.M{"$nor": bson.A{mongoFilter(e.Expr)}}
+	case query.Comparison:
+		field := e.Field
+		if mapped, ok := mongoFilterFields[field]; ok {
+			field = mapped
+		}
+
+		switch e.Op {
+		case query.OpEq:
+			return bson.M{field: e.Value}
+		case query.OpContains:
+			if query.ProductSchema[e.Field].Type == query.TypeStringArray {
+				// Array contains an element equal to the value
+				return bson.M{field: e.Value}
+			}
+			pattern := regexp.QuoteMeta(e.Value.(string))
+			return bson.M{field: bson.M{"$regex": primitive.Regex{Pattern: pattern, Options: "i"}}}
+		case query.OpExists:
+			return bson.M{field: bson.M{"$exists": true, "$ne": nil}}
+		default:
+			return bson.M{field: bson.M{mongoComparisonOps[e.Op]: e.Value}}
+		}
+	}
+	return bson.M{}
+}
+
+func mongoFilters(exprs []query.Expr) bson.A {
+	filters := make(bson.A, 0, len(exprs))
+	for _, e := range exprs {
+		filters = append(filters, mongoFilter(e))
+	}
+	return filters
+}
@@ -0,0 +1,158 @@
+// internal/repository/pagination.go
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// pageCursor is the decoded form of a keyset pagination token. It records the
+// sort key and ID of the item the next page starts after (or, when Backward
+// is set, the item the previous page ends before).
+type pageCursor struct {
+	SortBy   string      `json:"s,omitempty"`
+	Desc     bool        `json:"d,omitempty"`
+	Value    interface{} `json:"v,omitempty"`
+	ID       domain.ID   `json:"id"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses token and checks that it was issued for the sort order
+// of filter. Sort values are converted back to their Go types.
+func decodeCursor(token string, filter domain.ProductFilter) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID.IsZero() {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	if c.SortBy != filter.SortBy || c.Desc != (filter.SortOrder == "desc") {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	switch c.SortBy {
+	case "":
+		c.Value = nil
+	case "name", "sku":
+		if _, ok := c.Value.(string); !ok {
+			return nil, domain.ErrInvalidCursor
+		}
+	case "price":
+		// Amounts are in minor units, so a fraction means a tampered cursor
+		f, ok := c.Value.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, domain.ErrInvalidCursor
+		}
+		c.Value = int64(f)
+	case "inventory":
+		f, ok := c.Value.(float64)
+		if !ok {
+			return nil, domain.ErrInvalidCursor
+		}
+		c.Value = int(f)
+	case "created_at", "updated_at":
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, domain.ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		c.Value = t
+	default:
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// sortValue returns the value of the sort field for p. The second result is
+// false for fields that cannot be used for keyset pagination.
+func sortValue(p domain.Product, field string) (interface{}, bool) {
+	switch field {
+	case "":
+		return nil, true
+	case "name":
+		return p.Name, true
+	case "sku":
+		return p.SKU, true
+	case "price":
+		return p.Price.Amount, true
+	case "inventory":
+		return p.Inventory, true
+	case "created_at":
+		return p.CreatedAt, true
+	case "updated_at":
+		return p.UpdatedAt, true
+	default:
+		return nil, false
+	}
+}
+
+// newProductPage assembles a page from items fetched with limit+1 rows so
+// that hasMore can be detected. Items fetched backwards are expected in
+// reverse order and are flipped here.
+func newProductPage(items []domain.Product, total int64, filter domain.ProductFilter, cur *pageCursor) *domain.ProductPage {
+	hasMore := len(items) > filter.Limit
+	if hasMore {
+		items = items[:filter.Limit]
+	}
+
+	backward := cur != nil && cur.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := &domain.ProductPage{
+		Items: items,
+		Total: total,
+	}
+	if page.Items == nil {
+		page.Items = []domain.Product{}
+	}
+
+	if len(items) == 0 {
+		return page
+	}
+
+	cursorFor := func(p domain.Product, backward bool) string {
+		value, ok := sortValue(p, filter.SortBy)
+		if !ok {
+			return ""
+		}
+		return encodeCursor(pageCursor{
+			SortBy:   filter.SortBy,
+			Desc:     filter.SortOrder == "desc",
+			Value:    value,
+			ID:       p.ID,
+			Backward: backward,
+		})
+	}
+
+	// There is a next page if more rows followed, or if we paged backwards
+	if hasMore || backward {
+		page.NextCursor = cursorFor(items[len(items)-1], false)
+	}
+	// There is a previous page if we paged forwards past something
+	if (cur != nil && !backward) || (cur == nil && filter.Offset > 0) || (backward && hasMore) {
+		page.PrevCursor = cursorFor(items[0], true)
+	}
+
+	return page
+}
@@ -0,0 +1,147 @@
+// internal/repository/pagination_test.go
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// pageID returns the nth of a run of ascending ObjectIDs.
+func pageID(n int) domain.ID {
+	return domain.ID(fmt.Sprintf("507f1f77bcf86cd7994390%02x", n))
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2026, 6, 1, 12, 30, 0, 123456789, time.UTC)
+	p := domain.Product{
+		ID:        pageID(1),
+		Name:      "Leather jacket",
+		SKU:       "JKT-001",
+		Price:     domain.Money{Amount: 19999, Currency: "USD"},
+		Inventory: 7,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+
+	tests := []struct {
+		sortBy string
+		want   interface{}
+	}{
+		{"", nil},
+		{"name", "Leather jacket"},
+		{"sku", "JKT-001"},
+		{"price", int64(19999)},
+		{"inventory", 7},
+		{"created_at", created},
+		{"updated_at", created},
+	}
+
+	for _, tt := range tests {
+		for _, desc := range []bool{false, true} {
+			filter := domain.ProductFilter{SortBy: tt.sortBy}
+			if desc {
+				filter.SortOrder = "desc"
+			}
+
+			value, _ := sortValue(p, tt.sortBy)
+			cur, err := decodeCursor(encodeCursor(pageCursor{SortBy: tt.sortBy, Desc: desc, Value: value, ID: p.ID}), filter)
+			if err != nil {
+				t.Errorf("decodeCursor() by %q desc=%v error = %v", tt.sortBy, desc, err)
+				continue
+			}
+			if cur.Value != tt.want || cur.ID != p.ID {
+				t.Errorf("decodeCursor() by %q desc=%v = %#v after %s, want %#v", tt.sortBy, desc, cur.Value, cur.ID, tt.want)
+			}
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	token := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+	byPrice := domain.ProductFilter{SortBy: "price"}
+
+	tests := []struct {
+		name   string
+		token  string
+		filter domain.ProductFilter
+	}{
+		{"not base64", "!!!", byPrice},
+		{"not JSON", token("price"), byPrice},
+		{"no ID", token(`{"s":"price","v":100}`), byPrice},
+		{"malformed ID", token(`{"s":"price","v":100,"id":"x"}`), byPrice},
+		{"other sort field", token(`{"s":"name","v":"a","id":"507f1f77bcf86cd799439001"}`), byPrice},
+		{"other sort order", token(`{"s":"price","d":true,"v":100,"id":"507f1f77bcf86cd799439001"}`), byPrice},
+		{"fractional amount", token(`{"s":"price","v":100.5,"id":"507f1f77bcf86cd799439001"}`), byPrice},
+		{"string price", token(`{"s":"price","v":"100","id":"507f1f77bcf86cd799439001"}`), byPrice},
+		{"bad time", token(`{"s":"created_at","v":"yesterday","id":"507f1f77bcf86cd799439001"}`), domain.ProductFilter{SortBy: "created_at"}},
+		{"unsortable field", token(`{"s":"description","v":"a","id":"507f1f77bcf86cd799439001"}`), domain.ProductFilter{SortBy: "description"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token, tt.filter); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestNewProductPage(t *testing.T) {
+	items := func(ns ...int) []domain.Product {
+		products := make([]domain.Product, 0, len(ns))
+		for _, n := range ns {
+			products = append(products, domain.Product{ID: pageID(n)})
+		}
+		return products
+	}
+
+	// Pages hold two items; a cursor follows or precedes the item it names
+	tests := []struct {
+		name     string
+		fetched  []domain.Product
+		offset   int
+		cur      *pageCursor
+		want     []domain.Product
+		wantNext bool
+		wantPrev bool
+	}{
+		{"first page", items(1, 2, 3), 0, nil, items(1, 2), true, false},
+		{"only page", items(1, 2), 0, nil, items(1, 2), false, false},
+		{"after an offset", items(3, 4), 2, nil, items(3, 4), false, true},
+		{"forward from a cursor", items(3, 4, 5), 0, &pageCursor{ID: pageID(2)}, items(3, 4), true, true},
+		{"backward to the start", items(2, 1), 0, &pageCursor{ID: pageID(3), Backward: true}, items(1, 2), true, false},
+		{"backward with more before", items(4, 3, 2), 0, &pageCursor{ID: pageID(5), Backward: true}, items(3, 4), true, true},
+		{"empty", nil, 0, nil, items(), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := domain.ProductFilter{Limit: 2, Offset: tt.offset}
+			page := newProductPage(tt.fetched, 10, filter, tt.cur)
+
+			if !reflect.DeepEqual(page.Items, tt.want) {
+				t.Errorf("items = %v, want %v", page.Items, tt.want)
+			}
+			if page.Total != 10 {
+				t.Errorf("total = %d, want 10", page.Total)
+			}
+			if (page.NextCursor != "") != tt.wantNext || (page.PrevCursor != "") != tt.wantPrev {
+				t.Errorf("next cursor %q, previous cursor %q, want next %v, previous %v", page.NextCursor, page.PrevCursor, tt.wantNext, tt.wantPrev)
+			}
+
+			// The previous page ends before the first item shown
+			if page.PrevCursor != "" {
+				prev, err := decodeCursor(page.PrevCursor, filter)
+				if err != nil || !prev.Backward || prev.ID != page.Items[0].ID {
+					t.Errorf("previous cursor = %+v, %v, want backward from %s", prev, err, page.Items[0].ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// internal/repository/postgres_attribute_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const attributeColumns = "name, label, type, unit, values, required, categories, created_at, updated_at"
+
+type postgresAttributeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAttributeRepository(pool *pgxpool.Pool) AttributeRepository {
+	return &postgresAttributeRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresAttributeRepository) FindAll(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+attributeColumns+" FROM attribute_definitions ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	defs, err := pgx.CollectRows(rows, scanAttribute)
+	if err != nil {
+		return nil, err
+	}
+	if defs == nil {
+		defs = []domain.AttributeDefinition{}
+	}
+
+	return defs, nil
+}
+
+func (r *postgresAttributeRepository) FindByName(ctx context.Context, name string) (*domain.AttributeDefinition, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+attributeColumns+" FROM attribute_definitions WHERE name = $1", name)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := pgx.CollectOneRow(rows, scanAttribute)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+func (r *postgresAttributeRepository) Create(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	if def.Categories == nil {
+		def.Categories = []string{}
+	}
+	if def.Values == nil {
+		def.Values = []string{}
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO attribute_definitions (name, label, type, unit, values, required, categories, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING `+attributeColumns,
+		def.Name, def.Label, string(def.Type), def.Unit, def.Values, def.Required, def.Categories, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanAttribute)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrAttributeExists
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresAttributeRepository) Update(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	if def.Categories == nil {
+		def.Categories = []string{}
+	}
+	if def.Values == nil {
+		def.Values = []string{}
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		UPDATE attribute_definitions
+		SET label = $2, type = $3, unit = $4, values = $5, required = $6, categories = $7, updated_at = $8
+		WHERE name = $1
+		RETURNING `+attributeColumns,
+		def.Name, def.Label, string(def.Type), def.Unit, def.Values, def.Required, def.Categories, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanAttribute)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresAttributeRepository) Delete(ctx context.Context, name string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM attribute_definitions WHERE name = $1", name)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAttributeNotFound
+	}
+
+	return nil
+}
+
+func scanAttribute(row pgx.CollectableRow) (domain.AttributeDefinition, error) {
+	var d domain.AttributeDefinition
+	err := row.Scan(&d.Name, &d.Label, &d.Type, &d.Unit, &d.Values, &d.Required, &d.Categories, &d.CreatedAt, &d.UpdatedAt)
+	if len(d.Values) == 0 {
+		d.Values = nil
+	}
+	return d, err
+}
@@ -0,0 +1,165 @@
+// internal/repository/postgres_category_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// categoryColumns reads the NULL parent of a root as an empty ID.
+const categoryColumns = "id, name, slug, COALESCE(parent_id, ''), path, ancestors, position, created_at, updated_at"
+
+type postgresCategoryRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresCategoryRepository(pool *pgxpool.Pool, ids domain.IDGenerator) CategoryRepository {
+	return &postgresCategoryRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresCategoryRepository) FindAll(ctx context.Context) ([]domain.Category, error) {
+	return r.query(ctx, "SELECT "+categoryColumns+" FROM categories ORDER BY path")
+}
+
+func (r *postgresCategoryRepository) FindByID(ctx context.Context, id string) (*domain.Category, error) {
+	categoryID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE id = $1", categoryID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := pgx.CollectOneRow(rows, scanCategory)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *postgresCategoryRepository) FindByIDs(ctx context.Context, ids []domain.ID) ([]domain.Category, error) {
+	return r.query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE id = ANY($1)", idStrings(ids))
+}
+
+func (r *postgresCategoryRepository) FindBySlugs(ctx context.Context, slugs []string) ([]domain.Category, error) {
+	return r.query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE slug = ANY($1)", slugs)
+}
+
+func (r *postgresCategoryRepository) FindChildren(ctx context.Context, parentID domain.ID) ([]domain.Category, error) {
+	return r.query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE parent_id IS NOT DISTINCT FROM NULLIF($1, '') ORDER BY position, name",
+		parentID.String())
+}
+
+func (r *postgresCategoryRepository) FindDescendants(ctx context.Context, id domain.ID) ([]domain.Category, error) {
+	return r.query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE ancestors @> ARRAY[$1::text] ORDER BY path", id.String())
+}
+
+func (r *postgresCategoryRepository) query(ctx context.Context, sql string, args ...interface{}) ([]domain.Category, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := pgx.CollectRows(rows, scanCategory)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = []domain.Category{}
+	}
+
+	return categories, nil
+}
+
+func (r *postgresCategoryRepository) Create(ctx context.Context, category domain.Category) (*domain.Category, error) {
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO categories (id, name, slug, parent_id, path, ancestors, position, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $8)
+		RETURNING `+categoryColumns,
+		r.ids.NewID().String(), category.Name, category.Slug, category.ParentID.String(), category.Path,
+		idStrings(category.Ancestors), category.Position, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanCategory)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrCategoryExists
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresCategoryRepository) Update(ctx context.Context, categories []domain.Category) error {
+	now := time.Now()
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, c := range categories {
+			_, err := tx.Exec(ctx, `
+				UPDATE categories
+				SET name = $2, slug = $3, parent_id = NULLIF($4, ''), path = $5, ancestors = $6, position = $7, updated_at = $8
+				WHERE id = $1`,
+				c.ID.String(), c.Name, c.Slug, c.ParentID.String(), c.Path, idStrings(c.Ancestors), c.Position, now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrCategoryExists
+	}
+	return err
+}
+
+func (r *postgresCategoryRepository) Delete(ctx context.Context, id domain.ID) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM categories WHERE id = $1", id.String())
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+func scanCategory(row pgx.CollectableRow) (domain.Category, error) {
+	var c domain.Category
+	err := row.Scan(&c.ID, &c.Name, &c.Slug, &c.ParentID, &c.Path, &c.Ancestors, &c.Position, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// idStrings converts IDs to the strings stored in TEXT[] columns.
+func idStrings(ids []domain.ID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
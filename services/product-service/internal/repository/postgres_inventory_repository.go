@@ -0,0 +1,101 @@
+// internal/repository/postgres_inventory_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const inventoryMovementColumns = "id, product_id, sku, delta, reason, reference, note, balance, created_by, created_at, location_id"
+
+type postgresInventoryRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresInventoryRepository(pool *pgxpool.Pool, ids domain.IDGenerator) InventoryRepository {
+	return &postgresInventoryRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresInventoryRepository) Record(ctx context.Context, movements []domain.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, m := range movements {
+		batch.Queue(`
+			INSERT INTO inventory_movements (`+inventoryMovementColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			r.ids.NewID().String(), m.ProductID.String(), m.SKU, m.Delta, m.Reason,
+			m.Reference, m.Note, m.Balance, m.CreatedBy, m.CreatedAt, m.LocationID,
+		)
+	}
+
+	return r.pool.SendBatch(ctx, batch).Close()
+}
+
+func (r *postgresInventoryRepository) FindByProduct(ctx context.Context, productID domain.ID, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+inventoryMovementColumns+` FROM inventory_movements
+		WHERE product_id = $1 AND ($2 = '' OR sku = $2) AND ($3 = '' OR reason = $3)
+			AND ($4::timestamptz IS NULL OR created_at >= $4) AND ($5::timestamptz IS NULL OR created_at <= $5)
+			AND ($8 = '' OR location_id = $8) AND ($9 = '' OR reference = $9)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6 OFFSET $7`,
+		productID.String(), query.SKU, string(query.Reason), query.From, query.To, query.Limit, query.Offset, query.Location, query.Reference,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := pgx.CollectRows(rows, scanInventoryMovement)
+	if err != nil {
+		return nil, err
+	}
+	if movements == nil {
+		movements = []domain.InventoryMovement{}
+	}
+
+	return movements, nil
+}
+
+func (r *postgresInventoryRepository) Totals(ctx context.Context, productID domain.ID) ([]domain.InventoryTotal, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT sku, reason, sum(delta), count(*) FROM inventory_movements
+		WHERE product_id = $1
+		GROUP BY sku, reason
+		ORDER BY sku, reason`,
+		productID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.InventoryTotal, error) {
+		var t domain.InventoryTotal
+		err := row.Scan(&t.SKU, &t.Reason, &t.Quantity, &t.Count)
+		return t, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if totals == nil {
+		totals = []domain.InventoryTotal{}
+	}
+
+	return totals, nil
+}
+
+func scanInventoryMovement(row pgx.CollectableRow) (domain.InventoryMovement, error) {
+	var m domain.InventoryMovement
+	err := row.Scan(&m.ID, &m.ProductID, &m.SKU, &m.Delta, &m.Reason, &m.Reference, &m.Note, &m.Balance, &m.CreatedBy, &m.CreatedAt, &m.LocationID)
+	return m, err
+}
@@ -0,0 +1,139 @@
+// internal/repository/postgres_location_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const locationColumns = "id, name, type, priority, latitude, longitude, disabled, is_default, created_at, updated_at"
+
+type postgresLocationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresLocationRepository(pool *pgxpool.Pool) LocationRepository {
+	return &postgresLocationRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresLocationRepository) FindAll(ctx context.Context) ([]domain.Location, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+locationColumns+" FROM stock_locations ORDER BY is_default DESC, priority, id")
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := pgx.CollectRows(rows, scanLocation)
+	if err != nil {
+		return nil, err
+	}
+	if locations == nil {
+		locations = []domain.Location{}
+	}
+
+	return locations, nil
+}
+
+func (r *postgresLocationRepository) FindByID(ctx context.Context, id string) (*domain.Location, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+locationColumns+" FROM stock_locations WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := pgx.CollectOneRow(rows, scanLocation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+func (r *postgresLocationRepository) Create(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	latitude, longitude := positionArgs(location.Position)
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO stock_locations (id, name, type, priority, latitude, longitude, disabled, is_default, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING `+locationColumns,
+		location.ID, location.Name, location.Type, location.Priority, latitude, longitude, location.Disabled, location.Default, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanLocation)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrLocationExists
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresLocationRepository) Update(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	latitude, longitude := positionArgs(location.Position)
+	rows, err := r.pool.Query(ctx, `
+		UPDATE stock_locations
+		SET name = $2, type = $3, priority = $4, latitude = $5, longitude = $6, disabled = $7, updated_at = $8
+		WHERE id = $1
+		RETURNING `+locationColumns,
+		location.ID, location.Name, location.Type, location.Priority, latitude, longitude, location.Disabled, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanLocation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresLocationRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM stock_locations WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrLocationNotFound
+	}
+
+	return nil
+}
+
+// positionArgs splits a position into its nullable columns.
+func positionArgs(p *domain.GeoPoint) (*float64, *float64) {
+	if p == nil {
+		return nil, nil
+	}
+	return &p.Latitude, &p.Longitude
+}
+
+func scanLocation(row pgx.CollectableRow) (domain.Location, error) {
+	var l domain.Location
+	var latitude, longitude *float64
+	err := row.Scan(&l.ID, &l.Name, &l.Type, &l.Priority, &latitude, &longitude, &l.Disabled, &l.Default, &l.CreatedAt, &l.UpdatedAt)
+	if latitude != nil && longitude != nil {
+		l.Position = &domain.GeoPoint{Latitude: *latitude, Longitude: *longitude}
+	}
+	return l, err
+}
@@ -0,0 +1,136 @@
+// internal/repository/postgres_location_stock_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const locationStockColumns = "product_id, sku, location_id, on_hand, reserved, available, updated_at"
+
+type postgresLocationStockRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresLocationStockRepository(pool *pgxpool.Pool) LocationStockRepository {
+	return &postgresLocationStockRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresLocationStockRepository) Adjust(ctx context.Context, productID domain.ID, sku, locationID string, change domain.StockChange) (*domain.LocationStock, error) {
+	// Only stock that grows may start a level, so a level never starts out
+	// negative; the guards of shrinking stock work as for products
+	query := `
+		INSERT INTO location_stock (` + locationStockColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $4 - $5, $6)
+		ON CONFLICT (product_id, sku, location_id) DO UPDATE
+		SET on_hand = location_stock.on_hand + $4, reserved = location_stock.reserved + $5,
+			available = location_stock.available + $4 - $5, updated_at = $6
+		RETURNING ` + locationStockColumns
+	if change.Available() < 0 || change.Reserved < 0 {
+		query = `
+			UPDATE location_stock
+			SET on_hand = on_hand + $4, reserved = reserved + $5, available = available + $4 - $5, updated_at = $6
+			WHERE product_id = $1 AND sku = $2 AND location_id = $3
+				AND available + $4 - $5 >= LEAST(available, 0) AND reserved + $5 >= LEAST(reserved, 0)
+			RETURNING ` + locationStockColumns
+	}
+
+	rows, err := r.pool.Query(ctx, query, productID.String(), sku, locationID, change.OnHand, change.Reserved, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := pgx.CollectOneRow(rows, scanLocationStock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInsufficientInventory
+		}
+		return nil, err
+	}
+
+	return &level, nil
+}
+
+func (r *postgresLocationStockRepository) FindByProducts(ctx context.Context, productIDs []domain.ID) ([]domain.LocationStock, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+locationStockColumns+` FROM location_stock
+		WHERE product_id = ANY($1)
+		ORDER BY product_id, sku, location_id`,
+		idStrings(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	levels, err := pgx.CollectRows(rows, scanLocationStock)
+	if err != nil {
+		return nil, err
+	}
+	if levels == nil {
+		levels = []domain.LocationStock{}
+	}
+
+	return levels, nil
+}
+
+func (r *postgresLocationStockRepository) ProductsWithStock(ctx context.Context, locationID string) ([]domain.ID, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT product_id FROM location_stock
+		WHERE location_id = $1 AND available > 0`,
+		locationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[domain.ID])
+	if err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		ids = []domain.ID{}
+	}
+
+	return ids, nil
+}
+
+func (r *postgresLocationStockRepository) HasStock(ctx context.Context, locationID string) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM location_stock WHERE location_id = $1 AND (on_hand <> 0 OR reserved <> 0))`,
+		locationID,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *postgresLocationStockRepository) Remove(ctx context.Context, productID domain.ID, skus []string) error {
+	if len(skus) == 0 {
+		return nil
+	}
+
+	_, err := r.pool.Exec(ctx, "DELETE FROM location_stock WHERE product_id = $1 AND sku = ANY($2)", productID.String(), skus)
+	return err
+}
+
+func (r *postgresLocationStockRepository) RemoveProducts(ctx context.Context, productIDs []domain.ID) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.pool.Exec(ctx, "DELETE FROM location_stock WHERE product_id = ANY($1)", idStrings(productIDs))
+	return err
+}
+
+func scanLocationStock(row pgx.CollectableRow) (domain.LocationStock, error) {
+	var l domain.LocationStock
+	err := row.Scan(&l.ProductID, &l.SKU, &l.LocationID, &l.OnHand, &l.Reserved, &l.Available, &l.UpdatedAt)
+	return l, err
+}
@@ -0,0 +1,622 @@
+// internal/repository/postgres_migrations.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/migration"
+)
+
+// migrationLockKey identifies the advisory lock held while migrating.
+const migrationLockKey = 0x70726f64
+
+type postgresMigrationStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresMigrationStore records applied migrations in the schema_migrations table.
+func NewPostgresMigrationStore(pool *pgxpool.Pool) migration.Store {
+	return &postgresMigrationStore{
+		pool: pool,
+	}
+}
+
+// Lock takes a session level advisory lock on a connection of its own,
+// which it keeps until the lock is released.
+func (s *postgresMigrationStore) Lock(ctx context.Context) (func(), error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			// Ending the session releases its locks
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}, nil
+}
+
+func (s *postgresMigrationStore) Applied(ctx context.Context) ([]migration.Record, error) {
+	_, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `SELECT version, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (migration.Record, error) {
+		var rec migration.Record
+		err := row.Scan(&rec.Version, &rec.AppliedAt)
+		return rec, err
+	})
+}
+
+func (s *postgresMigrationStore) Insert(ctx context.Context, m migration.Migration) error {
+	_, err := s.pool.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.Version)
+	return err
+}
+
+func (s *postgresMigrationStore) Remove(ctx context.Context, version int) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version)
+	return err
+}
+
+// postgresMigration is a pair of SQL scripts, each run in a single
+// transaction with the insert or removal of its record.
+type postgresMigration struct {
+	description string
+	up          string
+	down        string
+}
+
+// postgresMigrations holds the schema history of the products tables. Entries
+// are applied in order and must never be edited once released; add a new
+// entry instead.
+var postgresMigrations = []postgresMigration{
+	{
+		description: "products table with trigram name search and indexed price/created_at",
+		up: `CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+		CREATE TABLE products (
+			id          TEXT PRIMARY KEY DEFAULT gen_random_uuid()::text,
+			name        TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			price       DOUBLE PRECISION NOT NULL,
+			sku         TEXT NOT NULL,
+			inventory   INTEGER NOT NULL DEFAULT 0,
+			categories  TEXT[] NOT NULL DEFAULT '{}',
+			created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+
+		CREATE INDEX products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
+		CREATE INDEX products_categories_idx ON products USING GIN (categories);
+		CREATE INDEX products_price_idx ON products (price);
+		CREATE INDEX products_created_at_idx ON products (created_at);`,
+		down: `DROP TABLE products;`,
+	},
+	{
+		// Duplicate SKUs fail the migration with a list of them rather than
+		// with the first unique violation.
+		description: "unique SKU index",
+		up: `DO $$
+		DECLARE
+			duplicates TEXT;
+		BEGIN
+			SELECT string_agg(format('%L (%s products)', sku, n), ', ' ORDER BY sku) INTO duplicates
+			FROM (
+				SELECT sku, count(*) AS n
+				FROM products
+				GROUP BY sku
+				HAVING count(*) > 1
+			) AS d;
+
+			IF duplicates IS NOT NULL THEN
+				RAISE EXCEPTION 'SKUs are used by more than one product, rename them before adding the unique index: %', duplicates;
+			END IF;
+		END $$;
+
+		CREATE UNIQUE INDEX products_sku_unique_idx ON products (sku);`,
+		down: `DROP INDEX products_sku_unique_idx;`,
+	},
+	{
+		// The original spelling is not kept, so there is nothing to restore.
+		// SKUs that would collide once normalized fail the migration with a
+		// list of them rather than with the first unique violation.
+		description: "normalize SKUs to trimmed upper case",
+		up: `DO $$
+		DECLARE
+			collisions TEXT;
+		BEGIN
+			SELECT string_agg(skus, ', ' ORDER BY skus) INTO collisions
+			FROM (
+				SELECT '[' || string_agg(sku, ' ' ORDER BY sku) || ']' AS skus
+				FROM products
+				GROUP BY upper(btrim(sku))
+				HAVING count(*) > 1
+			) AS c;
+
+			IF collisions IS NOT NULL THEN
+				RAISE EXCEPTION 'SKUs differ only in case or surrounding spaces, rename them before normalizing: %', collisions;
+			END IF;
+		END $$;
+
+		UPDATE products SET sku = upper(btrim(sku)) WHERE sku <> upper(btrim(sku));`,
+		down: `SELECT 1;`,
+	},
+	{
+		description: "indexes for the remaining sortable fields",
+		up: `CREATE INDEX products_name_idx ON products (name);
+		CREATE INDEX products_inventory_idx ON products (inventory);
+		CREATE INDEX products_updated_at_idx ON products (updated_at);`,
+		down: `DROP INDEX products_name_idx;
+		DROP INDEX products_inventory_idx;
+		DROP INDEX products_updated_at_idx;`,
+	},
+	{
+		// array_to_string is only STABLE, so the generated column goes through
+		// a wrapper declared IMMUTABLE
+		description: "weighted full-text search vector over name, SKU, categories and description",
+		up: `CREATE FUNCTION products_search_vector(name TEXT, sku TEXT, categories TEXT[], description TEXT)
+		RETURNS tsvector LANGUAGE SQL IMMUTABLE AS $$
+			SELECT setweight(to_tsvector('simple', name), 'A') ||
+				setweight(to_tsvector('simple', sku), 'A') ||
+				setweight(to_tsvector('simple', array_to_string(categories, ' ')), 'B') ||
+				setweight(to_tsvector('simple', description), 'D')
+		$$;
+
+		ALTER TABLE products ADD COLUMN search_vector tsvector
+			GENERATED ALWAYS AS (products_search_vector(name, sku, categories, description)) STORED;
+
+		CREATE INDEX products_search_idx ON products USING GIN (search_vector);`,
+		down: `ALTER TABLE products DROP COLUMN search_vector;
+		DROP FUNCTION products_search_vector(TEXT, TEXT, TEXT[], TEXT);`,
+	},
+	{
+		description: "version column for optimistic concurrency control",
+		up:          `ALTER TABLE products ADD COLUMN version BIGINT NOT NULL DEFAULT 1;`,
+		down:        `ALTER TABLE products DROP COLUMN version;`,
+	},
+	{
+		description: "soft delete columns and an index for purging the trash",
+		up: `ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+		ALTER TABLE products ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+		CREATE INDEX products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;`,
+		down: `ALTER TABLE products DROP COLUMN deleted_at;
+		ALTER TABLE products DROP COLUMN deleted_by;`,
+	},
+	{
+		// Products created before the lifecycle existed were already live,
+		// so they are backfilled as published before new rows default to draft
+		description: "lifecycle status with who/when metadata",
+		up: `ALTER TABLE products ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+			CHECK (status IN ('draft', 'review', 'published', 'discontinued', 'archived'));
+		ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+		ALTER TABLE products ADD COLUMN status_changed_at TIMESTAMPTZ;
+		ALTER TABLE products ADD COLUMN status_changed_by TEXT NOT NULL DEFAULT '';
+
+		CREATE INDEX products_status_idx ON products (status);`,
+		down: `ALTER TABLE products DROP COLUMN status;
+		ALTER TABLE products DROP COLUMN status_changed_at;
+		ALTER TABLE products DROP COLUMN status_changed_by;`,
+	},
+	{
+		description: "product_schedules table for scheduled publishing and price changes",
+		up: `CREATE TABLE product_schedules (
+			id         TEXT PRIMARY KEY,
+			product_id TEXT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+			action     TEXT NOT NULL CHECK (action IN ('publish', 'unpublish', 'price')),
+			run_at     TIMESTAMPTZ NOT NULL,
+			price      DOUBLE PRECISION,
+			state      TEXT NOT NULL DEFAULT 'pending',
+			error      TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			applied_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX product_schedules_due_idx ON product_schedules (run_at) WHERE state = 'pending';
+		CREATE INDEX product_schedules_product_idx ON product_schedules (product_id, run_at);`,
+		down: `DROP TABLE product_schedules;`,
+	},
+	{
+		description: "product options and variants with an index on variant SKUs",
+		up: `ALTER TABLE products
+			ADD COLUMN options  JSONB NOT NULL DEFAULT '[]',
+			ADD COLUMN variants JSONB NOT NULL DEFAULT '[]';
+
+		CREATE FUNCTION products_variant_skus(variants JSONB) RETURNS TEXT[]
+			LANGUAGE SQL IMMUTABLE STRICT
+			AS $$ SELECT coalesce(array_agg(v->>'sku'), '{}') FROM jsonb_array_elements(variants) AS v $$;
+
+		ALTER TABLE products
+			ADD COLUMN variant_skus TEXT[] GENERATED ALWAYS AS (products_variant_skus(variants)) STORED;
+
+		CREATE INDEX products_variant_skus_idx ON products USING GIN (variant_skus);
+		CREATE INDEX products_variants_idx ON products USING GIN (variants jsonb_path_ops);`,
+		down: `ALTER TABLE products DROP COLUMN variant_skus, DROP COLUMN variants, DROP COLUMN options;
+		DROP FUNCTION products_variant_skus(JSONB);`,
+	},
+	{
+		description: "custom product attributes and their definitions",
+		up: `ALTER TABLE products ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+		CREATE INDEX products_attributes_idx ON products USING GIN (attributes);
+
+		CREATE TABLE attribute_definitions (
+			name       TEXT PRIMARY KEY,
+			label      TEXT NOT NULL,
+			type       TEXT NOT NULL CHECK (type IN ('string', 'number', 'enum', 'boolean')),
+			unit       TEXT NOT NULL DEFAULT '',
+			values     TEXT[] NOT NULL DEFAULT '{}',
+			required   BOOLEAN NOT NULL DEFAULT false,
+			categories TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`,
+		down: `DROP TABLE attribute_definitions;
+		ALTER TABLE products DROP COLUMN attributes;`,
+	},
+	{
+		description: "category taxonomy, seeded with the categories already on products",
+		up: `CREATE TABLE categories (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			slug       TEXT NOT NULL UNIQUE,
+			parent_id  TEXT REFERENCES categories (id),
+			path       TEXT NOT NULL,
+			ancestors  TEXT[] NOT NULL DEFAULT '{}',
+			position   INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+
+		CREATE INDEX categories_parent_idx ON categories (parent_id, position, name);
+		CREATE INDEX categories_ancestors_idx ON categories USING GIN (ancestors);
+
+		ALTER TABLE products ADD COLUMN category_ids TEXT[] NOT NULL DEFAULT '{}';
+		CREATE INDEX products_category_ids_idx ON products USING GIN (category_ids);
+
+		CREATE TEMPORARY TABLE product_category_slugs ON COMMIT DROP AS
+			SELECT DISTINCT p.id AS product_id, c.name,
+				trim(both '-' from lower(regexp_replace(c.name, '[^a-zA-Z0-9]+', '-', 'g'))) AS slug
+			FROM products p CROSS JOIN LATERAL unnest(p.categories) AS c (name);
+
+		INSERT INTO categories (id, name, slug, path)
+		SELECT gen_random_uuid()::text, min(name), slug, slug
+		FROM product_category_slugs
+		WHERE slug <> ''
+		GROUP BY slug;
+
+		UPDATE products p
+		SET category_ids = a.ids, categories = a.slugs
+		FROM (
+			SELECT s.product_id, array_agg(c.id ORDER BY c.slug) AS ids, array_agg(c.slug ORDER BY c.slug) AS slugs
+			FROM (SELECT DISTINCT product_id, slug FROM product_category_slugs) s
+			JOIN categories c ON c.slug = s.slug
+			GROUP BY s.product_id
+		) a
+		WHERE p.id = a.product_id;`,
+		down: `ALTER TABLE products DROP COLUMN category_ids;
+		DROP TABLE categories;`,
+	},
+	{
+		// Stored prices had no currency; they were all in US dollars
+		description: "prices in minor units with a currency, and price lists",
+		up: `ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING round(price * 100)::bigint;
+		ALTER TABLE products ADD COLUMN price_currency TEXT NOT NULL DEFAULT 'USD';
+		ALTER TABLE products ALTER COLUMN price_currency DROP DEFAULT;
+		ALTER TABLE products ADD COLUMN prices JSONB NOT NULL DEFAULT '{}';
+
+		UPDATE products p
+		SET variants = (
+			SELECT jsonb_agg(
+				CASE WHEN jsonb_typeof(e.v->'price') = 'number'
+					THEN jsonb_set(e.v, '{price}', jsonb_build_object('amount', round((e.v->>'price')::numeric * 100)::bigint, 'currency', 'USD'))
+					ELSE e.v
+				END ORDER BY e.n)
+			FROM jsonb_array_elements(p.variants) WITH ORDINALITY AS e (v, n)
+		)
+		WHERE EXISTS (SELECT 1 FROM jsonb_array_elements(p.variants) v WHERE jsonb_typeof(v->'price') = 'number');
+
+		ALTER TABLE product_schedules ALTER COLUMN price TYPE BIGINT USING round(price * 100)::bigint;
+		ALTER TABLE product_schedules ADD COLUMN price_currency TEXT;
+		UPDATE product_schedules SET price_currency = 'USD' WHERE price IS NOT NULL;
+
+		CREATE TABLE price_lists (
+			id             TEXT PRIMARY KEY,
+			name           TEXT NOT NULL,
+			currency       TEXT NOT NULL,
+			region         TEXT NOT NULL DEFAULT '',
+			customer_group TEXT NOT NULL DEFAULT '',
+			is_default     BOOLEAN NOT NULL DEFAULT false,
+			created_at     TIMESTAMPTZ NOT NULL,
+			updated_at     TIMESTAMPTZ NOT NULL
+		);
+		CREATE UNIQUE INDEX price_lists_default_idx ON price_lists (is_default) WHERE is_default;
+		INSERT INTO price_lists (id, name, currency, is_default, created_at, updated_at)
+		VALUES ('default', 'Default', 'USD', true, now(), now());`,
+		down: `DROP TABLE price_lists;
+
+		ALTER TABLE product_schedules DROP COLUMN price_currency;
+		ALTER TABLE product_schedules ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0;
+
+		UPDATE products p
+		SET variants = (
+			SELECT jsonb_agg(
+				CASE WHEN jsonb_typeof(e.v->'price') = 'object'
+					THEN jsonb_set(e.v - 'prices', '{price}', to_jsonb((e.v->'price'->>'amount')::numeric / 100))
+					ELSE e.v - 'prices'
+				END ORDER BY e.n)
+			FROM jsonb_array_elements(p.variants) WITH ORDINALITY AS e (v, n)
+		)
+		WHERE jsonb_array_length(p.variants) > 0;
+
+		ALTER TABLE products DROP COLUMN prices;
+		ALTER TABLE products DROP COLUMN price_currency;
+		ALTER TABLE products ALTER COLUMN price TYPE DOUBLE PRECISION USING price / 100.0;`,
+	},
+	{
+		description: "price_history table for the audit of price changes",
+		up: `CREATE TABLE price_history (
+			id           TEXT PRIMARY KEY,
+			product_id   TEXT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+			variant_sku  TEXT NOT NULL DEFAULT '',
+			price_list   TEXT NOT NULL,
+			old_amount   BIGINT,
+			old_currency TEXT,
+			new_amount   BIGINT,
+			new_currency TEXT,
+			changed_by   TEXT NOT NULL DEFAULT '',
+			reason       TEXT NOT NULL DEFAULT '',
+			changed_at   TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX price_history_product_idx ON price_history (product_id, changed_at DESC);`,
+		down: `DROP TABLE price_history;`,
+	},
+	{
+		description: "promotions table for discount rules",
+		up: `CREATE TABLE promotions (
+			id              TEXT PRIMARY KEY,
+			name            TEXT NOT NULL,
+			description     TEXT NOT NULL DEFAULT '',
+			type            TEXT NOT NULL,
+			percent         DOUBLE PRECISION NOT NULL DEFAULT 0,
+			amount_off      BIGINT,
+			amount_currency TEXT,
+			buy_quantity    INTEGER NOT NULL DEFAULT 0,
+			free_quantity   INTEGER NOT NULL DEFAULT 0,
+			product_ids     TEXT[] NOT NULL DEFAULT '{}',
+			category_ids    TEXT[] NOT NULL DEFAULT '{}',
+			skus            TEXT[] NOT NULL DEFAULT '{}',
+			starts_at       TIMESTAMPTZ,
+			ends_at         TIMESTAMPTZ,
+			priority        INTEGER NOT NULL DEFAULT 0,
+			stackable       BOOLEAN NOT NULL DEFAULT FALSE,
+			active          BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at      TIMESTAMPTZ NOT NULL,
+			updated_at      TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX promotions_priority_idx ON promotions (priority DESC, id);`,
+		down: `DROP TABLE promotions;`,
+	},
+	{
+		description: "inventory_movements ledger with opening stock",
+		up: `CREATE TABLE inventory_movements (
+			id         TEXT PRIMARY KEY,
+			product_id TEXT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+			sku        TEXT NOT NULL,
+			delta      INTEGER NOT NULL,
+			reason     TEXT NOT NULL,
+			reference  TEXT NOT NULL DEFAULT '',
+			note       TEXT NOT NULL DEFAULT '',
+			balance    INTEGER NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX inventory_movements_product_idx ON inventory_movements (product_id, created_at DESC);
+
+		INSERT INTO inventory_movements (id, product_id, sku, delta, reason, note, balance, created_at)
+		SELECT gen_random_uuid()::text, id, sku, inventory, 'receipt', 'opening stock', inventory, now()
+		FROM products
+		WHERE jsonb_array_length(variants) = 0 AND inventory <> 0;
+
+		INSERT INTO inventory_movements (id, product_id, sku, delta, reason, note, balance, created_at)
+		SELECT gen_random_uuid()::text, p.id, v->>'sku', (v->>'inventory')::int, 'receipt', 'opening stock', (v->>'inventory')::int, now()
+		FROM products p, jsonb_array_elements(p.variants) AS v
+		WHERE (v->>'inventory')::int <> 0;`,
+		down: `DROP TABLE inventory_movements;`,
+	},
+	{
+		description: "reserved and available stock, and reservations table",
+		up: `ALTER TABLE products ADD COLUMN reserved INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE products ADD COLUMN available INTEGER NOT NULL DEFAULT 0;
+		UPDATE products SET available = inventory;
+
+		UPDATE products p SET variants = (
+			SELECT jsonb_agg(e.v || jsonb_build_object('reserved', 0, 'available', (e.v->>'inventory')::int) ORDER BY e.n)
+			FROM jsonb_array_elements(p.variants) WITH ORDINALITY AS e (v, n)
+		)
+		WHERE jsonb_array_length(p.variants) > 0;
+
+		CREATE TABLE reservations (
+			order_id     TEXT PRIMARY KEY,
+			items        JSONB NOT NULL,
+			state        TEXT NOT NULL,
+			expires_at   TIMESTAMPTZ NOT NULL,
+			created_by   TEXT NOT NULL DEFAULT '',
+			created_at   TIMESTAMPTZ NOT NULL,
+			updated_at   TIMESTAMPTZ NOT NULL,
+			confirmed_at TIMESTAMPTZ,
+			released_at  TIMESTAMPTZ
+		);
+		CREATE INDEX reservations_pending_idx ON reservations (expires_at) WHERE state = 'pending';`,
+		down: `DROP TABLE reservations;
+
+		UPDATE products p SET variants = (
+			SELECT jsonb_agg(e.v - 'reserved' - 'available' ORDER BY e.n)
+			FROM jsonb_array_elements(p.variants) WITH ORDINALITY AS e (v, n)
+		)
+		WHERE jsonb_array_length(p.variants) > 0;
+
+		ALTER TABLE products DROP COLUMN available;
+		ALTER TABLE products DROP COLUMN reserved;`,
+	},
+	{
+		description: "processed_events table for idempotent event consumers",
+		up: `CREATE TABLE processed_events (
+			id           TEXT PRIMARY KEY,
+			type         TEXT NOT NULL,
+			order_id     TEXT NOT NULL DEFAULT '',
+			outcome      TEXT NOT NULL DEFAULT '',
+			processed_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX processed_events_processed_at_idx ON processed_events (processed_at);`,
+		down: `DROP TABLE processed_events;`,
+	},
+	{
+		description: "stock locations, the default location and its stock",
+		up: `CREATE TABLE stock_locations (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			type       TEXT NOT NULL,
+			priority   INTEGER NOT NULL DEFAULT 0,
+			latitude   DOUBLE PRECISION,
+			longitude  DOUBLE PRECISION,
+			disabled   BOOLEAN NOT NULL DEFAULT false,
+			is_default BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE UNIQUE INDEX stock_locations_default_idx ON stock_locations (is_default) WHERE is_default;
+		INSERT INTO stock_locations (id, name, type, is_default, created_at, updated_at)
+		VALUES ('default', 'Default', 'warehouse', true, now(), now());
+
+		CREATE TABLE location_stock (
+			product_id  TEXT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+			sku         TEXT NOT NULL,
+			location_id TEXT NOT NULL REFERENCES stock_locations (id),
+			on_hand     INTEGER NOT NULL,
+			reserved    INTEGER NOT NULL,
+			available   INTEGER NOT NULL,
+			updated_at  TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (product_id, sku, location_id)
+		);
+		CREATE INDEX location_stock_location_idx ON location_stock (location_id, available);
+
+		INSERT INTO location_stock (product_id, sku, location_id, on_hand, reserved, available, updated_at)
+		SELECT id, sku, 'default', inventory, reserved, available, now()
+		FROM products
+		WHERE jsonb_array_length(variants) = 0;
+
+		INSERT INTO location_stock (product_id, sku, location_id, on_hand, reserved, available, updated_at)
+		SELECT p.id, v->>'sku', 'default', (v->>'inventory')::int, (v->>'reserved')::int, (v->>'available')::int, now()
+		FROM products p, jsonb_array_elements(p.variants) AS v;
+
+		ALTER TABLE inventory_movements ADD COLUMN location_id TEXT NOT NULL DEFAULT '';`,
+		down: `ALTER TABLE inventory_movements DROP COLUMN location_id;
+		DROP TABLE location_stock;
+		DROP TABLE stock_locations;`,
+	},
+	{
+		// Reserved items and promotion targets are kept in JSONB and arrays,
+		// which foreign keys cannot reach, so a trigger does their cascade.
+		// A promotion left without targets would apply to every product, so
+		// it is deactivated instead.
+		description: "cascade purged products to reservations and promotion targets",
+		up: `CREATE FUNCTION products_purge_cascade() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			UPDATE reservations r SET items = (
+				SELECT coalesce(jsonb_agg(e.v ORDER BY e.n), '[]')
+				FROM jsonb_array_elements(r.items) WITH ORDINALITY AS e (v, n)
+				WHERE e.v->>'product_id' NOT IN (SELECT id FROM purged)
+			), updated_at = now()
+			WHERE EXISTS (
+				SELECT 1 FROM jsonb_array_elements(r.items) AS e (v)
+				WHERE e.v->>'product_id' IN (SELECT id FROM purged)
+			);
+			DELETE FROM reservations WHERE items = '[]';
+
+			WITH kept AS (
+				SELECT p.id, array(
+					SELECT t.id FROM unnest(p.product_ids) WITH ORDINALITY AS t (id, n)
+					WHERE t.id NOT IN (SELECT id FROM purged)
+					ORDER BY t.n
+				) AS product_ids
+				FROM promotions p
+				WHERE p.product_ids && array(SELECT id FROM purged)
+			)
+			UPDATE promotions p
+			SET product_ids = kept.product_ids,
+				active = p.active AND cardinality(kept.product_ids) + cardinality(p.category_ids) + cardinality(p.skus) > 0,
+				updated_at = now()
+			FROM kept
+			WHERE p.id = kept.id;
+
+			RETURN NULL;
+		END $$;
+
+		CREATE TRIGGER products_purge_cascade AFTER DELETE ON products
+		REFERENCING OLD TABLE AS purged
+		FOR EACH STATEMENT EXECUTE FUNCTION products_purge_cascade();`,
+		down: `DROP TRIGGER products_purge_cascade ON products;
+		DROP FUNCTION products_purge_cascade();`,
+	},
+}
+
+// PostgresMigrations returns the schema history of the products tables.
+func PostgresMigrations(pool *pgxpool.Pool) []migration.Migration {
+	migrations := make([]migration.Migration, 0, len(postgresMigrations))
+	for i, m := range postgresMigrations {
+		m := m
+		version := i + 1
+		migrations = append(migrations, migration.Migration{
+			Version:     version,
+			Description: m.description,
+			Up: func(ctx context.Context) error {
+				return execInTx(ctx, pool, m.up, "INSERT INTO schema_migrations (version) VALUES ($1)", version)
+			},
+			Down: func(ctx context.Context) error {
+				if m.down == "" {
+					return errors.New("no down migration")
+				}
+				return execInTx(ctx, pool, m.down, "DELETE FROM schema_migrations WHERE version = $1", version)
+			},
+			Recorded: true,
+		})
+	}
+	return migrations
+}
+
+// execInTx runs a migration script and the statement that records or
+// unrecords its version in one transaction.
+func execInTx(ctx context.Context, pool *pgxpool.Pool, sql, record string, version int) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, record, version)
+		return err
+	})
+}
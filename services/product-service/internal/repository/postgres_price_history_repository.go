@@ -0,0 +1,97 @@
+// internal/repository/postgres_price_history_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const priceHistoryColumns = "id, product_id, variant_sku, price_list, old_amount, old_currency, new_amount, new_currency, changed_by, reason, changed_at"
+
+type postgresPriceHistoryRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresPriceHistoryRepository(pool *pgxpool.Pool, ids domain.IDGenerator) PriceHistoryRepository {
+	return &postgresPriceHistoryRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresPriceHistoryRepository) Record(ctx context.Context, changes []domain.PriceChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, change := range changes {
+		oldAmount, oldCurrency := moneyColumns(change.OldPrice)
+		newAmount, newCurrency := moneyColumns(change.NewPrice)
+		batch.Queue(`
+			INSERT INTO price_history (`+priceHistoryColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			r.ids.NewID().String(), change.ProductID.String(), change.VariantSKU, change.PriceList,
+			oldAmount, oldCurrency, newAmount, newCurrency, change.ChangedBy, change.Reason, change.ChangedAt,
+		)
+	}
+
+	return r.pool.SendBatch(ctx, batch).Close()
+}
+
+func (r *postgresPriceHistoryRepository) FindByProduct(ctx context.Context, productID domain.ID, query domain.PriceHistoryQuery) ([]domain.PriceChange, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+priceHistoryColumns+` FROM price_history
+		WHERE product_id = $1 AND ($2::timestamptz IS NULL OR changed_at >= $2) AND ($3::timestamptz IS NULL OR changed_at <= $3)
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $4 OFFSET $5`,
+		productID.String(), query.From, query.To, query.Limit, query.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := pgx.CollectRows(rows, scanPriceChange)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []domain.PriceChange{}
+	}
+
+	return changes, nil
+}
+
+// moneyColumns splits an optional price into its amount and currency
+// columns, both NULL when it is not set.
+func moneyColumns(m *domain.Money) (*int64, *string) {
+	if m == nil {
+		return nil, nil
+	}
+	return &m.Amount, &m.Currency
+}
+
+// scanMoney joins the columns written by moneyColumns.
+func scanMoney(amount *int64, currency *string) *domain.Money {
+	if amount == nil || currency == nil {
+		return nil
+	}
+	return &domain.Money{Amount: *amount, Currency: *currency}
+}
+
+func scanPriceChange(row pgx.CollectableRow) (domain.PriceChange, error) {
+	var (
+		c                        domain.PriceChange
+		oldAmount, newAmount     *int64
+		oldCurrency, newCurrency *string
+	)
+	err := row.Scan(&c.ID, &c.ProductID, &c.VariantSKU, &c.PriceList, &oldAmount, &oldCurrency, &newAmount, &newCurrency, &c.ChangedBy, &c.Reason, &c.ChangedAt)
+	c.OldPrice = scanMoney(oldAmount, oldCurrency)
+	c.NewPrice = scanMoney(newAmount, newCurrency)
+	return c, err
+}
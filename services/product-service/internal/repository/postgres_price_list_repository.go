@@ -0,0 +1,125 @@
+// internal/repository/postgres_price_list_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const priceListColumns = "id, name, currency, region, customer_group, is_default, created_at, updated_at"
+
+type postgresPriceListRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPriceListRepository(pool *pgxpool.Pool) PriceListRepository {
+	return &postgresPriceListRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresPriceListRepository) FindAll(ctx context.Context) ([]domain.PriceList, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+priceListColumns+" FROM price_lists ORDER BY is_default DESC, id")
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := pgx.CollectRows(rows, scanPriceList)
+	if err != nil {
+		return nil, err
+	}
+	if lists == nil {
+		lists = []domain.PriceList{}
+	}
+
+	return lists, nil
+}
+
+func (r *postgresPriceListRepository) FindByID(ctx context.Context, id string) (*domain.PriceList, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+priceListColumns+" FROM price_lists WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := pgx.CollectOneRow(rows, scanPriceList)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (r *postgresPriceListRepository) Create(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO price_lists (id, name, currency, region, customer_group, is_default, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING `+priceListColumns,
+		list.ID, list.Name, list.Currency, list.Region, list.CustomerGroup, list.Default, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanPriceList)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrPriceListExists
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresPriceListRepository) Update(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE price_lists
+		SET name = $2, currency = $3, region = $4, customer_group = $5, updated_at = $6
+		WHERE id = $1
+		RETURNING `+priceListColumns,
+		list.ID, list.Name, list.Currency, list.Region, list.CustomerGroup, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanPriceList)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresPriceListRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM price_lists WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPriceListNotFound
+	}
+
+	return nil
+}
+
+func scanPriceList(row pgx.CollectableRow) (domain.PriceList, error) {
+	var l domain.PriceList
+	err := row.Scan(&l.ID, &l.Name, &l.Currency, &l.Region, &l.CustomerGroup, &l.Default, &l.CreatedAt, &l.UpdatedAt)
+	return l, err
+}
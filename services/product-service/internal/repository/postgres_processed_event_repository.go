@@ -0,0 +1,46 @@
+// internal/repository/postgres_processed_event_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type postgresProcessedEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresProcessedEventRepository(pool *pgxpool.Pool) ProcessedEventRepository {
+	return &postgresProcessedEventRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresProcessedEventRepository) IsProcessed(ctx context.Context, id string) (bool, error) {
+	var processed bool
+	err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM processed_events WHERE id = $1)", id).Scan(&processed)
+	return processed, err
+}
+
+func (r *postgresProcessedEventRepository) Record(ctx context.Context, event domain.ProcessedEvent) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO processed_events (id, type, order_id, outcome, processed_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING`,
+		event.ID, event.Type, event.OrderID, event.Outcome, event.ProcessedAt,
+	)
+	return err
+}
+
+func (r *postgresProcessedEventRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM processed_events WHERE processed_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,879 @@
+// internal/repository/postgres_product_repository.go
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// uniqueViolation is the SQLSTATE raised when a unique index rejects a write.
+const uniqueViolation = "23505"
+
+const productColumns = "id, name, description, price, sku, inventory, categories, created_at, updated_at, version, deleted_at, deleted_by, status, status_changed_at, status_changed_by, options, variants, attributes, category_ids, price_currency, prices, reserved, available"
+
+// postgresProductColumns lists productColumns with a typed placeholder that
+// is selected instead of the column when a sparse fieldset leaves it out.
+// The column order is kept, so scanProduct works either way.
+var postgresProductColumns = []struct {
+	name        string
+	placeholder string
+}{
+	{"id", ""},
+	{"name", "''"},
+	{"description", "''"},
+	{"price", "0::bigint"},
+	{"sku", "''"},
+	{"inventory", "0"},
+	{"categories", "'{}'::text[]"},
+	{"created_at", "'epoch'::timestamptz"},
+	{"updated_at", "'epoch'::timestamptz"},
+	{"version", "0::bigint"},
+	{"deleted_at", "NULL::timestamptz"},
+	{"deleted_by", "''"},
+	{"status", "''"},
+	{"status_changed_at", "NULL::timestamptz"},
+	{"status_changed_by", "''"},
+	{"options", "'[]'::jsonb"},
+	{"variants", "'[]'::jsonb"},
+	{"attributes", "'{}'::jsonb"},
+	{"category_ids", "'{}'::text[]"},
+	{"price_currency", "''"},
+	{"prices", "'{}'::jsonb"},
+	{"reserved", "0"},
+	{"available", "0"},
+}
+
+// postgresFieldColumns maps the columns that are selected along with another
+// field to that field.
+var postgresFieldColumns = map[string]string{
+	"price_currency": "price",
+}
+
+// postgresSortColumns maps the whitelisted sort_by values to indexed columns.
+// Without a sort field rows are ordered by ID only.
+var postgresSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price",
+	"sku":        "sku",
+	"inventory":  "inventory",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+type postgresProductRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresProductRepository(pool *pgxpool.Pool, ids domain.IDGenerator) ProductRepository {
+	return &postgresProductRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresProductRepository) FindAll(ctx context.Context, filter domain.ProductFilter) (*domain.ProductPage, error) {
+	var cur *pageCursor
+	if filter.Cursor != "" {
+		var err error
+		if cur, err = decodeCursor(filter.Cursor, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	var args []interface{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := listConditions(filter, addArg)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products"+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// The ID breaks ties, and is the only key when no sort field is given
+	column, sorted := postgresSortColumns[filter.SortBy]
+	desc := filter.SortOrder == "desc"
+	if cur != nil && cur.Backward {
+		desc = !desc
+	}
+	order, op := "ASC", ">"
+	if desc {
+		order, op = "DESC", "<"
+	}
+
+	if cur != nil {
+		if sorted {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", column, op, addArg(cur.Value), addArg(cur.ID.String())))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("id %s %s", op, addArg(cur.ID.String())))
+		}
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := "SELECT " + selectColumns(filter.FieldSet.Stored(), column) + " FROM products" + where
+	if sorted {
+		query += " ORDER BY " + column + " " + order + ", id " + order
+	} else {
+		query += " ORDER BY id " + order
+	}
+
+	query += " LIMIT " + addArg(filter.Limit+1)
+	if cur == nil {
+		query += " OFFSET " + addArg(filter.Offset)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := pgx.CollectRows(rows, scanProduct)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProductPage(products, total, filter, cur), nil
+}
+
+// Search matches every term as a word prefix against the weighted
+// search_vector column. When nothing matches it falls back to typo tolerant
+// regex matching, ranked in memory.
+func (r *postgresProductRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchPage, error) {
+	matcher := newSearchMatcher(q.Terms)
+
+	var args []interface{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := listConditions(q.ProductFilter, addArg)
+
+	column, sorted := postgresSortColumns[q.SortBy]
+	order := "ASC"
+	if q.SortOrder == "desc" {
+		order = "DESC"
+	}
+
+	prefixes := make([]string, 0, len(q.Terms))
+	for _, t := range q.Terms {
+		prefixes = append(prefixes, t+":*")
+	}
+	tsquery := "to_tsquery('simple', " + addArg(strings.Join(prefixes, " | ")) + ")"
+
+	where := " WHERE " + strings.Join(append(conditions, "search_vector @@ "+tsquery), " AND ")
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products"+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	if total == 0 {
+		// The fallback does not use the tsquery, so bind its arguments
+		// afresh. PostgreSQL spells the start-of-word anchor \m.
+		args = nil
+		conditions = listConditions(q.ProductFilter, addArg)
+		pattern := addArg(`\m` + strings.TrimPrefix(matcher.pattern, `\b`))
+		where = " WHERE " + strings.Join(append(conditions, fmt.Sprintf(
+			"(name ~* %[1]s OR sku ~* %[1]s OR array_to_string(categories, ' ') ~* %[1]s OR description ~* %[1]s)", pattern)), " AND ")
+
+		query := "SELECT " + productColumns + " FROM products" + where
+		if sorted {
+			query += " ORDER BY " + column + " " + order + ", id " + order
+		}
+		query += fmt.Sprintf(" LIMIT %d", fuzzyCandidateLimit)
+
+		rows, err := r.pool.Query(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates, err := pgx.CollectRows(rows, scanProduct)
+		if err != nil {
+			return nil, err
+		}
+
+		return matcher.fuzzyPage(candidates, q), nil
+	}
+
+	query := "SELECT " + productColumns + ", ts_rank(search_vector, " + tsquery + ") AS score FROM products" + where
+	if sorted {
+		query += " ORDER BY " + column + " " + order + ", id " + order
+	} else {
+		query += " ORDER BY score DESC, id"
+	}
+	query += " LIMIT " + addArg(q.Limit) + " OFFSET " + addArg(q.Offset)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []float64
+	products, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Product, error) {
+		var p domain.Product
+		var score float32
+		err := row.Scan(append(productScanTargets(&p), &score)...)
+		scores = append(scores, float64(score))
+		return p, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SearchPage{
+		Items: matcher.scoredResults(products, scores),
+		Total: total,
+	}, nil
+}
+
+// Facets sends the summary, category, price bucket and attribute queries in
+// a single batch. Price buckets are built with ntile, so each holds roughly
+// the same number of products.
+func (r *postgresProductRepository) Facets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error) {
+	var args []interface{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := ""
+	if conditions := listConditions(filter, addArg); len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue("SELECT count(*), count(*) FILTER (WHERE available > 0), min(price), max(price) FROM products"+where, args...)
+	batch.Queue(fmt.Sprintf("SELECT category, count(*) AS n FROM products, unnest(categories) AS category%s GROUP BY category ORDER BY n DESC, category LIMIT %d",
+		where, domain.FacetCategoryLimit), args...)
+	batch.Queue(fmt.Sprintf("SELECT min(price), max(price), count(*) FROM (SELECT price, ntile(%d) OVER (ORDER BY price) AS bucket FROM products%s) b GROUP BY bucket ORDER BY bucket",
+		domain.FacetPriceBuckets, where), args...)
+	for _, name := range filter.FacetAttributes {
+		attrArgs := append(args[:len(args):len(args)], name)
+		key := fmt.Sprintf("$%d", len(attrArgs))
+
+		attrWhere := " WHERE attributes ? " + key
+		if where != "" {
+			attrWhere = where + " AND attributes ? " + key
+		}
+		batch.Queue(fmt.Sprintf("SELECT attributes->%s AS value, count(*) AS n FROM products%s GROUP BY value ORDER BY n DESC, value LIMIT %d",
+			key, attrWhere, domain.FacetAttributeValueLimit), attrArgs...)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	facets := &domain.ProductFacets{}
+	var minPrice, maxPrice *int64
+	if err := results.QueryRow().Scan(&facets.Total, &facets.InStock, &minPrice, &maxPrice); err != nil {
+		return nil, err
+	}
+	facets.OutOfStock = facets.Total - facets.InStock
+	if minPrice != nil && maxPrice != nil {
+		facets.MinPrice = &domain.Money{Amount: *minPrice, Currency: filter.Currency}
+		facets.MaxPrice = &domain.Money{Amount: *maxPrice, Currency: filter.Currency}
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, err
+	}
+	facets.Categories, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.CategoryCount, error) {
+		var c domain.CategoryCount
+		err := row.Scan(&c.Category, &c.Count)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, err
+	}
+	facets.PriceBuckets, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.PriceBucket, error) {
+		b := domain.PriceBucket{
+			Min: domain.Money{Currency: filter.Currency},
+			Max: domain.Money{Currency: filter.Currency},
+		}
+		err := row.Scan(&b.Min.Amount, &b.Max.Amount, &b.Count)
+		return b, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range filter.FacetAttributes {
+		rows, err = results.Query()
+		if err != nil {
+			return nil, err
+		}
+		values, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AttributeValueCount, error) {
+			var v domain.AttributeValueCount
+			err := row.Scan(&v.Value, &v.Count)
+			return v, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []domain.AttributeValueCount{}
+		}
+		facets.Attributes = append(facets.Attributes, domain.AttributeFacet{Name: name, Values: values})
+	}
+
+	return facets, nil
+}
+
+// selectColumns returns the select list for a sparse fieldset. The ID and the
+// sort column are always selected because the page cursors are built from them.
+func selectColumns(fields domain.FieldSet, sortColumn string) string {
+	if fields == nil {
+		return productColumns
+	}
+
+	columns := make([]string, 0, len(postgresProductColumns))
+	for _, c := range postgresProductColumns {
+		field, ok := postgresFieldColumns[c.name]
+		if !ok {
+			field = c.name
+		}
+		if c.name == "id" || c.name == sortColumn || fields.Has(field) {
+			columns = append(columns, c.name)
+		} else {
+			columns = append(columns, c.placeholder+" AS "+c.name)
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// listConditions translates the listing parameters of filter into SQL
+// conditions, binding values through addArg.
+func listConditions(filter domain.ProductFilter, addArg func(interface{}) string) []string {
+	var conditions []string
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if len(filter.Status) > 0 {
+		statuses := make([]string, 0, len(filter.Status))
+		for _, s := range filter.Status {
+			statuses = append(statuses, string(s))
+		}
+		conditions = append(conditions, "status = ANY("+addArg(statuses)+")")
+	}
+	if filter.Name != "" {
+		// Served by the trigram index, which supports ILIKE
+		conditions = append(conditions, "name ILIKE "+addArg(namePattern(filter.Name, filter.NameMatch)))
+	}
+	if len(filter.Categories) > 0 {
+		conditions = append(conditions, "categories && "+addArg(filter.Categories))
+	}
+	if len(filter.CategoryIDs) > 0 {
+		conditions = append(conditions, "category_ids && "+addArg(idStrings(filter.CategoryIDs)))
+	}
+	if filter.Location != "" {
+		conditions = append(conditions, "id = ANY("+addArg(idStrings(filter.StockedIDs))+")")
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "price >= "+addArg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "price <= "+addArg(*filter.MaxAmount))
+	}
+	if len(filter.VariantAttributes) > 0 {
+		// Array containment matches when a single variant has every attribute
+		contained, _ := json.Marshal([]map[string]interface{}{{"attributes": filter.VariantAttributes}})
+		conditions = append(conditions, "variants @> "+addArg(string(contained))+"::jsonb")
+	}
+	for _, a := range filter.AttributeFilters {
+		if a.Value != nil {
+			// Containment keeps the JSON type, so 1 does not match "1"
+			contained, _ := json.Marshal(map[string]interface{}{a.Name: a.Value})
+			conditions = append(conditions, "attributes @> "+addArg(string(contained))+"::jsonb")
+			continue
+		}
+		// Values of other types than number never fall in a range
+		value := "CASE WHEN jsonb_typeof(attributes->" + addArg(a.Name) + ") = 'number' THEN (attributes->>" + addArg(a.Name) + ")::numeric END"
+		if a.Min != nil {
+			conditions = append(conditions, value+" >= "+addArg(*a.Min))
+		}
+		if a.Max != nil {
+			conditions = append(conditions, value+" <= "+addArg(*a.Max))
+		}
+	}
+	if filter.Expr != nil {
+		conditions = append(conditions, sqlFilter(filter.Expr, addArg))
+	}
+
+	return conditions
+}
+
+func (r *postgresProductRepository) FindByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + productColumns + " FROM products WHERE id = $1"
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
+	rows, err := r.pool.Query(ctx, query, productID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *postgresProductRepository) FindBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+productColumns+" FROM products WHERE (sku = $1 OR variant_skus @> ARRAY[$1::text]) AND deleted_at IS NULL", sku)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *postgresProductRepository) FindBySKUs(ctx context.Context, skus []string) ([]domain.Product, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+productColumns+" FROM products WHERE (sku = ANY($1) OR variant_skus && $1::text[]) AND deleted_at IS NULL", skus)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanProduct)
+}
+
+func (r *postgresProductRepository) FindSKUOwner(ctx context.Context, skus []string, excludeID domain.ID) (*domain.Product, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+productColumns+` FROM products
+		WHERE (sku = ANY($1) OR variant_skus && $1::text[]) AND id <> $2
+		LIMIT 1`,
+		skus, excludeID.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *postgresProductRepository) CountWithAttribute(ctx context.Context, name string) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products WHERE attributes ? $1", name).Scan(&n)
+	return n, err
+}
+
+func (r *postgresProductRepository) CountInCategory(ctx context.Context, categoryID domain.ID) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products WHERE category_ids @> ARRAY[$1::text]", categoryID.String()).Scan(&n)
+	return n, err
+}
+
+func (r *postgresProductRepository) CountWithPriceList(ctx context.Context, priceListID string) (int64, error) {
+	var n int64
+	err := r.pool.QueryRow(ctx, `
+		SELECT count(*) FROM products
+		WHERE prices ? $1 OR EXISTS (SELECT 1 FROM jsonb_array_elements(variants) v WHERE v->'prices' ? $1)`, priceListID).Scan(&n)
+	return n, err
+}
+
+func (r *postgresProductRepository) ReplaceCategory(ctx context.Context, from domain.ID, fromSlug string, to domain.ID, toSlug string) ([]domain.ID, error) {
+	// A product already in the target category only loses the source
+	rows, err := r.pool.Query(ctx, `
+		UPDATE products
+		SET category_ids = CASE WHEN $2::text = ANY(category_ids) AND $1::text <> $2::text
+				THEN array_remove(category_ids, $1::text) ELSE array_replace(category_ids, $1::text, $2::text) END,
+			categories = CASE WHEN $4::text = ANY(categories) AND $3::text <> $4::text
+				THEN array_remove(categories, $3::text) ELSE array_replace(categories, $3::text, $4::text) END,
+			version = version + 1, updated_at = $5
+		WHERE category_ids @> ARRAY[$1::text]
+		RETURNING id`,
+		from.String(), to.String(), fromSlug, toSlug, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[domain.ID])
+}
+
+func (r *postgresProductRepository) Create(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	now := time.Now()
+	product.ID = r.ids.NewID()
+	if product.Categories == nil {
+		product.Categories = []string{}
+	}
+	if product.Options == nil {
+		product.Options = []domain.ProductOption{}
+	}
+	if product.Variants == nil {
+		product.Variants = []domain.Variant{}
+	}
+	if product.Attributes == nil {
+		product.Attributes = map[string]interface{}{}
+	}
+	if product.Prices == nil {
+		product.Prices = map[string]domain.Money{}
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO products (id, name, description, price, sku, inventory, categories, created_at, updated_at, status, status_changed_at, options, variants, attributes, category_ids, price_currency, prices, reserved, available)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9, $8, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING `+productColumns,
+		product.ID.String(), product.Name, product.Description, product.Price.Amount, product.SKU, product.Inventory, product.Categories, now, string(product.Status),
+		product.Options, product.Variants, product.Attributes, idStrings(product.CategoryIDs), product.Price.Currency, product.Prices, product.Reserved, product.Available,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		return nil, r.duplicateSKUError(ctx, err, product.SKUs(), product.ID)
+	}
+
+	return &created, nil
+}
+
+func (r *postgresProductRepository) Update(ctx context.Context, id string, product domain.Product, expectedVersion int64) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Categories == nil {
+		product.Categories = []string{}
+	}
+	if product.Options == nil {
+		product.Options = []domain.ProductOption{}
+	}
+	if product.Variants == nil {
+		product.Variants = []domain.Variant{}
+	}
+	if product.Attributes == nil {
+		product.Attributes = map[string]interface{}{}
+	}
+	if product.Prices == nil {
+		product.Prices = map[string]domain.Money{}
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		UPDATE products
+		SET name = $2, description = $3, price = $4, sku = $5, inventory = $6, categories = $7, updated_at = $8, version = version + 1,
+			options = $10, variants = $11, attributes = $12, category_ids = $13, price_currency = $14, prices = $15, reserved = $16, available = $17
+		WHERE id = $1 AND deleted_at IS NULL AND ($9::bigint = 0 OR version = $9)
+		RETURNING `+productColumns,
+		productID.String(), product.Name, product.Description, product.Price.Amount, product.SKU, product.Inventory, product.Categories, time.Now(), expectedVersion,
+		product.Options, product.Variants, product.Attributes, idStrings(product.CategoryIDs), product.Price.Currency, product.Prices, product.Reserved, product.Available,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.versionMismatch(ctx, productID, expectedVersion)
+		}
+		return nil, r.duplicateSKUError(ctx, err, product.SKUs(), productID)
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresProductRepository) AdjustStock(ctx context.Context, id domain.ID, sku string, change domain.StockChange) (*domain.Product, error) {
+	// The row lock taken by UPDATE makes the guards and the increments
+	// atomic; a concurrent change re-evaluates the guards against the new
+	// stock. Stock that only grows never fails them.
+	query := `
+		UPDATE products
+		SET inventory = inventory + $2, reserved = reserved + $3, available = available + $2 - $3, updated_at = $4, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND jsonb_array_length(variants) = 0
+			AND available + $2 - $3 >= LEAST(available, 0) AND reserved + $3 >= LEAST(reserved, 0)
+		RETURNING ` + productColumns
+	args := []interface{}{id.String(), change.OnHand, change.Reserved, time.Now()}
+	if sku != "" {
+		query = `
+			UPDATE products
+			SET variants = (
+					SELECT jsonb_agg(CASE WHEN e.v->>'sku' = $5
+						THEN e.v || jsonb_build_object(
+							'inventory', (e.v->>'inventory')::int + $2,
+							'reserved', (e.v->>'reserved')::int + $3,
+							'available', (e.v->>'available')::int + $2 - $3)
+						ELSE e.v
+					END ORDER BY e.n)
+					FROM jsonb_array_elements(variants) WITH ORDINALITY AS e (v, n)
+				),
+				inventory = inventory + $2, reserved = reserved + $3, available = available + $2 - $3, updated_at = $4, version = version + 1
+			WHERE id = $1 AND deleted_at IS NULL AND EXISTS (
+				SELECT 1 FROM jsonb_array_elements(variants) AS e (v)
+				WHERE e.v->>'sku' = $5
+					AND (e.v->>'available')::int + $2 - $3 >= LEAST((e.v->>'available')::int, 0)
+					AND (e.v->>'reserved')::int + $3 >= LEAST((e.v->>'reserved')::int, 0)
+			)
+			RETURNING ` + productColumns
+		args = append(args, sku)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		var exists bool
+		if err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", id.String()).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, domain.ErrInsufficientInventory
+		}
+		return nil, nil
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresProductRepository) Delete(ctx context.Context, id string, expectedVersion int64, deletedBy string) error {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE products
+		SET deleted_at = $3, deleted_by = $4, updated_at = $3, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)`,
+		productID.String(), expectedVersion, time.Now(), deletedBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		if err := r.versionMismatch(ctx, productID, expectedVersion); err != nil {
+			return err
+		}
+		return domain.ErrProductNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresProductRepository) Restore(ctx context.Context, id string, expectedVersion int64) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		UPDATE products
+		SET deleted_at = NULL, deleted_by = '', updated_at = $3, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::bigint = 0 OR version = $2)
+		RETURNING `+productColumns,
+		productID.String(), expectedVersion, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		current, err := r.FindByID(ctx, productID.String(), true)
+		switch {
+		case err != nil || current == nil:
+			return nil, err
+		case current.DeletedAt == nil:
+			return nil, domain.ErrProductNotDeleted
+		default:
+			return nil, domain.ErrVersionMismatch
+		}
+	}
+
+	return &restored, nil
+}
+
+func (r *postgresProductRepository) Purge(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.ID, error) {
+	rows, err := r.pool.Query(ctx, `
+		DELETE FROM products
+		WHERE id IN (
+			SELECT id FROM products
+			WHERE deleted_at < $1
+			ORDER BY deleted_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		deletedBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[domain.ID])
+}
+
+func (r *postgresProductRepository) Transition(ctx context.Context, id string, from, to domain.Status, changedBy string, expectedVersion int64) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matching on the current status keeps concurrent transitions from
+	// skipping a step of the state machine
+	rows, err := r.pool.Query(ctx, `
+		UPDATE products
+		SET status = $4, status_changed_at = $5, status_changed_by = $6, updated_at = $5, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND status = $3 AND ($2::bigint = 0 OR version = $2)
+		RETURNING `+productColumns,
+		productID.String(), expectedVersion, string(from), string(to), time.Now(), changedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanProduct)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		current, err := r.FindByID(ctx, productID.String(), false)
+		switch {
+		case err != nil || current == nil:
+			return nil, err
+		case expectedVersion > 0 && current.Version != expectedVersion:
+			return nil, domain.ErrVersionMismatch
+		default:
+			return nil, &domain.TransitionError{From: current.Status, To: to}
+		}
+	}
+
+	return &updated, nil
+}
+
+// versionMismatch is called when a conditional write matched no row. It
+// returns domain.ErrVersionMismatch if the product exists and nil if it does
+// not, leaving the not-found handling to the caller.
+func (r *postgresProductRepository) versionMismatch(ctx context.Context, id domain.ID, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", id.String()).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrVersionMismatch
+	}
+	return nil
+}
+
+// likeEscaper escapes the LIKE wildcards so names are matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// namePattern builds an ILIKE pattern matching name in the given mode.
+func namePattern(name, mode string) string {
+	escaped := likeEscaper.Replace(name)
+	switch mode {
+	case domain.NameMatchContains:
+		return "%" + escaped + "%"
+	case domain.NameMatchPrefix:
+		return escaped + "%"
+	default:
+		return escaped
+	}
+}
+
+// duplicateSKUError converts a violation of the unique SKU index into a
+// DuplicateSKUError naming the product that owns the SKU. Variant SKUs are
+// not covered by the index; the service checks them before writing.
+func (r *postgresProductRepository) duplicateSKUError(ctx context.Context, err error, skus []string, productID domain.ID) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation || pgErr.ConstraintName != "products_sku_unique_idx" {
+		return err
+	}
+
+	// The owner may be in the trash, which FindBySKU does not look at
+	dupErr := &domain.DuplicateSKUError{SKU: skus[0]}
+	if owner, findErr := r.FindSKUOwner(ctx, skus, productID); findErr == nil && owner != nil {
+		dupErr.ProductID = owner.ID
+		dupErr.Deleted = owner.DeletedAt != nil
+		for _, sku := range skus {
+			if owner.HasSKU(sku) {
+				dupErr.SKU = sku
+				break
+			}
+		}
+	}
+
+	return dupErr
+}
+
+func scanProduct(row pgx.CollectableRow) (domain.Product, error) {
+	var p domain.Product
+	err := row.Scan(productScanTargets(&p)...)
+	return p, err
+}
+
+// productScanTargets returns the fields of p in productColumns order.
+func productScanTargets(p *domain.Product) []interface{} {
+	return []interface{}{&p.ID, &p.Name, &p.Description, &p.Price.Amount, &p.SKU, &p.Inventory, &p.Categories, &p.CreatedAt, &p.UpdatedAt, &p.Version, &p.DeletedAt, &p.DeletedBy, &p.Status, &p.StatusChangedAt, &p.StatusChangedBy, &p.Options, &p.Variants, &p.Attributes, &p.CategoryIDs, &p.Price.Currency, &p.Prices, &p.Reserved, &p.Available}
+}
@@ -0,0 +1,21 @@
+// internal/repository/postgres_product_repository_test.go
+package repository
+
+import "testing"
+
+func TestNamePattern(t *testing.T) {
+	tests := []struct {
+		name, mode, want string
+	}{
+		{"shoe", "exact", "shoe"},
+		{"shoe", "prefix", "shoe%"},
+		{"shoe", "contains", "%shoe%"},
+		{`50%_off\`, "exact", `50\%\_off\\`},
+	}
+
+	for _, tt := range tests {
+		if got := namePattern(tt.name, tt.mode); got != tt.want {
+			t.Errorf("namePattern(%q, %s) = %q, want %q", tt.name, tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// internal/repository/postgres_promotion_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const promotionColumns = "id, name, description, type, percent, amount_off, amount_currency, buy_quantity, free_quantity, product_ids, category_ids, skus, starts_at, ends_at, priority, stackable, active, created_at, updated_at"
+
+type postgresPromotionRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresPromotionRepository(pool *pgxpool.Pool, ids domain.IDGenerator) PromotionRepository {
+	return &postgresPromotionRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresPromotionRepository) FindAll(ctx context.Context) ([]domain.Promotion, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+promotionColumns+" FROM promotions ORDER BY priority DESC, id")
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := pgx.CollectRows(rows, scanPromotion)
+	if err != nil {
+		return nil, err
+	}
+	if promotions == nil {
+		promotions = []domain.Promotion{}
+	}
+
+	return promotions, nil
+}
+
+func (r *postgresPromotionRepository) FindByID(ctx context.Context, id string) (*domain.Promotion, error) {
+	promotionID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1", promotionID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := pgx.CollectOneRow(rows, scanPromotion)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (r *postgresPromotionRepository) Create(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error) {
+	amountOff, amountCurrency := moneyColumns(promotion.AmountOff)
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO promotions (id, name, description, type, percent, amount_off, amount_currency, buy_quantity, free_quantity,
+			product_ids, category_ids, skus, starts_at, ends_at, priority, stackable, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $18)
+		RETURNING `+promotionColumns,
+		r.ids.NewID().String(), promotion.Name, promotion.Description, promotion.Type, promotion.Percent, amountOff, amountCurrency,
+		promotion.BuyQuantity, promotion.FreeQuantity, idStrings(promotion.ProductIDs), idStrings(promotion.CategoryIDs), promotion.SKUs,
+		promotion.StartsAt, promotion.EndsAt, promotion.Priority, promotion.Stackable, promotion.Active, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanPromotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresPromotionRepository) Update(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error) {
+	amountOff, amountCurrency := moneyColumns(promotion.AmountOff)
+	rows, err := r.pool.Query(ctx, `
+		UPDATE promotions
+		SET name = $2, description = $3, type = $4, percent = $5, amount_off = $6, amount_currency = $7,
+			buy_quantity = $8, free_quantity = $9, product_ids = $10, category_ids = $11, skus = $12,
+			starts_at = $13, ends_at = $14, priority = $15, stackable = $16, active = $17, updated_at = $18
+		WHERE id = $1
+		RETURNING `+promotionColumns,
+		promotion.ID.String(), promotion.Name, promotion.Description, promotion.Type, promotion.Percent, amountOff, amountCurrency,
+		promotion.BuyQuantity, promotion.FreeQuantity, idStrings(promotion.ProductIDs), idStrings(promotion.CategoryIDs), promotion.SKUs,
+		promotion.StartsAt, promotion.EndsAt, promotion.Priority, promotion.Stackable, promotion.Active, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanPromotion)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresPromotionRepository) Delete(ctx context.Context, id domain.ID) error {
+	tag, err := r.pool.Exec(ctx, "DELETE FROM promotions WHERE id = $1", id.String())
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPromotionNotFound
+	}
+
+	return nil
+}
+
+func scanPromotion(row pgx.CollectableRow) (domain.Promotion, error) {
+	var (
+		p              domain.Promotion
+		amountOff      *int64
+		amountCurrency *string
+	)
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Type, &p.Percent, &amountOff, &amountCurrency, &p.BuyQuantity, &p.FreeQuantity,
+		&p.ProductIDs, &p.CategoryIDs, &p.SKUs, &p.StartsAt, &p.EndsAt, &p.Priority, &p.Stackable, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	p.AmountOff = scanMoney(amountOff, amountCurrency)
+	return p, err
+}
@@ -0,0 +1,78 @@
+// internal/repository/postgres_query.go
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ntdt/product-service/internal/query"
+)
+
+var sqlComparisonOps = map[query.Op]string{
+	query.OpEq: "=",
+	query.OpNe: "IS DISTINCT FROM",
+	query.OpLt: "<",
+	query.OpLe: "<=",
+	query.OpGt: ">",
+	query.OpGe: ">=",
+}
+
+// sqlFilter translates a parsed filter expression into a SQL condition.
+// Values are bound through addArg; field names were whitelisted by the parser
+// and match the column names. A NULL column matches like a field missing
+// from a MongoDB document: never equal and always unequal to a value, and
+// included by a negation of anything it does not match.
+func sqlFilter(expr query.Expr, addArg func(interface{}) string) string {
+	switch e := expr.(type) {
+	case query.And:
+		return "(" + strings.Join(sqlFilters(e.Exprs, addArg), " AND ") + ")"
+	case query.Or:
+		return "(" + strings.Join(sqlFilters(e.Exprs, addArg), " OR ") + ")"
+	case query.Not:
+		return "NOT COALESCE(" + sqlFilter(e.Expr, addArg) + ", false)"
+	case query.Comparison:
+		isArray := query.ProductSchema[e.Field].Type == query.TypeStringArray
+
+		switch e.Op {
+		case query.OpIn:
+			values := e.Value.([]interface{})
+			if isArray {
+				strs := make([]string, 0, len(values))
+				for _, v := range values {
+					strs = append(strs, v.(string))
+				}
+				return fmt.Sprintf("%s && %s", e.Field, addArg(strs))
+			}
+			placeholders := make([]string, 0, len(values))
+			for _, v := range values {
+				placeholders = append(placeholders, addArg(v))
+			}
+			return fmt.Sprintf("%s IN (%s)", e.Field, strings.Join(placeholders, ", "))
+		case query.OpContains:
+			if isArray {
+				return fmt.Sprintf("%s = ANY(%s)", addArg(e.Value), e.Field)
+			}
+			return fmt.Sprintf("%s ILIKE %s", e.Field, addArg(namePattern(e.Value.(string), "contains")))
+		case query.OpExists:
+			return e.Field + " IS NOT NULL"
+		case query.OpEq, query.OpNe:
+			if isArray {
+				cond := fmt.Sprintf("%s = ANY(%s)", addArg(e.Value), e.Field)
+				if e.Op == query.OpNe {
+					cond = "NOT COALESCE(" + cond + ", false)"
+				}
+				return cond
+			}
+		}
+		return fmt.Sprintf("%s %s %s", e.Field, sqlComparisonOps[e.Op], addArg(e.Value))
+	}
+	return "TRUE"
+}
+
+func sqlFilters(exprs []query.Expr, addArg func(interface{}) string) []string {
+	conds := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		conds = append(conds, sqlFilter(e, addArg))
+	}
+	return conds
+}
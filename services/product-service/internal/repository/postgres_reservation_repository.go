@@ -0,0 +1,130 @@
+// internal/repository/postgres_reservation_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const reservationColumns = "order_id, items, state, expires_at, created_by, created_at, updated_at, confirmed_at, released_at"
+
+type postgresReservationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresReservationRepository(pool *pgxpool.Pool) ReservationRepository {
+	return &postgresReservationRepository{
+		pool: pool,
+	}
+}
+
+func (r *postgresReservationRepository) Create(ctx context.Context, reservation domain.Reservation) (*domain.Reservation, error) {
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO reservations (order_id, items, state, expires_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (order_id) DO NOTHING
+		RETURNING `+reservationColumns,
+		reservation.OrderID, reservation.Items, reservation.State, reservation.ExpiresAt, reservation.CreatedBy, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanReservation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresReservationRepository) FindByOrderID(ctx context.Context, orderID string) (*domain.Reservation, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE order_id = $1", orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := pgx.CollectOneRow(rows, scanReservation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+func (r *postgresReservationRepository) Transition(ctx context.Context, orderID string, to domain.ReservationState, at time.Time) (*domain.Reservation, error) {
+	query := `
+		UPDATE reservations
+		SET state = $2, updated_at = $3, released_at = $3
+		WHERE order_id = $1 AND state = 'pending'`
+	switch to {
+	case domain.ReservationConfirmed:
+		query = `
+			UPDATE reservations
+			SET state = $2, updated_at = $3, confirmed_at = $3
+			WHERE order_id = $1 AND state = 'pending' AND expires_at > $3`
+	case domain.ReservationExpired:
+		query += " AND expires_at <= $3"
+	}
+
+	rows, err := r.pool.Query(ctx, query+" RETURNING "+reservationColumns, orderID, to, at)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := pgx.CollectOneRow(rows, scanReservation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *postgresReservationRepository) Delete(ctx context.Context, orderID string) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM reservations WHERE order_id = $1", orderID)
+	return err
+}
+
+func (r *postgresReservationRepository) FindExpired(ctx context.Context, before time.Time, limit int) ([]domain.Reservation, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+reservationColumns+` FROM reservations
+		WHERE state = 'pending' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations, err := pgx.CollectRows(rows, scanReservation)
+	if err != nil {
+		return nil, err
+	}
+	if reservations == nil {
+		reservations = []domain.Reservation{}
+	}
+
+	return reservations, nil
+}
+
+func scanReservation(row pgx.CollectableRow) (domain.Reservation, error) {
+	var r domain.Reservation
+	err := row.Scan(&r.OrderID, &r.Items, &r.State, &r.ExpiresAt, &r.CreatedBy, &r.CreatedAt, &r.UpdatedAt, &r.ConfirmedAt, &r.ReleasedAt)
+	return r, err
+}
@@ -0,0 +1,152 @@
+// internal/repository/postgres_schedule_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const scheduleColumns = "id, product_id, action, run_at, price, price_currency, state, error, created_by, created_at, applied_at"
+
+type postgresScheduleRepository struct {
+	pool *pgxpool.Pool
+	ids  domain.IDGenerator
+}
+
+func NewPostgresScheduleRepository(pool *pgxpool.Pool, ids domain.IDGenerator) ScheduleRepository {
+	return &postgresScheduleRepository{
+		pool: pool,
+		ids:  ids,
+	}
+}
+
+func (r *postgresScheduleRepository) Create(ctx context.Context, change domain.ScheduledChange) (*domain.ScheduledChange, error) {
+	amount, currency := moneyColumns(change.Price)
+	rows, err := r.pool.Query(ctx, `
+		INSERT INTO product_schedules (id, product_id, action, run_at, price, price_currency, state, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+scheduleColumns,
+		r.ids.NewID().String(), change.ProductID.String(), string(change.Action), change.RunAt, amount, currency,
+		string(domain.SchedulePending), change.CreatedBy, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := pgx.CollectOneRow(rows, scanSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *postgresScheduleRepository) FindByProduct(ctx context.Context, productID string) ([]domain.ScheduledChange, error) {
+	id, err := domain.ParseID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, "SELECT "+scheduleColumns+" FROM product_schedules WHERE product_id = $1 ORDER BY run_at, id", id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := pgx.CollectRows(rows, scanSchedule)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []domain.ScheduledChange{}
+	}
+
+	return changes, nil
+}
+
+func (r *postgresScheduleRepository) CountPending(ctx context.Context, productID string) (int64, error) {
+	id, err := domain.ParseID(productID)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = r.pool.QueryRow(ctx, "SELECT count(*) FROM product_schedules WHERE product_id = $1 AND state = $2",
+		id.String(), string(domain.SchedulePending)).Scan(&n)
+	return n, err
+}
+
+func (r *postgresScheduleRepository) Cancel(ctx context.Context, productID, id string) error {
+	pid, err := domain.ParseID(productID)
+	if err != nil {
+		return err
+	}
+	scheduleID, err := domain.ParseID(id)
+	if err != nil {
+		return domain.ErrScheduleNotFound
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE product_schedules SET state = $3
+		WHERE id = $1 AND product_id = $2 AND state = $4`,
+		scheduleID.String(), pid.String(), string(domain.ScheduleCancelled), string(domain.SchedulePending),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM product_schedules WHERE id = $1 AND product_id = $2)",
+			scheduleID.String(), pid.String()).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.ErrScheduleNotFound
+		}
+		return domain.ErrScheduleNotPending
+	}
+
+	return nil
+}
+
+func (r *postgresScheduleRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+scheduleColumns+` FROM product_schedules
+		WHERE state = $1 AND run_at <= $2
+		ORDER BY run_at, id
+		LIMIT $3`,
+		string(domain.SchedulePending), now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanSchedule)
+}
+
+func (r *postgresScheduleRepository) Complete(ctx context.Context, id domain.ID, state domain.ScheduleState, errMsg string) error {
+	// A change cancelled while it was being applied keeps its state
+	_, err := r.pool.Exec(ctx, `
+		UPDATE product_schedules SET state = $2, error = $3, applied_at = $4
+		WHERE id = $1 AND state = $5`,
+		id.String(), string(state), errMsg, time.Now(), string(domain.SchedulePending),
+	)
+	return err
+}
+
+func scanSchedule(row pgx.CollectableRow) (domain.ScheduledChange, error) {
+	var (
+		sc       domain.ScheduledChange
+		amount   *int64
+		currency *string
+	)
+	err := row.Scan(&sc.ID, &sc.ProductID, &sc.Action, &sc.RunAt, &amount, &currency, &sc.State, &sc.Error, &sc.CreatedBy, &sc.CreatedAt, &sc.AppliedAt)
+	sc.Price = scanMoney(amount, currency)
+	return sc, err
+}
@@ -0,0 +1,87 @@
+// internal/repository/price_history_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type PriceHistoryRepository interface {
+	// Record stores price changes, assigning their IDs
+	Record(ctx context.Context, changes []domain.PriceChange) error
+	// FindByProduct returns the price changes of a product matching query,
+	// most recent first
+	FindByProduct(ctx context.Context, productID domain.ID, query domain.PriceHistoryQuery) ([]domain.PriceChange, error)
+}
+
+type mongoPriceHistoryRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	ids        domain.IDGenerator
+}
+
+func NewPriceHistoryRepository(client *mongo.Client, database string, ids domain.IDGenerator) PriceHistoryRepository {
+	return &mongoPriceHistoryRepository{
+		client:     client,
+		database:   database,
+		collection: "price_history",
+		ids:        ids,
+	}
+}
+
+func (r *mongoPriceHistoryRepository) Record(ctx context.Context, changes []domain.PriceChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	docs := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		change.ID = r.ids.NewID()
+		docs = append(docs, change)
+	}
+
+	_, err := coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoPriceHistoryRepository) FindByProduct(ctx context.Context, productID domain.ID, query domain.PriceHistoryQuery) ([]domain.PriceChange, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"product_id": productID}
+	if query.From != nil || query.To != nil {
+		changedAt := bson.M{}
+		if query.From != nil {
+			changedAt["$gte"] = *query.From
+		}
+		if query.To != nil {
+			changedAt["$lte"] = *query.To
+		}
+		filter["changed_at"] = changedAt
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "changed_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []domain.PriceChange{}
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,132 @@
+// internal/repository/price_list_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type PriceListRepository interface {
+	// FindAll returns every price list, the default first and the others
+	// ordered by ID
+	FindAll(ctx context.Context) ([]domain.PriceList, error)
+	FindByID(ctx context.Context, id string) (*domain.PriceList, error)
+	// Create fails with domain.ErrPriceListExists if the ID is taken
+	Create(ctx context.Context, list domain.PriceList) (*domain.PriceList, error)
+	// Update replaces the name, currency, region and customer group of a
+	// price list. It returns nil if it does not exist.
+	Update(ctx context.Context, list domain.PriceList) (*domain.PriceList, error)
+	// Delete fails with domain.ErrPriceListNotFound if it does not exist
+	Delete(ctx context.Context, id string) error
+}
+
+type mongoPriceListRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewPriceListRepository(client *mongo.Client, database string) PriceListRepository {
+	return &mongoPriceListRepository{
+		client:     client,
+		database:   database,
+		collection: "price_lists",
+	}
+}
+
+func (r *mongoPriceListRepository) FindAll(ctx context.Context) ([]domain.PriceList, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "default", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	lists := []domain.PriceList{}
+	if err = cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func (r *mongoPriceListRepository) FindByID(ctx context.Context, id string) (*domain.PriceList, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	var list domain.PriceList
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&list)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (r *mongoPriceListRepository) Create(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	list.CreatedAt = time.Now()
+	list.UpdatedAt = list.CreatedAt
+
+	if _, err := coll.InsertOne(ctx, list); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrPriceListExists
+		}
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (r *mongoPriceListRepository) Update(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":           list.Name,
+			"currency":       list.Currency,
+			"region":         list.Region,
+			"customer_group": list.CustomerGroup,
+			"updated_at":     time.Now(),
+		},
+	}
+
+	var updated domain.PriceList
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": list.ID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *mongoPriceListRepository) Delete(ctx context.Context, id string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrPriceListNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// internal/repository/processed_event_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// ProcessedEventRepository remembers the events consumers processed, by
+// event ID.
+type ProcessedEventRepository interface {
+	IsProcessed(ctx context.Context, id string) (bool, error)
+	// Record stores a processed event; recording it again is a no-op
+	Record(ctx context.Context, event domain.ProcessedEvent) error
+	// Prune forgets the events processed before the given time and returns
+	// how many it forgot
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+type mongoProcessedEventRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewProcessedEventRepository(client *mongo.Client, database string) ProcessedEventRepository {
+	return &mongoProcessedEventRepository{
+		client:     client,
+		database:   database,
+		collection: "processed_events",
+	}
+}
+
+func (r *mongoProcessedEventRepository) IsProcessed(ctx context.Context, id string) (bool, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	n, err := coll.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *mongoProcessedEventRepository) Record(ctx context.Context, event domain.ProcessedEvent) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	if _, err := coll.InsertOne(ctx, event); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (r *mongoProcessedEventRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteMany(ctx, bson.M{"processed_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -3,6 +3,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,61 +17,539 @@ import (
 )
 
 type ProductRepository interface {
-	FindAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error)
-	FindByID(ctx context.Context, id string) (*domain.Product, error)
+	FindAll(ctx context.Context, filter domain.ProductFilter) (*domain.ProductPage, error)
+	Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchPage, error)
+	Facets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error)
+	// Reads skip products in the trash; FindByID includes them on request
+	FindByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error)
+	// FindBySKU and FindBySKUs match product and variant SKUs
+	FindBySKU(ctx context.Context, sku string) (*domain.Product, error)
+	FindBySKUs(ctx context.Context, skus []string) ([]domain.Product, error)
+	// FindSKUOwner returns a product other than excludeID, in the trash or
+	// not, that uses any of skus as product or variant SKU
+	FindSKUOwner(ctx context.Context, skus []string, excludeID domain.ID) (*domain.Product, error)
+	// CountWithAttribute counts the products, in the trash or not, that
+	// have a value for the named attribute
+	CountWithAttribute(ctx context.Context, name string) (int64, error)
+	// CountInCategory counts the products, in the trash or not, assigned
+	// to a category
+	CountInCategory(ctx context.Context, categoryID domain.ID) (int64, error)
+	// CountWithPriceList counts the products, in the trash or not, that
+	// have a price in a price list, themselves or in a variant
+	CountWithPriceList(ctx context.Context, priceListID string) (int64, error)
+	// ReplaceCategory reassigns every product, in the trash or not, from
+	// one category to another and returns the IDs of the products changed.
+	// With from equal to to it only replaces the slug.
+	ReplaceCategory(ctx context.Context, from domain.ID, fromSlug string, to domain.ID, toSlug string) ([]domain.ID, error)
 	Create(ctx context.Context, product domain.Product) (*domain.Product, error)
-	Update(ctx context.Context, id string, product domain.Product) (*domain.Product, error)
-	Delete(ctx context.Context, id string) error
+	// AdjustStock atomically applies a change to the stock of a product, or
+	// of its variant sku if given, and bumps its version. It returns nil if
+	// the product does not exist or is in the trash, and fails with
+	// domain.ErrInsufficientInventory if the available or the reserved
+	// stock would drop below zero.
+	AdjustStock(ctx context.Context, id domain.ID, sku string, change domain.StockChange) (*domain.Product, error)
+	// Update and Delete only apply when the stored version equals
+	// expectedVersion, or unconditionally when it is 0. A mismatch is
+	// reported as domain.ErrVersionMismatch.
+	Update(ctx context.Context, id string, product domain.Product, expectedVersion int64) (*domain.Product, error)
+	// Delete moves a product to the trash, recording who deleted it
+	Delete(ctx context.Context, id string, expectedVersion int64, deletedBy string) error
+	// Restore takes a product out of the trash. It returns nil if the
+	// product does not exist and domain.ErrProductNotDeleted if it is not
+	// in the trash.
+	Restore(ctx context.Context, id string, expectedVersion int64) (*domain.Product, error)
+	// Purge permanently removes up to limit products deleted before the
+	// given time and returns their IDs. What refers to them goes too: their
+	// history, stock, reserved items and promotion targets.
+	Purge(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.ID, error)
+	// Transition moves a product from one lifecycle state to another,
+	// recording who changed it. It returns nil if the product does not exist
+	// and a domain.TransitionError if it is no longer in the from state.
+	Transition(ctx context.Context, id string, from, to domain.Status, changedBy string, expectedVersion int64) (*domain.Product, error)
+}
+
+// mongoSortFields maps the whitelisted sort_by values to indexed document fields.
+var mongoSortFields = map[string]string{
+	"name":       "name",
+	"price":      "price.amount",
+	"sku":        "sku",
+	"inventory":  "inventory",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
 }
 
 type mongoProductRepository struct {
 	client     *mongo.Client
 	database   string
 	collection string
+	ids        domain.IDGenerator
 }
 
-func NewProductRepository(client *mongo.Client, database string) ProductRepository {
+func NewProductRepository(client *mongo.Client, database string, ids domain.IDGenerator) ProductRepository {
 	return &mongoProductRepository{
 		client:     client,
 		database:   database,
 		collection: "products",
+		ids:        ids,
 	}
 }
 
-func (r *mongoProductRepository) FindAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+func (r *mongoProductRepository) FindAll(ctx context.Context, filter domain.ProductFilter) (*domain.ProductPage, error) {
 	coll := r.client.Database(r.database).Collection(r.collection)
 
+	var cur *pageCursor
+	if filter.Cursor != "" {
+		var err error
+		if cur, err = decodeCursor(filter.Cursor, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	filterBson := listFilter(filter)
+
+	total, err := coll.CountDocuments(ctx, filterBson)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort by the requested field with _id as tie-breaker so that keyset
+	// pagination has a total order. Paging backwards flips the direction.
+	order := 1
+	if filter.SortOrder == "desc" {
+		order = -1
+	}
+	if cur != nil && cur.Backward {
+		order = -order
+	}
+
+	sortField, sorted := mongoSortFields[filter.SortBy]
+
+	sort := bson.D{}
+	if sorted {
+		sort = append(sort, bson.E{Key: sortField, Value: order})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: order})
+
 	findOptions := options.Find()
-	findOptions.SetLimit(int64(filter.Limit))
-	findOptions.SetSkip(int64(filter.Offset))
+	findOptions.SetSort(sort)
+	findOptions.SetLimit(int64(filter.Limit) + 1)
+	if filter.FieldSet != nil {
+		findOptions.SetProjection(mongoProjection(filter.FieldSet.Stored(), sortField))
+	}
+
+	if cur != nil {
+		filterBson = bson.M{"$and": bson.A{filterBson, keysetFilter(sortField, cur, order)}}
+	} else {
+		findOptions.SetSkip(int64(filter.Offset))
+	}
 
-	if filter.SortBy != "" {
-		order := 1
-		if filter.SortOrder == "desc" {
-			order = -1
+	cursor, err := coll.Find(ctx, filterBson, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []domain.Product
+	if err = cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	return newProductPage(products, total, filter, cur), nil
+}
+
+// Search runs a $text query against the weighted text index. When no product
+// matches the terms exactly it falls back to prefix and typo tolerant regex
+// matching, ranked in memory.
+func (r *mongoProductRepository) Search(ctx context.Context, q domain.SearchQuery) (*domain.SearchPage, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+	matcher := newSearchMatcher(q.Terms)
+	base := listFilter(q.ProductFilter)
+
+	textFilter := bson.M{"$text": bson.M{"$search": strings.Join(q.Terms, " ")}}
+	for k, v := range base {
+		textFilter[k] = v
+	}
+
+	total, err := coll.CountDocuments(ctx, textFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	order := 1
+	if q.SortOrder == "desc" {
+		order = -1
+	}
+	sortField, sorted := mongoSortFields[q.SortBy]
+
+	if total == 0 {
+		or := bson.A{}
+		for _, f := range searchFields {
+			or = append(or, bson.M{f.name: bson.M{"$regex": primitive.Regex{Pattern: matcher.pattern, Options: "i"}}})
+		}
+
+		findOptions := options.Find().SetLimit(fuzzyCandidateLimit)
+		if sorted {
+			findOptions.SetSort(bson.D{{Key: sortField, Value: order}, {Key: "_id", Value: order}})
+		}
+
+		cursor, err := coll.Find(ctx, bson.M{"$and": bson.A{base, bson.M{"$or": or}}}, findOptions)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		var candidates []domain.Product
+		if err = cursor.All(ctx, &candidates); err != nil {
+			return nil, err
+		}
+
+		return matcher.fuzzyPage(candidates, q), nil
+	}
+
+	sort := bson.D{}
+	if sorted {
+		sort = append(sort, bson.E{Key: sortField, Value: order})
+	} else {
+		sort = append(sort, bson.E{Key: "score", Value: bson.M{"$meta": "textScore"}})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: order})
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(sort).
+		SetSkip(int64(q.Offset)).
+		SetLimit(int64(q.Limit))
+
+	cursor, err := coll.Find(ctx, textFilter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		domain.Product `bson:",inline"`
+		Score          float64 `bson:"score"`
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	products := make([]domain.Product, 0, len(docs))
+	scores := make([]float64, 0, len(docs))
+	for _, d := range docs {
+		products = append(products, d.Product)
+		scores = append(scores, d.Score)
+	}
+
+	return &domain.SearchPage{
+		Items: matcher.scoredResults(products, scores),
+		Total: total,
+	}, nil
+}
+
+// Facets computes every facet in a single $facet aggregation over the
+// products matching filter.
+func (r *mongoProductRepository) Facets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	facetStages := bson.M{
+		"categories": bson.A{
+			bson.M{"$unwind": "$categories"},
+			bson.M{"$sortByCount": "$categories"},
+			bson.M{"$limit": domain.FacetCategoryLimit},
+		},
+		"price_buckets": bson.A{
+			bson.M{"$bucketAuto": bson.M{
+				"groupBy": "$price.amount",
+				"buckets": domain.FacetPriceBuckets,
+				"output": bson.M{
+					"min":   bson.M{"$min": "$price.amount"},
+					"max":   bson.M{"$max": "$price.amount"},
+					"count": bson.M{"$sum": 1},
+				},
+			}},
+		},
+		"summary": bson.A{
+			bson.M{"$group": bson.M{
+				"_id":       nil,
+				"total":     bson.M{"$sum": 1},
+				"in_stock":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$available", 0}}, 1, 0}}},
+				"min_price": bson.M{"$min": "$price.amount"},
+				"max_price": bson.M{"$max": "$price.amount"},
+			}},
+		},
+	}
+	for i, name := range filter.FacetAttributes {
+		field := "attributes." + name
+		facetStages[fmt.Sprintf("attribute_%d", i)] = bson.A{
+			bson.M{"$match": bson.M{field: bson.M{"$exists": true}}},
+			bson.M{"$sortByCount": "$" + field},
+			bson.M{"$limit": domain.FacetAttributeValueLimit},
 		}
-		findOptions.SetSort(bson.D{{Key: filter.SortBy, Value: order}})
 	}
 
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: listFilter(filter)}},
+		{{Key: "$facet", Value: facetStages}},
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Categories []struct {
+			ID    string `bson:"_id"`
+			Count int64  `bson:"count"`
+		} `bson:"categories"`
+		PriceBuckets []struct {
+			Min   int64 `bson:"min"`
+			Max   int64 `bson:"max"`
+			Count int64 `bson:"count"`
+		} `bson:"price_buckets"`
+		Summary []struct {
+			Total    int64 `bson:"total"`
+			InStock  int64 `bson:"in_stock"`
+			MinPrice int64 `bson:"min_price"`
+			MaxPrice int64 `bson:"max_price"`
+		} `bson:"summary"`
+		// Attributes collects the attribute_<i> facets
+		Attributes map[string][]struct {
+			Value interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:",inline"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	facets := &domain.ProductFacets{
+		Categories:   []domain.CategoryCount{},
+		PriceBuckets: []domain.PriceBucket{},
+	}
+	if len(results) == 0 {
+		return facets, nil
+	}
+
+	res := results[0]
+	for _, c := range res.Categories {
+		facets.Categories = append(facets.Categories, domain.CategoryCount{Category: c.ID, Count: c.Count})
+	}
+	for _, b := range res.PriceBuckets {
+		facets.PriceBuckets = append(facets.PriceBuckets, domain.PriceBucket{
+			Min:   domain.Money{Amount: b.Min, Currency: filter.Currency},
+			Max:   domain.Money{Amount: b.Max, Currency: filter.Currency},
+			Count: b.Count,
+		})
+	}
+	if len(res.Summary) > 0 {
+		sum := res.Summary[0]
+		facets.Total = sum.Total
+		facets.InStock = sum.InStock
+		facets.OutOfStock = sum.Total - sum.InStock
+		facets.MinPrice = &domain.Money{Amount: sum.MinPrice, Currency: filter.Currency}
+		facets.MaxPrice = &domain.Money{Amount: sum.MaxPrice, Currency: filter.Currency}
+	}
+	for i, name := range filter.FacetAttributes {
+		facet := domain.AttributeFacet{Name: name, Values: []domain.AttributeValueCount{}}
+		for _, v := range res.Attributes[fmt.Sprintf("attribute_%d", i)] {
+			facet.Values = append(facet.Values, domain.AttributeValueCount{Value: v.Value, Count: v.Count})
+		}
+		facets.Attributes = append(facets.Attributes, facet)
+	}
+
+	return facets, nil
+}
+
+// listFilter translates the listing parameters of filter into a MongoDB query.
+func listFilter(filter domain.ProductFilter) bson.M {
 	filterBson := bson.M{}
+	if !filter.IncludeDeleted {
+		filterBson["deleted_at"] = nil
+	}
+	if len(filter.Status) > 0 {
+		filterBson["status"] = bson.M{"$in": filter.Status}
+	}
+	if len(filter.VariantAttributes) > 0 {
+		// Every attribute has to match on the same variant
+		elem := bson.M{}
+		for name, value := range filter.VariantAttributes {
+			elem["attributes."+name] = value
+		}
+		filterBson["variants"] = bson.M{"$elemMatch": elem}
+	}
+	for _, a := range filter.AttributeFilters {
+		if a.Value != nil {
+			filterBson["attributes."+a.Name] = a.Value
+			continue
+		}
+		bounds := bson.M{}
+		if a.Min != nil {
+			bounds["$gte"] = *a.Min
+		}
+		if a.Max != nil {
+			bounds["$lte"] = *a.Max
+		}
+		filterBson["attributes."+a.Name] = bounds
+	}
 	if filter.Name != "" {
-		filterBson["name"] = bson.M{"$regex": primitive.Regex{Pattern: filter.Name, Options: "i"}}
+		filterBson["name"] = bson.M{"$regex": nameRegex(filter.Name, filter.NameMatch)}
 	}
 	if len(filter.Categories) > 0 {
 		filterBson["categories"] = bson.M{"$in": filter.Categories}
 	}
-	if filter.MinPrice > 0 {
-		filterBson["price"] = bson.M{"$gte": filter.MinPrice}
+	if len(filter.CategoryIDs) > 0 {
+		filterBson["category_ids"] = bson.M{"$in": filter.CategoryIDs}
 	}
-	if filter.MaxPrice > 0 {
-		if _, ok := filterBson["price"]; ok {
-			filterBson["price"].(bson.M)["$lte"] = filter.MaxPrice
+	if filter.Location != "" {
+		filterBson["_id"] = bson.M{"$in": filter.StockedIDs}
+	}
+	if filter.MinAmount != nil {
+		filterBson["price.amount"] = bson.M{"$gte": *filter.MinAmount}
+	}
+	if filter.MaxAmount != nil {
+		if _, ok := filterBson["price.amount"]; ok {
+			filterBson["price.amount"].(bson.M)["$lte"] = *filter.MaxAmount
 		} else {
-			filterBson["price"] = bson.M{"$lte": filter.MaxPrice}
+			filterBson["price.amount"] = bson.M{"$lte": *filter.MaxAmount}
 		}
 	}
+	if filter.Expr != nil {
+		filterBson = bson.M{"$and": bson.A{filterBson, mongoFilter(filter.Expr)}}
+	}
 
-	cursor, err := coll.Find(ctx, filterBson, findOptions)
+	return filterBson
+}
+
+// replaceElement is an update pipeline expression that replaces from with
+// to in an array field, in place, or drops from if to is already present.
+func replaceElement(field string, from, to interface{}) bson.M {
+	arr := bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}}
+	from, to = bson.M{"$literal": from}, bson.M{"$literal": to}
+
+	return bson.M{"$cond": bson.A{
+		bson.M{"$and": bson.A{bson.M{"$in": bson.A{to, arr}}, bson.M{"$ne": bson.A{from, to}}}},
+		bson.M{"$filter": bson.M{"input": arr, "cond": bson.M{"$ne": bson.A{"$$this", from}}}},
+		bson.M{"$map": bson.M{"input": arr, "in": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$this", from}}, to, "$$this"}}}},
+	}}
+}
+
+// mongoProjection selects the fields of a sparse fieldset. The sort field is
+// always fetched because the page cursors are built from it, unless it or
+// one of its parents is already selected: MongoDB rejects a projection of
+// both price and price.amount as a path collision.
+func mongoProjection(fields domain.FieldSet, sortField string) bson.M {
+	projection := bson.M{"_id": 1}
+	for _, f := range fields {
+		if f != "id" {
+			projection[f] = 1
+		}
+	}
+	if sortField != "" && !projectsPath(projection, sortField) {
+		projection[sortField] = 1
+	}
+	return projection
+}
+
+// projectsPath reports whether projection selects the dotted path or one of
+// its parents.
+func projectsPath(projection bson.M, path string) bool {
+	for {
+		if _, ok := projection[path]; ok {
+			return true
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+}
+
+// nameRegex builds a case-insensitive regex matching name literally in the
+// given mode. User input is always escaped so it cannot inject patterns.
+func nameRegex(name, mode string) primitive.Regex {
+	quoted := regexp.QuoteMeta(name)
+	switch mode {
+	case domain.NameMatchContains:
+		return primitive.Regex{Pattern: quoted, Options: "i"}
+	case domain.NameMatchPrefix:
+		return primitive.Regex{Pattern: "^" + quoted, Options: "i"}
+	default:
+		return primitive.Regex{Pattern: "^" + quoted + "$", Options: "i"}
+	}
+}
+
+// keysetFilter matches the documents that come after cur in the given sort
+// direction.
+func keysetFilter(sortBy string, cur *pageCursor, order int) bson.M {
+	op := "$gt"
+	if order < 0 {
+		op = "$lt"
+	}
+
+	if sortBy == "" {
+		return bson.M{"_id": bson.M{op: cur.ID}}
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{sortBy: bson.M{op: cur.Value}},
+		bson.M{sortBy: cur.Value, "_id": bson.M{op: cur.ID}},
+	}}
+}
+
+func (r *mongoProductRepository) FindByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": productID}
+	if !includeDeleted {
+		filter["deleted_at"] = nil
+	}
+
+	var product domain.Product
+	err = coll.FindOne(ctx, filter).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *mongoProductRepository) FindBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	var product domain.Product
+	err := coll.FindOne(ctx, bson.M{
+		"$or":        bson.A{bson.M{"sku": sku}, bson.M{"variants.sku": sku}},
+		"deleted_at": nil,
+	}).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *mongoProductRepository) FindBySKUs(ctx context.Context, skus []string) ([]domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	cursor, err := coll.Find(ctx, bson.M{
+		"$or":        bson.A{bson.M{"sku": bson.M{"$in": skus}}, bson.M{"variants.sku": bson.M{"$in": skus}}},
+		"deleted_at": nil,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -82,16 +563,16 @@ func (r *mongoProductRepository) FindAll(ctx context.Context, filter domain.Prod
 	return products, nil
 }
 
-func (r *mongoProductRepository) FindByID(ctx context.Context, id string) (*domain.Product, error) {
+func (r *mongoProductRepository) FindSKUOwner(ctx context.Context, skus []string, excludeID domain.ID) (*domain.Product, error) {
 	coll := r.client.Database(r.database).Collection(r.collection)
 
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, err
+	filter := bson.M{"$or": bson.A{bson.M{"sku": bson.M{"$in": skus}}, bson.M{"variants.sku": bson.M{"$in": skus}}}}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
 	}
 
 	var product domain.Product
-	err = coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+	err := coll.FindOne(ctx, filter).Decode(&product)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -102,63 +583,450 @@ func (r *mongoProductRepository) FindByID(ctx context.Context, id string) (*doma
 	return &product, nil
 }
 
+func (r *mongoProductRepository) CountWithAttribute(ctx context.Context, name string) (int64, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	return coll.CountDocuments(ctx, bson.M{"attributes." + name: bson.M{"$exists": true}})
+}
+
+func (r *mongoProductRepository) CountInCategory(ctx context.Context, categoryID domain.ID) (int64, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	return coll.CountDocuments(ctx, bson.M{"category_ids": categoryID})
+}
+
+func (r *mongoProductRepository) CountWithPriceList(ctx context.Context, priceListID string) (int64, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	return coll.CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"prices." + priceListID: bson.M{"$exists": true}},
+		bson.M{"variants.prices." + priceListID: bson.M{"$exists": true}},
+	}})
+}
+
+func (r *mongoProductRepository) ReplaceCategory(ctx context.Context, from domain.ID, fromSlug string, to domain.ID, toSlug string) ([]domain.ID, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	cursor, err := coll.Find(ctx, bson.M{"category_ids": from}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matched []struct {
+		ID domain.ID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &matched); err != nil {
+		return nil, err
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]domain.ID, 0, len(matched))
+	for _, m := range matched {
+		ids = append(ids, m.ID)
+	}
+
+	update := bson.A{bson.M{"$set": bson.M{
+		"category_ids": replaceElement("category_ids", from, to),
+		"categories":   replaceElement("categories", fromSlug, toSlug),
+		"version":      bson.M{"$add": bson.A{"$version", 1}},
+		"updated_at":   "$$NOW",
+	}}}
+	if _, err := coll.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "category_ids": from}, update); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 func (r *mongoProductRepository) Create(ctx context.Context, product domain.Product) (*domain.Product, error) {
 	coll := r.client.Database(r.database).Collection(r.collection)
 
-	product.ID = primitive.NewObjectID()
-	product.CreatedAt = time.Now()
-	product.UpdatedAt = time.Now()
+	now := time.Now()
+	product.ID = r.ids.NewID()
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	product.StatusChangedAt = &now
+	product.Version = 1
 
 	_, err := coll.InsertOne(ctx, product)
 	if err != nil {
-		return nil, err
+		return nil, r.duplicateSKUError(ctx, err, product.SKUs(), product.ID)
 	}
 
 	return &product, nil
 }
 
-func (r *mongoProductRepository) Update(ctx context.Context, id string, product domain.Product) (*domain.Product, error) {
+func (r *mongoProductRepository) Update(ctx context.Context, id string, product domain.Product, expectedVersion int64) (*domain.Product, error) {
 	coll := r.client.Database(r.database).Collection(r.collection)
 
-	objID, err := primitive.ObjectIDFromHex(id)
+	productID, err := domain.ParseID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	product.UpdatedAt = time.Now()
+	if product.Categories == nil {
+		product.Categories = []string{}
+	}
+	if product.Options == nil {
+		product.Options = []domain.ProductOption{}
+	}
+	if product.Variants == nil {
+		product.Variants = []domain.Variant{}
+	}
+	if product.Attributes == nil {
+		product.Attributes = map[string]interface{}{}
+	}
+	if product.CategoryIDs == nil {
+		product.CategoryIDs = []domain.ID{}
+	}
 
-	filter := bson.M{"_id": objID}
-	update := bson.M{"$set": product}
+	// Replace every client-managed field; the ID and creation time are kept
+	filter := bson.M{"_id": productID, "deleted_at": nil}
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+	update := bson.M{"$inc": bson.M{"version": 1}, "$set": bson.M{
+		"name":         product.Name,
+		"description":  product.Description,
+		"price":        product.Price,
+		"sku":          product.SKU,
+		"inventory":    product.Inventory,
+		"reserved":     product.Reserved,
+		"available":    product.Available,
+		"categories":   product.Categories,
+		"options":      product.Options,
+		"variants":     product.Variants,
+		"attributes":   product.Attributes,
+		"category_ids": product.CategoryIDs,
+		"prices":       product.Prices,
+		"updated_at":   time.Now(),
+	}}
 
 	result := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
 
 	var updatedProduct domain.Product
 	if err := result.Decode(&updatedProduct); err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil
+			return nil, r.versionMismatch(ctx, productID, expectedVersion)
 		}
-		return nil, err
+		return nil, r.duplicateSKUError(ctx, err, product.SKUs(), productID)
+	}
+
+	return &updatedProduct, nil
+}
+
+func (r *mongoProductRepository) AdjustStock(ctx context.Context, id domain.ID, sku string, change domain.StockChange) (*domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	// The guards against negative stock are part of the filter, so checking
+	// and incrementing are a single atomic operation
+	guards := bson.M{}
+	if available := change.Available(); available < 0 {
+		guards["available"] = bson.M{"$gte": -available}
+	}
+	if change.Reserved < 0 {
+		guards["reserved"] = bson.M{"$gte": -change.Reserved}
+	}
+
+	filter := bson.M{"_id": id, "deleted_at": nil}
+	inc := bson.M{"inventory": change.OnHand, "reserved": change.Reserved, "available": change.Available(), "version": 1}
+	if sku == "" {
+		filter["variants.0"] = bson.M{"$exists": false}
+		for field, guard := range guards {
+			filter[field] = guard
+		}
+	} else {
+		guards["sku"] = sku
+		filter["variants"] = bson.M{"$elemMatch": guards}
+		inc["variants.$.inventory"] = change.OnHand
+		inc["variants.$.reserved"] = change.Reserved
+		inc["variants.$.available"] = change.Available()
+	}
+	update := bson.M{"$inc": inc, "$set": bson.M{"updated_at": time.Now()}}
+
+	result := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	var updatedProduct domain.Product
+	if err := result.Decode(&updatedProduct); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		n, err := coll.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil}, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return nil, domain.ErrInsufficientInventory
+		}
+		return nil, nil
 	}
 
 	return &updatedProduct, nil
 }
 
-func (r *mongoProductRepository) Delete(ctx context.Context, id string) error {
+func (r *mongoProductRepository) Delete(ctx context.Context, id string, expectedVersion int64, deletedBy string) error {
 	coll := r.client.Database(r.database).Collection(r.collection)
 
-	objID, err := primitive.ObjectIDFromHex(id)
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": productID, "deleted_at": nil}
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+
+	now := time.Now()
+	result, err := coll.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"version": 1},
+		"$set": bson.M{"deleted_at": now, "deleted_by": deletedBy, "updated_at": now},
+	})
 	if err != nil {
 		return err
 	}
 
-	result, err := coll.DeleteOne(ctx, bson.M{"_id": objID})
+	if result.MatchedCount == 0 {
+		if err := r.versionMismatch(ctx, productID, expectedVersion); err != nil {
+			return err
+		}
+		return domain.ErrProductNotFound
+	}
+
+	return nil
+}
+
+func (r *mongoProductRepository) Restore(ctx context.Context, id string, expectedVersion int64) (*domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": productID, "deleted_at": bson.M{"$ne": nil}}
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+	update := bson.M{
+		"$inc":   bson.M{"version": 1},
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+	}
+
+	result := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	var restored domain.Product
+	if err := result.Decode(&restored); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		current, err := r.FindByID(ctx, productID.String(), true)
+		switch {
+		case err != nil || current == nil:
+			return nil, err
+		case current.DeletedAt == nil:
+			return nil, domain.ErrProductNotDeleted
+		default:
+			return nil, domain.ErrVersionMismatch
+		}
+	}
+
+	return &restored, nil
+}
+
+func (r *mongoProductRepository) Purge(ctx context.Context, deletedBefore time.Time, limit int) ([]domain.ID, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}
+
+	cursor, err := coll.Find(ctx, filter, options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.M{"deleted_at": 1}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID domain.ID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]domain.ID, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+
+	// Re-check the deletion time in case a product was restored meanwhile
+	filter["_id"] = bson.M{"$in": ids}
+	if _, err := coll.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	// Scheduled changes, price history, the inventory ledger and the stock
+	// at each location go with their product, like the foreign key
+	// cascades of the Postgres schema
+	for _, name := range []string{"product_schedules", "price_history", "inventory_movements", "location_stock"} {
+		related := r.client.Database(r.database).Collection(name)
+		if _, err := related.DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": ids}}); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.purgeReservedItems(ctx, ids); err != nil {
+		return nil, err
+	}
+	if err := r.purgePromotionTargets(ctx, ids); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// purgeReservedItems removes the items of purged products from
+// reservations, and the reservations left without items.
+func (r *mongoProductRepository) purgeReservedItems(ctx context.Context, ids []domain.ID) error {
+	reservations := r.client.Database(r.database).Collection("reservations")
+
+	_, err := reservations.UpdateMany(ctx,
+		bson.M{"items.product_id": bson.M{"$in": ids}},
+		bson.M{
+			"$pull": bson.M{"items": bson.M{"product_id": bson.M{"$in": ids}}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+	_, err = reservations.DeleteMany(ctx, bson.M{"items": bson.M{"$size": 0}})
+	return err
+}
+
+// purgePromotionTargets removes purged products from the targets of
+// promotions. A promotion left without targets would apply to every
+// product, so it is deactivated instead.
+func (r *mongoProductRepository) purgePromotionTargets(ctx context.Context, ids []domain.ID) error {
+	promotions := r.client.Database(r.database).Collection("promotions")
+
+	_, err := promotions.UpdateMany(ctx, bson.M{"product_ids": bson.M{"$in": ids}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"product_ids": bson.M{"$filter": bson.M{
+				"input": "$product_ids",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this", ids}}}},
+			}},
+			"updated_at": time.Now(),
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"active": bson.M{"$and": bson.A{"$active", bson.M{"$gt": bson.A{
+				bson.M{"$add": bson.A{
+					bson.M{"$size": "$product_ids"},
+					bson.M{"$size": bson.M{"$ifNull": bson.A{"$category_ids", bson.A{}}}},
+					bson.M{"$size": bson.M{"$ifNull": bson.A{"$skus", bson.A{}}}},
+				}},
+				0,
+			}}}},
+		}}},
+	})
+	return err
+}
+
+func (r *mongoProductRepository) Transition(ctx context.Context, id string, from, to domain.Status, changedBy string, expectedVersion int64) (*domain.Product, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Matching on the current status keeps concurrent transitions from
+	// skipping a step of the state machine
+	filter := bson.M{"_id": productID, "deleted_at": nil, "status": from}
+	if expectedVersion > 0 {
+		filter["version"] = expectedVersion
+	}
+
+	now := time.Now()
+	update := bson.M{"$inc": bson.M{"version": 1}, "$set": bson.M{
+		"status":            to,
+		"status_changed_at": now,
+		"status_changed_by": changedBy,
+		"updated_at":        now,
+	}}
+
+	result := coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	var updated domain.Product
+	if err := result.Decode(&updated); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		current, err := r.FindByID(ctx, productID.String(), false)
+		switch {
+		case err != nil || current == nil:
+			return nil, err
+		case expectedVersion > 0 && current.Version != expectedVersion:
+			return nil, domain.ErrVersionMismatch
+		default:
+			return nil, &domain.TransitionError{From: current.Status, To: to}
+		}
+	}
+
+	return &updated, nil
+}
+
+// versionMismatch is called when a conditional write matched no document. It
+// returns domain.ErrVersionMismatch if the product exists and nil if it does
+// not, leaving the not-found handling to the caller.
+func (r *mongoProductRepository) versionMismatch(ctx context.Context, id domain.ID, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		return nil
 	}
 
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	n, err := coll.CountDocuments(ctx, bson.M{"_id": id, "deleted_at": nil}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrVersionMismatch
+	}
 	return nil
 }
+
+// duplicateSKUError converts a unique index violation on a product or
+// variant SKU into a DuplicateSKUError naming the product that owns the SKU.
+// Other errors are returned unchanged.
+func (r *mongoProductRepository) duplicateSKUError(ctx context.Context, err error, skus []string, productID domain.ID) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	// The owner may be in the trash, which FindBySKU does not look at
+	dupErr := &domain.DuplicateSKUError{SKU: skus[0]}
+	if owner, findErr := r.FindSKUOwner(ctx, skus, productID); findErr == nil && owner != nil {
+		dupErr.ProductID = owner.ID
+		dupErr.Deleted = owner.DeletedAt != nil
+		for _, sku := range skus {
+			if owner.HasSKU(sku) {
+				dupErr.SKU = sku
+				break
+			}
+		}
+	}
+
+	return dupErr
+}
@@ -0,0 +1,59 @@
+// internal/repository/product_repository_test.go
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+func TestMongoProjection(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    domain.FieldSet
+		sortField string
+		want      bson.M
+	}{
+		{
+			name:   "fields only",
+			fields: domain.FieldSet{"id", "name"},
+			want:   bson.M{"_id": 1, "name": 1},
+		},
+		{
+			name:      "sort field added",
+			fields:    domain.FieldSet{"name"},
+			sortField: "price.amount",
+			want:      bson.M{"_id": 1, "name": 1, "price.amount": 1},
+		},
+		{
+			name:      "sort field under a selected field",
+			fields:    domain.FieldSet{"name", "price"},
+			sortField: "price.amount",
+			want:      bson.M{"_id": 1, "name": 1, "price": 1},
+		},
+		{
+			name:      "sort field selected",
+			fields:    domain.FieldSet{"sku"},
+			sortField: "sku",
+			want:      bson.M{"_id": 1, "sku": 1},
+		},
+		{
+			name:      "sibling path",
+			fields:    domain.FieldSet{"prices"},
+			sortField: "price.amount",
+			want:      bson.M{"_id": 1, "prices": 1, "price.amount": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mongoProjection(tt.fields, tt.sortField)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mongoProjection(%v, %q) = %v, want %v", tt.fields, tt.sortField, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// internal/repository/promotion_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type PromotionRepository interface {
+	// FindAll returns every promotion, including inactive and expired ones,
+	// by priority, highest first, then by ID
+	FindAll(ctx context.Context) ([]domain.Promotion, error)
+	FindByID(ctx context.Context, id string) (*domain.Promotion, error)
+	Create(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error)
+	// Update replaces everything but the creation time of a promotion. It
+	// returns nil if it does not exist.
+	Update(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error)
+	// Delete fails with domain.ErrPromotionNotFound if it does not exist
+	Delete(ctx context.Context, id domain.ID) error
+}
+
+type mongoPromotionRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	ids        domain.IDGenerator
+}
+
+func NewPromotionRepository(client *mongo.Client, database string, ids domain.IDGenerator) PromotionRepository {
+	return &mongoPromotionRepository{
+		client:     client,
+		database:   database,
+		collection: "promotions",
+		ids:        ids,
+	}
+}
+
+func (r *mongoPromotionRepository) FindAll(ctx context.Context) ([]domain.Promotion, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	promotions := []domain.Promotion{}
+	if err = cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (r *mongoPromotionRepository) FindByID(ctx context.Context, id string) (*domain.Promotion, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	promotionID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var promotion domain.Promotion
+	err = coll.FindOne(ctx, bson.M{"_id": promotionID}).Decode(&promotion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (r *mongoPromotionRepository) Create(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	promotion.ID = r.ids.NewID()
+	promotion.CreatedAt = time.Now()
+	promotion.UpdatedAt = promotion.CreatedAt
+
+	if _, err := coll.InsertOne(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (r *mongoPromotionRepository) Update(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":          promotion.Name,
+			"description":   promotion.Description,
+			"type":          promotion.Type,
+			"percent":       promotion.Percent,
+			"amount_off":    promotion.AmountOff,
+			"buy_quantity":  promotion.BuyQuantity,
+			"free_quantity": promotion.FreeQuantity,
+			"product_ids":   promotion.ProductIDs,
+			"category_ids":  promotion.CategoryIDs,
+			"skus":          promotion.SKUs,
+			"starts_at":     promotion.StartsAt,
+			"ends_at":       promotion.EndsAt,
+			"priority":      promotion.Priority,
+			"stackable":     promotion.Stackable,
+			"active":        promotion.Active,
+			"updated_at":    time.Now(),
+		},
+	}
+
+	var updated domain.Promotion
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": promotion.ID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *mongoPromotionRepository) Delete(ctx context.Context, id domain.ID) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	result, err := coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrPromotionNotFound
+	}
+
+	return nil
+}
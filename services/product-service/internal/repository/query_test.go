@@ -0,0 +1,83 @@
+// internal/repository/query_test.go
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/ntdt/product-service/internal/query"
+)
+
+func parseFilter(t *testing.T, filter string) query.Expr {
+	t.Helper()
+	expr, err := query.Parse(filter, query.ProductSchema)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", filter, err)
+	}
+	return expr
+}
+
+func TestSQLFilter(t *testing.T) {
+	tests := []struct {
+		filter   string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"price ge 10", "price >= $1", []interface{}{10.0}},
+		{"sku ne 'A'", "sku IS DISTINCT FROM $1", []interface{}{"A"}},
+		{"inventory gt 0 and (name contains '50%' or not sku eq 'B')", "(inventory > $1 AND (name ILIKE $2 OR NOT COALESCE(sku = $3, false)))", []interface{}{int64(0), `%50\%%`, "B"}},
+		{"sku in ('A', 'B')", "sku IN ($1, $2)", []interface{}{"A", "B"}},
+		{"categories any ('shoes', 'sale')", "categories && $1", []interface{}{[]string{"shoes", "sale"}}},
+		{"categories contains 'shoes'", "$1 = ANY(categories)", []interface{}{"shoes"}},
+		{"categories eq 'shoes'", "$1 = ANY(categories)", []interface{}{"shoes"}},
+		{"categories ne 'shoes'", "NOT COALESCE($1 = ANY(categories), false)", []interface{}{"shoes"}},
+		{"description exists", "description IS NOT NULL", nil},
+		{"not deleted_at lt '2026-01-01'", "NOT COALESCE(deleted_at < $1, false)", []interface{}{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	for _, tt := range tests {
+		var args []interface{}
+		addArg := func(v interface{}) string {
+			args = append(args, v)
+			return fmt.Sprintf("$%d", len(args))
+		}
+
+		if got := sqlFilter(parseFilter(t, tt.filter), addArg); got != tt.wantSQL {
+			t.Errorf("sqlFilter(%q) = %s, want %s", tt.filter, got, tt.wantSQL)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("sqlFilter(%q) args = %#v, want %#v", tt.filter, args, tt.wantArgs)
+		}
+	}
+}
+
+func TestMongoFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   bson.M
+	}{
+		{"price ge 10", bson.M{"price.amount": bson.M{"$gte": 10.0}}},
+		{"sku eq 'A'", bson.M{"sku": "A"}},
+		{"sku in ('A', 'B')", bson.M{"sku": bson.M{"$in": []interface{}{"A", "B"}}}},
+		{"name contains 'a.b'", bson.M{"name": bson.M{"$regex": primitive.Regex{Pattern: `a\.b`, Options: "i"}}}},
+		{"categories contains 'shoes'", bson.M{"categories": "shoes"}},
+		{"description exists", bson.M{"description": bson.M{"$exists": true, "$ne": nil}}},
+		{"not sku eq 'A'", bson.M{"$nor": bson.A{bson.M{"sku": "A"}}}},
+		{"sku ne 'A'", bson.M{"sku": bson.M{"$ne": "A"}}},
+		{"inventory gt 0 and (sku eq 'A' or sku eq 'B')", bson.M{"$and": bson.A{
+			bson.M{"inventory": bson.M{"$gt": int64(0)}},
+			bson.M{"$or": bson.A{bson.M{"sku": "A"}, bson.M{"sku": "B"}}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		if got := mongoFilter(parseFilter(t, tt.filter)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mongoFilter(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// internal/repository/reservation_repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// ReservationRepository stores the stock reservations of orders, keyed by
+// order ID. The reserved stock itself is kept on the products.
+type ReservationRepository interface {
+	// Create stores a new reservation. It returns nil if the order already
+	// has one.
+	Create(ctx context.Context, reservation domain.Reservation) (*domain.Reservation, error)
+	FindByOrderID(ctx context.Context, orderID string) (*domain.Reservation, error)
+	// Transition atomically moves a pending reservation to another state at
+	// the given time. Confirming requires the reservation not to have
+	// expired by then, and expiring requires it to have. It returns nil if
+	// the order has no reservation that can make the move.
+	Transition(ctx context.Context, orderID string, to domain.ReservationState, at time.Time) (*domain.Reservation, error)
+	// Delete removes a reservation whose stock could not be held
+	Delete(ctx context.Context, orderID string) error
+	// FindExpired returns up to limit pending reservations that expired
+	// before the given time, oldest first
+	FindExpired(ctx context.Context, before time.Time, limit int) ([]domain.Reservation, error)
+}
+
+type mongoReservationRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewReservationRepository(client *mongo.Client, database string) ReservationRepository {
+	return &mongoReservationRepository{
+		client:     client,
+		database:   database,
+		collection: "reservations",
+	}
+}
+
+func (r *mongoReservationRepository) Create(ctx context.Context, reservation domain.Reservation) (*domain.Reservation, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	reservation.CreatedAt = time.Now()
+	reservation.UpdatedAt = reservation.CreatedAt
+
+	if _, err := coll.InsertOne(ctx, reservation); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+func (r *mongoReservationRepository) FindByOrderID(ctx context.Context, orderID string) (*domain.Reservation, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	var reservation domain.Reservation
+	err := coll.FindOne(ctx, bson.M{"_id": orderID}).Decode(&reservation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+func (r *mongoReservationRepository) Transition(ctx context.Context, orderID string, to domain.ReservationState, at time.Time) (*domain.Reservation, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"_id": orderID, "state": domain.ReservationPending}
+	set := bson.M{"state": to, "updated_at": at}
+	switch to {
+	case domain.ReservationConfirmed:
+		filter["expires_at"] = bson.M{"$gt": at}
+		set["confirmed_at"] = at
+	case domain.ReservationExpired:
+		filter["expires_at"] = bson.M{"$lte": at}
+		set["released_at"] = at
+	default:
+		set["released_at"] = at
+	}
+
+	var updated domain.Reservation
+	err := coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *mongoReservationRepository) Delete(ctx context.Context, orderID string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	_, err := coll.DeleteOne(ctx, bson.M{"_id": orderID})
+	return err
+}
+
+func (r *mongoReservationRepository) FindExpired(ctx context.Context, before time.Time, limit int) ([]domain.Reservation, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	filter := bson.M{"state": domain.ReservationPending, "expires_at": bson.M{"$lte": before}}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []domain.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
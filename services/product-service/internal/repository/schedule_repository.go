@@ -0,0 +1,155 @@
+// internal/repository/schedule_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, change domain.ScheduledChange) (*domain.ScheduledChange, error)
+	// FindByProduct returns every change of a product, soonest first
+	FindByProduct(ctx context.Context, productID string) ([]domain.ScheduledChange, error)
+	CountPending(ctx context.Context, productID string) (int64, error)
+	// Cancel marks a pending change as cancelled. It fails with
+	// domain.ErrScheduleNotFound or domain.ErrScheduleNotPending.
+	Cancel(ctx context.Context, productID, id string) error
+	// FindDue returns up to limit pending changes due at now, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error)
+	// Complete records the outcome of a pending change
+	Complete(ctx context.Context, id domain.ID, state domain.ScheduleState, errMsg string) error
+}
+
+type mongoScheduleRepository struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	ids        domain.IDGenerator
+}
+
+func NewScheduleRepository(client *mongo.Client, database string, ids domain.IDGenerator) ScheduleRepository {
+	return &mongoScheduleRepository{
+		client:     client,
+		database:   database,
+		collection: "product_schedules",
+		ids:        ids,
+	}
+}
+
+func (r *mongoScheduleRepository) Create(ctx context.Context, change domain.ScheduledChange) (*domain.ScheduledChange, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	change.ID = r.ids.NewID()
+	change.State = domain.SchedulePending
+	change.CreatedAt = time.Now()
+
+	if _, err := coll.InsertOne(ctx, change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (r *mongoScheduleRepository) FindByProduct(ctx context.Context, productID string) ([]domain.ScheduledChange, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	id, err := domain.ParseID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"product_id": id}, options.Find().SetSort(bson.D{{Key: "run_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []domain.ScheduledChange{}
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (r *mongoScheduleRepository) CountPending(ctx context.Context, productID string) (int64, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	id, err := domain.ParseID(productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return coll.CountDocuments(ctx, bson.M{"product_id": id, "state": domain.SchedulePending})
+}
+
+func (r *mongoScheduleRepository) Cancel(ctx context.Context, productID, id string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	pid, err := domain.ParseID(productID)
+	if err != nil {
+		return err
+	}
+	scheduleID, err := domain.ParseID(id)
+	if err != nil {
+		return domain.ErrScheduleNotFound
+	}
+
+	filter := bson.M{"_id": scheduleID, "product_id": pid, "state": domain.SchedulePending}
+	result, err := coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"state": domain.ScheduleCancelled}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		delete(filter, "state")
+		n, err := coll.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrScheduleNotFound
+		}
+		return domain.ErrScheduleNotPending
+	}
+
+	return nil
+}
+
+func (r *mongoScheduleRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledChange, error) {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	cursor, err := coll.Find(ctx,
+		bson.M{"state": domain.SchedulePending, "run_at": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.D{{Key: "run_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []domain.ScheduledChange
+	if err = cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (r *mongoScheduleRepository) Complete(ctx context.Context, id domain.ID, state domain.ScheduleState, errMsg string) error {
+	coll := r.client.Database(r.database).Collection(r.collection)
+
+	set := bson.M{"state": state, "applied_at": time.Now()}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+
+	// A change cancelled while it was being applied keeps its state
+	_, err := coll.UpdateOne(ctx, bson.M{"_id": id, "state": domain.SchedulePending}, bson.M{"$set": set})
+	return err
+}
@@ -0,0 +1,259 @@
+// internal/repository/search.go
+package repository
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+const (
+	// minFuzzyTermLength is the shortest term that tolerates a typo. Shorter
+	// terms only match as word prefixes.
+	minFuzzyTermLength = 4
+	// maxFuzzyTermLength is the longest term that tolerates a typo. The
+	// variants of a term grow with its length, and longer terms only match
+	// as word prefixes.
+	maxFuzzyTermLength = 12
+	// maxFuzzyVariants caps the typo variants of all terms together. It
+	// keeps the fallback pattern well below the regex length limit of
+	// MongoDB; terms past the cap only match as word prefixes.
+	maxFuzzyVariants = 160
+
+	// fuzzyCandidateLimit caps how many products are ranked in memory when a
+	// search falls back to fuzzy matching.
+	fuzzyCandidateLimit = 500
+
+	// snippetLength is the approximate length in bytes of description snippets.
+	snippetLength = 160
+)
+
+// searchFields lists the searchable fields with the same relative weights as
+// the text indexes.
+var searchFields = []struct {
+	name   string
+	weight float64
+}{
+	{"name", 10},
+	{"sku", 8},
+	{"categories", 5},
+	{"description", 1},
+}
+
+type termMatcher struct {
+	exact  *regexp.Regexp
+	prefix *regexp.Regexp
+	fuzzy  *regexp.Regexp
+}
+
+// searchMatcher matches search terms against product fields in Go. It backs
+// the fuzzy fallback of both repositories and the highlighting of results.
+type searchMatcher struct {
+	terms []termMatcher
+	// pattern matches the start of any word within one edit of a term. It
+	// has no flags and uses \b, so it can be sent to MongoDB as is.
+	pattern string
+	words   *regexp.Regexp
+}
+
+func newSearchMatcher(terms []string) *searchMatcher {
+	m := &searchMatcher{}
+
+	var all []string
+	budget := maxFuzzyVariants
+	for _, term := range terms {
+		quoted := regexp.QuoteMeta(term)
+		variants := termVariants(term)
+		if len(variants) > 1 && len(variants) > budget {
+			variants = variants[:1]
+		}
+		budget -= len(variants) - 1
+		all = append(all, variants...)
+
+		m.terms = append(m.terms, termMatcher{
+			exact:  regexp.MustCompile(`(?i)\b` + quoted + `\b`),
+			prefix: regexp.MustCompile(`(?i)\b` + quoted),
+			fuzzy:  regexp.MustCompile(`(?i)\b(?:` + strings.Join(variants, "|") + `)`),
+		})
+	}
+
+	m.pattern = `\b(?:` + strings.Join(all, "|") + `)`
+	m.words = regexp.MustCompile(`(?i)` + m.pattern + `\w*`)
+	return m
+}
+
+// termVariants returns regex fragments for term, first, and, if its length
+// tolerates typos, every spelling one deletion, substitution, insertion or
+// transposition away.
+func termVariants(term string) []string {
+	runes := []rune(term)
+	quote := func(rs []rune) string { return regexp.QuoteMeta(string(rs)) }
+
+	variants := []string{quote(runes)}
+	if len(runes) < minFuzzyTermLength || len(runes) > maxFuzzyTermLength {
+		return variants
+	}
+
+	for i := range runes {
+		head, tail := runes[:i], runes[i+1:]
+		variants = append(variants,
+			quote(head)+quote(tail),           // deletion
+			quote(head)+`\S`+quote(tail),      // substitution
+			quote(head)+`\S`+quote(runes[i:]), // insertion
+		)
+		if i+1 < len(runes) {
+			swapped := append(append(append([]rune{}, head...), runes[i+1], runes[i]), runes[i+2:]...)
+			variants = append(variants, quote(swapped)) // transposition
+		}
+	}
+
+	seen := make(map[string]bool, len(variants))
+	unique := variants[:0]
+	for _, v := range variants {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+func searchFieldText(p domain.Product, field string) string {
+	switch field {
+	case "name":
+		return p.Name
+	case "sku":
+		return p.SKU
+	case "categories":
+		return strings.Join(p.Categories, ", ")
+	default:
+		return p.Description
+	}
+}
+
+// score ranks p by how well each term matches each field: an exact word
+// counts fully, a prefix three quarters and a typo half.
+func (m *searchMatcher) score(p domain.Product) float64 {
+	var total float64
+	for _, t := range m.terms {
+		for _, f := range searchFields {
+			text := searchFieldText(p, f.name)
+			switch {
+			case t.exact.MatchString(text):
+				total += f.weight
+			case t.prefix.MatchString(text):
+				total += f.weight * 0.75
+			case t.fuzzy.MatchString(text):
+				total += f.weight * 0.5
+			}
+		}
+	}
+	return total
+}
+
+// highlights returns an HTML-escaped snippet per matching field with the
+// matched words wrapped in <em>. Descriptions are cut to a window around
+// the first match.
+func (m *searchMatcher) highlights(p domain.Product) map[string]string {
+	out := make(map[string]string)
+	for _, f := range searchFields {
+		text := searchFieldText(p, f.name)
+		first := m.words.FindStringIndex(text)
+		if first == nil {
+			continue
+		}
+
+		prefix, suffix := "", ""
+		if f.name == "description" && len(text) > snippetLength {
+			start := first[0] - snippetLength/4
+			if start <= 0 {
+				start = 0
+			} else {
+				for !utf8.RuneStart(text[start]) {
+					start++
+				}
+				if i := strings.IndexByte(text[start:first[0]], ' '); i >= 0 {
+					start += i + 1
+				}
+				prefix = "…"
+			}
+			end := start + snippetLength
+			if end >= len(text) {
+				end = len(text)
+			} else {
+				for !utf8.RuneStart(text[end]) {
+					end--
+				}
+				suffix = "…"
+			}
+			text = text[start:end]
+		}
+
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		last := 0
+		for _, loc := range m.words.FindAllStringIndex(text, -1) {
+			sb.WriteString(html.EscapeString(text[last:loc[0]]))
+			sb.WriteString("<em>")
+			sb.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+			sb.WriteString("</em>")
+			last = loc[1]
+		}
+		sb.WriteString(html.EscapeString(text[last:]))
+		sb.WriteString(suffix)
+
+		out[f.name] = sb.String()
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// scoredResults wraps products and their scores into search results.
+func (m *searchMatcher) scoredResults(products []domain.Product, scores []float64) []domain.SearchResult {
+	results := make([]domain.SearchResult, 0, len(products))
+	for i, p := range products {
+		results = append(results, domain.SearchResult{
+			Product:    p,
+			Score:      scores[i],
+			Highlights: m.highlights(p),
+		})
+	}
+	return results
+}
+
+// fuzzyPage ranks fuzzy candidates in memory and cuts out the requested page.
+// Candidates keep their database order when the query has an explicit sort.
+func (m *searchMatcher) fuzzyPage(candidates []domain.Product, q domain.SearchQuery) *domain.SearchPage {
+	scores := make([]float64, len(candidates))
+	for i, p := range candidates {
+		scores[i] = m.score(p)
+	}
+	results := m.scoredResults(candidates, scores)
+
+	if q.SortBy == "" {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+	}
+
+	page := &domain.SearchPage{
+		Items: []domain.SearchResult{},
+		Total: int64(len(results)),
+		Fuzzy: true,
+	}
+	if q.Offset < len(results) {
+		end := q.Offset + q.Limit
+		if end > len(results) {
+			end = len(results)
+		}
+		page.Items = results[q.Offset:end]
+	}
+	return page
+}
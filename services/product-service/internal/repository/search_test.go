@@ -0,0 +1,95 @@
+// internal/repository/search_test.go
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ntdt/product-service/internal/domain"
+)
+
+// mongoRegexLimit is the longest pattern MongoDB accepts in $regex.
+const mongoRegexLimit = 32 * 1024
+
+func TestTermVariants(t *testing.T) {
+	tests := []struct {
+		term      string
+		wantFuzzy bool
+	}{
+		{"tee", false},
+		{"shoe", true},
+		{"jacket", true},
+		{strings.Repeat("a", maxFuzzyTermLength-1) + "b", true},
+		{strings.Repeat("a", maxFuzzyTermLength) + "b", false},
+		{strings.Repeat("x", domain.MaxSearchQueryLength), false},
+	}
+
+	for _, tt := range tests {
+		variants := termVariants(tt.term)
+		if variants[0] != regexp.QuoteMeta(tt.term) {
+			t.Errorf("termVariants(%q)[0] = %q, want the term itself", tt.term, variants[0])
+		}
+		if fuzzy := len(variants) > 1; fuzzy != tt.wantFuzzy {
+			t.Errorf("termVariants(%q) has %d variants, want fuzzy %v", tt.term, len(variants), tt.wantFuzzy)
+		}
+	}
+}
+
+func TestSearchMatcherPatternBounded(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+	}{
+		{"one long term", []string{strings.Repeat("x", domain.MaxSearchQueryLength)}},
+		{"longest fuzzy terms", repeatTerms(strings.Repeat("abcdef", 2), domain.MaxSearchTerms)},
+		{"mixed", append(repeatTerms("trousers", domain.MaxSearchTerms-1), strings.Repeat("y", 100))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newSearchMatcher(tt.terms)
+			if len(m.pattern) > mongoRegexLimit {
+				t.Errorf("pattern is %d bytes, want at most %d", len(m.pattern), mongoRegexLimit)
+			}
+			if n := strings.Count(m.pattern, "|") + 1; n > maxFuzzyVariants+len(tt.terms) {
+				t.Errorf("pattern has %d alternatives, want at most %d", n, maxFuzzyVariants+len(tt.terms))
+			}
+		})
+	}
+}
+
+func TestSearchMatcherScore(t *testing.T) {
+	product := domain.Product{Name: "Leather jacket", SKU: "JKT-001", Description: "A warm winter coat"}
+
+	tests := []struct {
+		terms []string
+		want  float64
+	}{
+		{[]string{"jacket"}, 10},                    // exact name word
+		{[]string{"leath"}, 7.5},                    // name prefix
+		{[]string{"jakcet"}, 5},                     // name typo
+		{[]string{"winter"}, 1},                     // exact description word
+		{[]string{"wintre"}, 0.5},                   // description typo
+		{[]string{"boots"}, 0},                      // no match
+		{[]string{"jacketsandcoats"}, 0},            // too long for typos
+		{[]string{"jacket", "winter"}, 11},          // terms add up
+		{[]string{"jkt"}, 8},                        // word of the SKU
+		{[]string{"leather", "jacket", "coat"}, 21}, // every term matches a word
+	}
+
+	for _, tt := range tests {
+		if got := newSearchMatcher(tt.terms).score(product); got != tt.want {
+			t.Errorf("score(%v) = %v, want %v", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func repeatTerms(term string, n int) []string {
+	terms := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		// Distinct terms, as SearchTerms de-duplicates them
+		terms = append(terms, term[:len(term)-1]+string(rune('a'+i)))
+	}
+	return terms
+}
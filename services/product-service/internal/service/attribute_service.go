@@ -0,0 +1,183 @@
+// internal/service/attribute_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type AttributeService interface {
+	ListAttributes(ctx context.Context) ([]domain.AttributeDefinition, error)
+	// GetAttribute returns nil if the definition does not exist
+	GetAttribute(ctx context.Context, name string) (*domain.AttributeDefinition, error)
+	CreateAttribute(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error)
+	// UpdateAttribute replaces a definition and returns nil if it does not
+	// exist. Changing the type fails with domain.ErrAttributeInUse while
+	// products have a value for the attribute.
+	UpdateAttribute(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error)
+	// DeleteAttribute fails with domain.ErrAttributeInUse while products
+	// have a value for the attribute
+	DeleteAttribute(ctx context.Context, name string) error
+	// Schema returns every attribute definition, indexed by name
+	Schema(ctx context.Context) (domain.AttributeSchema, error)
+	// RenameCategory moves definitions assigned to the category slug from
+	// to the slug to, after a category is renamed or merged
+	RenameCategory(ctx context.Context, from, to string) error
+}
+
+const (
+	// attributesCacheKey holds every attribute definition. Product writes
+	// and filters read them, so they are cached as a whole.
+	attributesCacheKey = "product:attributes"
+	attributesCacheTTL = 10 * time.Minute
+)
+
+type attributeService struct {
+	repo     repository.AttributeRepository
+	products repository.ProductRepository
+	cache    cache.RedisClient
+	logger   logger.Logger
+}
+
+// NewAttributeService checks definition changes against the products using
+// them, which are looked up through products.
+func NewAttributeService(repo repository.AttributeRepository, products repository.ProductRepository, cache cache.RedisClient, logger logger.Logger) AttributeService {
+	return &attributeService{
+		repo:     repo,
+		products: products,
+		cache:    cache,
+		logger:   logger,
+	}
+}
+
+func (s *attributeService) ListAttributes(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	return s.definitions(ctx)
+}
+
+func (s *attributeService) GetAttribute(ctx context.Context, name string) (*domain.AttributeDefinition, error) {
+	return s.repo.FindByName(ctx, name)
+}
+
+func (s *attributeService) CreateAttribute(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	created, err := s.repo.Create(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, attributesCacheKey)
+	return created, nil
+}
+
+func (s *attributeService) UpdateAttribute(ctx context.Context, def domain.AttributeDefinition) (*domain.AttributeDefinition, error) {
+	current, err := s.repo.FindByName(ctx, def.Name)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	// Stored values would no longer match the type
+	if current.Type != def.Type {
+		if err := s.checkUnused(ctx, def.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, def)
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, attributesCacheKey)
+	return updated, nil
+}
+
+func (s *attributeService) DeleteAttribute(ctx context.Context, name string) error {
+	if err := s.checkUnused(ctx, name); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, attributesCacheKey)
+	return nil
+}
+
+func (s *attributeService) Schema(ctx context.Context) (domain.AttributeSchema, error) {
+	defs, err := s.definitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewAttributeSchema(defs), nil
+}
+
+func (s *attributeService) RenameCategory(ctx context.Context, from, to string) error {
+	defs, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range defs {
+		renamed := false
+		seen := make(map[string]bool, len(def.Categories))
+		categories := make([]string, 0, len(def.Categories))
+		for _, c := range def.Categories {
+			if c == from {
+				c, renamed = to, true
+			}
+			if !seen[c] {
+				seen[c] = true
+				categories = append(categories, c)
+			}
+		}
+		if !renamed {
+			continue
+		}
+		def.Categories = categories
+
+		if _, err := s.repo.Update(ctx, def); err != nil {
+			return err
+		}
+	}
+
+	s.cache.Delete(ctx, attributesCacheKey)
+	return nil
+}
+
+// checkUnused returns domain.ErrAttributeInUse if any product, including
+// those in the trash, has a value for the attribute.
+func (s *attributeService) checkUnused(ctx context.Context, name string) error {
+	n, err := s.products.CountWithAttribute(ctx, name)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrAttributeInUse
+	}
+	return nil
+}
+
+func (s *attributeService) definitions(ctx context.Context) ([]domain.AttributeDefinition, error) {
+	if cached, err := s.cache.Get(ctx, attributesCacheKey); err == nil && cached != "" {
+		var defs []domain.AttributeDefinition
+		if err := json.Unmarshal([]byte(cached), &defs); err == nil {
+			return defs, nil
+		}
+	}
+
+	defs, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defsJSON, _ := json.Marshal(defs)
+	s.cache.Set(ctx, attributesCacheKey, string(defsJSON), attributesCacheTTL)
+
+	return defs, nil
+}
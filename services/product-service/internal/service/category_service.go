@@ -0,0 +1,357 @@
+// internal/service/category_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+type CategoryService interface {
+	// ListCategories returns the whole taxonomy in tree order, or only the
+	// children of parentID if it is given
+	ListCategories(ctx context.Context, parentID string) ([]domain.Category, error)
+	// GetCategory returns nil if the category does not exist
+	GetCategory(ctx context.Context, id string) (*domain.Category, error)
+	// CreateCategory places a category below its ParentID, or at the root
+	CreateCategory(ctx context.Context, category domain.Category) (*domain.Category, error)
+	// UpdateCategory changes the name, slug and position of a category and
+	// returns nil if it does not exist. A new slug is carried over to the
+	// paths of its subtree and to the products assigned to it.
+	UpdateCategory(ctx context.Context, id string, category domain.Category) (*domain.Category, error)
+	// MoveCategory places a category and its subtree below parentID, or at
+	// the root if it is empty, and returns nil if it does not exist. It
+	// fails with domain.ErrCategoryCycle or domain.ErrCategoryTooDeep.
+	MoveCategory(ctx context.Context, id, parentID string, position int) (*domain.Category, error)
+	// MergeCategory reassigns the products and subcategories of a category
+	// to targetID, deletes it and returns the target. It returns nil if the
+	// category does not exist.
+	MergeCategory(ctx context.Context, id, targetID string) (*domain.Category, error)
+	// DeleteCategory fails with domain.ErrCategoryInUse while the category
+	// has subcategories or products, including products in the trash
+	DeleteCategory(ctx context.Context, id string) error
+}
+
+type categoryService struct {
+	repo       repository.CategoryRepository
+	products   repository.ProductRepository
+	attributes AttributeService
+	cache      cache.RedisClient
+	messageBus messaging.RabbitMQClient
+	logger     logger.Logger
+}
+
+// NewCategoryService reassigns products through products when categories
+// are renamed or merged, and keeps the categories of attributes in step.
+func NewCategoryService(repo repository.CategoryRepository, products repository.ProductRepository, attributes AttributeService, cache cache.RedisClient, messageBus messaging.RabbitMQClient, logger logger.Logger) CategoryService {
+	return &categoryService{
+		repo:       repo,
+		products:   products,
+		attributes: attributes,
+		cache:      cache,
+		messageBus: messageBus,
+		logger:     logger,
+	}
+}
+
+func (s *categoryService) ListCategories(ctx context.Context, parentID string) ([]domain.Category, error) {
+	if parentID == "" {
+		categories, err := s.repo.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return domain.SortCategoryTree(categories), nil
+	}
+
+	parent, err := s.repo.FindByID(ctx, parentID)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+
+	return s.repo.FindChildren(ctx, parent.ID)
+}
+
+func (s *categoryService) GetCategory(ctx context.Context, id string) (*domain.Category, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *categoryService) CreateCategory(ctx context.Context, category domain.Category) (*domain.Category, error) {
+	parent, err := s.parent(ctx, "parent_id", category.ParentID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	category.Place(parent)
+	if category.Depth() >= domain.MaxCategoryDepth {
+		return nil, domain.ErrCategoryTooDeep
+	}
+
+	created, err := s.repo.Create(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishCategoryEvent("category.created", created, nil)
+	return created, nil
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, id string, category domain.Category) (*domain.Category, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	oldSlug := current.Slug
+	current.Name = category.Name
+	current.Slug = category.Slug
+	current.Position = category.Position
+
+	subtree := []domain.Category{*current}
+	if current.Slug != oldSlug {
+		parent, err := s.parent(ctx, "parent_id", current.ParentID.String())
+		if err != nil {
+			return nil, err
+		}
+		if subtree, err = s.place(ctx, *current, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, subtree); err != nil {
+		return nil, err
+	}
+
+	if current.Slug != oldSlug {
+		if err := s.reassign(ctx, current.ID, oldSlug, current.ID, current.Slug); err != nil {
+			return nil, err
+		}
+	}
+
+	updated := subtree[0]
+	s.publishCategoryEvent("category.updated", &updated, nil)
+	return &updated, nil
+}
+
+func (s *categoryService) MoveCategory(ctx context.Context, id, parentID string, position int) (*domain.Category, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	parent, err := s.parent(ctx, "parent_id", parentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent != nil && (parent.ID == current.ID || parent.HasAncestor(current.ID)) {
+		return nil, domain.ErrCategoryCycle
+	}
+
+	current.Position = position
+	subtree, err := s.place(ctx, *current, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, subtree); err != nil {
+		return nil, err
+	}
+
+	moved := subtree[0]
+	s.publishCategoryEvent("category.moved", &moved, nil)
+	return &moved, nil
+}
+
+func (s *categoryService) MergeCategory(ctx context.Context, id, targetID string) (*domain.Category, error) {
+	source, err := s.repo.FindByID(ctx, id)
+	if err != nil || source == nil {
+		return nil, err
+	}
+
+	if targetID == "" {
+		verr := &domain.ValidationError{}
+		verr.Add("target_id", "is required")
+		return nil, verr.Err()
+	}
+	target, err := s.parent(ctx, "target_id", targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == source.ID || target.HasAncestor(source.ID) {
+		return nil, domain.ErrCategoryCycle
+	}
+
+	// Subcategories keep their position, now among those of the target
+	children, err := s.repo.FindChildren(ctx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	var moved []domain.Category
+	for _, child := range children {
+		subtree, err := s.place(ctx, child, target)
+		if err != nil {
+			return nil, err
+		}
+		moved = append(moved, subtree...)
+	}
+
+	if err := s.repo.Update(ctx, moved); err != nil {
+		return nil, err
+	}
+
+	if err := s.reassign(ctx, source.ID, source.Slug, target.ID, target.Slug); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, source.ID); err != nil {
+		return nil, err
+	}
+
+	s.publishCategoryEvent("category.merged", source, map[string]interface{}{"target_id": target.ID})
+	return target, nil
+}
+
+func (s *categoryService) DeleteCategory(ctx context.Context, id string) error {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return domain.ErrCategoryNotFound
+	}
+
+	children, err := s.repo.FindChildren(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return domain.ErrCategoryInUse
+	}
+
+	n, err := s.products.CountInCategory(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrCategoryInUse
+	}
+
+	if err := s.repo.Delete(ctx, category.ID); err != nil {
+		return err
+	}
+
+	s.publishCategoryEvent("category.deleted", category, nil)
+	return nil
+}
+
+// parent loads the category id refers to, or returns nil for an empty id.
+// An unknown category is reported as a validation error on field.
+func (s *categoryService) parent(ctx context.Context, field, id string) (*domain.Category, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	verr := &domain.ValidationError{}
+	parentID, err := domain.ParseID(id)
+	if err != nil {
+		verr.Add(field, "must be a valid ID")
+		return nil, verr.Err()
+	}
+
+	parent, err := s.repo.FindByID(ctx, parentID.String())
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		verr.Add(field, "is not a category")
+		return nil, verr.Err()
+	}
+
+	return parent, nil
+}
+
+// place positions category below parent and rematerializes the paths and
+// ancestors of its subtree. It returns the category followed by its
+// descendants, parents before children.
+func (s *categoryService) place(ctx context.Context, category domain.Category, parent *domain.Category) ([]domain.Category, error) {
+	descendants, err := s.repo.FindDescendants(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(descendants, func(i, j int) bool {
+		return descendants[i].Depth() < descendants[j].Depth()
+	})
+
+	category.Place(parent)
+	placed := map[domain.ID]domain.Category{category.ID: category}
+	subtree := append(make([]domain.Category, 0, len(descendants)+1), category)
+	for _, d := range descendants {
+		p := placed[d.ParentID]
+		d.Place(&p)
+		placed[d.ID] = d
+		subtree = append(subtree, d)
+	}
+
+	for _, c := range subtree {
+		if c.Depth() >= domain.MaxCategoryDepth {
+			return nil, domain.ErrCategoryTooDeep
+		}
+	}
+
+	return subtree, nil
+}
+
+// reassign moves the products and attribute definitions of one category to
+// another, or to a new slug of the same category, and drops the cached
+// copies of the products changed.
+func (s *categoryService) reassign(ctx context.Context, from domain.ID, fromSlug string, to domain.ID, toSlug string) error {
+	ids, err := s.products.ReplaceCategory(ctx, from, fromSlug, to, toSlug)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.cache.Delete(ctx, fmt.Sprintf("product:%s", id))
+	}
+	if len(ids) > 0 {
+		if _, err := s.cache.Incr(ctx, facetsGenerationKey); err != nil {
+			s.logger.Error("Failed to invalidate facets cache", err)
+		}
+	}
+
+	s.logger.Info("Reassigned products to category", logger.Fields{
+		"from":     from,
+		"to":       to,
+		"products": len(ids),
+	})
+
+	return s.attributes.RenameCategory(ctx, fromSlug, toSlug)
+}
+
+// publishCategoryEvent announces a change to the taxonomy. Failures are
+// logged; the change itself has already been stored.
+func (s *categoryService) publishCategoryEvent(eventType string, category *domain.Category, extra map[string]interface{}) {
+	event := map[string]interface{}{
+		"id":        category.ID,
+		"category":  category,
+		"timestamp": time.Now(),
+	}
+	for k, v := range extra {
+		event[k] = v
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err == nil {
+		err = s.messageBus.Publish("product_exchange", eventType, eventJSON)
+	}
+	if err != nil {
+		s.logger.Error("Failed to publish category event", err, logger.Fields{"event": eventType})
+	}
+}
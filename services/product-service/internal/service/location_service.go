@@ -0,0 +1,87 @@
+// internal/service/location_service.go
+package service
+
+import (
+	"context"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type LocationService interface {
+	// ListLocations returns the default location first, then the others by
+	// priority and ID
+	ListLocations(ctx context.Context) ([]domain.Location, error)
+	// GetLocation returns nil if the location does not exist
+	GetLocation(ctx context.Context, id string) (*domain.Location, error)
+	// CreateLocation adds a location other than the default
+	CreateLocation(ctx context.Context, location domain.Location) (*domain.Location, error)
+	// UpdateLocation changes the name, type, priority, position and
+	// disabled flag of a location and returns nil if it does not exist
+	UpdateLocation(ctx context.Context, location domain.Location) (*domain.Location, error)
+	// DeleteLocation fails with domain.ErrLocationInUse for the default
+	// location and while stock is on hand or reserved at the location
+	DeleteLocation(ctx context.Context, id string) error
+}
+
+type locationService struct {
+	repo   repository.LocationRepository
+	stock  repository.LocationStockRepository
+	logger logger.Logger
+}
+
+// NewLocationService checks that locations are empty through stock before
+// deleting them.
+func NewLocationService(repo repository.LocationRepository, stock repository.LocationStockRepository, logger logger.Logger) LocationService {
+	return &locationService{
+		repo:   repo,
+		stock:  stock,
+		logger: logger,
+	}
+}
+
+func (s *locationService) ListLocations(ctx context.Context) ([]domain.Location, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *locationService) GetLocation(ctx context.Context, id string) (*domain.Location, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *locationService) CreateLocation(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	// The default location is seeded by the migrations and there is only
+	// one
+	location.Default = false
+
+	return s.repo.Create(ctx, location)
+}
+
+func (s *locationService) UpdateLocation(ctx context.Context, location domain.Location) (*domain.Location, error) {
+	return s.repo.Update(ctx, location)
+}
+
+func (s *locationService) DeleteLocation(ctx context.Context, id string) error {
+	location, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if location == nil {
+		return domain.ErrLocationNotFound
+	}
+	if location.Default {
+		return domain.ErrLocationInUse
+	}
+
+	// Stock has to be transferred out first; the check races with stock
+	// arriving, which the ledger of the products then shows
+	held, err := s.stock.HasStock(ctx, id)
+	if err != nil {
+		return err
+	}
+	if held {
+		return domain.ErrLocationInUse
+	}
+
+	return s.repo.Delete(ctx, id)
+}
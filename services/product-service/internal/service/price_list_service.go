@@ -0,0 +1,183 @@
+// internal/service/price_list_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type PriceListService interface {
+	// ListPriceLists returns the default price list first, then the others
+	// by ID
+	ListPriceLists(ctx context.Context) ([]domain.PriceList, error)
+	// GetPriceList returns nil if the price list does not exist
+	GetPriceList(ctx context.Context, id string) (*domain.PriceList, error)
+	// CreatePriceList adds a list other than the default
+	CreatePriceList(ctx context.Context, list domain.PriceList) (*domain.PriceList, error)
+	// UpdatePriceList changes the name, currency, region and customer group
+	// of a price list and returns nil if it does not exist. The currency
+	// cannot change while products have prices in the list, nor ever for
+	// the default list.
+	UpdatePriceList(ctx context.Context, list domain.PriceList) (*domain.PriceList, error)
+	// DeletePriceList fails with domain.ErrPriceListInUse for the default
+	// list and while products have prices in the list
+	DeletePriceList(ctx context.Context, id string) error
+	// PriceLists returns every price list for resolving prices
+	PriceLists(ctx context.Context) (domain.PriceLists, error)
+	// ApplyPrices sets the effective prices of products for a caller. A
+	// price list that does not exist is reported as a validation error.
+	ApplyPrices(ctx context.Context, pc domain.PriceContext, products ...*domain.Product) error
+}
+
+const (
+	// priceListsCacheKey holds every price list. Every priced read resolves
+	// against them, so they are cached as a whole.
+	priceListsCacheKey = "product:price_lists"
+	priceListsCacheTTL = 10 * time.Minute
+)
+
+type priceListService struct {
+	repo     repository.PriceListRepository
+	products repository.ProductRepository
+	cache    cache.RedisClient
+	logger   logger.Logger
+}
+
+// NewPriceListService checks price list changes against the products priced
+// in them, which are looked up through products.
+func NewPriceListService(repo repository.PriceListRepository, products repository.ProductRepository, cache cache.RedisClient, logger logger.Logger) PriceListService {
+	return &priceListService{
+		repo:     repo,
+		products: products,
+		cache:    cache,
+		logger:   logger,
+	}
+}
+
+func (s *priceListService) ListPriceLists(ctx context.Context) ([]domain.PriceList, error) {
+	return s.PriceLists(ctx)
+}
+
+func (s *priceListService) GetPriceList(ctx context.Context, id string) (*domain.PriceList, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *priceListService) CreatePriceList(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	// The default list is seeded by the migrations and there is only one
+	list.Default = false
+
+	created, err := s.repo.Create(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, priceListsCacheKey)
+	return created, nil
+}
+
+func (s *priceListService) UpdatePriceList(ctx context.Context, list domain.PriceList) (*domain.PriceList, error) {
+	current, err := s.repo.FindByID(ctx, list.ID)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	// Stored prices would no longer be in the currency of their list
+	if current.Currency != list.Currency {
+		if current.Default {
+			verr := &domain.ValidationError{}
+			verr.Add("currency", "cannot change for the default price list")
+			return nil, verr.Err()
+		}
+		if err := s.checkUnused(ctx, list.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, list)
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, priceListsCacheKey)
+	return updated, nil
+}
+
+func (s *priceListService) DeletePriceList(ctx context.Context, id string) error {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return domain.ErrPriceListNotFound
+	}
+	if current.Default {
+		return domain.ErrPriceListInUse
+	}
+
+	if err := s.checkUnused(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, priceListsCacheKey)
+	return nil
+}
+
+func (s *priceListService) PriceLists(ctx context.Context) (domain.PriceLists, error) {
+	if cached, err := s.cache.Get(ctx, priceListsCacheKey); err == nil && cached != "" {
+		var lists domain.PriceLists
+		if err := json.Unmarshal([]byte(cached), &lists); err == nil {
+			return lists, nil
+		}
+	}
+
+	lists, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listsJSON, _ := json.Marshal(lists)
+	s.cache.Set(ctx, priceListsCacheKey, string(listsJSON), priceListsCacheTTL)
+
+	return lists, nil
+}
+
+func (s *priceListService) ApplyPrices(ctx context.Context, pc domain.PriceContext, products ...*domain.Product) error {
+	lists, err := s.PriceLists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pc.PriceList != "" && lists.Find(pc.PriceList) == nil {
+		verr := &domain.ValidationError{}
+		verr.Add("price_list", "is not a price list")
+		return verr.Err()
+	}
+
+	for _, p := range products {
+		lists.ApplyPrices(pc, p)
+	}
+	return nil
+}
+
+// checkUnused returns domain.ErrPriceListInUse if any product, including
+// those in the trash, has a price in the list.
+func (s *priceListService) checkUnused(ctx context.Context, id string) error {
+	n, err := s.products.CountWithPriceList(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrPriceListInUse
+	}
+	return nil
+}
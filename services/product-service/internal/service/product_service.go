@@ -3,8 +3,11 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ntdt/product-service/internal/domain"
@@ -15,36 +18,213 @@ import (
 )
 
 type ProductService interface {
-	GetProducts(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error)
-	GetProductByID(ctx context.Context, id string) (*domain.Product, error)
+	GetProducts(ctx context.Context, filter domain.ProductFilter) (*domain.ProductPage, error)
+	SearchProducts(ctx context.Context, query domain.SearchQuery) (*domain.SearchPage, error)
+	GetFacets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error)
+	GetProductByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error)
+	ResolveSKUs(ctx context.Context, skus []string) (map[string]domain.Product, []string, error)
 	CreateProduct(ctx context.Context, product domain.Product) (*domain.Product, error)
-	UpdateProduct(ctx context.Context, id string, product domain.Product) (*domain.Product, error)
-	DeleteProduct(ctx context.Context, id string) error
+	// The write methods take the version the caller last saw, or 0 to write
+	// unconditionally, and fail with domain.ErrVersionMismatch if it is stale.
+	// Price changes they make are recorded in the price history with audit.
+	UpdateProduct(ctx context.Context, id string, product domain.Product, expectedVersion int64, audit domain.Audit) (*domain.Product, error)
+	PatchProduct(ctx context.Context, id string, mediaType string, patch []byte, expectedVersion int64, audit domain.Audit) (*domain.Product, error)
+	DeleteProduct(ctx context.Context, id string, expectedVersion int64, deletedBy string) error
+	RestoreProduct(ctx context.Context, id string, expectedVersion int64) (*domain.Product, error)
+	PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int, error)
+	// TransitionProduct moves a product to another lifecycle state. It fails
+	// with a domain.TransitionError if the state machine does not allow it.
+	TransitionProduct(ctx context.Context, id string, to domain.Status, changedBy string, expectedVersion int64) (*domain.Product, error)
+	// GetPriceHistory lists the price changes of a product, most recent
+	// first. It returns nil if the product does not exist.
+	GetPriceHistory(ctx context.Context, id string, query domain.PriceHistoryQuery) ([]domain.PriceChange, error)
+	// AdjustInventory changes the stock of a product or variant at a
+	// location and records the movement in the ledger. It returns nil if the
+	// product does not exist and fails with domain.ErrInsufficientInventory
+	// if the stock available there, which excludes reserved stock, would
+	// drop below zero.
+	// Stock is never changed by the write methods above, except for SKUs
+	// that start keeping stock.
+	AdjustInventory(ctx context.Context, id string, adjustment domain.InventoryAdjustment, adjustedBy string) (*domain.InventoryMovement, error)
+	// GetInventoryMovements lists the ledger of a product, most recent
+	// first. It returns nil if the product does not exist.
+	GetInventoryMovements(ctx context.Context, id string, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error)
+	// ReconcileInventory compares the stock of a product with its ledger. It
+	// returns nil if the product does not exist.
+	ReconcileInventory(ctx context.Context, id string) (*domain.InventoryReconciliation, error)
+	// ReserveStock holds quantity units of a SKU at a location for an
+	// order, or gives them back when quantity is negative. It returns nil if
+	// the product does not exist or keeps no stock under sku, and fails with
+	// domain.ErrInsufficientInventory if fewer units are available there.
+	ReserveStock(ctx context.Context, id domain.ID, sku, location string, quantity int) (*domain.Product, error)
+	// SellReservedStock takes quantity units of a SKU reserved at a location
+	// out of stock and records the sale in the ledger under reference. It
+	// returns nil if the product does not exist or keeps no stock under sku.
+	SellReservedStock(ctx context.Context, id domain.ID, sku, location string, quantity int, reference, soldBy string) (*domain.Product, error)
+	// TransferStock moves available stock of a SKU between locations and
+	// records both sides in the ledger. It returns nil if the product does
+	// not exist and fails with domain.ErrInsufficientInventory if the source
+	// has too little available.
+	TransferStock(ctx context.Context, id string, transfer domain.StockTransfer, transferredBy string) ([]domain.InventoryMovement, error)
+	// GetLocationStock lists the stock of a product at every location, by
+	// SKU and location. It returns nil if the product does not exist.
+	GetLocationStock(ctx context.Context, id string) ([]domain.LocationStock, error)
+	// ApplyLocations sets the stock of products and their variants at each
+	// location.
+	ApplyLocations(ctx context.Context, products ...*domain.Product) error
+}
+
+const (
+	// facetsGenerationKey holds a counter that is bumped on every product
+	// write. It is part of every facets cache key, so bumping it orphans all
+	// cached facets at once; the TTL cleans them up.
+	facetsGenerationKey = "product:facets:generation"
+	facetsCacheTTL      = 5 * time.Minute
+
+	// purgeBatchSize bounds how many products a single repository call
+	// removes from the trash.
+	purgeBatchSize = 500
+
+	// maxWriteAttempts bounds how often an unconditional write is retried
+	// when an inventory adjustment changes the product in between.
+	maxWriteAttempts = 3
+)
+
+// lifecycleEvents are published when a product enters these states.
+var lifecycleEvents = map[domain.Status]string{
+	domain.StatusPublished:    "product.published",
+	domain.StatusDiscontinued: "product.discontinued",
+	domain.StatusArchived:     "product.archived",
 }
 
 type productService struct {
 	repo       repository.ProductRepository
+	categories repository.CategoryRepository
+	attributes AttributeService
+	priceLists PriceListService
+	history    repository.PriceHistoryRepository
+	inventory  repository.InventoryRepository
+	stock      repository.LocationStockRepository
+	locations  repository.LocationRepository
+	// lowStock is the available stock at or below which a SKU is reported
+	// as running low
+	lowStock   int
 	cache      cache.RedisClient
 	messageBus messaging.RabbitMQClient
 	logger     logger.Logger
 }
 
-func NewProductService(repo repository.ProductRepository, cache cache.RedisClient, messageBus messaging.RabbitMQClient, logger logger.Logger) ProductService {
+// NewProductService resolves the categories of products and category
+// filters through categories, validates product attributes and attribute
+// filters against the definitions of attributes, and product prices and
+// price filters against priceLists. Price changes are recorded in history
+// and stock movements in the inventory ledger. The stock of each SKU at each
+// location is kept in stock, next to the totals on the products, and
+// locations are looked up in locations. Stock changes that leave a SKU with
+// lowStock units or fewer available are announced.
+func NewProductService(repo repository.ProductRepository, categories repository.CategoryRepository, attributes AttributeService, priceLists PriceListService, history repository.PriceHistoryRepository, inventory repository.InventoryRepository, stock repository.LocationStockRepository, locations repository.LocationRepository, lowStock int, cache cache.RedisClient, messageBus messaging.RabbitMQClient, logger logger.Logger) ProductService {
 	return &productService{
 		repo:       repo,
+		categories: categories,
+		attributes: attributes,
+		priceLists: priceLists,
+		history:    history,
+		inventory:  inventory,
+		stock:      stock,
+		locations:  locations,
+		lowStock:   lowStock,
 		cache:      cache,
 		messageBus: messageBus,
 		logger:     logger,
 	}
 }
 
-func (s *productService) GetProducts(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+func (s *productService) GetProducts(ctx context.Context, filter domain.ProductFilter) (*domain.ProductPage, error) {
+	if err := s.resolveCategoryFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttributeFilters(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolvePriceFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveLocationFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
 	return s.repo.FindAll(ctx, filter)
 }
 
-func (s *productService) GetProductByID(ctx context.Context, id string) (*domain.Product, error) {
+func (s *productService) SearchProducts(ctx context.Context, query domain.SearchQuery) (*domain.SearchPage, error) {
+	if err := s.resolveCategoryFilter(ctx, &query.ProductFilter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttributeFilters(ctx, &query.ProductFilter); err != nil {
+		return nil, err
+	}
+	if err := s.resolvePriceFilter(ctx, &query.ProductFilter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveLocationFilter(ctx, &query.ProductFilter); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Search(ctx, query)
+}
+
+func (s *productService) GetFacets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error) {
+	if err := s.resolveCategoryFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttributeFilters(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolvePriceFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+	if err := s.resolveLocationFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	cacheKey := s.facetsCacheKey(ctx, filter)
+
+	cachedFacets, err := s.cache.Get(ctx, cacheKey)
+	if err == nil && cachedFacets != "" {
+		var facets domain.ProductFacets
+		if err := json.Unmarshal([]byte(cachedFacets), &facets); err == nil {
+			return &facets, nil
+		}
+	}
+
+	facets, err := s.repo.Facets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	facetsJSON, _ := json.Marshal(facets)
+	s.cache.Set(ctx, cacheKey, string(facetsJSON), facetsCacheTTL)
+
+	return facets, nil
+}
+
+// GetProductByID reads through the product cache. Products in the trash are
+// never cached, so including them goes straight to the repository.
+func (s *productService) GetProductByID(ctx context.Context, id string, includeDeleted bool) (*domain.Product, error) {
+	if includeDeleted {
+		return s.repo.FindByID(ctx, id, true)
+	}
+
+	// Products are cached under their canonical ID, however the caller
+	// spelled it
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
 	// Try to get from cache first
-	cacheKey := fmt.Sprintf("product:%s", id)
+	cacheKey := fmt.Sprintf("product:%s", productID)
 
 	// Check if product exists in cache
 	cachedProduct, err := s.cache.Get(ctx, cacheKey)
@@ -56,26 +236,130 @@ func (s *productService) GetProductByID(ctx context.Context, id string) (*domain
 	}
 
 	// If not in cache, get from repository
-	product, err := s.repo.FindByID(ctx, id)
+	product, err := s.repo.FindByID(ctx, productID.String(), false)
 	if err != nil {
 		return nil, err
 	}
 
 	if product != nil {
 		// Cache product
-		productJSON, _ := json.Marshal(product)
-		s.cache.Set(ctx, cacheKey, string(productJSON), 30*time.Minute)
+		s.cacheProduct(ctx, product)
 	}
 
 	return product, nil
 }
 
+// GetProductBySKU resolves a SKU through a cached SKU -> ID mapping and then
+// reuses the product cache of GetProductByID. Stale mappings (renamed SKU or
+// deleted product) are detected and dropped.
+func (s *productService) GetProductBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	sku = domain.NormalizeSKU(sku)
+	cacheKey := fmt.Sprintf("product:sku:%s", sku)
+
+	if id, err := s.cache.Get(ctx, cacheKey); err == nil && id != "" {
+		product, err := s.GetProductByID(ctx, id, false)
+		if err == nil && product != nil && product.HasSKU(sku) {
+			return product, nil
+		}
+		s.cache.Delete(ctx, cacheKey)
+	}
+
+	product, err := s.repo.FindBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if product != nil {
+		s.cacheProduct(ctx, product)
+	}
+
+	return product, nil
+}
+
+// ResolveSKUs looks up several SKUs at once. It returns the products found,
+// keyed by normalized SKU, and the SKUs that matched no product. A variant
+// SKU resolves to the product the variant belongs to.
+func (s *productService) ResolveSKUs(ctx context.Context, skus []string) (map[string]domain.Product, []string, error) {
+	found := make(map[string]domain.Product, len(skus))
+	var misses []string
+
+	for _, sku := range skus {
+		sku = domain.NormalizeSKU(sku)
+		if _, ok := found[sku]; ok {
+			continue
+		}
+
+		if id, err := s.cache.Get(ctx, fmt.Sprintf("product:sku:%s", sku)); err == nil && id != "" {
+			if cached, err := s.cache.Get(ctx, fmt.Sprintf("product:%s", id)); err == nil && cached != "" {
+				var product domain.Product
+				if err := json.Unmarshal([]byte(cached), &product); err == nil && product.HasSKU(sku) {
+					found[sku] = product
+					continue
+				}
+			}
+		}
+		misses = append(misses, sku)
+	}
+
+	var notFound []string
+	if len(misses) > 0 {
+		products, err := s.repo.FindBySKUs(ctx, misses)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := range products {
+			s.cacheProduct(ctx, &products[i])
+		}
+
+		for _, sku := range misses {
+			for i := range products {
+				if products[i].HasSKU(sku) {
+					found[sku] = products[i]
+					break
+				}
+			}
+			if _, ok := found[sku]; !ok {
+				notFound = append(notFound, sku)
+			}
+		}
+	}
+
+	return found, notFound, nil
+}
+
+// CreateProduct stores a new product as a draft; it has to go through
+// review before it is published.
 func (s *productService) CreateProduct(ctx context.Context, product domain.Product) (*domain.Product, error) {
+	product.SKU = domain.NormalizeSKU(product.SKU)
+	product.NormalizeVariants()
+	product.Status = domain.StatusDraft
+	product.StatusChangedBy = ""
+	product.InitStock()
+	if err := product.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategories(ctx, &product, nil); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributes(ctx, &product); err != nil {
+		return nil, err
+	}
+	if err := s.checkPrices(ctx, &product); err != nil {
+		return nil, err
+	}
+	if err := s.checkSKUAvailable(ctx, &product, ""); err != nil {
+		return nil, err
+	}
+
 	newProduct, err := s.repo.Create(ctx, product)
 	if err != nil {
 		return nil, err
 	}
 
+	s.invalidateFacets(ctx)
+	s.updateStockSKUs(ctx, nil, newProduct, "")
+
 	// Publish event to message bus
 	err = s.publishProductEvent("product.created", newProduct)
 	if err != nil {
@@ -86,41 +370,202 @@ func (s *productService) CreateProduct(ctx context.Context, product domain.Produ
 	return newProduct, nil
 }
 
-func (s *productService) UpdateProduct(ctx context.Context, id string, product domain.Product) (*domain.Product, error) {
-	updatedProduct, err := s.repo.Update(ctx, id, product)
+func (s *productService) UpdateProduct(ctx context.Context, id string, product domain.Product, expectedVersion int64, audit domain.Audit) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.SKU = domain.NormalizeSKU(product.SKU)
+	product.NormalizeVariants()
+	if err := product.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The stored categories tell which assignment the client changed, and
+	// the stored variants are compared with the new ones for variant events
+	before, err := s.repo.FindByID(ctx, productID.String(), false)
+	if err != nil || before == nil {
+		return nil, err
+	}
+
+	if err := s.resolveCategories(ctx, &product, before); err != nil {
+		return nil, err
+	}
+	if err := s.checkAttributes(ctx, &product); err != nil {
+		return nil, err
+	}
+	if err := s.checkPrices(ctx, &product); err != nil {
+		return nil, err
+	}
+	if err := s.checkSKUAvailable(ctx, &product, productID); err != nil {
+		return nil, err
+	}
+
+	// Stock only changes through adjustments, so the stored stock is kept.
+	// An unconditional write is pinned to the version the stock was read at
+	// and retried if an adjustment lands in between.
+	var updatedProduct *domain.Product
+	for attempt := 1; ; attempt++ {
+		product.CarryInventory(before)
+		version := expectedVersion
+		if version == 0 {
+			version = before.Version
+		}
+
+		updatedProduct, err = s.repo.Update(ctx, productID.String(), product, version)
+		if !errors.Is(err, domain.ErrVersionMismatch) || expectedVersion > 0 || attempt == maxWriteAttempts {
+			break
+		}
+
+		before, err = s.repo.FindByID(ctx, productID.String(), false)
+		if err != nil || before == nil {
+			return nil, err
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if updatedProduct != nil {
 		// Invalidate cache
-		cacheKey := fmt.Sprintf("product:%s", id)
+		cacheKey := fmt.Sprintf("product:%s", updatedProduct.ID)
 		s.cache.Delete(ctx, cacheKey)
+		s.invalidateFacets(ctx)
 
 		// Publish event to message bus
 		err = s.publishProductEvent("product.updated", updatedProduct)
 		if err != nil {
 			s.logger.Error("Failed to publish product updated event", err)
 		}
+
+		s.publishVariantEvents(updatedProduct, before.Variants)
+		s.recordPriceChanges(ctx, before, updatedProduct, audit)
+		s.updateStockSKUs(ctx, before, updatedProduct, audit.By)
 	}
 
 	return updatedProduct, nil
 }
 
-func (s *productService) DeleteProduct(ctx context.Context, id string) error {
-	err := s.repo.Delete(ctx, id)
+// recordPriceChanges stores the prices an update changed in the price
+// history and announces them. Failures are logged; the update itself has
+// already been stored.
+func (s *productService) recordPriceChanges(ctx context.Context, before, after *domain.Product, audit domain.Audit) {
+	changes := domain.DiffPrices(before, after)
+	if len(changes) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for i := range changes {
+		changes[i].ChangedBy = audit.By
+		changes[i].Reason = audit.Reason
+		changes[i].ChangedAt = now
+	}
+
+	if err := s.history.Record(ctx, changes); err != nil {
+		s.logger.Error("Failed to record price history", err, logger.Fields{"productId": after.ID})
+	}
+
+	priceEvent := map[string]interface{}{
+		"id":         after.ID,
+		"sku":        after.SKU,
+		"changes":    changes,
+		"changed_by": audit.By,
+		"reason":     audit.Reason,
+		"timestamp":  now,
+	}
+	if err := s.publishEvent("product.price_changed", priceEvent); err != nil {
+		s.logger.Error("Failed to publish product price changed event", err)
+	}
+}
+
+// publishVariantEvents announces the variants added, changed and removed by
+// an update, so consumers tracking stock per SKU need not diff products.
+func (s *productService) publishVariantEvents(product *domain.Product, before []domain.Variant) {
+	added, updated, removed := domain.DiffVariants(before, product.Variants)
+
+	for _, changes := range []struct {
+		eventType string
+		variants  []domain.Variant
+	}{
+		{"product.variant_added", added},
+		{"product.variant_updated", updated},
+		{"product.variant_removed", removed},
+	} {
+		eventType := changes.eventType
+		for _, v := range changes.variants {
+			variantEvent := map[string]interface{}{
+				"id":          product.ID,
+				"variant_sku": v.SKU,
+				"variant":     v,
+				"timestamp":   time.Now(),
+			}
+			if err := s.publishEvent(eventType, variantEvent); err != nil {
+				s.logger.Error("Failed to publish product variant event", err, logger.Fields{"event": eventType, "sku": v.SKU})
+			}
+		}
+	}
+}
+
+// PatchProduct applies a merge patch or JSON patch to the stored product and
+// saves the result through UpdateProduct. The save is conditional on the
+// version that was patched, so concurrent writes are never lost. It returns
+// nil if the product does not exist.
+func (s *productService) PatchProduct(ctx context.Context, id string, mediaType string, patch []byte, expectedVersion int64, audit domain.Audit) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Patch the stored product rather than a possibly stale cached copy
+		current, err := s.repo.FindByID(ctx, productID.String(), false)
+		if err != nil || current == nil {
+			return nil, err
+		}
+
+		if expectedVersion > 0 && current.Version != expectedVersion {
+			return nil, domain.ErrVersionMismatch
+		}
+
+		patched, err := domain.ApplyPatch(*current, mediaType, patch)
+		if err != nil {
+			return nil, err
+		}
+
+		// Without a version from the client, a product changed by an
+		// inventory adjustment meanwhile is patched again
+		updated, err := s.UpdateProduct(ctx, productID.String(), patched, current.Version, audit)
+		if !errors.Is(err, domain.ErrVersionMismatch) || expectedVersion > 0 || attempt == maxWriteAttempts {
+			return updated, err
+		}
+	}
+}
+
+// DeleteProduct moves a product to the trash. It stays restorable until the
+// purge job removes it.
+func (s *productService) DeleteProduct(ctx context.Context, id string, expectedVersion int64, deletedBy string) error {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(ctx, productID.String(), expectedVersion, deletedBy)
 	if err != nil {
 		return err
 	}
 
 	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%s", id)
+	cacheKey := fmt.Sprintf("product:%s", productID)
 	s.cache.Delete(ctx, cacheKey)
+	s.invalidateFacets(ctx)
 
 	// Publish event to message bus
 	deleteEvent := map[string]interface{}{
-		"id":        id,
-		"timestamp": time.Now(),
+		"id":         productID,
+		"deleted_by": deletedBy,
+		"timestamp":  time.Now(),
 	}
 
 	err = s.publishEvent("product.deleted", deleteEvent)
@@ -131,9 +576,816 @@ func (s *productService) DeleteProduct(ctx context.Context, id string) error {
 	return nil
 }
 
+// RestoreProduct takes a product out of the trash. It returns nil if the
+// product does not exist.
+func (s *productService) RestoreProduct(ctx context.Context, id string, expectedVersion int64) (*domain.Product, error) {
+	restoredProduct, err := s.repo.Restore(ctx, id, expectedVersion)
+	if err != nil || restoredProduct == nil {
+		return nil, err
+	}
+
+	s.cacheProduct(ctx, restoredProduct)
+	s.invalidateFacets(ctx)
+
+	err = s.publishProductEvent("product.restored", restoredProduct)
+	if err != nil {
+		s.logger.Error("Failed to publish product restored event", err)
+	}
+
+	return restoredProduct, nil
+}
+
+// PurgeDeletedProducts permanently removes the products deleted before
+// deletedBefore and returns how many were removed.
+func (s *productService) PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	for {
+		ids, err := s.repo.Purge(ctx, deletedBefore, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		if err := s.stock.RemoveProducts(ctx, ids); err != nil {
+			s.logger.Error("Failed to remove stock of purged products", err)
+		}
+
+		for _, id := range ids {
+			purgeEvent := map[string]interface{}{
+				"id":        id,
+				"timestamp": time.Now(),
+			}
+			if err := s.publishEvent("product.purged", purgeEvent); err != nil {
+				s.logger.Error("Failed to publish product purged event", err)
+			}
+		}
+
+		purged += len(ids)
+		if len(ids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// TransitionProduct checks the move against the lifecycle state machine and
+// applies it. It returns nil if the product does not exist.
+func (s *productService) TransitionProduct(ctx context.Context, id string, to domain.Status, changedBy string, expectedVersion int64) (*domain.Product, error) {
+	productID, err := domain.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.FindByID(ctx, productID.String(), false)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	from := current.Status
+	if !from.CanTransition(to) {
+		return nil, &domain.TransitionError{From: from, To: to}
+	}
+
+	updatedProduct, err := s.repo.Transition(ctx, productID.String(), from, to, changedBy, expectedVersion)
+	if err != nil || updatedProduct == nil {
+		return nil, err
+	}
+
+	s.cacheProduct(ctx, updatedProduct)
+	s.invalidateFacets(ctx)
+
+	if eventType, ok := lifecycleEvents[to]; ok {
+		transitionEvent := map[string]interface{}{
+			"id":         updatedProduct.ID,
+			"product":    updatedProduct,
+			"from":       from,
+			"to":         to,
+			"changed_by": changedBy,
+			"timestamp":  time.Now(),
+		}
+
+		if err := s.publishEvent(eventType, transitionEvent); err != nil {
+			s.logger.Error("Failed to publish product lifecycle event", err, logger.Fields{"event": eventType})
+		}
+	}
+
+	return updatedProduct, nil
+}
+
+func (s *productService) GetPriceHistory(ctx context.Context, id string, query domain.PriceHistoryQuery) ([]domain.PriceChange, error) {
+	// Products in the trash keep their history until they are purged
+	product, err := s.GetProductByID(ctx, id, true)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	return s.history.FindByProduct(ctx, product.ID, query)
+}
+
+func (s *productService) AdjustInventory(ctx context.Context, id string, adjustment domain.InventoryAdjustment, adjustedBy string) (*domain.InventoryMovement, error) {
+	// The stored product tells whether stock is kept per variant
+	product, err := s.repo.FindByID(ctx, id, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	sku, err := stockSKU(product, adjustment.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	location := adjustment.Location
+	if location == "" {
+		location = domain.DefaultLocationID
+	}
+	if err := s.checkLocation(ctx, "location", location); err != nil {
+		return nil, err
+	}
+
+	adjusted, err := s.changeStock(ctx, product, sku, location, domain.StockChange{OnHand: adjustment.Delta})
+	if err != nil || adjusted == nil {
+		return nil, err
+	}
+
+	movement := domain.InventoryMovement{
+		ProductID:  adjusted.ID,
+		SKU:        sku,
+		Delta:      adjustment.Delta,
+		Reason:     adjustment.Reason,
+		Reference:  adjustment.Reference,
+		Note:       adjustment.Note,
+		Balance:    adjusted.Stock(sku),
+		CreatedBy:  adjustedBy,
+		CreatedAt:  time.Now(),
+		LocationID: location,
+	}
+	s.recordMovements(ctx, adjusted.ID, []domain.InventoryMovement{movement}, adjustedBy)
+	s.publishInventoryChanged(adjusted, movement)
+
+	return &movement, nil
+}
+
+func (s *productService) GetInventoryMovements(ctx context.Context, id string, query domain.InventoryMovementQuery) ([]domain.InventoryMovement, error) {
+	// Products in the trash keep their ledger until they are purged
+	product, err := s.GetProductByID(ctx, id, true)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	return s.inventory.FindByProduct(ctx, product.ID, query)
+}
+
+func (s *productService) ReconcileInventory(ctx context.Context, id string) (*domain.InventoryReconciliation, error) {
+	// Compare against the stored stock rather than a possibly stale cached
+	// copy
+	product, err := s.repo.FindByID(ctx, id, true)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	totals, err := s.inventory.Totals(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation := domain.Reconcile(product, totals)
+	return &reconciliation, nil
+}
+
+func (s *productService) ReserveStock(ctx context.Context, id domain.ID, sku, location string, quantity int) (*domain.Product, error) {
+	product, err := s.repo.FindByID(ctx, id.String(), false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	return s.changeStock(ctx, product, sku, location, domain.StockChange{Reserved: quantity})
+}
+
+func (s *productService) SellReservedStock(ctx context.Context, id domain.ID, sku, location string, quantity int, reference, soldBy string) (*domain.Product, error) {
+	product, err := s.repo.FindByID(ctx, id.String(), false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	sold, err := s.changeStock(ctx, product, sku, location, domain.StockChange{OnHand: -quantity, Reserved: -quantity})
+	if err != nil || sold == nil {
+		return nil, err
+	}
+
+	movement := domain.InventoryMovement{
+		ProductID:  sold.ID,
+		SKU:        sku,
+		Delta:      -quantity,
+		Reason:     domain.InventorySale,
+		Reference:  reference,
+		Balance:    sold.Stock(sku),
+		CreatedBy:  soldBy,
+		CreatedAt:  time.Now(),
+		LocationID: location,
+	}
+	s.recordMovements(ctx, sold.ID, []domain.InventoryMovement{movement}, soldBy)
+	s.publishInventoryChanged(sold, movement)
+
+	return sold, nil
+}
+
+func (s *productService) TransferStock(ctx context.Context, id string, transfer domain.StockTransfer, transferredBy string) ([]domain.InventoryMovement, error) {
+	product, err := s.repo.FindByID(ctx, id, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	sku, err := stockSKU(product, transfer.SKU)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLocation(ctx, "from", transfer.From); err != nil {
+		return nil, err
+	}
+	if err := s.checkLocation(ctx, "to", transfer.To); err != nil {
+		return nil, err
+	}
+
+	// Only available stock moves, so the totals on the product stay as they
+	// are. The source gives it up first so that its guard decides.
+	out := domain.StockChange{OnHand: -transfer.Quantity}
+	if _, err := s.stock.Adjust(ctx, product.ID, sku, transfer.From, out); err != nil {
+		return nil, err
+	}
+	if _, err := s.stock.Adjust(ctx, product.ID, sku, transfer.To, domain.StockChange{OnHand: transfer.Quantity}); err != nil {
+		s.undoLocationChange(ctx, product.ID, sku, transfer.From, out)
+		return nil, err
+	}
+
+	// An empty change leaves the totals on the product as they are but bumps
+	// its version, so that copies cached by clients show the move
+	variantSKU := ""
+	if len(product.Variants) > 0 {
+		variantSKU = sku
+	}
+	if moved, err := s.repo.AdjustStock(ctx, product.ID, variantSKU, domain.StockChange{}); err != nil {
+		s.logger.Error("Failed to bump product version after stock transfer", err, logger.Fields{"productId": product.ID})
+	} else if moved != nil {
+		product = moved
+	}
+
+	// Facets filtered by location depend on where stock is available
+	s.cache.Delete(ctx, fmt.Sprintf("product:%s", product.ID))
+	s.invalidateFacets(ctx)
+
+	now := time.Now()
+	movements := []domain.InventoryMovement{
+		{
+			ProductID:  product.ID,
+			SKU:        sku,
+			Delta:      -transfer.Quantity,
+			Reason:     domain.InventoryTransfer,
+			Reference:  transfer.Reference,
+			Note:       transfer.Note,
+			Balance:    product.Stock(sku),
+			CreatedBy:  transferredBy,
+			CreatedAt:  now,
+			LocationID: transfer.From,
+		},
+		{
+			ProductID:  product.ID,
+			SKU:        sku,
+			Delta:      transfer.Quantity,
+			Reason:     domain.InventoryTransfer,
+			Reference:  transfer.Reference,
+			Note:       transfer.Note,
+			Balance:    product.Stock(sku),
+			CreatedBy:  transferredBy,
+			CreatedAt:  now,
+			LocationID: transfer.To,
+		},
+	}
+	s.recordMovements(ctx, product.ID, movements, transferredBy)
+
+	transferEvent := map[string]interface{}{
+		"id":             product.ID,
+		"sku":            sku,
+		"from":           transfer.From,
+		"to":             transfer.To,
+		"quantity":       transfer.Quantity,
+		"reference":      transfer.Reference,
+		"transferred_by": transferredBy,
+		"timestamp":      now,
+	}
+	if err := s.publishEvent("product.stock_transferred", transferEvent); err != nil {
+		s.logger.Error("Failed to publish product stock transferred event", err)
+	}
+
+	return movements, nil
+}
+
+func (s *productService) GetLocationStock(ctx context.Context, id string) ([]domain.LocationStock, error) {
+	product, err := s.GetProductByID(ctx, id, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	levels, err := s.stock.FindByProducts(ctx, []domain.ID{product.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Levels of SKUs the product no longer keeps stock under are left out
+	skus := product.StockSKUs()
+	kept := make([]domain.LocationStock, 0, len(levels))
+	for _, l := range levels {
+		if containsString(skus, l.SKU) {
+			kept = append(kept, l)
+		}
+	}
+
+	return kept, nil
+}
+
+// ApplyLocations reads the stock at each location on every request rather
+// than caching it with the products, as it changes with every order.
+func (s *productService) ApplyLocations(ctx context.Context, products ...*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]domain.ID, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+
+	levels, err := s.stock.FindByProducts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byProduct := make(map[domain.ID][]domain.LocationStock, len(products))
+	for _, l := range levels {
+		byProduct[l.ProductID] = append(byProduct[l.ProductID], l)
+	}
+	for _, p := range products {
+		p.SetLocations(byProduct[p.ID])
+	}
+
+	return nil
+}
+
+// stockSKU returns the SKU a product keeps the stock of sku under, the
+// product SKU if sku is empty.
+func stockSKU(product *domain.Product, sku string) (string, error) {
+	if sku == "" {
+		sku = product.SKU
+	}
+	if containsString(product.StockSKUs(), sku) {
+		return sku, nil
+	}
+
+	verr := &domain.ValidationError{}
+	if len(product.Variants) > 0 {
+		verr.Add("sku", "must be the SKU of a variant; stock is kept per variant")
+	} else {
+		verr.Add("sku", "is not the SKU of the product")
+	}
+	return "", verr.Err()
+}
+
+// changeStock applies a change to the stock product, as read before the
+// change, keeps under sku at a location and announces the stock level it
+// leaves the SKU at when that gets low. It returns nil if the product does
+// not exist or keeps no stock under sku.
+func (s *productService) changeStock(ctx context.Context, product *domain.Product, sku, location string, change domain.StockChange) (*domain.Product, error) {
+	if !containsString(product.StockSKUs(), sku) {
+		return nil, nil
+	}
+
+	// The stock at the location is changed first, so that its guards decide
+	// whether the change fits; the totals on the product follow
+	if _, err := s.stock.Adjust(ctx, product.ID, sku, location, change); err != nil {
+		return nil, err
+	}
+
+	variantSKU := ""
+	if len(product.Variants) > 0 {
+		variantSKU = sku
+	}
+	changed, err := s.repo.AdjustStock(ctx, product.ID, variantSKU, change)
+	if err != nil || changed == nil {
+		s.undoLocationChange(ctx, product.ID, sku, location, change)
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, fmt.Sprintf("product:%s", changed.ID))
+	s.invalidateFacets(ctx)
+
+	available := changed.AvailableStock(sku)
+	level := domain.StockLevelOf(available, s.lowStock)
+	if level == domain.StockLevelOf(available-change.Available(), s.lowStock) || level == domain.StockInStock {
+		return changed, nil
+	}
+
+	stockEvent := map[string]interface{}{
+		"id":        changed.ID,
+		"sku":       sku,
+		"available": available,
+		"inventory": changed.Stock(sku),
+		"threshold": s.lowStock,
+		"version":   changed.Version,
+		"timestamp": time.Now(),
+	}
+	if err := s.publishEvent("product."+string(level), stockEvent); err != nil {
+		s.logger.Error("Failed to publish product stock level event", err, logger.Fields{"level": level})
+	}
+
+	return changed, nil
+}
+
+// undoLocationChange takes back a change to the stock at a location that
+// the rest of its operation failed after. Failures are logged;
+// reconciliation does not see the stock at locations, so they need a
+// manual fix.
+func (s *productService) undoLocationChange(ctx context.Context, productID domain.ID, sku, location string, change domain.StockChange) {
+	inverse := domain.StockChange{OnHand: -change.OnHand, Reserved: -change.Reserved}
+	if _, err := s.stock.Adjust(ctx, productID, sku, location, inverse); err != nil {
+		s.logger.Error("Failed to undo location stock change", err, logger.Fields{"productId": productID, "sku": sku, "location": location})
+	}
+}
+
+// updateStockSKUs puts the opening stock of the SKUs after starts keeping
+// stock under at the default location and records it in the ledger, and
+// forgets the stock at every location of the SKUs it stops keeping stock
+// under. Failures are logged; the product itself has already been stored.
+func (s *productService) updateStockSKUs(ctx context.Context, before, after *domain.Product, updatedBy string) {
+	movements := domain.OpeningMovements(before, after)
+	for i := range movements {
+		m := &movements[i]
+		m.LocationID = domain.DefaultLocationID
+		if _, err := s.stock.Adjust(ctx, after.ID, m.SKU, m.LocationID, domain.StockChange{OnHand: m.Delta}); err != nil {
+			s.logger.Error("Failed to store opening stock at the default location", err, logger.Fields{"productId": after.ID, "sku": m.SKU})
+		}
+	}
+	s.recordMovements(ctx, after.ID, movements, updatedBy)
+
+	if before == nil {
+		return
+	}
+
+	skus := after.StockSKUs()
+	var dropped []string
+	for _, sku := range before.StockSKUs() {
+		if !containsString(skus, sku) {
+			dropped = append(dropped, sku)
+		}
+	}
+	if err := s.stock.Remove(ctx, after.ID, dropped); err != nil {
+		s.logger.Error("Failed to remove stock of dropped SKUs", err, logger.Fields{"productId": after.ID})
+	}
+}
+
+// publishInventoryChanged announces a movement of the stock on hand.
+func (s *productService) publishInventoryChanged(product *domain.Product, movement domain.InventoryMovement) {
+	inventoryEvent := map[string]interface{}{
+		"id":         product.ID,
+		"sku":        movement.SKU,
+		"delta":      movement.Delta,
+		"reason":     movement.Reason,
+		"reference":  movement.Reference,
+		"balance":    movement.Balance,
+		"inventory":  product.Inventory,
+		"available":  product.AvailableStock(movement.SKU),
+		"version":    product.Version,
+		"changed_by": movement.CreatedBy,
+		"timestamp":  movement.CreatedAt,
+	}
+	if err := s.publishEvent("product.inventory_changed", inventoryEvent); err != nil {
+		s.logger.Error("Failed to publish product inventory changed event", err)
+	}
+}
+
+// recordMovements appends movements of a product to the inventory ledger,
+// stamping those not yet stamped. Failures are logged; the stock itself has
+// already been stored and reconciliation reports the gap.
+func (s *productService) recordMovements(ctx context.Context, productID domain.ID, movements []domain.InventoryMovement, createdBy string) {
+	if len(movements) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for i := range movements {
+		if movements[i].CreatedAt.IsZero() {
+			movements[i].CreatedBy = createdBy
+			movements[i].CreatedAt = now
+		}
+	}
+
+	if err := s.inventory.Record(ctx, movements); err != nil {
+		s.logger.Error("Failed to record inventory movements", err, logger.Fields{"productId": productID})
+	}
+}
+
+// checkSKUAvailable returns a DuplicateSKUError when the product SKU or one
+// of its variant SKUs belongs to a product other than ownerID, including
+// products in the trash. The unique indexes remain the final guard against
+// races.
+func (s *productService) checkSKUAvailable(ctx context.Context, product *domain.Product, ownerID domain.ID) error {
+	skus := product.SKUs()
+	existing, err := s.repo.FindSKUOwner(ctx, skus, ownerID)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	dupErr := &domain.DuplicateSKUError{SKU: skus[0], ProductID: existing.ID, Deleted: existing.DeletedAt != nil}
+	for _, sku := range skus {
+		if existing.HasSKU(sku) {
+			dupErr.SKU = sku
+			break
+		}
+	}
+
+	return dupErr
+}
+
+// resolveCategories assigns a product to categories by ID and sets its
+// categories to their slugs. Clients may assign categories through
+// category_ids or, by name or slug, through categories; the one that differs
+// from before, the stored product, wins. Both must agree if both changed.
+func (s *productService) resolveCategories(ctx context.Context, product *domain.Product, before *domain.Product) error {
+	verr := &domain.ValidationError{}
+
+	idsChanged := len(product.CategoryIDs) > 0
+	namesChanged := len(product.Categories) > 0
+	if before != nil {
+		idsChanged = idsChanged && !sameIDs(product.CategoryIDs, before.CategoryIDs)
+		namesChanged = namesChanged && !sameStrings(product.Categories, before.Categories)
+	}
+
+	var categories []domain.Category
+	if idsChanged {
+		if len(product.CategoryIDs) > domain.MaxProductCategories {
+			verr.Add("category_ids", "must contain at most %d entries", domain.MaxProductCategories)
+			return verr.Err()
+		}
+
+		found, err := s.categories.FindByIDs(ctx, product.CategoryIDs)
+		if err != nil {
+			return err
+		}
+		byID := make(map[domain.ID]domain.Category, len(found))
+		for _, c := range found {
+			byID[c.ID] = c
+		}
+
+		seen := make(map[domain.ID]bool, len(product.CategoryIDs))
+		for _, id := range product.CategoryIDs {
+			c, ok := byID[id]
+			if !ok {
+				verr.Add("category_ids", "%q is not a category", id)
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				categories = append(categories, c)
+			}
+		}
+	} else {
+		slugs := categorySlugs(product.Categories)
+
+		found, err := s.categories.FindBySlugs(ctx, slugs)
+		if err != nil {
+			return err
+		}
+		bySlug := make(map[string]domain.Category, len(found))
+		for _, c := range found {
+			bySlug[c.Slug] = c
+		}
+
+		for _, slug := range slugs {
+			c, ok := bySlug[slug]
+			if !ok {
+				verr.Add("categories", "%q is not a category", slug)
+				continue
+			}
+			categories = append(categories, c)
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	product.CategoryIDs = make([]domain.ID, 0, len(categories))
+	slugs := make([]string, 0, len(categories))
+	for _, c := range categories {
+		product.CategoryIDs = append(product.CategoryIDs, c.ID)
+		slugs = append(slugs, c.Slug)
+	}
+
+	if idsChanged && namesChanged && !sameSet(slugs, categorySlugs(product.Categories)) {
+		verr.Add("categories", "do not match category_ids; assign categories through category_ids only")
+		return verr.Err()
+	}
+	product.Categories = slugs
+
+	return nil
+}
+
+// resolveCategoryFilter matches the categories filter by slug, as products
+// store them, and expands the category_id filter to the category and all of
+// its descendants.
+func (s *productService) resolveCategoryFilter(ctx context.Context, filter *domain.ProductFilter) error {
+	if len(filter.Categories) > 0 {
+		filter.Categories = categorySlugs(filter.Categories)
+	}
+	if filter.CategoryID == "" {
+		return nil
+	}
+
+	category, err := s.categories.FindByID(ctx, filter.CategoryID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		verr := &domain.ValidationError{}
+		verr.Add("category_id", "is not a category")
+		return verr.Err()
+	}
+
+	descendants, err := s.categories.FindDescendants(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+
+	filter.CategoryIDs = append(make([]domain.ID, 0, len(descendants)+1), category.ID)
+	for _, d := range descendants {
+		filter.CategoryIDs = append(filter.CategoryIDs, d.ID)
+	}
+
+	return nil
+}
+
+// checkAttributes validates the attributes of a product against the schema
+// of its categories.
+func (s *productService) checkAttributes(ctx context.Context, product *domain.Product) error {
+	schema, err := s.attributes.Schema(ctx)
+	if err != nil {
+		return err
+	}
+
+	return schema.ForCategories(product.Categories).Check(product.Attributes)
+}
+
+// resolveAttributeFilters types the attribute filters of filter and checks
+// the facet attributes against the attribute definitions.
+func (s *productService) resolveAttributeFilters(ctx context.Context, filter *domain.ProductFilter) error {
+	if len(filter.Attribute) == 0 && len(filter.FacetAttributes) == 0 {
+		return nil
+	}
+
+	schema, err := s.attributes.Schema(ctx)
+	if err != nil {
+		return err
+	}
+
+	verr := &domain.ValidationError{}
+	if filter.AttributeFilters, err = schema.ParseFilters(filter.Attribute); err != nil {
+		verr.Add("attribute", "%s", err.Error())
+	}
+	for _, name := range filter.FacetAttributes {
+		if _, ok := schema[name]; !ok {
+			verr.Add("facet_attributes", "%q is not a defined attribute", name)
+			break
+		}
+	}
+
+	return verr.Err()
+}
+
+// checkPrices checks that a product is priced in the currency of the default
+// price list, and in other lists in theirs. Effective prices are never
+// stored.
+func (s *productService) checkPrices(ctx context.Context, product *domain.Product) error {
+	lists, err := s.priceLists.PriceLists(ctx)
+	if err != nil {
+		return err
+	}
+
+	currency := lists.Currency()
+	verr := &domain.ValidationError{}
+	checkList := func(field string, prices map[string]domain.Money) {
+		ids := make([]string, 0, len(prices))
+		for id := range prices {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			list := lists.Find(id)
+			switch {
+			case list == nil:
+				verr.Add(field, "%q is not a price list", id)
+			case prices[id].Currency != list.Currency:
+				verr.Add(field+"."+id+".currency", "must be %s", list.Currency)
+			}
+		}
+	}
+
+	if product.Price.Currency != currency {
+		verr.Add("price.currency", "must be %s, the currency of the default price list", currency)
+	}
+	checkList("prices", product.Prices)
+	product.EffectivePrice = nil
+
+	for i := range product.Variants {
+		v := &product.Variants[i]
+		checkList(fmt.Sprintf("variants[%d].prices", i), v.Prices)
+		v.EffectivePrice = nil
+	}
+
+	return verr.Err()
+}
+
+// resolvePriceFilter converts the price bounds and comparisons of filter to
+// minor units of the currency of the default price list.
+func (s *productService) resolvePriceFilter(ctx context.Context, filter *domain.ProductFilter) error {
+	lists, err := s.priceLists.PriceLists(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter.SetCurrency(lists.Currency())
+	return nil
+}
+
+// resolveLocationFilter restricts filter to the products with stock
+// available at the location it names.
+func (s *productService) resolveLocationFilter(ctx context.Context, filter *domain.ProductFilter) error {
+	if filter.Location == "" {
+		return nil
+	}
+
+	if err := s.checkLocation(ctx, "location", filter.Location); err != nil {
+		return err
+	}
+
+	ids, err := s.stock.ProductsWithStock(ctx, filter.Location)
+	if err != nil {
+		return err
+	}
+
+	filter.StockedIDs = ids
+	return nil
+}
+
+// checkLocation returns a validation error on field if id is not a stock
+// location.
+func (s *productService) checkLocation(ctx context.Context, field, id string) error {
+	location, err := s.locations.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if location == nil {
+		verr := &domain.ValidationError{}
+		verr.Add(field, "is not a stock location")
+		return verr.Err()
+	}
+
+	return nil
+}
+
+// cacheProduct stores a product under its ID and maps its SKU and the SKUs
+// of its variants to that ID.
+func (s *productService) cacheProduct(ctx context.Context, product *domain.Product) {
+	productJSON, _ := json.Marshal(product)
+	s.cache.Set(ctx, fmt.Sprintf("product:%s", product.ID), string(productJSON), 30*time.Minute)
+	for _, sku := range product.SKUs() {
+		s.cache.Set(ctx, fmt.Sprintf("product:sku:%s", sku), product.ID.String(), 30*time.Minute)
+	}
+}
+
+// facetsCacheKey identifies cached facets by the current generation and a
+// hash of the filter parameters that affect them.
+func (s *productService) facetsCacheKey(ctx context.Context, filter domain.ProductFilter) string {
+	generation, _ := s.cache.Get(ctx, facetsGenerationKey)
+
+	params, _ := json.Marshal([]interface{}{
+		filter.Name, filter.NameMatch, filter.Categories, filter.MinPrice, filter.MaxPrice, filter.Filter,
+		filter.IncludeDeleted, filter.Status, filter.Variant, filter.Attribute, filter.FacetAttributes,
+		filter.CategoryID, filter.Currency, filter.Location,
+	})
+	sum := sha256.Sum256(params)
+
+	return fmt.Sprintf("product:facets:%s:%x", generation, sum[:16])
+}
+
+func (s *productService) invalidateFacets(ctx context.Context) {
+	if _, err := s.cache.Incr(ctx, facetsGenerationKey); err != nil {
+		s.logger.Error("Failed to invalidate facets cache", err)
+	}
+}
+
 func (s *productService) publishProductEvent(eventType string, product *domain.Product) error {
 	event := map[string]interface{}{
-		"id":        product.ID.Hex(),
+		"id":        product.ID,
 		"product":   product,
 		"timestamp": time.Now(),
 	}
@@ -149,3 +1401,63 @@ func (s *productService) publishEvent(eventType string, payload interface{}) err
 
 	return s.messageBus.Publish("product_exchange", eventType, eventJSON)
 }
+
+// categorySlugs derives the slugs of category names, dropping repetitions.
+func categorySlugs(names []string) []string {
+	slugs := make([]string, 0, len(names))
+	for _, name := range names {
+		if slug := domain.Slugify(name); !containsString(slugs, slug) {
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs
+}
+
+func sameIDs(a, b []domain.ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSet reports whether a and b hold the same strings, ignoring order and
+// repetitions.
+func sameSet(a, b []string) bool {
+	for _, s := range a {
+		if !containsString(b, s) {
+			return false
+		}
+	}
+	for _, s := range b {
+		if !containsString(a, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,367 @@
+// internal/service/product_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+const storedID = domain.ID("507f1f77bcf86cd799439011")
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Fields)        {}
+func (nopLogger) Info(string, ...logger.Fields)         {}
+func (nopLogger) Warn(string, error, ...logger.Fields)  {}
+func (nopLogger) Error(string, error, ...logger.Fields) {}
+func (nopLogger) Fatal(string, error, ...logger.Fields) {}
+
+// fakeBus records the routing keys of the published events.
+type fakeBus struct {
+	messaging.RabbitMQClient
+	events []string
+}
+
+func (b *fakeBus) Publish(_, routingKey string, _ []byte) error {
+	b.events = append(b.events, routingKey)
+	return nil
+}
+
+// fakeCache is an in-memory cache.
+type fakeCache struct {
+	cache.RedisClient
+	entries map[string]string
+}
+
+func (c *fakeCache) Get(_ context.Context, key string) (string, error) {
+	return c.entries[key], nil
+}
+
+func (c *fakeCache) Set(_ context.Context, key, value string, _ time.Duration) error {
+	c.entries[key] = value
+	return nil
+}
+
+func (c *fakeCache) Delete(_ context.Context, key string) error {
+	delete(c.entries, key)
+	return nil
+}
+
+func (c *fakeCache) Incr(_ context.Context, key string) (int64, error) {
+	n, _ := strconv.ParseInt(c.entries[key], 10, 64)
+	n++
+	c.entries[key] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+// fakePriceLists serves the default price list only.
+type fakePriceLists struct {
+	PriceListService
+}
+
+func (fakePriceLists) PriceLists(context.Context) (domain.PriceLists, error) {
+	return domain.PriceLists{{ID: domain.DefaultPriceListID, Currency: "USD", Default: true}}, nil
+}
+
+// fakeStock counts the products whose stock levels are removed.
+type fakeStock struct {
+	repository.LocationStockRepository
+	removed int
+}
+
+func (r *fakeStock) RemoveProducts(_ context.Context, ids []domain.ID) error {
+	r.removed += len(ids)
+	return nil
+}
+
+// fakeRepository stores one product under its canonical ID, counts the
+// reads and facet computations that reach it and serves canned trash
+// operations.
+type fakeRepository struct {
+	repository.ProductRepository
+	reads  int
+	facets int
+
+	deletedBy string
+	restored  *domain.Product
+	err       error
+	// purgeBatches are the sizes of the batches Purge returns, in order;
+	// once they run out it fails with err, or returns nothing
+	purgeBatches []int
+	purges       int
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, id string, _ bool) (*domain.Product, error) {
+	r.reads++
+	if id != storedID.String() {
+		return nil, nil
+	}
+	return &domain.Product{ID: storedID, Name: "Leather jacket", SKU: "JKT-001"}, nil
+}
+
+func (r *fakeRepository) Facets(context.Context, domain.ProductFilter) (*domain.ProductFacets, error) {
+	r.facets++
+	return &domain.ProductFacets{Total: int64(r.facets)}, nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, _ string, _ int64, deletedBy string) error {
+	r.deletedBy = deletedBy
+	return r.err
+}
+
+func (r *fakeRepository) Restore(context.Context, string, int64) (*domain.Product, error) {
+	return r.restored, r.err
+}
+
+func (r *fakeRepository) Purge(_ context.Context, _ time.Time, limit int) ([]domain.ID, error) {
+	r.purges++
+	if len(r.purgeBatches) == 0 {
+		return nil, r.err
+	}
+
+	n := r.purgeBatches[0]
+	r.purgeBatches = r.purgeBatches[1:]
+	if n > limit {
+		n = limit
+	}
+	return make([]domain.ID, n), nil
+}
+
+func newCachedService() (*productService, *fakeRepository, *fakeCache) {
+	s, repo, c, _ := newTestService()
+	return s, repo, c
+}
+
+func newTestService() (*productService, *fakeRepository, *fakeCache, *fakeBus) {
+	repo := &fakeRepository{}
+	c := &fakeCache{entries: map[string]string{}}
+	bus := &fakeBus{}
+	s := NewProductService(repo, nil, nil, fakePriceLists{}, nil, nil, &fakeStock{}, nil, 0, c, bus, nopLogger{})
+	return s.(*productService), repo, c, bus
+}
+
+func TestGetFacetsCache(t *testing.T) {
+	s, repo, _ := newCachedService()
+	ctx := context.Background()
+	denim := domain.ProductFilter{Categories: []string{"denim"}, Limit: 10}
+
+	steps := []struct {
+		name   string
+		filter domain.ProductFilter
+		write  bool
+		want   int
+	}{
+		{"first request", denim, false, 1},
+		{"repeated request", denim, false, 1},
+		{"other page size", domain.ProductFilter{Categories: []string{"denim"}, Limit: 50}, false, 1},
+		{"other filter", domain.ProductFilter{Categories: []string{"shoes"}, Limit: 10}, false, 2},
+		{"after a write", denim, true, 3},
+		{"after a write, repeated", denim, false, 3},
+	}
+
+	for _, step := range steps {
+		if step.write {
+			if err := s.DeleteProduct(ctx, storedID.String(), 0, "admin"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		facets, err := s.GetFacets(ctx, step.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if repo.facets != step.want {
+			t.Errorf("%s: repository computed facets %d times, want %d", step.name, repo.facets, step.want)
+		}
+		// Each computation is numbered by its Total, so a cached answer shows
+		// which one it came from
+		if facets.Total != int64(step.want) {
+			t.Errorf("%s: got facets of computation %d, want %d", step.name, facets.Total, step.want)
+		}
+	}
+}
+
+func TestDeleteProductMovesToTrash(t *testing.T) {
+	s, repo, c, bus := newTestService()
+	ctx := context.Background()
+	c.entries["product:507f1f77bcf86cd799439011"] = `{"name":"Leather jacket"}`
+
+	if err := s.DeleteProduct(ctx, storedID.String(), 2, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.deletedBy != "admin" {
+		t.Errorf("deleted by %q, want admin", repo.deletedBy)
+	}
+	if _, ok := c.entries["product:507f1f77bcf86cd799439011"]; ok {
+		t.Error("DeleteProduct() left the product cached")
+	}
+	if c.entries[facetsGenerationKey] != "1" {
+		t.Errorf("facets generation = %q, want 1", c.entries[facetsGenerationKey])
+	}
+	if fmt.Sprint(bus.events) != "[product.deleted]" {
+		t.Errorf("events = %v, want [product.deleted]", bus.events)
+	}
+
+	repo.err = domain.ErrVersionMismatch
+	if err := s.DeleteProduct(ctx, storedID.String(), 1, "admin"); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("DeleteProduct(stale version) error = %v, want ErrVersionMismatch", err)
+	}
+	if len(bus.events) != 1 {
+		t.Errorf("events = %v after a failed delete, want only the first", bus.events)
+	}
+}
+
+func TestRestoreProduct(t *testing.T) {
+	restored := &domain.Product{ID: "507f1f77bcf86cd799439011", Name: "Leather jacket", SKU: "JKT-001", Version: 4}
+
+	tests := []struct {
+		name       string
+		restored   *domain.Product
+		err        error
+		wantErr    error
+		wantCached bool
+	}{
+		{"in the trash", restored, nil, nil, true},
+		{"unknown", nil, nil, nil, false},
+		{"not deleted", nil, domain.ErrProductNotDeleted, domain.ErrProductNotDeleted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, c, bus := newTestService()
+			repo.restored, repo.err = tt.restored, tt.err
+
+			got, err := s.RestoreProduct(context.Background(), "507f1f77bcf86cd799439011", 3)
+			if !errors.Is(err, tt.wantErr) || got != tt.restored {
+				t.Fatalf("RestoreProduct() = %v, %v, want %v, %v", got, err, tt.restored, tt.wantErr)
+			}
+
+			_, cached := c.entries["product:507f1f77bcf86cd799439011"]
+			if cached != tt.wantCached || (c.entries["product:sku:JKT-001"] != "") != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+			wantEvents := "[]"
+			if tt.wantCached {
+				wantEvents = "[product.restored]"
+			}
+			if fmt.Sprint(bus.events) != wantEvents {
+				t.Errorf("events = %v, want %s", bus.events, wantEvents)
+			}
+		})
+	}
+}
+
+func TestPurgeDeletedProducts(t *testing.T) {
+	failed := errors.New("connection reset")
+
+	tests := []struct {
+		name       string
+		batches    []int
+		err        error
+		wantPurged int
+		wantCalls  int
+	}{
+		{"nothing to purge", nil, nil, 0, 1},
+		{"one partial batch", []int{3}, nil, 3, 1},
+		{"full batches", []int{purgeBatchSize, purgeBatchSize, 2}, nil, 2*purgeBatchSize + 2, 3},
+		{"full batches exactly", []int{purgeBatchSize, purgeBatchSize}, nil, 2 * purgeBatchSize, 3},
+		{"failure after a batch", []int{purgeBatchSize}, failed, purgeBatchSize, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _, bus := newTestService()
+			repo.purgeBatches, repo.err = tt.batches, tt.err
+
+			purged, err := s.PurgeDeletedProducts(context.Background(), time.Now())
+			if purged != tt.wantPurged || !errors.Is(err, tt.err) {
+				t.Errorf("PurgeDeletedProducts() = %d, %v, want %d, %v", purged, err, tt.wantPurged, tt.err)
+			}
+			if repo.purges != tt.wantCalls {
+				t.Errorf("Purge called %d times, want %d", repo.purges, tt.wantCalls)
+			}
+			if len(bus.events) != tt.wantPurged {
+				t.Errorf("published %d events, want one per purged product", len(bus.events))
+			}
+			if removed := s.stock.(*fakeStock).removed; removed != tt.wantPurged {
+				t.Errorf("removed the stock of %d products, want %d", removed, tt.wantPurged)
+			}
+		})
+	}
+}
+
+func TestGetProductByIDCacheKey(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"canonical", storedID.String()},
+		{"upper case", strings.ToUpper(storedID.String())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newCachedService()
+			ctx := context.Background()
+
+			for i := 0; i < 2; i++ {
+				product, err := s.GetProductByID(ctx, tt.id, false)
+				if err != nil || product == nil {
+					t.Fatalf("GetProductByID(%q) = %v, %v", tt.id, product, err)
+				}
+			}
+			if repo.reads != 1 {
+				t.Errorf("repository read %d times, want 1", repo.reads)
+			}
+		})
+	}
+}
+
+func TestDeleteProductInvalidatesCache(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"canonical", storedID.String()},
+		{"upper case", strings.ToUpper(storedID.String())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, c := newCachedService()
+			ctx := context.Background()
+
+			if _, err := s.GetProductByID(ctx, storedID.String(), false); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.DeleteProduct(ctx, tt.id, 0, "admin"); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := c.entries["product:"+storedID.String()]; ok {
+				t.Errorf("DeleteProduct(%q) left the cached product", tt.id)
+			}
+		})
+	}
+}
+
+func TestGetProductByIDInvalid(t *testing.T) {
+	s, repo, _ := newCachedService()
+
+	if _, err := s.GetProductByID(context.Background(), "not-an-id", false); err == nil {
+		t.Error(`GetProductByID("not-an-id") succeeded, want ErrInvalidID`)
+	}
+	if repo.reads != 0 {
+		t.Errorf("repository read %d times, want 0", repo.reads)
+	}
+}
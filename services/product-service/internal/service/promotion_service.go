@@ -0,0 +1,350 @@
+// internal/service/promotion_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/cache"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+type PromotionService interface {
+	// ListPromotions returns every promotion by priority, highest first
+	ListPromotions(ctx context.Context) ([]domain.Promotion, error)
+	// GetPromotion returns nil if the promotion does not exist
+	GetPromotion(ctx context.Context, id string) (*domain.Promotion, error)
+	CreatePromotion(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error)
+	// UpdatePromotion replaces a promotion and returns nil if it does not
+	// exist
+	UpdatePromotion(ctx context.Context, id string, promotion domain.Promotion) (*domain.Promotion, error)
+	DeletePromotion(ctx context.Context, id string) error
+	// EvaluateProduct prices quantity units of a product, or of its variant
+	// sku, after the promotions active now. It returns nil if the product
+	// does not exist or the caller may not see it.
+	EvaluateProduct(ctx context.Context, pc domain.PriceContext, id, sku string, quantity int) (*domain.ItemEvaluation, error)
+	// EvaluateCart prices the items of a cart after the promotions active
+	// now. A cart is priced in a single currency: that of the price context,
+	// or of the default price list. Items that cannot be priced are
+	// reported as validation errors.
+	EvaluateCart(ctx context.Context, pc domain.PriceContext, items []domain.CartItem) (*domain.CartEvaluation, error)
+}
+
+const (
+	// promotionsCacheKey holds every promotion, active or not, since every
+	// evaluation goes through all of them. Validity windows are checked at
+	// evaluation time, so the cache only changes with the promotions.
+	promotionsCacheKey = "product:promotions"
+	promotionsCacheTTL = 10 * time.Minute
+)
+
+type promotionService struct {
+	repo       repository.PromotionRepository
+	products   ProductService
+	priceLists PriceListService
+	categories repository.CategoryRepository
+	cache      cache.RedisClient
+	messageBus messaging.RabbitMQClient
+	logger     logger.Logger
+}
+
+// NewPromotionService evaluates promotions against products read through
+// products, priced through priceLists. Category targets are matched through
+// the ancestors of the product categories, read from categories.
+func NewPromotionService(repo repository.PromotionRepository, products ProductService, priceLists PriceListService, categories repository.CategoryRepository, cache cache.RedisClient, messageBus messaging.RabbitMQClient, logger logger.Logger) PromotionService {
+	return &promotionService{
+		repo:       repo,
+		products:   products,
+		priceLists: priceLists,
+		categories: categories,
+		cache:      cache,
+		messageBus: messageBus,
+		logger:     logger,
+	}
+}
+
+func (s *promotionService) ListPromotions(ctx context.Context) ([]domain.Promotion, error) {
+	return s.promotions(ctx)
+}
+
+func (s *promotionService) GetPromotion(ctx context.Context, id string) (*domain.Promotion, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *promotionService) CreatePromotion(ctx context.Context, promotion domain.Promotion) (*domain.Promotion, error) {
+	if err := s.checkCategories(ctx, promotion.CategoryIDs); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.Create(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, promotionsCacheKey)
+	s.publishPromotionEvent("promotion.created", created.ID, created)
+	return created, nil
+}
+
+func (s *promotionService) UpdatePromotion(ctx context.Context, id string, promotion domain.Promotion) (*domain.Promotion, error) {
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	if err := s.checkCategories(ctx, promotion.CategoryIDs); err != nil {
+		return nil, err
+	}
+
+	promotion.ID = current.ID
+	updated, err := s.repo.Update(ctx, promotion)
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, promotionsCacheKey)
+	s.publishPromotionEvent("promotion.updated", updated.ID, updated)
+	return updated, nil
+}
+
+func (s *promotionService) DeletePromotion(ctx context.Context, id string) error {
+	promotionID, err := domain.ParseID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, promotionID); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, promotionsCacheKey)
+	s.publishPromotionEvent("promotion.deleted", promotionID, nil)
+	return nil
+}
+
+func (s *promotionService) EvaluateProduct(ctx context.Context, pc domain.PriceContext, id, sku string, quantity int) (*domain.ItemEvaluation, error) {
+	product, err := s.products.GetProductByID(ctx, id, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	if product.Status != domain.StatusPublished && !pc.Staff {
+		return nil, nil
+	}
+
+	verr := &domain.ValidationError{}
+	if sku == "" {
+		sku = product.SKU
+	}
+	sku = domain.NormalizeSKU(sku)
+	if !product.HasSKU(sku) {
+		verr.Add("sku", "is not a SKU of the product")
+	}
+	if quantity < 1 || quantity > domain.MaxCartItemQuantity {
+		verr.Add("quantity", "must be between 1 and %d", domain.MaxCartItemQuantity)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.evaluate(ctx, pc, []domain.CartItem{{SKU: sku, Quantity: quantity}}, map[string]domain.Product{sku: *product})
+	if err != nil {
+		return nil, err
+	}
+	return &cart.Items[0], nil
+}
+
+func (s *promotionService) EvaluateCart(ctx context.Context, pc domain.PriceContext, items []domain.CartItem) (*domain.CartEvaluation, error) {
+	verr := &domain.ValidationError{}
+	if len(items) == 0 || len(items) > domain.MaxCartItems {
+		verr.Add("items", "must contain 1 to %d entries", domain.MaxCartItems)
+	}
+
+	skus := make([]string, 0, len(items))
+	for i := range items {
+		items[i].SKU = domain.NormalizeSKU(items[i].SKU)
+		if items[i].Quantity < 1 || items[i].Quantity > domain.MaxCartItemQuantity {
+			verr.Add(fmt.Sprintf("items[%d].quantity", i), "must be between 1 and %d", domain.MaxCartItemQuantity)
+		}
+		skus = append(skus, items[i].SKU)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	found, _, err := s.products.ResolveSKUs(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Products the caller may not see are reported like unknown SKUs
+	for i, item := range items {
+		p, ok := found[item.SKU]
+		if !ok || (p.Status != domain.StatusPublished && !pc.Staff) {
+			verr.Add(fmt.Sprintf("items[%d].sku", i), "matches no product")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.evaluate(ctx, pc, items, found)
+}
+
+// evaluate prices items, whose products are keyed by SKU, and applies the
+// promotions to them.
+func (s *promotionService) evaluate(ctx context.Context, pc domain.PriceContext, items []domain.CartItem, products map[string]domain.Product) (*domain.CartEvaluation, error) {
+	lists, err := s.priceLists.PriceLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := pc.Currency
+	if pc.PriceList != "" {
+		if l := lists.Find(pc.PriceList); l != nil {
+			currency = l.Currency
+		}
+	}
+	if currency == "" {
+		currency = lists.Currency()
+		pc.Currency = currency
+	}
+
+	priced := make([]domain.PricedItem, 0, len(items))
+	categoryIDs := []domain.ID{}
+	for _, item := range items {
+		product := products[item.SKU]
+		if err := s.priceLists.ApplyPrices(ctx, pc, &product); err != nil {
+			return nil, err
+		}
+		priced = append(priced, domain.PricedItem{Product: &product, SKU: item.SKU, Quantity: item.Quantity})
+		categoryIDs = append(categoryIDs, product.CategoryIDs...)
+	}
+
+	categories, err := s.categoryAncestors(ctx, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	verr := &domain.ValidationError{}
+	for i := range priced {
+		item := &priced[i]
+
+		price := item.Product.EffectivePrice
+		if v := item.Product.Variant(item.SKU); v != nil {
+			price = v.EffectivePrice
+		}
+		if price == nil || price.Price.Currency != currency {
+			verr.Add(fmt.Sprintf("items[%d].sku", i), "has no price in %s for the caller", currency)
+			continue
+		}
+		item.Price = *price
+
+		item.Categories = map[domain.ID]bool{}
+		for _, id := range item.Product.CategoryIDs {
+			for _, ancestor := range categories[id] {
+				item.Categories[ancestor] = true
+			}
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.promotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := domain.Promotions(promotions).EvaluateCart(time.Now(), currency, priced)
+	return &cart, nil
+}
+
+// categoryAncestors maps each category to itself and its ancestors.
+func (s *promotionService) categoryAncestors(ctx context.Context, ids []domain.ID) (map[domain.ID][]domain.ID, error) {
+	ancestors := make(map[domain.ID][]domain.ID, len(ids))
+	if len(ids) == 0 {
+		return ancestors, nil
+	}
+
+	categories, err := s.categories.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range categories {
+		ancestors[c.ID] = append(append([]domain.ID{}, c.Ancestors...), c.ID)
+	}
+	return ancestors, nil
+}
+
+// checkCategories returns a validation error unless every category
+// targeted by a promotion exists.
+func (s *promotionService) checkCategories(ctx context.Context, ids []domain.ID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	categories, err := s.categories.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[domain.ID]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+
+	verr := &domain.ValidationError{}
+	for i, id := range ids {
+		if !known[id] {
+			verr.Add(fmt.Sprintf("category_ids[%d]", i), "is not a category")
+		}
+	}
+	return verr.Err()
+}
+
+// promotions reads every promotion through the cache.
+func (s *promotionService) promotions(ctx context.Context) ([]domain.Promotion, error) {
+	if cached, err := s.cache.Get(ctx, promotionsCacheKey); err == nil && cached != "" {
+		var promotions []domain.Promotion
+		if err := json.Unmarshal([]byte(cached), &promotions); err == nil {
+			return promotions, nil
+		}
+	}
+
+	promotions, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promotionsJSON, _ := json.Marshal(promotions)
+	s.cache.Set(ctx, promotionsCacheKey, string(promotionsJSON), promotionsCacheTTL)
+
+	return promotions, nil
+}
+
+// publishPromotionEvent announces a change to the promotions, so that
+// consumers caching prices can drop them. Failures are logged; the change
+// itself has already been stored.
+func (s *promotionService) publishPromotionEvent(eventType string, id domain.ID, promotion *domain.Promotion) {
+	event := map[string]interface{}{
+		"id":        id,
+		"timestamp": time.Now(),
+	}
+	if promotion != nil {
+		event["promotion"] = promotion
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err == nil {
+		err = s.messageBus.Publish("product_exchange", eventType, eventJSON)
+	}
+	if err != nil {
+		s.logger.Error("Failed to publish promotion event", err, logger.Fields{"event": eventType})
+	}
+}
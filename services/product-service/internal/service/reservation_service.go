@@ -0,0 +1,398 @@
+// internal/service/reservation_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/logger"
+	"github.com/ntdt/product-service/pkg/messaging"
+)
+
+type ReservationService interface {
+	// Reserve holds stock for an order until the reservation is confirmed,
+	// released or expires. It reports whether the reservation is new:
+	// reserving an order again with the same items returns its reservation,
+	// whatever its state, and with other items fails with
+	// domain.ErrReservationMismatch. The stock of each item is allocated to
+	// locations by the strategy of the request. If any item cannot be held,
+	// nothing is and it fails with domain.ErrInsufficientInventory.
+	Reserve(ctx context.Context, req domain.ReservationRequest, reservedBy string) (*domain.Reservation, bool, error)
+	// GetReservation returns nil if the order has no reservation
+	GetReservation(ctx context.Context, orderID string) (*domain.Reservation, error)
+	// ConfirmReservation sells the stock held for an order. Confirming a
+	// confirmed reservation returns it as is; one that was released or
+	// expired fails with a domain.ReservationStateError.
+	ConfirmReservation(ctx context.Context, orderID, confirmedBy string) (*domain.Reservation, error)
+	// ReleaseReservation gives the stock held for an order back. Releasing
+	// a released or expired reservation returns it as is; a confirmed one
+	// fails with a domain.ReservationStateError.
+	ReleaseReservation(ctx context.Context, orderID string) (*domain.Reservation, error)
+	// ExpireReservations releases the pending reservations that expired
+	// before now and returns how many it released.
+	ExpireReservations(ctx context.Context, now time.Time) (int, error)
+}
+
+// expireBatchSize bounds how many reservations a single repository call
+// returns for expiry.
+const expireBatchSize = 100
+
+type reservationService struct {
+	repo       repository.ReservationRepository
+	products   ProductService
+	locations  LocationService
+	messageBus messaging.RabbitMQClient
+	logger     logger.Logger
+}
+
+// NewReservationService holds and releases stock through products at the
+// locations of locations.
+func NewReservationService(repo repository.ReservationRepository, products ProductService, locations LocationService, messageBus messaging.RabbitMQClient, logger logger.Logger) ReservationService {
+	return &reservationService{
+		repo:       repo,
+		products:   products,
+		locations:  locations,
+		messageBus: messageBus,
+		logger:     logger,
+	}
+}
+
+func (s *reservationService) Reserve(ctx context.Context, req domain.ReservationRequest, reservedBy string) (*domain.Reservation, bool, error) {
+	items, err := s.resolveItems(ctx, req.Items)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Reserving an order again returns its reservation even when the stock
+	// it holds could no longer be allocated
+	existing, err := s.repo.FindByOrderID(ctx, req.OrderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return s.existing(existing, items)
+	}
+
+	if err := s.allocate(ctx, req, items); err != nil {
+		return nil, false, err
+	}
+
+	ttl := domain.DefaultReservationTTL
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+
+	// The reservation is stored first, so that a concurrent request for the
+	// same order finds it instead of holding the stock a second time
+	created, err := s.repo.Create(ctx, domain.Reservation{
+		OrderID:   req.OrderID,
+		Items:     items,
+		State:     domain.ReservationPending,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: reservedBy,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if created == nil {
+		existing, err := s.repo.FindByOrderID(ctx, req.OrderID)
+		if err != nil {
+			return nil, false, err
+		}
+		return s.existing(existing, items)
+	}
+
+	for i, item := range created.Items {
+		product, err := s.holdItem(ctx, created.OrderID, item)
+		if err != nil {
+			s.rollback(ctx, created, i)
+			return nil, false, fmt.Errorf("items[%d]: %w", i, err)
+		}
+
+		available := product.AvailableStock(item.SKU)
+		created.Items[i].Available = &available
+	}
+
+	s.publishReservationEvent("stock.reserved", created)
+	return created, true, nil
+}
+
+// existing answers a request for an order that is already reserved.
+func (s *reservationService) existing(reservation *domain.Reservation, items []domain.ReservationItem) (*domain.Reservation, bool, error) {
+	if reservation == nil || !reservation.SameItems(items) {
+		return nil, false, domain.ErrReservationMismatch
+	}
+	return reservation, false, nil
+}
+
+// allocate splits the stock of each item over the locations it is taken
+// from. The locations are ranked by the strategy of req, or limited to the
+// one it names. Stock that runs out before the reservation is stored fails
+// it when the stock is held.
+func (s *reservationService) allocate(ctx context.Context, req domain.ReservationRequest, items []domain.ReservationItem) error {
+	locations, err := s.locations.ListLocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if req.Location != "" {
+		var pinned []domain.Location
+		for _, l := range locations {
+			if l.ID == req.Location {
+				pinned = append(pinned, l)
+			}
+		}
+		if len(pinned) == 0 {
+			verr := &domain.ValidationError{}
+			verr.Add("location", "is not a stock location")
+			return verr.Err()
+		}
+		locations = pinned
+	}
+	ranked := domain.RankLocations(locations, req.Strategy, req.Destination)
+
+	for i := range items {
+		item := &items[i]
+
+		levels, err := s.products.GetLocationStock(ctx, item.ProductID.String())
+		if err != nil {
+			return err
+		}
+
+		available := map[string]int{}
+		for _, l := range levels {
+			if l.SKU == item.SKU {
+				available[l.LocationID] = l.Available
+			}
+		}
+
+		item.Allocations, err = domain.AllocateStock(ranked, available, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("items[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// holdItem reserves the stock of an item at the locations it is allocated
+// to and returns the product as the last of them left it. If a location
+// cannot hold its part, the parts already held are given back.
+func (s *reservationService) holdItem(ctx context.Context, orderID string, item domain.ReservationItem) (*domain.Product, error) {
+	allocations := item.HeldAt()
+
+	var product *domain.Product
+	for j, a := range allocations {
+		held, err := s.products.ReserveStock(ctx, item.ProductID, item.SKU, a.LocationID, a.Quantity)
+		if err == nil && held == nil {
+			err = domain.ErrProductNotFound
+		}
+		if err != nil {
+			if j > 0 {
+				item.Allocations = allocations[:j]
+				s.releaseItems(ctx, orderID, []domain.ReservationItem{item})
+			}
+			return nil, err
+		}
+		product = held
+	}
+
+	return product, nil
+}
+
+// rollback gives back the stock held for the first n items of a
+// reservation that could not be completed, and removes it.
+func (s *reservationService) rollback(ctx context.Context, reservation *domain.Reservation, n int) {
+	s.releaseItems(ctx, reservation.OrderID, reservation.Items[:n])
+	if err := s.repo.Delete(ctx, reservation.OrderID); err != nil {
+		s.logger.Error("Failed to delete incomplete reservation", err, logger.Fields{"orderId": reservation.OrderID})
+	}
+}
+
+func (s *reservationService) GetReservation(ctx context.Context, orderID string) (*domain.Reservation, error) {
+	return s.repo.FindByOrderID(ctx, orderID)
+}
+
+func (s *reservationService) ConfirmReservation(ctx context.Context, orderID, confirmedBy string) (*domain.Reservation, error) {
+	confirmed, err := s.repo.Transition(ctx, orderID, domain.ReservationConfirmed, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if confirmed == nil {
+		return s.unchanged(ctx, orderID, domain.ReservationConfirmed)
+	}
+
+	for i, item := range confirmed.Items {
+		var product *domain.Product
+		for _, a := range item.HeldAt() {
+			sold, err := s.products.SellReservedStock(ctx, item.ProductID, item.SKU, a.LocationID, a.Quantity, orderID, confirmedBy)
+			if err != nil || sold == nil {
+				// The sale stands; the ledger and the reserved stock are
+				// fixed by hand from the logged item
+				s.logger.Error("Failed to sell reserved stock", err, logger.Fields{"orderId": orderID, "productId": item.ProductID, "sku": item.SKU, "location": a.LocationID})
+				continue
+			}
+			product = sold
+		}
+
+		if product != nil {
+			available := product.AvailableStock(item.SKU)
+			confirmed.Items[i].Available = &available
+		}
+	}
+
+	s.publishReservationEvent("stock.reservation_confirmed", confirmed)
+	return confirmed, nil
+}
+
+func (s *reservationService) ReleaseReservation(ctx context.Context, orderID string) (*domain.Reservation, error) {
+	released, err := s.repo.Transition(ctx, orderID, domain.ReservationReleased, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if released == nil {
+		return s.unchanged(ctx, orderID, domain.ReservationReleased)
+	}
+
+	s.releaseItems(ctx, orderID, released.Items)
+	s.publishReservationEvent("stock.reservation_released", released)
+	return released, nil
+}
+
+func (s *reservationService) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		batch, err := s.repo.FindExpired(ctx, now, expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, r := range batch {
+			// Another replica, or the order itself, may have got there first
+			reservation, err := s.repo.Transition(ctx, r.OrderID, domain.ReservationExpired, now)
+			if err != nil {
+				return expired, err
+			}
+			if reservation == nil {
+				continue
+			}
+
+			s.releaseItems(ctx, reservation.OrderID, reservation.Items)
+			s.publishReservationEvent("stock.reservation_expired", reservation)
+			expired++
+		}
+
+		if len(batch) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// unchanged answers a confirmation or release the repository did not make:
+// repeating it returns the reservation as is, otherwise its state is
+// reported. A pending reservation that could not be confirmed has expired,
+// even if the sweeper has not released it yet.
+func (s *reservationService) unchanged(ctx context.Context, orderID string, to domain.ReservationState) (*domain.Reservation, error) {
+	reservation, err := s.repo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reservation == nil:
+		return nil, domain.ErrReservationNotFound
+	case reservation.State == to:
+		return reservation, nil
+	case to == domain.ReservationReleased && reservation.State == domain.ReservationExpired:
+		return reservation, nil
+	case reservation.State == domain.ReservationPending:
+		return nil, &domain.ReservationStateError{State: domain.ReservationExpired}
+	default:
+		return nil, &domain.ReservationStateError{State: reservation.State}
+	}
+}
+
+// releaseItems gives back the stock held for items. Failures are logged;
+// the reservation has already left the pending state and the reserved
+// stock is fixed by hand from the logged item.
+func (s *reservationService) releaseItems(ctx context.Context, orderID string, items []domain.ReservationItem) {
+	for i, item := range items {
+		var product *domain.Product
+		for _, a := range item.HeldAt() {
+			released, err := s.products.ReserveStock(ctx, item.ProductID, item.SKU, a.LocationID, -a.Quantity)
+			if err != nil || released == nil {
+				s.logger.Error("Failed to release reserved stock", err, logger.Fields{"orderId": orderID, "productId": item.ProductID, "sku": item.SKU, "location": a.LocationID})
+				continue
+			}
+			product = released
+		}
+
+		if product != nil {
+			available := product.AvailableStock(item.SKU)
+			items[i].Available = &available
+		}
+	}
+}
+
+// resolveItems checks that the items of a request name products that can
+// be sold and sets the SKU of items for products without variants, which
+// keep their stock under the product SKU.
+func (s *reservationService) resolveItems(ctx context.Context, items []domain.ReservationItem) ([]domain.ReservationItem, error) {
+	verr := &domain.ValidationError{}
+	resolved := make([]domain.ReservationItem, 0, len(items))
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+
+		product, err := s.products.GetProductByID(ctx, item.ProductID.String(), false)
+		if err != nil && !errors.Is(err, domain.ErrInvalidID) {
+			return nil, err
+		}
+		if product == nil || product.Status != domain.StatusPublished {
+			verr.Add(field+".product_id", "is not a product on sale")
+			continue
+		}
+
+		if item.SKU == "" && len(product.Variants) == 0 {
+			item.SKU = product.SKU
+		}
+		if !containsString(product.StockSKUs(), item.SKU) {
+			if len(product.Variants) > 0 {
+				verr.Add(field+".sku", "must be the SKU of a variant; stock is kept per variant")
+			} else {
+				verr.Add(field+".sku", "is not the SKU of the product")
+			}
+			continue
+		}
+
+		item.ProductID = product.ID
+		resolved = append(resolved, item)
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// publishReservationEvent announces a change to the stock held for an
+// order. Failures are logged; the change itself has already been stored.
+func (s *reservationService) publishReservationEvent(eventType string, reservation *domain.Reservation) {
+	event := map[string]interface{}{
+		"order_id":    reservation.OrderID,
+		"reservation": reservation,
+		"timestamp":   time.Now(),
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err == nil {
+		err = s.messageBus.Publish("product_exchange", eventType, eventJSON)
+	}
+	if err != nil {
+		s.logger.Error("Failed to publish reservation event", err, logger.Fields{"event": eventType})
+	}
+}
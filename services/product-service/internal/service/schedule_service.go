@@ -0,0 +1,146 @@
+// internal/service/schedule_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ntdt/product-service/internal/domain"
+	"github.com/ntdt/product-service/internal/repository"
+	"github.com/ntdt/product-service/pkg/logger"
+)
+
+type ScheduleService interface {
+	// ScheduleChange stores a change to apply later. It returns nil if the
+	// product does not exist.
+	ScheduleChange(ctx context.Context, productID string, change domain.ScheduledChange) (*domain.ScheduledChange, error)
+	// GetSchedules lists the changes of a product. It returns nil if the
+	// product does not exist.
+	GetSchedules(ctx context.Context, productID string) ([]domain.ScheduledChange, error)
+	CancelSchedule(ctx context.Context, productID, scheduleID string) error
+	// ApplyDueChanges applies every pending change due at now and returns
+	// how many were applied. It must only run on one replica at a time.
+	ApplyDueChanges(ctx context.Context, now time.Time) (int, error)
+}
+
+const (
+	// scheduleBatchSize bounds how many due changes are loaded at once.
+	scheduleBatchSize = 100
+
+	// schedulerActor is recorded as the author of the changes the
+	// scheduler applies.
+	schedulerActor = "scheduler"
+)
+
+type scheduleService struct {
+	repo     repository.ScheduleRepository
+	products ProductService
+	logger   logger.Logger
+}
+
+// NewScheduleService applies scheduled changes through products, so they
+// publish the same events and invalidate the same caches as API writes.
+func NewScheduleService(repo repository.ScheduleRepository, products ProductService, logger logger.Logger) ScheduleService {
+	return &scheduleService{
+		repo:     repo,
+		products: products,
+		logger:   logger,
+	}
+}
+
+func (s *scheduleService) ScheduleChange(ctx context.Context, productID string, change domain.ScheduledChange) (*domain.ScheduledChange, error) {
+	product, err := s.products.GetProductByID(ctx, productID, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	pending, err := s.repo.CountPending(ctx, product.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if pending >= domain.MaxPendingSchedules {
+		return nil, domain.ErrTooManySchedules
+	}
+
+	change.ProductID = product.ID
+	return s.repo.Create(ctx, change)
+}
+
+func (s *scheduleService) GetSchedules(ctx context.Context, productID string) ([]domain.ScheduledChange, error) {
+	product, err := s.products.GetProductByID(ctx, productID, false)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProduct(ctx, product.ID.String())
+}
+
+func (s *scheduleService) CancelSchedule(ctx context.Context, productID, scheduleID string) error {
+	return s.repo.Cancel(ctx, productID, scheduleID)
+}
+
+// ApplyDueChanges applies due changes in the order they were scheduled for.
+// A change that cannot be applied, such as publishing a product that is
+// still a draft, is marked as failed with the reason and not retried.
+func (s *scheduleService) ApplyDueChanges(ctx context.Context, now time.Time) (int, error) {
+	applied := 0
+	for {
+		changes, err := s.repo.FindDue(ctx, now, scheduleBatchSize)
+		if err != nil {
+			return applied, err
+		}
+
+		for _, change := range changes {
+			state, errMsg := domain.ScheduleApplied, ""
+			if err := s.apply(ctx, change); err != nil {
+				if ctx.Err() != nil {
+					return applied, ctx.Err()
+				}
+
+				s.logger.Error("Failed to apply scheduled change", err, logger.Fields{
+					"scheduleId": change.ID,
+					"productId":  change.ProductID,
+					"action":     change.Action,
+				})
+				state, errMsg = domain.ScheduleFailed, err.Error()
+			} else {
+				applied++
+			}
+
+			// Stop rather than picking the same change up again
+			if err := s.repo.Complete(ctx, change.ID, state, errMsg); err != nil {
+				return applied, err
+			}
+		}
+
+		if len(changes) < scheduleBatchSize {
+			return applied, nil
+		}
+	}
+}
+
+func (s *scheduleService) apply(ctx context.Context, change domain.ScheduledChange) error {
+	var (
+		product *domain.Product
+		err     error
+	)
+
+	id := change.ProductID.String()
+	switch change.Action {
+	case domain.SchedulePublish:
+		product, err = s.products.TransitionProduct(ctx, id, domain.StatusPublished, schedulerActor, 0)
+	case domain.ScheduleUnpublish:
+		product, err = s.products.TransitionProduct(ctx, id, domain.StatusDiscontinued, schedulerActor, 0)
+	case domain.SchedulePrice:
+		patch, _ := json.Marshal(map[string]domain.Money{"price": *change.Price})
+		audit := domain.Audit{By: schedulerActor, Reason: fmt.Sprintf("scheduled change %s", change.ID)}
+		product, err = s.products.PatchProduct(ctx, id, domain.MergePatchMediaType, patch, 0, audit)
+	}
+
+	if err == nil && product == nil {
+		return domain.ErrProductNotFound
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+// pkg/cache/lock.go
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Lock is a lease on a Redis key that at most one owner holds at a time. It
+// is used to elect a leader among replicas: whoever holds the lease does the
+// work, and the lease expires if its holder stops renewing it.
+type Lock interface {
+	// Acquire takes the lease if it is free and renews it if this owner
+	// already holds it. It reports whether this owner holds the lease.
+	Acquire(ctx context.Context) (bool, error)
+	// Release gives up the lease if this owner holds it.
+	Release(ctx context.Context) error
+}
+
+// acquireScript sets the key if it is unset and extends it if it already
+// belongs to the owner, in one atomic step.
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if holder == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the key only if it still belongs to the owner.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	owner  string
+	ttl    time.Duration
+}
+
+func (r *redisClient) NewLock(key, owner string, ttl time.Duration) Lock {
+	return &redisLock{
+		client: r.client,
+		key:    key,
+		owner:  owner,
+		ttl:    ttl,
+	}
+}
+
+func (l *redisLock) Acquire(ctx context.Context) (bool, error) {
+	held, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.owner, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.owner).Err()
+}
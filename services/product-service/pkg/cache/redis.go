@@ -14,6 +14,9 @@ type RedisClient interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string, expiration time.Duration) error
 	Delete(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string) (int64, error)
+	// NewLock returns a lease on key held by owner; see Lock
+	NewLock(key, owner string, ttl time.Duration) Lock
 	Close() error
 }
 
@@ -52,6 +55,10 @@ func (r *redisClient) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+func (r *redisClient) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
 func (r *redisClient) Close() error {
 	return r.client.Close()
 }
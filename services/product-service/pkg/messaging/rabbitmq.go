@@ -2,33 +2,87 @@
 package messaging
 
 import (
+	"errors"
+	"sync"
+	"time"
+
 	"github.com/streadway/amqp"
 
 	"github.com/ntdt/product-service/config"
 )
 
+const (
+	// resubscribeDelay is how long a subscription waits before declaring its
+	// queue again after its channel or connection dropped; it doubles up to
+	// maxResubscribeDelay while that fails
+	resubscribeDelay    = time.Second
+	maxResubscribeDelay = 30 * time.Second
+)
+
 type RabbitMQClient interface {
 	Publish(exchange, routingKey string, message []byte) error
+	// Subscribe consumes a queue bound to exchange on a channel of its own
+	// and resubscribes whenever that channel or the connection drops, until
+	// the client is closed. A message is acknowledged when handler returns
+	// nil and requeued when it fails, unless the error is a rejection: then
+	// it is moved to the dead-letter queue of the queue.
 	Subscribe(exchange, routingKey, queueName string, handler func([]byte) error) error
+	// QueueDepth returns how many messages wait in a queue
+	QueueDepth(queueName string) (int, error)
 	Close() error
 }
 
+// rejection is a handler error that redelivering the message would not
+// change.
+type rejection struct {
+	err error
+}
+
+func (r *rejection) Error() string { return r.err.Error() }
+func (r *rejection) Unwrap() error { return r.err }
+
+// Reject marks a handler error as final, so that the message is
+// dead-lettered rather than requeued.
+func Reject(err error) error {
+	return &rejection{err: err}
+}
+
+// IsRejection reports whether err was marked final by Reject.
+func IsRejection(err error) bool {
+	var r *rejection
+	return errors.As(err, &r)
+}
+
+// DeadLetterQueue returns the name of the queue rejected messages of
+// queueName are moved to.
+func DeadLetterQueue(queueName string) string {
+	return queueName + ".dead"
+}
+
+// rabbitMQClient publishes on one channel and opens another for each
+// subscription and queue inspection. A channel error, such as inspecting a
+// missing queue, closes only the channel it happened on.
 type rabbitMQClient struct {
-	conn     *amqp.Connection
-	channel  *amqp.Channel
+	uri      string
 	exchange string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// channelClosed is notified when the publishing channel closes
+	channelClosed chan *amqp.Error
+	closed        bool
 }
 
 // NewRabbitMQClient creates a new RabbitMQ client
 func NewRabbitMQClient(cfg config.RabbitMQConfig) (RabbitMQClient, error) {
-	conn, err := amqp.Dial(cfg.URI)
-	if err != nil {
-		return nil, err
-	}
+	r := &rabbitMQClient{uri: cfg.URI, exchange: cfg.Exchange}
 
-	ch, err := conn.Channel()
+	r.mu.Lock()
+	ch, err := r.publishChannel()
+	r.mu.Unlock()
 	if err != nil {
-		conn.Close()
+		r.Close()
 		return nil, err
 	}
 
@@ -43,16 +97,68 @@ func NewRabbitMQClient(cfg config.RabbitMQConfig) (RabbitMQClient, error) {
 		nil,          // arguments
 	)
 	if err != nil {
-		ch.Close()
-		conn.Close()
+		r.Close()
 		return nil, err
 	}
 
-	return &rabbitMQClient{
-		conn:     conn,
-		channel:  ch,
-		exchange: cfg.Exchange,
-	}, nil
+	return r, nil
+}
+
+// connection returns the connection, dialing again if it dropped. It is
+// called with r.mu held.
+func (r *rabbitMQClient) connection() (*amqp.Connection, error) {
+	if r.conn != nil && !r.conn.IsClosed() {
+		return r.conn, nil
+	}
+
+	conn, err := amqp.Dial(r.uri)
+	if err != nil {
+		return nil, err
+	}
+	r.conn = conn
+	return conn, nil
+}
+
+// publishChannel returns the publishing channel, opening it again if it
+// closed. It is called with r.mu held.
+func (r *rabbitMQClient) publishChannel() (*amqp.Channel, error) {
+	if r.channel != nil {
+		select {
+		case <-r.channelClosed:
+			r.channel = nil
+		default:
+			return r.channel, nil
+		}
+	}
+
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	r.channel, r.channelClosed = ch, ch.NotifyClose(make(chan *amqp.Error, 1))
+	return ch, nil
+}
+
+// openChannel opens a channel for a single use on the current connection.
+func (r *rabbitMQClient) openChannel() (*amqp.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Channel()
+}
+
+func (r *rabbitMQClient) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
 }
 
 func (r *rabbitMQClient) Publish(exchange, routingKey string, message []byte) error {
@@ -60,7 +166,14 @@ func (r *rabbitMQClient) Publish(exchange, routingKey string, message []byte) er
 		exchange = r.exchange
 	}
 
-	return r.channel.Publish(
+	r.mu.Lock()
+	ch, err := r.publishChannel()
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ch.Publish(
 		exchange,   // exchange
 		routingKey, // routing key
 		false,      // mandatory
@@ -78,8 +191,49 @@ func (r *rabbitMQClient) Subscribe(exchange, routingKey, queueName string, handl
 		exchange = r.exchange
 	}
 
+	ch, msgs, err := r.consume(exchange, routingKey, queueName)
+	if err != nil {
+		return err
+	}
+
+	go r.deliver(ch, msgs, exchange, routingKey, queueName, handler)
+	return nil
+}
+
+// consume declares the exchange, queue and binding of a subscription and
+// starts consuming the queue on a new channel.
+func (r *rabbitMQClient) consume(exchange, routingKey, queueName string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := r.openChannel()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msgs, err := declareAndConsume(ch, exchange, routingKey, queueName)
+	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+	return ch, msgs, nil
+}
+
+func declareAndConsume(ch *amqp.Channel, exchange, routingKey, queueName string) (<-chan amqp.Delivery, error) {
+	// Declare the exchange of another service too, so that consumers can
+	// start before its publisher does
+	err := ch.ExchangeDeclare(
+		exchange, // exchange name
+		"topic",  // exchange type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Declare a queue
-	q, err := r.channel.QueueDeclare(
+	q, err := ch.QueueDeclare(
 		queueName, // queue name
 		true,      // durable
 		false,     // delete when unused
@@ -88,11 +242,24 @@ func (r *rabbitMQClient) Subscribe(exchange, routingKey, queueName string, handl
 		nil,       // arguments
 	)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Rejected messages are kept for inspection rather than dropped
+	_, err = ch.QueueDeclare(
+		DeadLetterQueue(queueName), // queue name
+		true,                       // durable
+		false,                      // delete when unused
+		false,                      // exclusive
+		false,                      // no-wait
+		nil,                        // arguments
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	// Bind the queue to the exchange
-	err = r.channel.QueueBind(
+	err = ch.QueueBind(
 		q.Name,     // queue name
 		routingKey, // routing key
 		exchange,   // exchange
@@ -100,11 +267,11 @@ func (r *rabbitMQClient) Subscribe(exchange, routingKey, queueName string, handl
 		nil,        // arguments
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Consume messages
-	msgs, err := r.channel.Consume(
+	return ch.Consume(
 		q.Name, // queue
 		"",     // consumer
 		false,  // auto-ack
@@ -113,33 +280,92 @@ func (r *rabbitMQClient) Subscribe(exchange, routingKey, queueName string, handl
 		false,  // no-wait
 		nil,    // args
 	)
-	if err != nil {
-		return err
-	}
+}
 
-	go func() {
+// deliver hands the deliveries of a subscription to handler. The deliveries
+// end when the channel or the connection drops; unless the client was
+// closed, it then subscribes again, waiting longer after each failure.
+func (r *rabbitMQClient) deliver(ch *amqp.Channel, msgs <-chan amqp.Delivery, exchange, routingKey, queueName string, handler func([]byte) error) {
+	for {
 		for d := range msgs {
-			if err := handler(d.Body); err != nil {
+			err := handler(d.Body)
+			if err != nil && IsRejection(err) {
+				err = deadLetter(ch, queueName, d)
+			}
+			if err != nil {
 				// Failed to process message, nack
 				d.Nack(false, true)
 			} else {
-				// Successfully processed message, ack
+				// Successfully processed or dead-lettered message, ack
 				d.Ack(false)
 			}
 		}
-	}()
 
-	return nil
+		delay := resubscribeDelay
+		for {
+			time.Sleep(delay)
+			if r.isClosed() {
+				return
+			}
+
+			var err error
+			if ch, msgs, err = r.consume(exchange, routingKey, queueName); err == nil {
+				break
+			}
+			if delay *= 2; delay > maxResubscribeDelay {
+				delay = maxResubscribeDelay
+			}
+		}
+	}
+}
+
+// deadLetter moves a delivery to the dead-letter queue of queueName. It is
+// published through the default exchange, which routes by queue name.
+func deadLetter(ch *amqp.Channel, queueName string, d amqp.Delivery) error {
+	return ch.Publish(
+		"",                         // exchange
+		DeadLetterQueue(queueName), // routing key
+		false,                      // mandatory
+		false,                      // immediate
+		amqp.Publishing{
+			Headers:      d.Headers,
+			ContentType:  d.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    d.Timestamp,
+			Body:         d.Body,
+		},
+	)
+}
+
+// QueueDepth inspects the queue on a channel of its own: the broker closes
+// the channel when the queue does not exist.
+func (r *rabbitMQClient) QueueDepth(queueName string) (int, error) {
+	ch, err := r.openChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueInspect(queueName)
+	if err != nil {
+		return 0, err
+	}
+
+	return q.Messages, nil
 }
 
 func (r *rabbitMQClient) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+
 	if r.channel != nil {
-		if err := r.channel.Close(); err != nil {
+		if err := r.channel.Close(); err != nil && r.conn != nil && !r.conn.IsClosed() {
 			return err
 		}
 	}
 
-	if r.conn != nil {
+	if r.conn != nil && !r.conn.IsClosed() {
 		return r.conn.Close()
 	}
 